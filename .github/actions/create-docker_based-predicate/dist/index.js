@@ -261,8 +261,12 @@ function generatePredicate(bd, binaryRef, jobWorkflowRef, currentRun) {
             },
         },
     };
-    // Add the builder binary to the resolved dependencies.
-    pred.buildDefinition.resolvedDependencies = [binaryRef];
+    // Add the builder binary to the resolved dependencies, after the ones
+    // recorded by the builder (e.g., the builder image).
+    pred.buildDefinition.resolvedDependencies = [
+        ...(bd.resolvedDependencies || []),
+        binaryRef,
+    ];
     // Update the systemParameters with the GH context, including workflow
     // inputs.
     pred = (0, github_1.addGitHubSystemParameters)(pred, currentRun);
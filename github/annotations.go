@@ -0,0 +1,99 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tokenRe matches GitHub tokens, which are redacted from annotations.
+var tokenRe = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`)
+
+// AnnotationLocation is the optional location of a workflow annotation.
+type AnnotationLocation struct {
+	// File is the path of the file relative to the repository root.
+	File string
+
+	// Line is the line in File, starting at 1. It is ignored if 0.
+	Line int
+}
+
+// Warning writes a warning annotation with the message to w. When not
+// running in GitHub Actions, i.e. if GITHUB_ACTIONS is not "true", a plain
+// "WARNING: <message>" line is written instead.
+//
+// Annotations are shown on the workflow run page to anyone who can read the
+// repository, so the message must not contain secrets. GitHub tokens are
+// redacted as a precaution.
+func Warning(w io.Writer, loc *AnnotationLocation, message string) {
+	annotate(w, "warning", "WARNING", loc, message)
+}
+
+// Error writes an error annotation with the message to w. When not running
+// in GitHub Actions, a plain "ERROR: <message>" line is written instead. The
+// same care as for Warning must be taken with the message.
+func Error(w io.Writer, loc *AnnotationLocation, message string) {
+	annotate(w, "error", "ERROR", loc, message)
+}
+
+// Debug writes a debug message to w if GitHub Actions debug logging is
+// enabled, i.e. if RUNNER_DEBUG is "1". Otherwise nothing is written. The same
+// care as for Warning must be taken with the message.
+func Debug(w io.Writer, message string) {
+	if os.Getenv("RUNNER_DEBUG") != "1" {
+		return
+	}
+	message = tokenRe.ReplaceAllString(message, "***")
+	fmt.Fprintf(w, "::debug::%s\n", escapeData(message))
+}
+
+func annotate(w io.Writer, command, prefix string, loc *AnnotationLocation, message string) {
+	message = tokenRe.ReplaceAllString(message, "***")
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		fmt.Fprintf(w, "%s: %s\n", prefix, message)
+		return
+	}
+
+	var props []string
+	if loc != nil && loc.File != "" {
+		props = append(props, "file="+escapeProperty(loc.File))
+		if loc.Line > 0 {
+			props = append(props, "line="+strconv.Itoa(loc.Line))
+		}
+	}
+	if len(props) > 0 {
+		command += " " + strings.Join(props, ",")
+	}
+	fmt.Fprintf(w, "::%s::%s\n", command, escapeData(message))
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
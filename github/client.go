@@ -5,6 +5,8 @@ import (
 
 	"github.com/google/go-github/v50/github"
 	"golang.org/x/oauth2"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/httpclient"
 )
 
 // NewGithubClient returns a new GitHub API client authenticated using the
@@ -14,6 +16,18 @@ func NewGithubClient(ctx context.Context) (*github.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewGithubClientWithToken(ctx, t)
+}
+
+// NewGithubClientWithToken returns a new GitHub API client authenticated
+// using the given token.
+func NewGithubClientWithToken(ctx context.Context, t string) (*github.Client, error) {
+	httpClient, err := httpclient.New()
+	if err != nil {
+		return nil, err
+	}
+	// The oauth2 client uses the HTTP client in the context as its base.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 	return github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: t},
 	))), nil
@@ -23,11 +23,14 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 
 	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/httpclient"
 )
 
 var defaultActionsProviderURL = "https://token.actions.githubusercontent.com"
@@ -35,6 +38,10 @@ var defaultActionsProviderURL = "https://token.actions.githubusercontent.com"
 const (
 	requestTokenEnvKey = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
 	requestURLEnvKey   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+
+	// tokenExpiryLeeway is the time before a cached token's expiry at which
+	// a new token will be requested.
+	tokenExpiryLeeway = 30 * time.Second
 )
 
 // OIDCToken represents the contents of a GitHub OIDC JWT token.
@@ -59,6 +66,13 @@ type OIDCToken struct {
 
 	// Audience is the audience for which the token was granted.
 	Audience []string
+
+	// Subject is the subject of the token.
+	Subject string `json:"-"`
+
+	// RawToken is the raw JWT. It can be exchanged for credentials with
+	// services, such as Fulcio, that accept GitHub OIDC tokens.
+	RawToken string `json:"-"`
 }
 
 // errURLError indicates the OIDC server URL is invalid.
@@ -86,6 +100,12 @@ type errVerify struct {
 	errors.WrappableError
 }
 
+// errOIDCTokenTimeout indicates that requesting and verifying a token did not
+// complete within the timeout.
+type errOIDCTokenTimeout struct {
+	errors.WrappableError
+}
+
 // OIDCClient is a client for the GitHub OIDC provider.
 type OIDCClient struct {
 	// requestURL is the GitHub URL to request a OIDC token.
@@ -97,6 +117,27 @@ type OIDCClient struct {
 
 	// bearerToken is used to request an ID token.
 	bearerToken string
+
+	// httpClient is used to request an ID token. http.DefaultClient is used
+	// if nil.
+	httpClient *http.Client
+
+	// audience overrides the audience given to Token if set.
+	audience []string
+
+	// timeout bounds the time taken to request and verify a new token if
+	// greater than zero.
+	timeout time.Duration
+
+	// now returns the current time. It is used to check the expiry of
+	// cached tokens. This is used for tests.
+	now func() time.Time
+
+	// mu protects tokens.
+	mu sync.Mutex
+
+	// tokens caches verified tokens by audience.
+	tokens map[string]*OIDCToken
 }
 
 // NewOIDCClient returns new GitHub OIDC provider client.
@@ -111,12 +152,18 @@ func NewOIDCClient() (*OIDCClient, error) {
 		)
 	}
 
+	httpClient, err := httpclient.New()
+	if err != nil {
+		return nil, err
+	}
+
 	c := OIDCClient{
 		requestURL:  parsedURL,
 		bearerToken: os.Getenv(requestTokenEnvKey),
+		httpClient:  httpClient,
 	}
 	c.verifierFunc = func(ctx context.Context) (*oidc.IDTokenVerifier, error) {
-		provider, err := oidc.NewProvider(ctx, defaultActionsProviderURL)
+		provider, err := oidc.NewProvider(oidc.ClientContext(ctx, httpClient), defaultActionsProviderURL)
 		if err != nil {
 			return nil, err
 		}
@@ -148,7 +195,11 @@ func (c *OIDCClient) requestToken(ctx context.Context, audience []string) ([]byt
 	}
 	req.Header.Add("Authorization", "bearer "+c.bearerToken)
 	req = req.WithContext(ctx)
-	resp, err := http.DefaultClient.Do(req)
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, errors.Errorf(&errRequestError{}, "request: %w", err)
 	}
@@ -203,6 +254,7 @@ func (c *OIDCClient) decodeToken(token *oidc.IDToken) (*OIDCToken, error) {
 	t.Issuer = token.Issuer
 	t.Audience = token.Audience
 	t.Expiry = token.Expiry
+	t.Subject = token.Subject
 
 	if err := token.Claims(&t); err != nil {
 		return nil, errors.Errorf(&errToken{}, "getting claims: %w", err)
@@ -228,9 +280,76 @@ func (c *OIDCClient) verifyClaims(token *OIDCToken) error {
 	return nil
 }
 
+// WithAudience overrides the audience requested by Token. Tokens are always
+// requested for the given audience regardless of the audience given to Token.
+func (c *OIDCClient) WithAudience(audience []string) *OIDCClient {
+	c.audience = audience
+	return c
+}
+
+// WithBearerToken overrides the token used to authenticate requests for OIDC
+// tokens. By default, it is read from the ACTIONS_ID_TOKEN_REQUEST_TOKEN
+// environment variable.
+func (c *OIDCClient) WithBearerToken(token string) *OIDCClient {
+	c.bearerToken = token
+	return c
+}
+
+// WithTimeout bounds the time taken to request and verify a new token.
+// Token returns an error if the timeout is exceeded. There is no timeout if
+// timeout is zero.
+func (c *OIDCClient) WithTimeout(timeout time.Duration) *OIDCClient {
+	c.timeout = timeout
+	return c
+}
+
 // Token requests an OIDC token from GitHub's provider, verifies it, and
-// returns the token.
+// returns the token. Tokens are cached by audience until shortly before they
+// expire.
 func (c *OIDCClient) Token(ctx context.Context, audience []string) (*OIDCToken, error) {
+	if len(c.audience) > 0 {
+		audience = c.audience
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := audienceKey(audience)
+	if t, ok := c.tokens[key]; ok && c.currentTime().Before(t.Expiry.Add(-tokenExpiryLeeway)) {
+		return t, nil
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	token, err := c.newToken(ctx, audience)
+	if err != nil {
+		if c.timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, errors.Errorf(&errOIDCTokenTimeout{}, "OIDC token request timed out after %s: %w", c.timeout, err)
+		}
+		return nil, err
+	}
+
+	if c.tokens == nil {
+		c.tokens = map[string]*OIDCToken{}
+	}
+	c.tokens[key] = token
+
+	return token, nil
+}
+
+func (c *OIDCClient) currentTime() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// newToken requests a new token from GitHub's provider and verifies it.
+func (c *OIDCClient) newToken(ctx context.Context, audience []string) (*OIDCToken, error) {
 	tokenBytes, err := c.requestToken(ctx, audience)
 	if err != nil {
 		return nil, err
@@ -250,6 +369,7 @@ func (c *OIDCClient) Token(ctx context.Context, audience []string) (*OIDCToken,
 	if err != nil {
 		return nil, err
 	}
+	token.RawToken = tokenPayload
 
 	if err := c.verifyClaims(token); err != nil {
 		return nil, err
@@ -258,6 +378,14 @@ func (c *OIDCClient) Token(ctx context.Context, audience []string) (*OIDCToken,
 	return token, nil
 }
 
+// audienceKey returns a key for the audience that does not depend on the
+// order of its elements.
+func audienceKey(audience []string) string {
+	a := append([]string{}, audience...)
+	sort.Strings(a)
+	return strings.Join(a, "\n")
+}
+
 func compareStringSlice(s1, s2 []string) bool {
 	// Verify the audience received is the one we requested.
 	if len(s1) != len(s2) {
@@ -281,6 +281,168 @@ func TestToken(t *testing.T) {
 	}
 }
 
+// TestToken_cache tests that tokens are cached until they expire.
+func TestToken_cache(t *testing.T) {
+	now := time.Date(2022, 4, 14, 12, 24, 0, 0, time.UTC)
+
+	token := &OIDCToken{
+		Audience:          []string{"hoge"},
+		Expiry:            now.Add(1 * time.Hour),
+		JobWorkflowRef:    "pico",
+		RepositoryID:      "1234",
+		RepositoryOwnerID: "4321",
+		ActorID:           "4567",
+	}
+	s, c := NewTestOIDCServer(t, now, token)
+	defer s.Close()
+
+	clock := now
+	c.now = func() time.Time { return clock }
+
+	got, err := c.Token(context.Background(), []string{"hoge"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "pico"; got.JobWorkflowRef != want {
+		t.Fatalf("unexpected workflow ref, want: %q, got: %q", want, got.JobWorkflowRef)
+	}
+
+	// The server now returns a different token but the cached one should
+	// be returned.
+	token.JobWorkflowRef = "hoge"
+	token.Expiry = now.Add(2 * time.Hour)
+
+	got, err = c.Token(context.Background(), []string{"hoge"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "pico"; got.JobWorkflowRef != want {
+		t.Errorf("unexpected workflow ref for cached token, want: %q, got: %q", want, got.JobWorkflowRef)
+	}
+
+	// The cached token has expired so a new token should be requested.
+	clock = now.Add(1 * time.Hour)
+
+	got, err = c.Token(context.Background(), []string{"hoge"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "hoge"; got.JobWorkflowRef != want {
+		t.Errorf("unexpected workflow ref for refreshed token, want: %q, got: %q", want, got.JobWorkflowRef)
+	}
+}
+
+// TestToken_bearerToken tests overriding the token used to request OIDC tokens.
+func TestToken_bearerToken(t *testing.T) {
+	now := time.Date(2022, 4, 14, 12, 24, 0, 0, time.UTC)
+
+	var auth string
+	s, c := newTestOIDCServer(t, now, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer s.Close()
+	c.WithBearerToken("from-file")
+
+	want := &errRequestError{}
+	if _, err := c.Token(context.Background(), []string{"hoge"}); !errors.As(err, &want) {
+		t.Fatalf("unexpected error: %v", cmp.Diff(err, want, cmpopts.EquateErrors()))
+	}
+	if want, got := "bearer from-file", auth; want != got {
+		t.Errorf("unexpected authorization header, want: %q, got: %q", want, got)
+	}
+}
+
+// TestToken_timeout tests that a token request that takes longer than the
+// timeout fails.
+func TestToken_timeout(t *testing.T) {
+	now := time.Date(2022, 4, 14, 12, 24, 0, 0, time.UTC)
+
+	s, c := newTestOIDCServer(t, now, func(w http.ResponseWriter, r *http.Request) {
+		// Sleep longer than the timeout, but stop once the client gives up
+		// so the server can be closed.
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer s.Close()
+	c.WithTimeout(10 * time.Millisecond)
+
+	start := time.Now()
+	_, err := c.Token(context.Background(), []string{"hoge"})
+	want := &errOIDCTokenTimeout{}
+	if !errors.As(err, &want) {
+		t.Fatalf("unexpected error: %v", cmp.Diff(err, want, cmpopts.EquateErrors()))
+	}
+	if elapsed := time.Since(start); elapsed >= 5*time.Second {
+		t.Errorf("unexpected token request time, want: < 5s, got: %s", elapsed)
+	}
+}
+
+// TestToken_audience tests overriding the token audience.
+func TestToken_audience(t *testing.T) {
+	now := time.Date(2022, 4, 14, 12, 24, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		audience []string
+		token    *OIDCToken
+		err      bool
+	}{
+		{
+			name:     "override",
+			audience: []string{"custom"},
+			token: &OIDCToken{
+				Audience:          []string{"custom"},
+				Expiry:            now.Add(1 * time.Hour),
+				JobWorkflowRef:    "pico",
+				RepositoryID:      "1234",
+				RepositoryOwnerID: "4321",
+				ActorID:           "4567",
+			},
+		},
+		{
+			name:     "mismatch",
+			audience: []string{"custom"},
+			token: &OIDCToken{
+				Audience:          []string{"hoge"},
+				Expiry:            now.Add(1 * time.Hour),
+				JobWorkflowRef:    "pico",
+				RepositoryID:      "1234",
+				RepositoryOwnerID: "4321",
+				ActorID:           "4567",
+			},
+			err: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, c := NewTestOIDCServer(t, now, tc.token)
+			defer s.Close()
+			c.WithAudience(tc.audience)
+
+			token, err := c.Token(context.Background(), []string{"hoge"})
+			if tc.err {
+				want := &errVerify{}
+				if !errors.As(err, &want) {
+					t.Fatalf("unexpected error: %v", cmp.Diff(err, want, cmpopts.EquateErrors()))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want, got := tc.token, token; !tokenEqual(s.URL, want, got) {
+				t.Errorf("unexpected token\nwant: %#v\ngot:  %#v", want, got)
+			}
+		})
+	}
+}
+
 func Test_compareStringSlice(t *testing.T) {
 	testCases := []struct {
 		name     string
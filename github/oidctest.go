@@ -20,6 +20,7 @@ import (
 
 type jsonToken struct {
 	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
 	JobWorkflowRef    string   `json:"job_workflow_ref"`
 	RepositoryID      string   `json:"repository_id"`
 	RepositoryOwnerID string   `json:"repository_owner_id"`
@@ -71,6 +72,7 @@ func NewTestOIDCServer(t *testing.T, now time.Time, token *OIDCToken) (*httptest
 
 		b, err := json.Marshal(jsonToken{
 			Issuer:            issuer,
+			Subject:           token.Subject,
 			Audience:          token.Audience,
 			Expiry:            token.Expiry.Unix(),
 			JobWorkflowRef:    token.JobWorkflowRef,
@@ -0,0 +1,38 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"os"
+)
+
+// AppendStepSummary appends Markdown to the job summary file located at
+// GITHUB_STEP_SUMMARY. Nothing is written if GITHUB_STEP_SUMMARY is not set,
+// e.g. when running outside of GitHub Actions.
+func AppendStepSummary(markdown string) error {
+	filename := os.Getenv("GITHUB_STEP_SUMMARY")
+	if filename == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(markdown)
+	return err
+}
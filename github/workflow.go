@@ -23,6 +23,17 @@ import (
 
 const (
 	githubContextEnvKey = "GITHUB_CONTEXT"
+
+	// runAttemptEnvKey is the environment variable set by GitHub Actions
+	// containing the run attempt. It is used if the run attempt is missing
+	// from the github context.
+	runAttemptEnvKey = "GITHUB_RUN_ATTEMPT"
+
+	// workflowRefEnvKey and workflowSHAEnvKey are the environment variables
+	// set by GitHub Actions containing the ref and commit of the workflow
+	// file. They are used if they are missing from the github context.
+	workflowRefEnvKey = "GITHUB_WORKFLOW_REF"
+	workflowSHAEnvKey = "GITHUB_WORKFLOW_SHA"
 )
 
 // WorkflowContext is the `github` context given to workflows that contains
@@ -34,6 +45,8 @@ type WorkflowContext struct {
 	RepositoryOwner string                 `json:"repository_owner"`
 	ActionPath      string                 `json:"action_path"`
 	Workflow        string                 `json:"workflow"`
+	WorkflowRef     string                 `json:"workflow_ref"`
+	WorkflowSHA     string                 `json:"workflow_sha"`
 	EventName       string                 `json:"event_name"`
 	Event           map[string]interface{} `json:"event"`
 	SHA             string                 `json:"sha"`
@@ -65,6 +78,53 @@ func (c *WorkflowContext) RepositoryURI() string {
 	)
 }
 
+// PullRequest describes the pull request in the event payload of a workflow
+// triggered by a pull request.
+type PullRequest struct {
+	// HeadRepository is the full name of the repository the pull request
+	// was opened from.
+	HeadRepository string
+
+	// BaseRepository is the full name of the repository the pull request
+	// was opened against.
+	BaseRepository string
+
+	// AuthorAssociation is the relationship of the pull request author with
+	// the base repository, e.g. "MEMBER" or "FIRST_TIME_CONTRIBUTOR". It is
+	// empty if not available.
+	AuthorAssociation string
+}
+
+// FromFork reports whether the pull request was opened from a different
+// repository than the one it targets.
+func (p *PullRequest) FromFork() bool {
+	return p.HeadRepository != p.BaseRepository
+}
+
+// PullRequest returns the pull request in the event payload, or nil if the
+// payload has no pull request.
+func (c *WorkflowContext) PullRequest() *PullRequest {
+	pr, ok := c.Event["pull_request"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	repoName := func(key string) string {
+		ref, _ := pr[key].(map[string]interface{})
+		repo, _ := ref["repo"].(map[string]interface{})
+		name, _ := repo["full_name"].(string)
+		return name
+	}
+	p := &PullRequest{
+		HeadRepository: repoName("head"),
+		BaseRepository: repoName("base"),
+	}
+	if p.BaseRepository == "" {
+		p.BaseRepository = c.Repository
+	}
+	p.AuthorAssociation, _ = pr["author_association"].(string)
+	return p
+}
+
 // GetWorkflowContext returns the current GitHub Actions 'github' context.
 func GetWorkflowContext() (WorkflowContext, error) {
 	w := WorkflowContext{}
@@ -73,8 +133,23 @@ func GetWorkflowContext() (WorkflowContext, error) {
 		return w, errors.New("GITHUB_CONTEXT environment variable not set")
 	}
 
-	err := json.Unmarshal([]byte(ghContext), &w)
-	return w, err
+	if err := json.Unmarshal([]byte(ghContext), &w); err != nil {
+		return w, err
+	}
+
+	// NOTE: The run ID does not change when a workflow is re-run so the run
+	// attempt is needed to distinguish provenance generated by a re-run.
+	if w.RunAttempt == "" {
+		w.RunAttempt = os.Getenv(runAttemptEnvKey)
+	}
+	if w.WorkflowRef == "" {
+		w.WorkflowRef = os.Getenv(workflowRefEnvKey)
+	}
+	if w.WorkflowSHA == "" {
+		w.WorkflowSHA = os.Getenv(workflowSHAEnvKey)
+	}
+
+	return w, nil
 }
 
 // GetToken gets the Github Actions token.
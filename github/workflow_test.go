@@ -0,0 +1,182 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetWorkflowContext_runAttempt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		context  string
+		env      string
+		expected string
+	}{
+		{
+			name:     "from context",
+			context:  `{"run_id": "1234", "run_attempt": "2"}`,
+			env:      "3",
+			expected: "2",
+		},
+		{
+			name:     "from env",
+			context:  `{"run_id": "1234"}`,
+			env:      "3",
+			expected: "3",
+		},
+		{
+			name:     "missing",
+			context:  `{"run_id": "1234"}`,
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(githubContextEnvKey, tc.context)
+			t.Setenv(runAttemptEnvKey, tc.env)
+
+			w, err := GetWorkflowContext()
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := tc.expected, w.RunAttempt; want != got {
+				t.Errorf("unexpected run attempt, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+func TestGetWorkflowContext_workflowRef(t *testing.T) {
+	const ref = "owner/repo/.github/workflows/release.yml@refs/heads/main"
+	const sha = "8fa2e2fbf1a1ba4ea1b6ab5ec2e18b8fcc0a3b46"
+
+	testCases := []struct {
+		name    string
+		context string
+		env     bool
+	}{
+		{
+			name:    "from context",
+			context: `{"workflow_ref": "` + ref + `", "workflow_sha": "` + sha + `"}`,
+		},
+		{
+			name:    "from env",
+			context: `{}`,
+			env:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(githubContextEnvKey, tc.context)
+			t.Setenv(workflowRefEnvKey, "")
+			t.Setenv(workflowSHAEnvKey, "")
+			if tc.env {
+				t.Setenv(workflowRefEnvKey, ref)
+				t.Setenv(workflowSHAEnvKey, sha)
+			}
+
+			w, err := GetWorkflowContext()
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := ref, w.WorkflowRef; want != got {
+				t.Errorf("unexpected workflow ref, want: %q, got: %q", want, got)
+			}
+			if want, got := sha, w.WorkflowSHA; want != got {
+				t.Errorf("unexpected workflow sha, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+func TestWorkflowContext_PullRequest(t *testing.T) {
+	testCases := []struct {
+		name     string
+		context  WorkflowContext
+		expected *PullRequest
+		fork     bool
+	}{
+		{
+			name: "push",
+			context: WorkflowContext{
+				Repository: "org/repo",
+				EventName:  "push",
+				Event:      map[string]interface{}{"ref": "refs/heads/main"},
+			},
+		},
+		{
+			name: "same repository",
+			context: WorkflowContext{
+				Repository: "org/repo",
+				EventName:  "pull_request",
+				Event: map[string]interface{}{
+					"pull_request": map[string]interface{}{
+						"author_association": "MEMBER",
+						"head": map[string]interface{}{
+							"repo": map[string]interface{}{"full_name": "org/repo"},
+						},
+						"base": map[string]interface{}{
+							"repo": map[string]interface{}{"full_name": "org/repo"},
+						},
+					},
+				},
+			},
+			expected: &PullRequest{
+				HeadRepository:    "org/repo",
+				BaseRepository:    "org/repo",
+				AuthorAssociation: "MEMBER",
+			},
+		},
+		{
+			name: "fork",
+			context: WorkflowContext{
+				Repository: "org/repo",
+				EventName:  "pull_request",
+				Event: map[string]interface{}{
+					"pull_request": map[string]interface{}{
+						"head": map[string]interface{}{
+							"repo": map[string]interface{}{"full_name": "user/repo"},
+						},
+					},
+				},
+			},
+			expected: &PullRequest{
+				HeadRepository: "user/repo",
+				BaseRepository: "org/repo",
+			},
+			fork: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			pr := tc.context.PullRequest()
+			if diff := cmp.Diff(tc.expected, pr); diff != "" {
+				t.Fatalf("unexpected pull request (-want +got):\n%s", diff)
+			}
+			if pr != nil && pr.FromFork() != tc.fork {
+				t.Errorf("unexpected fork, want: %v, got: %v", tc.fork, pr.FromFork())
+			}
+		})
+	}
+}
@@ -18,24 +18,30 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/slsa-framework/slsa-github-generator/github"
-	"github.com/slsa-framework/slsa-github-generator/internal/builders/common"
 	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/provenance"
 	"github.com/slsa-framework/slsa-github-generator/slsa"
 )
 
 // generateCmd returns the 'generate' command.
 func generateCmd(provider slsa.ClientProvider, check func(error)) *cobra.Command {
 	var predicatePath string
+	var metadataPath string
+	var imagesPath string
 
 	c := &cobra.Command{
 		Use:   "generate",
 		Short: "Create a SLSA provenance predicate from a GitHub Action",
 		Long: `Generate SLSA provenance predicate from a GitHub Action. This command assumes
-that it is being run in the context of a Github Actions workflow.`,
+that it is being run in the context of a Github Actions workflow.
+
+If --image-metadata is set, the images in the metadata output of
+docker/build-push-action are written to --images as <name>@<digest> lines.`,
 
 		Run: func(cmd *cobra.Command, args []string) {
 			ghContext, err := github.GetWorkflowContext()
@@ -43,28 +49,24 @@ that it is being run in the context of a Github Actions workflow.`,
 
 			ctx := context.Background()
 
-			b := common.GenericBuild{
-				// NOTE: Subjects are nil because we are only writing the predicate.
-				GithubActionsBuild: slsa.NewGithubActionsBuild(nil, &ghContext),
-				BuildTypeURI:       containerBuildType,
+			var images []image
+			if metadataPath != "" {
+				images, err = readImageMetadata(metadataPath)
+				check(err)
 			}
 
-			if provider != nil {
-				b.WithClients(provider)
-			} else if utils.IsPresubmitTests() {
-				// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
-				b.WithClients(&slsa.NilClientProvider{})
+			b := provenance.Builder{
+				WorkflowContext: &ghContext,
+				// NOTE: Subjects are nil because we are only writing the predicate.
+				BuildType: containerBuildType,
+				Clients:   provider,
 			}
-
-			g := slsa.NewHostedActionsGenerator(&b)
-			if provider != nil {
-				g.WithClients(provider)
-			} else if utils.IsPresubmitTests() {
+			if provider == nil && utils.IsPresubmitTests() {
 				// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
-				g.WithClients(&slsa.NilClientProvider{})
+				b.Clients = &slsa.NilClientProvider{}
 			}
 
-			p, err := g.Generate(ctx)
+			p, err := b.StatementV02(ctx)
 			check(err)
 
 			pb, err := json.Marshal(p.Predicate)
@@ -75,6 +77,17 @@ that it is being run in the context of a Github Actions workflow.`,
 
 			_, err = pf.Write(pb)
 			check(err)
+
+			if images != nil {
+				var refs strings.Builder
+				for _, img := range images {
+					refs.WriteString(img.String() + "\n")
+				}
+				f, err := utils.CreateNewFileUnderCurrentDirectory(imagesPath, os.O_WRONLY)
+				check(err)
+				_, err = f.Write([]byte(refs.String()))
+				check(err)
+			}
 		},
 	}
 
@@ -83,6 +96,14 @@ that it is being run in the context of a Github Actions workflow.`,
 		"predicate", "p", "predicate.json",
 		"Path to write the unsigned provenance predicate.",
 	)
+	c.Flags().StringVar(
+		&metadataPath, "image-metadata", "",
+		"Path to the metadata output of docker/build-push-action to read the images from.",
+	)
+	c.Flags().StringVar(
+		&imagesPath, "images", "images.txt",
+		"Path to write the images read from --image-metadata, one <name>@<digest> per line.",
+	)
 
 	return c
 }
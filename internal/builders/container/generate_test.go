@@ -151,3 +151,46 @@ func Test_generateCmd_invalid_path(t *testing.T) {
 	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
 	t.Errorf("expected an error to occur.")
 }
+
+func Test_generateCmd_image_metadata(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	metadata := `{"containerimage.digest": "` + testDigest + `", "image.name": "ghcr.io/org/image:v1"}`
+	if err := os.WriteFile("metadata.json", []byte(metadata), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	c := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{"--image-metadata", "metadata.json"})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "images.txt"))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := "ghcr.io/org/image@"+testDigest+"\n", string(b); want != got {
+		t.Errorf("unexpected images, want: %q, got: %q", want, got)
+	}
+}
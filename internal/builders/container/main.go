@@ -15,14 +15,14 @@
 package main
 
 import (
-	"errors"
-	"fmt"
 	"os"
 
 	// Enable the github OIDC auth provider.
 	_ "github.com/sigstore/cosign/pkg/providers/github"
 
 	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
 )
 
 // containerBuildType is the URI for generic container SLSA generation.
@@ -30,8 +30,7 @@ var containerBuildType = "https://github.com/slsa-framework/slsa-github-generato
 
 func checkExit(err error) {
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(errors.WriteExitError(os.Stderr, err))
 	}
 }
 
@@ -0,0 +1,169 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	// metadataDigestKey is the key of the image digest in the metadata.
+	metadataDigestKey = "containerimage.digest"
+
+	// metadataNameKey is the key of the comma separated image names in the
+	// metadata.
+	metadataNameKey = "image.name"
+)
+
+// imageDigestRegex matches the image digests accepted as subjects.
+var imageDigestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// errImageMetadata indicates invalid image metadata.
+type errImageMetadata struct {
+	errors.ErrInput
+}
+
+// image is a container image subject.
+type image struct {
+	// Name is the image name without tag or digest.
+	Name string
+
+	// Digest is the image digest of the form sha256:<hex>.
+	Digest string
+}
+
+// String returns the image reference, i.e. <name>@<digest>.
+func (i image) String() string {
+	return i.Name + "@" + i.Digest
+}
+
+// metadataError returns an errImageMetadata that points at the action
+// expected to produce the metadata.
+func metadataError(format string, args ...interface{}) error {
+	return errors.Errorf(&errImageMetadata{}, "invalid image metadata: "+format+
+		"; expected the 'metadata' output of docker/build-push-action or docker/bake-action", args...)
+}
+
+// readImageMetadata reads the images from a metadata file written by
+// docker/build-push-action. The file is either the metadata of a single build
+// or, for docker/bake-action, an object with the metadata of each target.
+// Each name in image.name is returned with the digest of its build.
+func readImageMetadata(path string) ([]image, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errImageMetadata{}, "reading image metadata: %w", err)
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, metadataError("%v", err)
+	}
+
+	var builds []map[string]json.RawMessage
+	if _, ok := m[metadataDigestKey]; ok {
+		builds = append(builds, m)
+	} else {
+		for target, raw := range m {
+			var build map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &build); err != nil {
+				return nil, metadataError("target %q: %v", target, err)
+			}
+			builds = append(builds, build)
+		}
+	}
+
+	seen := map[image]bool{}
+	var images []image
+	for _, build := range builds {
+		imgs, err := buildImages(build)
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range imgs {
+			if !seen[img] {
+				seen[img] = true
+				images = append(images, img)
+			}
+		}
+	}
+	if len(images) == 0 {
+		return nil, metadataError("no images found")
+	}
+
+	// Sort the images so that the output doesn't depend on map ordering.
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].String() < images[j].String()
+	})
+	return images, nil
+}
+
+// buildImages returns the images pushed by a single build.
+func buildImages(build map[string]json.RawMessage) ([]image, error) {
+	var digest, names string
+	if raw, ok := build[metadataDigestKey]; !ok {
+		return nil, metadataError("missing %q", metadataDigestKey)
+	} else if err := json.Unmarshal(raw, &digest); err != nil {
+		return nil, metadataError("%q: %v", metadataDigestKey, err)
+	}
+	if !imageDigestRegex.MatchString(digest) {
+		return nil, metadataError("invalid digest %q", digest)
+	}
+	if raw, ok := build[metadataNameKey]; !ok {
+		return nil, metadataError("missing %q", metadataNameKey)
+	} else if err := json.Unmarshal(raw, &names); err != nil {
+		return nil, metadataError("%q: %v", metadataNameKey, err)
+	}
+
+	var images []image
+	for _, n := range strings.Split(names, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		name, err := imageName(n)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image{Name: name, Digest: digest})
+	}
+	if len(images) == 0 {
+		return nil, metadataError("empty %q", metadataNameKey)
+	}
+	return images, nil
+}
+
+// imageName returns the image name in ref without its tag or digest, e.g.
+// "ghcr.io/org/image" for "ghcr.io/org/image:v1".
+func imageName(ref string) (string, error) {
+	if strings.ContainsAny(ref, " \t\r\n") {
+		return "", metadataError("invalid image name %q", ref)
+	}
+	name, _, _ := strings.Cut(ref, "@")
+	// NOTE: The tag is after the last '/' since the registry may have a port.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if name == "" {
+		return "", metadataError("invalid image name %q", ref)
+	}
+	return name, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	testDigest  = "sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"
+	testDigest2 = "sha256:7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"
+)
+
+func Test_readImageMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata string
+		expected []image
+		err      bool
+	}{
+		{
+			name: "single image",
+			metadata: `{
+				"buildx.build.ref": "builder/builder0/abc",
+				"containerimage.config.digest": "` + testDigest2 + `",
+				"containerimage.digest": "` + testDigest + `",
+				"image.name": "ghcr.io/org/image:v1"
+			}`,
+			expected: []image{
+				{Name: "ghcr.io/org/image", Digest: testDigest},
+			},
+		},
+		{
+			name: "multiple names",
+			metadata: `{
+				"containerimage.digest": "` + testDigest + `",
+				"image.name": "ghcr.io/org/image:v1,ghcr.io/org/image:latest,localhost:5000/image:v1"
+			}`,
+			expected: []image{
+				{Name: "ghcr.io/org/image", Digest: testDigest},
+				{Name: "localhost:5000/image", Digest: testDigest},
+			},
+		},
+		{
+			name: "bake targets",
+			metadata: `{
+				"b": {
+					"containerimage.digest": "` + testDigest2 + `",
+					"image.name": "ghcr.io/org/b"
+				},
+				"a": {
+					"containerimage.digest": "` + testDigest + `",
+					"image.name": "ghcr.io/org/a:v1"
+				}
+			}`,
+			expected: []image{
+				{Name: "ghcr.io/org/a", Digest: testDigest},
+				{Name: "ghcr.io/org/b", Digest: testDigest2},
+			},
+		},
+		{
+			name: "invalid digest",
+			metadata: `{
+				"containerimage.digest": "sha256:abcdef",
+				"image.name": "ghcr.io/org/image:v1"
+			}`,
+			err: true,
+		},
+		{
+			name: "missing name",
+			metadata: `{
+				"containerimage.digest": "` + testDigest + `"
+			}`,
+			err: true,
+		},
+		{
+			name:     "empty",
+			metadata: `{}`,
+			err:      true,
+		},
+		{
+			name:     "not json",
+			metadata: `sha256:abcdef`,
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metadata.json")
+			if err := os.WriteFile(path, []byte(tc.metadata), 0o600); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			images, err := readImageMetadata(path)
+			errMetadata := &errImageMetadata{}
+			if got := errors.As(err, &errMetadata); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if diff := cmp.Diff(tc.expected, images); diff != "" {
+				t.Errorf("unexpected images (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
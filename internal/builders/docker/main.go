@@ -15,17 +15,16 @@
 package main
 
 import (
-	"errors"
-	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
 )
 
 func checkExit(err error) {
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(errors.WriteExitError(os.Stderr, err))
 	}
 }
 
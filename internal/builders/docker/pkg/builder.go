@@ -34,6 +34,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
@@ -41,6 +42,7 @@ import (
 
 	"github.com/slsa-framework/slsa-github-generator/internal/errors"
 	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
 )
 
 // errGitCommitMismatch indicates that the repo is checked out at an unexpected commit hash.
@@ -65,6 +67,8 @@ type DockerBuild struct {
 	config      *DockerBuildConfig
 	buildConfig *BuildConfig
 	RepoInfo    *RepoCheckoutInfo
+	// Host path mounted as the workspace of the builder container.
+	workspace string
 }
 
 // RepoCheckoutInfo contains info about the location of a locally checked out
@@ -83,8 +87,10 @@ type Fetcher interface {
 // Builder is responsible for setting up the environment and using docker
 // commands to build artifacts as specified in a DockerBuildConfig.
 type Builder struct {
-	repoFetcher Fetcher
-	config      DockerBuildConfig
+	repoFetcher   Fetcher
+	imageResolver ImageResolver
+	imageVerifier ImageVerifier
+	config        DockerBuildConfig
 }
 
 // NewBuilderWithGitFetcher creates a new Builder that fetches the sources
@@ -95,10 +101,18 @@ func NewBuilderWithGitFetcher(config *DockerBuildConfig) (*Builder, error) {
 		return nil, fmt.Errorf("could not create builder: %v", err)
 	}
 
-	return &Builder{
-		repoFetcher: gc,
-		config:      *config,
-	}, nil
+	b := &Builder{
+		repoFetcher:   gc,
+		imageResolver: DockerImageResolver{},
+		config:        *config,
+	}
+	if config.BuilderImageIdentity != "" {
+		b.imageVerifier = &CosignImageVerifier{
+			Identity:   config.BuilderImageIdentity,
+			OIDCIssuer: config.BuilderImageOIDCIssuer,
+		}
+	}
+	return b, nil
 }
 
 // CreateBuildDefinition creates a BuildDefinition from the DockerBuildConfig
@@ -108,14 +122,19 @@ func (db *DockerBuild) CreateBuildDefinition() *slsa1.ProvenanceBuildDefinition
 		Source:       sourceArtifact(db.config),
 		BuilderImage: builderImage(db.config),
 		ConfigPath:   db.config.BuildConfigPath,
-		Config:       *db.buildConfig,
+		Config: RecordedBuildConfig{
+			SchemaVersion: BuildConfigSchemaVersion,
+			BuildConfig:   *db.buildConfig,
+			DockerRun:     db.dockerRunConfig(),
+		},
 	}
 
-	// Currently we don't have any SystemParameters or ResolvedDependencies.
-	// So these fields are left empty.
+	// Currently we don't have any SystemParameters. So this field is left
+	// empty.
 	return &slsa1.ProvenanceBuildDefinition{
-		BuildType:          DockerBasedBuildType,
-		ExternalParameters: ep,
+		BuildType:            DockerBasedBuildType,
+		ExternalParameters:   ep,
+		ResolvedDependencies: []slsa1.ArtifactReference{builderImage(db.config)},
 	}
 }
 
@@ -135,10 +154,24 @@ func builderImage(config *DockerBuildConfig) slsa1.ArtifactReference {
 	}
 }
 
-// SetUpBuildState sets up the build by checking out the source repository and
-// loading the config file. It returns an instance of DockerBuild, or an error
-// if setting up the build state fails.
+// SetUpBuildState sets up the build by resolving and verifying the builder
+// image, checking out the source repository, and loading the config file. It
+// returns an instance of DockerBuild, or an error if setting up the build
+// state fails.
 func (b *Builder) SetUpBuildState() (*DockerBuild, error) {
+	// 0. Pin the builder image to a digest, so that all subsequent docker
+	// commands use the image that is recorded in the provenance.
+	image, err := resolveImage(b.imageResolver, &b.config.BuilderImage)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't resolve builder image: %w", err)
+	}
+	b.config.BuilderImage = *image
+	if b.imageVerifier != nil {
+		if err := b.imageVerifier.Verify(image); err != nil {
+			return nil, fmt.Errorf("couldn't verify builder image: %w", err)
+		}
+	}
+
 	// 1. Check out the repo, or verify that it is checked out.
 	repoInfo, err := b.repoFetcher.Fetch()
 	if err != nil {
@@ -157,10 +190,18 @@ func (b *Builder) SetUpBuildState() (*DockerBuild, error) {
 		return nil, err
 	}
 
+	// 4. Get the current working directory. It is mounted as the workspace of
+	// the builder container.
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the current working directory: %v", err)
+	}
+
 	db := &DockerBuild{
 		config:      &b.config,
 		buildConfig: bc,
 		RepoInfo:    repoInfo,
+		workspace:   cwd,
 	}
 	return db, nil
 }
@@ -174,35 +215,60 @@ func (db *DockerBuild) BuildArtifacts(outputFolder string) ([]intoto.Subject, er
 	return inspectAndWriteArtifacts(db.buildConfig.ArtifactPath, outputFolder, db.RepoInfo.RepoRoot)
 }
 
-func runDockerRun(db *DockerBuild) error {
-	// Get the current working directory. We will mount it as a Docker volume.
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("couldn't get the current working directory: %v", err)
+// dockerRunConfig returns the configuration of the `docker run` command used
+// for building the artifacts. Only the environment variables allowed by
+// slsa.DefaultEnvironmentAllowlist or the --env-allowlist input are recorded,
+// and values of secret environment variables are redacted.
+func (db *DockerBuild) dockerRunConfig() *DockerRunConfig {
+	var env map[string]string
+	if len(db.buildConfig.Env) > 0 {
+		env = slsa.CaptureEnvironment(db.buildConfig.Env, db.config.EnvAllowlist)
+	}
+
+	return &DockerRunConfig{
+		Image:      db.config.BuilderImage.ToString(),
+		Command:    db.buildConfig.Command,
+		WorkingDir: "/workspace",
+		Env:        env,
+		Mounts: []Mount{
+			{Source: db.workspace, Target: "/workspace"},
+		},
 	}
+}
 
-	defaultDockerRunFlags := []string{
-		// Mount the current working directory to workspace.
-		fmt.Sprintf("--volume=%s:/workspace", cwd),
-		"--workdir=/workspace",
+// args returns the arguments to the `docker` command for running this
+// config, using the given environment variables.
+func (rc *DockerRunConfig) args(env map[string]string) []string {
+	args := []string{"run"}
+	for _, m := range rc.Mounts {
+		args = append(args, fmt.Sprintf("--volume=%s:%s", m.Source, m.Target))
+	}
+	args = append(args,
+		fmt.Sprintf("--workdir=%s", rc.WorkingDir),
 		// Remove the container file system after the container exits.
 		"--rm",
-	}
+	)
 
-	buildDef := db.CreateBuildDefinition()
-	dockerEp, ok := buildDef.ExternalParameters.(DockerBasedExternalParameters)
-	if !ok {
-		return fmt.Errorf("expected docker-based external parameters")
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("--env=%s=%s", k, env[k]))
 	}
 
-	var args []string
-	args = append(args, "run")
-	args = append(args, defaultDockerRunFlags...)
-	args = append(args, dockerEp.BuilderImage.URI)
-	args = append(args, db.buildConfig.Command...)
-	cmd := exec.Command("docker", args...)
+	args = append(args, rc.Image)
+	return append(args, rc.Command...)
+}
+
+func runDockerRun(db *DockerBuild) error {
+	rc := db.dockerRunConfig()
+	//#nosec G204 -- Input from user config file.
+	cmd := exec.Command("docker", rc.args(db.buildConfig.Env)...)
 
-	log.Printf("Running command: %q.", cmd.String())
+	// Log the redacted command, to avoid leaking secrets.
+	log.Printf("Running command: %q.", append([]string{"docker"}, rc.args(rc.Env)...))
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -634,7 +700,7 @@ func (p *ProvenanceStatementSLSA1) ToDockerBuildConfig(forceCheckout bool) (*Doc
 	if err != nil {
 		return nil, fmt.Errorf("validating Docker image URI: %v", err)
 	}
-	if di.Digest.Value != ep.BuilderImage.Digest[di.Digest.Alg] {
+	if di.Digest.Value == "" || di.Digest.Value != ep.BuilderImage.Digest[di.Digest.Alg] {
 		return nil, fmt.Errorf("invalid Docker image digest")
 	}
 
@@ -15,6 +15,7 @@
 package pkg
 
 import (
+	"encoding/json"
 	"os"
 	"strings"
 	"testing"
@@ -43,6 +44,7 @@ func Test_CreateBuildDefinition(t *testing.T) {
 			Command:      []string{"cp", "internal/builders/docker/testdata/config.toml", "config.toml"},
 			ArtifactPath: "config.toml",
 		},
+		workspace: "/home/runner/work/slsa-github-generator/slsa-github-generator",
 	}
 
 	got := db.CreateBuildDefinition()
@@ -57,6 +59,118 @@ func Test_CreateBuildDefinition(t *testing.T) {
 	}
 }
 
+func Test_RecordedBuildConfig(t *testing.T) {
+	image := DockerImage{
+		Name:   "bash",
+		Digest: Digest{Alg: "sha256", Value: "9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9"},
+	}
+
+	tests := []struct {
+		name        string
+		image       DockerImage
+		buildConfig BuildConfig
+		allowlist   []string
+		golden      string
+	}{
+		{
+			name:  "simple",
+			image: image,
+			buildConfig: BuildConfig{
+				Command:      []string{"cp", "internal/builders/docker/testdata/config.toml", "config.toml"},
+				ArtifactPath: "config.toml",
+			},
+			golden: "testdata/build-config-simple.json",
+		},
+		{
+			name: "env with secrets",
+			image: DockerImage{
+				Name:   "ghcr.io/org/builder",
+				Digest: image.Digest,
+			},
+			buildConfig: BuildConfig{
+				Command:      []string{"make", "release"},
+				ArtifactPath: "out/*.tar.gz",
+				Env: map[string]string{
+					"CGO_ENABLED": "0",
+					"GOFLAGS":     "-trimpath",
+					"API_TOKEN":   "ghp_secret",
+					"signing_key": "secret",
+					// Not allowlisted, so not recorded.
+					"DEPLOY_REGION": "eu",
+				},
+			},
+			allowlist: []string{"CGO_ENABLED", "GO*", "API_TOKEN", "signing_key"},
+			golden:    "testdata/build-config-env.json",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			db := &DockerBuild{
+				config:      &DockerBuildConfig{BuilderImage: tt.image, EnvAllowlist: tt.allowlist},
+				buildConfig: &tt.buildConfig,
+				workspace:   "/home/runner/work/repo/repo",
+			}
+
+			ep, ok := db.CreateBuildDefinition().ExternalParameters.(DockerBasedExternalParameters)
+			if !ok {
+				t.Fatalf("expected docker-based external parameters")
+			}
+			gotBytes, err := json.Marshal(ep.Config)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			wantBytes, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(gotBytes, &got); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if err := json.Unmarshal(wantBytes, &want); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func Test_DockerRunConfig_args(t *testing.T) {
+	db := &DockerBuild{
+		config: &DockerBuildConfig{
+			BuilderImage: DockerImage{
+				Name:   "bash",
+				Digest: Digest{Alg: "sha256", Value: "9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9"},
+			},
+		},
+		buildConfig: &BuildConfig{
+			Command: []string{"make"},
+			Env:     map[string]string{"B": "b", "A_TOKEN": "secret"},
+		},
+		workspace: "/src",
+	}
+	rc := db.dockerRunConfig()
+
+	want := []string{
+		"run", "--volume=/src:/workspace", "--workdir=/workspace", "--rm",
+		"--env=A_TOKEN=secret", "--env=B=b",
+		"bash@sha256:9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9", "make",
+	}
+	if diff := cmp.Diff(want, rc.args(db.buildConfig.Env)); diff != "" {
+		t.Errorf(diff)
+	}
+
+	// The redacted env must not leak the secret.
+	if got := strings.Join(rc.args(rc.Env), " "); strings.Contains(got, "secret") {
+		t.Errorf("redacted args contain a secret: %q", got)
+	}
+}
+
 func Test_GitClient_verifyOrFetchRepo(t *testing.T) {
 	config := &DockerBuildConfig{
 		// Use a small repo for test
@@ -170,7 +284,7 @@ func Test_inspectArtifacts(t *testing.T) {
 
 	s1 := intoto.Subject{
 		Name:   "build-definition.json",
-		Digest: map[string]string{"sha256": "fbe3d5448f3e43b20368c223eda70ecbf41bbe6e5956ee81b5d490c1753ea118"},
+		Digest: map[string]string{"sha256": "b17d3700d8d77de22d12752b9565b230ded8afc70d6537cf330b15acafe144ec"},
 	}
 	s2 := intoto.Subject{
 		Name:   "config.toml",
@@ -179,7 +293,7 @@ func Test_inspectArtifacts(t *testing.T) {
 
 	s3 := intoto.Subject{
 		Name:   "slsa1-provenance.json",
-		Digest: map[string]string{"sha256": "d40de4149c9ad41d2fdcba44ddb2be1760eb4fbb01644f7ddf9ed0a424d7ed44"},
+		Digest: map[string]string{"sha256": "e14116428923343210c1c0f8506ddae3b86df8be6f2378cbc6aafcbb9ceb633d"},
 	}
 
 	s4 := intoto.Subject{
@@ -212,9 +326,11 @@ func Test_Builder_SetUpBuildState(t *testing.T) {
 	}
 
 	f := testFetcher{}
+	r := testImageResolver{repoDigests: []string{config.BuilderImage.ToString()}}
 	b := Builder{
-		repoFetcher: f,
-		config:      config,
+		repoFetcher:   f,
+		imageResolver: r,
+		config:        config,
 	}
 
 	db, err := b.SetUpBuildState()
@@ -35,6 +35,9 @@ const (
 	ArtifactPathKey = "artifactPath"
 	// CommandKey is the lookup key for the command in ExternalParameters.
 	CommandKey = "command"
+	// BuildConfigSchemaVersion is the version of the schema of
+	// RecordedBuildConfig. It must be updated when the schema changes.
+	BuildConfigSchemaVersion = "v1"
 )
 
 // DockerBasedExternalParameters is a representation of the top level inputs to a
@@ -50,5 +53,44 @@ type DockerBasedExternalParameters struct {
 	ConfigPath string `json:"configPath"`
 
 	// Unpacked build config parameters
-	Config BuildConfig `json:"buildConfig"`
+	Config RecordedBuildConfig `json:"buildConfig"`
+}
+
+// RecordedBuildConfig is the build config as recorded in the provenance. In
+// addition to the parameters in the config file, it records exactly how the
+// builder container was invoked.
+type RecordedBuildConfig struct {
+	// Version of the schema of this struct. Verifiers can use this to select
+	// the policy to apply.
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+
+	BuildConfig
+
+	// The `docker run` invocation used for building the artifacts.
+	DockerRun *DockerRunConfig `json:"dockerRun,omitempty"`
+}
+
+// DockerRunConfig describes the invocation of the `docker run` command.
+type DockerRunConfig struct {
+	// The builder image, pinned to a digest.
+	Image string `json:"image"`
+
+	// The command passed to the container.
+	Command []string `json:"command"`
+
+	// The working directory inside the container.
+	WorkingDir string `json:"workingDir"`
+
+	// Environment variables passed to the container that are allowed to be
+	// recorded. Values of variables that look like secrets are redacted.
+	Env map[string]string `json:"env,omitempty"`
+
+	// Host paths mounted into the container.
+	Mounts []Mount `json:"mounts"`
+}
+
+// Mount is a host path mounted into the container.
+type Mount struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
 }
@@ -47,15 +47,31 @@ func Test_BuildDefinition(t *testing.T) {
 			Source:       wantSource,
 			BuilderImage: wantBuilderImage,
 			ConfigPath:   "internal/builders/docker/testdata/config.toml",
-			Config: BuildConfig{
-				ArtifactPath: "config.toml",
-				Command: []string{
-					"cp",
-					"internal/builders/docker/testdata/config.toml",
-					"config.toml",
+			Config: RecordedBuildConfig{
+				SchemaVersion: BuildConfigSchemaVersion,
+				BuildConfig: BuildConfig{
+					ArtifactPath: "config.toml",
+					Command: []string{
+						"cp",
+						"internal/builders/docker/testdata/config.toml",
+						"config.toml",
+					},
+				},
+				DockerRun: &DockerRunConfig{
+					Image: "bash@sha256:9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9",
+					Command: []string{
+						"cp",
+						"internal/builders/docker/testdata/config.toml",
+						"config.toml",
+					},
+					WorkingDir: "/workspace",
+					Mounts: []Mount{
+						{Source: "/home/runner/work/slsa-github-generator/slsa-github-generator", Target: "/workspace"},
+					},
 				},
 			},
 		},
+		ResolvedDependencies: []slsa1.ArtifactReference{wantBuilderImage},
 	}
 
 	if diff := cmp.Diff(got, want); diff != "" {
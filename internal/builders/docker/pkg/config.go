@@ -30,14 +30,19 @@ import (
 type BuildConfig struct {
 	// The path, relative to the root of the git repository, where the artifact
 	// built by the `docker run` command is expected to be found.
-	ArtifactPath string `toml:"artifact_path"`
+	ArtifactPath string `toml:"artifact_path" json:"artifactPath"`
 
-	// TODO(#1191): Add env and options if needed.
+	// TODO(#1191): Add options if needed.
 	// Command to pass to `docker run`. The command is taken as an array
 	// instead of a single string to avoid unnecessary parsing. See
 	// https://docs.docker.com/engine/reference/builder/#cmd and
 	// https://man7.org/linux/man-pages/man3/exec.3.html for more details.
-	Command []string `toml:"command"`
+	Command []string `toml:"command" json:"command"`
+
+	// Environment variables to pass to `docker run`. The values are not
+	// serialized directly, since they may contain secrets. A redacted copy is
+	// recorded in DockerRunConfig instead.
+	Env map[string]string `toml:"env" json:"-"`
 }
 
 // Digest specifies a digest values, including the name of the hash function
@@ -48,14 +53,19 @@ type Digest struct {
 }
 
 // DockerImage fully specifies a docker image by a URI (e.g., including the
-// docker image name and registry), and its digest.
+// docker image name and registry), and its digest. The digest is empty if the
+// image was given by a tag and has not been resolved yet.
 type DockerImage struct {
 	Name   string
 	Digest Digest
 }
 
-// ToString returns the builder image in the form of NAME@ALG:VALUE.
+// ToString returns the builder image in the form of NAME@ALG:VALUE, or NAME
+// if the image does not have a digest.
 func (bi *DockerImage) ToString() string {
+	if bi.Digest.Value == "" {
+		return bi.Name
+	}
 	return fmt.Sprintf("%s@%s:%s", bi.Name, bi.Digest.Alg, bi.Digest.Value)
 }
 
@@ -66,6 +76,17 @@ type DockerBuildConfig struct {
 	BuilderImage    DockerImage
 	BuildConfigPath string
 	ForceCheckout   bool
+
+	// BuilderImageIdentity and BuilderImageOIDCIssuer, if set, are the
+	// expected identity and issuer of the cosign signature on the builder
+	// image.
+	BuilderImageIdentity   string
+	BuilderImageOIDCIssuer string
+
+	// EnvAllowlist lists the environment variables of the build config that
+	// are recorded in the provenance, in addition to
+	// slsa.DefaultEnvironmentAllowlist.
+	EnvAllowlist []string
 }
 
 // NewDockerBuildConfig validates the inputs and generates an instance of
@@ -89,12 +110,19 @@ func NewDockerBuildConfig(io *InputOptions) (*DockerBuildConfig, error) {
 		return nil, fmt.Errorf("invalid build config path: %v", err)
 	}
 
+	if (io.BuilderImageIdentity == "") != (io.BuilderImageOIDCIssuer == "") {
+		return nil, fmt.Errorf("both the builder image identity and OIDC issuer must be set to verify the builder image")
+	}
+
 	return &DockerBuildConfig{
-		SourceRepo:      io.SourceRepo,
-		SourceDigest:    *sourceRepoDigest,
-		BuilderImage:    *dockerImage,
-		BuildConfigPath: io.BuildConfigPath,
-		ForceCheckout:   io.ForceCheckout,
+		SourceRepo:             io.SourceRepo,
+		SourceDigest:           *sourceRepoDigest,
+		BuilderImage:           *dockerImage,
+		BuildConfigPath:        io.BuildConfigPath,
+		ForceCheckout:          io.ForceCheckout,
+		BuilderImageIdentity:   io.BuilderImageIdentity,
+		BuilderImageOIDCIssuer: io.BuilderImageOIDCIssuer,
+		EnvAllowlist:           io.EnvAllowlist,
 	}, nil
 }
 
@@ -119,10 +147,19 @@ func validateDigest(input string) (*Digest, error) {
 	return &digest, nil
 }
 
+// validateDockerImage validates an image of the form NAME@ALG:VALUE or
+// NAME[:TAG]. In the latter case the returned image has an empty digest, and
+// must be resolved before it is used.
 func validateDockerImage(image string) (*DockerImage, error) {
 	imageParts := strings.Split(image, "@")
+	if len(imageParts) == 1 {
+		if err := validateURI(image); err != nil {
+			return nil, fmt.Errorf("docker image name (%q) is not a valid URI: %v", image, err)
+		}
+		return &DockerImage{Name: image}, nil
+	}
 	if len(imageParts) != 2 {
-		return nil, fmt.Errorf("got %s, want NAME@DIGEST format", image)
+		return nil, fmt.Errorf("got %s, want NAME@DIGEST or NAME:TAG format", image)
 	}
 
 	if err := validateURI(imageParts[0]); err != nil {
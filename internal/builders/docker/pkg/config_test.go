@@ -45,6 +45,7 @@ func Test_NewDockerBuildConfig(t *testing.T) {
 		SourceRepo:      "https://github.com/project-oak/transparent-release",
 		GitCommitHash:   "sha1:9b5f98310dbbad675834474fa68c37d880687cb9",
 		BuilderImage:    "bash@sha256:9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9",
+		EnvAllowlist:    []string{"CGO_ENABLED", "GO*"},
 	}
 	got, err := NewDockerBuildConfig(io)
 	if err != nil {
@@ -65,9 +66,25 @@ func Test_NewDockerBuildConfig(t *testing.T) {
 			},
 		},
 		BuildConfigPath: io.BuildConfigPath,
+		EnvAllowlist:    io.EnvAllowlist,
 	}
 
 	if diff := cmp.Diff(got, want); diff != "" {
 		t.Errorf(diff)
 	}
 }
+
+func Test_validateDockerImage_tag(t *testing.T) {
+	got, err := validateDockerImage("ghcr.io/org/builder:v1")
+	if err != nil {
+		t.Fatalf("invalid image: %v", err)
+	}
+
+	want := &DockerImage{Name: "ghcr.io/org/builder:v1"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf(diff)
+	}
+	if got.ToString() != want.Name {
+		t.Errorf("unexpected image string, want: %q, got: %q", want.Name, got.ToString())
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+// This file contains functionality for resolving the builder image to a
+// digest, and for verifying the builder image before it is executed.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// errImageDigestMismatch indicates that the pulled builder image does not
+// have the expected digest.
+type errImageDigestMismatch struct {
+	errors.WrappableError
+}
+
+// errImageResolve indicates an error when resolving the builder image to a
+// digest.
+type errImageResolve struct {
+	errors.WrappableError
+}
+
+// errImageSignature indicates that the signature on the builder image could
+// not be verified.
+type errImageSignature struct {
+	errors.WrappableError
+}
+
+// ImageResolver is an interface with a single method Resolve, for pulling a
+// Docker image and returning the repo digests of the pulled image, of the
+// form NAME@ALG:VALUE.
+type ImageResolver interface {
+	Resolve(ref string) ([]string, error)
+}
+
+// ImageVerifier is an interface with a single method Verify, for verifying a
+// Docker image, pinned to a digest, before it is executed.
+type ImageVerifier interface {
+	Verify(image *DockerImage) error
+}
+
+// DockerImageResolver resolves images using the `docker` CLI.
+type DockerImageResolver struct{}
+
+// Resolve pulls the image and returns its RepoDigests.
+func (DockerImageResolver) Resolve(ref string) ([]string, error) {
+	//#nosec G204 -- Input from user config file.
+	if out, err := exec.Command("docker", "pull", ref).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pulling image %q: %v: %s", ref, err, out)
+	}
+
+	//#nosec G204 -- Input from user config file.
+	out, err := exec.Command("docker", "image", "inspect", "--format", "{{json .RepoDigests}}", ref).Output()
+	if err != nil {
+		return nil, fmt.Errorf("inspecting image %q: %v", ref, err)
+	}
+
+	var repoDigests []string
+	if err := json.Unmarshal(out, &repoDigests); err != nil {
+		return nil, fmt.Errorf("parsing RepoDigests of image %q: %v", ref, err)
+	}
+	return repoDigests, nil
+}
+
+// repoDigestsFor returns the distinct digests of the entries in repoDigests,
+// of the form NAME@ALG:VALUE, that belong to the named repository.
+func repoDigestsFor(name string, repoDigests []string) ([]Digest, error) {
+	var digests []Digest
+	seen := map[Digest]bool{}
+	for _, rd := range repoDigests {
+		image, err := validateDockerImage(rd)
+		if err != nil {
+			return nil, err
+		}
+		if image.Name == name && !seen[image.Digest] {
+			seen[image.Digest] = true
+			digests = append(digests, image.Digest)
+		}
+	}
+	return digests, nil
+}
+
+// CosignImageVerifier verifies the cosign signature on an image using the
+// `cosign` CLI.
+type CosignImageVerifier struct {
+	// Identity is the expected identity in the signing certificate.
+	Identity string
+
+	// OIDCIssuer is the expected OIDC issuer of the signing certificate.
+	OIDCIssuer string
+}
+
+// Verify runs `cosign verify` on the image, failing if no valid signature
+// by the configured identity is found.
+func (v *CosignImageVerifier) Verify(image *DockerImage) error {
+	//#nosec G204 -- Input from user config file.
+	cmd := exec.Command("cosign", "verify",
+		"--certificate-identity", v.Identity,
+		"--certificate-oidc-issuer", v.OIDCIssuer,
+		image.ToString())
+	log.Printf("Running command: %q.", cmd.String())
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Errorf(&errImageSignature{}, "verifying the signature on %q: %v: %s", image.ToString(), err, out)
+	}
+	return nil
+}
+
+// resolveImage pulls the image and pins it to the digest of its repository.
+// If the image already specifies a digest, the pulled image is verified to
+// have the same digest. An image with several digests in its repository
+// cannot be pinned unambiguously and must specify one.
+func resolveImage(r ImageResolver, image *DockerImage) (*DockerImage, error) {
+	ref := image.Name
+	if image.Digest.Value != "" {
+		ref = image.ToString()
+	}
+	repoDigests, err := r.Resolve(ref)
+	if err != nil {
+		return nil, errors.Errorf(&errImageResolve{}, "resolving image %q: %w", ref, err)
+	}
+
+	name, _ := splitImageTag(image.Name)
+	digests, err := repoDigestsFor(name, repoDigests)
+	if err != nil {
+		return nil, errors.Errorf(&errImageResolve{}, "resolving image %q: %w", ref, err)
+	}
+
+	if image.Digest.Value != "" {
+		for _, d := range digests {
+			if d == image.Digest {
+				return image, nil
+			}
+		}
+		return nil, errors.Errorf(&errImageDigestMismatch{}, "pulled image %q does not have digest %s:%s",
+			image.Name, image.Digest.Alg, image.Digest.Value)
+	}
+
+	switch len(digests) {
+	case 0:
+		return nil, errors.Errorf(&errImageResolve{}, "no digest found for image %q in repository %q", ref, name)
+	case 1:
+		return &DockerImage{
+			Name:   name,
+			Digest: digests[0],
+		}, nil
+	default:
+		return nil, errors.Errorf(&errImageResolve{},
+			"image %q has %d digests in repository %q, specify the digest to use", ref, len(digests), name)
+	}
+}
+
+// splitImageTag splits an image reference of the form NAME[:TAG] into the
+// name and the tag. A colon in the registry host (e.g., for a port) is not
+// treated as a tag separator.
+func splitImageTag(ref string) (string, string) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i+1:], "/") {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
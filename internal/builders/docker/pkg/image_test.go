@@ -0,0 +1,141 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testImageResolver returns the given repo digests for any image.
+type testImageResolver struct {
+	repoDigests []string
+}
+
+func (r testImageResolver) Resolve(string) ([]string, error) {
+	return r.repoDigests, nil
+}
+
+func Test_resolveImage(t *testing.T) {
+	pulled := Digest{Alg: "sha256", Value: "9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9"}
+	other := Digest{Alg: "sha256", Value: "0000000000000000000000000000000000000000000000000000000000000000"}
+	repoDigests := []string{
+		"ghcr.io/other/bash@sha256:" + other.Value,
+		"bash@sha256:" + pulled.Value,
+		"localhost:5000/bash@sha256:" + pulled.Value,
+	}
+
+	tests := []struct {
+		name        string
+		image       DockerImage
+		repoDigests []string
+		want        *DockerImage
+		// resolveErr indicates that errImageResolve is expected.
+		resolveErr bool
+		// mismatch indicates that errImageDigestMismatch is expected.
+		mismatch bool
+	}{
+		{
+			name:  "tag resolved to digest",
+			image: DockerImage{Name: "bash:5"},
+			want:  &DockerImage{Name: "bash", Digest: pulled},
+		},
+		{
+			name:  "registry with port",
+			image: DockerImage{Name: "localhost:5000/bash"},
+			want:  &DockerImage{Name: "localhost:5000/bash", Digest: pulled},
+		},
+		{
+			name:  "other repository",
+			image: DockerImage{Name: "ghcr.io/other/bash:5"},
+			want:  &DockerImage{Name: "ghcr.io/other/bash", Digest: other},
+		},
+		{
+			name:        "duplicate digest",
+			image:       DockerImage{Name: "bash:5"},
+			repoDigests: []string{"bash@sha256:" + pulled.Value, "bash@sha256:" + pulled.Value},
+			want:        &DockerImage{Name: "bash", Digest: pulled},
+		},
+		{
+			name:        "ambiguous digest",
+			image:       DockerImage{Name: "bash:5"},
+			repoDigests: []string{"bash@sha256:" + other.Value, "bash@sha256:" + pulled.Value},
+			resolveErr:  true,
+		},
+		{
+			name:       "no digest for repository",
+			image:      DockerImage{Name: "ghcr.io/missing/bash:5"},
+			resolveErr: true,
+		},
+		{
+			name:  "matching digest",
+			image: DockerImage{Name: "bash", Digest: pulled},
+			want:  &DockerImage{Name: "bash", Digest: pulled},
+		},
+		{
+			name:     "digest of another repository",
+			image:    DockerImage{Name: "bash", Digest: other},
+			mismatch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := testImageResolver{repoDigests: repoDigests}
+			if tt.repoDigests != nil {
+				r.repoDigests = tt.repoDigests
+			}
+			got, err := resolveImage(r, &tt.image)
+			if tt.resolveErr {
+				checkError(t, err, &errImageResolve{})
+				return
+			}
+			if tt.mismatch {
+				checkError(t, err, &errImageDigestMismatch{})
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func Test_resolveImage_noDigest(t *testing.T) {
+	_, err := resolveImage(testImageResolver{}, &DockerImage{Name: "bash:5"})
+	checkError(t, err, &errImageResolve{})
+}
+
+func Test_repoDigestsFor(t *testing.T) {
+	repoDigests := []string{
+		"bash@sha256:9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9",
+		"ghcr.io/other/bash@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	got, err := repoDigestsFor("bash", repoDigests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Digest{{Alg: "sha256", Value: "9e2ba52487d945504d250de186cb4fe2e3ba023ed2921dd6ac8b97ed43e76af9"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(diff)
+	}
+}
@@ -23,6 +23,11 @@ type InputOptions struct {
 	GitCommitHash   string
 	BuilderImage    string
 	ForceCheckout   bool
+
+	BuilderImageIdentity   string
+	BuilderImageOIDCIssuer string
+
+	EnvAllowlist []string
 }
 
 // AddFlags adds input flags to the given command.
@@ -37,8 +42,20 @@ func (io *InputOptions) AddFlags(cmd *cobra.Command) {
 		"Required - SHA1 Git commit digest of the revision of the source code to build the artefact from.")
 
 	cmd.Flags().StringVarP(&io.BuilderImage, "builder-image", "i", "",
-		"Required - URL indicating the Docker builder image, including a URI and image digest.")
+		"Required - URL indicating the Docker builder image, including a URI and either an image digest or a tag. "+
+			"A tag is resolved to a digest before the image is used.")
 
 	cmd.Flags().BoolVarP(&io.ForceCheckout, "force-checkout", "f", false,
 		"Optional - Forces checking out the source code from the given Git repo.")
+
+	cmd.Flags().StringVar(&io.BuilderImageIdentity, "builder-image-identity", "",
+		"Optional - Identity that must have signed the builder image with cosign. Requires --builder-image-oidc-issuer.")
+
+	cmd.Flags().StringVar(&io.BuilderImageOIDCIssuer, "builder-image-oidc-issuer", "",
+		"Optional - OIDC issuer of the identity that must have signed the builder image with cosign.")
+
+	cmd.Flags().StringSliceVar(&io.EnvAllowlist, "env-allowlist", nil,
+		"Optional - Comma separated list of the environment variables of the build config that are recorded "+
+			"in the provenance, in addition to GITHUB_* and RUNNER_*. An entry ending in '*' matches any "+
+			"variable with that prefix. Other variables are set for the build but not recorded.")
 }
@@ -0,0 +1,258 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	githubapi "github.com/google/go-github/v50/github"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// errArtifactDownload indicates an error downloading a workflow artifact.
+type errArtifactDownload struct {
+	errors.WrappableError
+}
+
+// errArtifactMismatch indicates that the files of a downloaded workflow
+// artifact do not match the subjects.
+type errArtifactMismatch struct {
+	errors.ErrInput
+}
+
+// artifactDownload downloads a workflow artifact of the current workflow run
+// and verifies it against the subjects before they are attested.
+type artifactDownload struct {
+	owner string
+	repo  string
+	runID int64
+	// httpClient is used to download the artifact archive from the URL
+	// returned by the GitHub API.
+	httpClient *http.Client
+}
+
+// newArtifactDownload returns an artifactDownload for the workflow run with
+// the given ID in the given repository. The repository should be given in
+// the "owner/name" format.
+func newArtifactDownload(repository, runID string) (*artifactDownload, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || repo == "" {
+		return nil, errors.Errorf(&errArtifactDownload{}, "unexpected repository: %q", repository)
+	}
+	id, err := strconv.ParseInt(runID, 10, 64)
+	if err != nil {
+		return nil, errors.Errorf(&errArtifactDownload{}, "unexpected workflow run ID: %q", runID)
+	}
+	return &artifactDownload{
+		owner:      owner,
+		repo:       repo,
+		runID:      id,
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+// Download extracts the artifact with the given name into dir, which must be
+// under the current directory, and verifies that it contains exactly one
+// file for each subject, with the subject's digest.
+func (d *artifactDownload) Download(ctx context.Context, ghClient *githubapi.Client, name, dir string,
+	subjects []intoto.Subject,
+) error {
+	if ghClient == nil {
+		return errors.Errorf(&errMissingGithubToken{}, "no GitHub client available to download artifact %q", name)
+	}
+
+	a, err := d.findArtifact(ctx, ghClient, name)
+	if err != nil {
+		return err
+	}
+
+	u, _, err := ghClient.Actions.DownloadArtifact(ctx, d.owner, d.repo, a.GetID(), false)
+	if err != nil {
+		return errors.Errorf(&errArtifactDownload{}, "downloading artifact %q: %w", name, err)
+	}
+
+	// NOTE: The archive is written to a temporary file since zip files are
+	// read from the end.
+	tmp, err := os.CreateTemp("", "artifact-*.zip")
+	if err != nil {
+		return errors.Errorf(&errArtifactDownload{}, "downloading artifact %q: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Errorf(&errArtifactDownload{}, "downloading artifact %q: %w", name, err)
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return errors.Errorf(&errArtifactDownload{}, "downloading artifact %q: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf(&errArtifactDownload{}, "downloading artifact %q: unexpected status: %s", name, resp.Status)
+	}
+	size, err := io.Copy(tmp, resp.Body)
+	if err != nil {
+		return errors.Errorf(&errArtifactDownload{}, "downloading artifact %q: %w", name, err)
+	}
+
+	digests, err := extractArtifact(tmp, size, dir)
+	if err != nil {
+		return err
+	}
+	return verifyArtifactFiles(name, digests, subjects)
+}
+
+// findArtifact returns the artifact of the workflow run with the given name.
+func (d *artifactDownload) findArtifact(ctx context.Context, ghClient *githubapi.Client, name string) (*githubapi.Artifact, error) {
+	opts := &githubapi.ListOptions{PerPage: 100}
+	for {
+		list, resp, err := ghClient.Actions.ListWorkflowRunArtifacts(ctx, d.owner, d.repo, d.runID, opts)
+		if err != nil {
+			return nil, errors.Errorf(&errArtifactDownload{}, "listing artifacts of workflow run %d: %w", d.runID, err)
+		}
+		for _, a := range list.Artifacts {
+			if a.GetName() == name {
+				return a, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return nil, errors.Errorf(&errArtifactDownload{}, "no artifact %q in workflow run %d", name, d.runID)
+}
+
+// extractArtifact extracts the regular files of the zip archive r into dir
+// and returns their digests by slash separated path.
+func extractArtifact(r io.ReaderAt, size int64, dir string) (map[string]slsacommon.DigestSet, error) {
+	// NOTE: The directory is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(dir); err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Errorf(&errArtifactDownload{}, "extracting artifact: %w", err)
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.Errorf(&errArtifactDownload{}, "extracting artifact: %w", err)
+	}
+
+	digests := map[string]slsacommon.DigestSet{}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			// Skip directories, symlinks, etc.
+			continue
+		}
+		// NOTE: The file names are untrusted and should be validated.
+		name := path.Clean(f.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, errors.Errorf(&errArtifactMismatch{}, "invalid file name %q in artifact", f.Name)
+		}
+		if _, ok := digests[name]; ok {
+			return nil, errors.Errorf(&errArtifactMismatch{}, "duplicate file %q in artifact", name)
+		}
+		d, err := extractArtifactFile(f, name, absDir)
+		if err != nil {
+			return nil, err
+		}
+		digests[name] = d
+	}
+	return digests, nil
+}
+
+// extractArtifactFile extracts f to name under dir and returns its digests.
+func extractArtifactFile(f *zip.File, name, dir string) (slsacommon.DigestSet, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.Errorf(&errArtifactDownload{}, "extracting %q: %w", name, err)
+	}
+	defer rc.Close()
+
+	w, err := utils.CreateNewFileUnderDirectory(filepath.FromSlash(name), dir, os.O_WRONLY)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := w.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	h256, h512 := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(w, h256, h512), rc); err != nil {
+		return nil, errors.Errorf(&errArtifactDownload{}, "extracting %q: %w", name, err)
+	}
+	return slsacommon.DigestSet{
+		"sha256": hex.EncodeToString(h256.Sum(nil)),
+		"sha512": hex.EncodeToString(h512.Sum(nil)),
+	}, nil
+}
+
+// verifyArtifactFiles returns an errArtifactMismatch unless the artifact
+// has exactly one file for each subject, with all of the subject's digests.
+func verifyArtifactFiles(artifact string, digests map[string]slsacommon.DigestSet, subjects []intoto.Subject) error {
+	seen := map[string]bool{}
+	for _, s := range subjects {
+		got, ok := digests[s.Name]
+		if !ok {
+			return errors.Errorf(&errArtifactMismatch{}, "subject %q is missing from artifact %q", s.Name, artifact)
+		}
+		if len(s.Digest) == 0 {
+			return errors.Errorf(&errArtifactMismatch{}, "subject %q has no digest", s.Name)
+		}
+		for alg, want := range s.Digest {
+			g, ok := got[alg]
+			if !ok {
+				return errors.Errorf(&errArtifactMismatch{}, "unsupported digest algorithm %q for subject %q", alg, s.Name)
+			}
+			if g != want {
+				return errors.Errorf(&errArtifactMismatch{}, "digest mismatch for subject %q, want: %s:%s, got: %s:%s",
+					s.Name, alg, want, alg, g)
+			}
+		}
+		seen[s.Name] = true
+	}
+
+	var extra []string
+	for name := range digests {
+		if !seen[name] {
+			extra = append(extra, name)
+		}
+	}
+	if len(extra) > 0 {
+		sort.Strings(extra)
+		return errors.Errorf(&errArtifactMismatch{}, "artifact %q has files that are not subjects: %q", artifact, extra)
+	}
+	return nil
+}
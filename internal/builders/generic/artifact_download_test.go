@@ -0,0 +1,199 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	githubapi "github.com/google/go-github/v50/github"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// newFakeArtifactsServer returns a fake GitHub API server serving the
+// "binaries" artifact of workflow run 123 of owner/repo, as a zip archive
+// of the given files.
+func newFakeArtifactsServer(t *testing.T, files map[string]string) *githubapi.Client {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/runs/123/artifacts":
+			fmt.Fprint(w, `{"total_count": 2, "artifacts": [{"id": 6, "name": "logs"}, {"id": 7, "name": "binaries"}]}`)
+		case "/repos/owner/repo/actions/artifacts/7/zip":
+			w.Header().Set("Location", srv.URL+"/blobs/7.zip")
+			w.WriteHeader(http.StatusFound)
+		case "/blobs/7.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			_, _ = w.Write(buf.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := githubapi.NewClient(nil)
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	client.BaseURL = u
+	return client
+}
+
+func Test_artifactDownload_Download(t *testing.T) {
+	// The sha256 digests of "hello\n" and "world\n".
+	subjects := []intoto.Subject{
+		{Name: "artifact1", Digest: slsacommon.DigestSet{"sha256": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"}},
+		{Name: "dist/artifact2", Digest: slsacommon.DigestSet{"sha256": "e258d248fda94c63753607f7c4494ee0fcbe92f1a76bfdac795c9d84101eb317"}},
+	}
+
+	testCases := []struct {
+		name     string
+		artifact string
+		files    map[string]string
+		err      interface{}
+	}{
+		{
+			name:     "match",
+			artifact: "binaries",
+			files:    map[string]string{"artifact1": "hello\n", "dist/artifact2": "world\n"},
+		},
+		{
+			name:     "digest mismatch",
+			artifact: "binaries",
+			files:    map[string]string{"artifact1": "hello\n", "dist/artifact2": "tampered\n"},
+			err:      new(*errArtifactMismatch),
+		},
+		{
+			name:     "missing file",
+			artifact: "binaries",
+			files:    map[string]string{"artifact1": "hello\n"},
+			err:      new(*errArtifactMismatch),
+		},
+		{
+			name:     "extra file",
+			artifact: "binaries",
+			files:    map[string]string{"artifact1": "hello\n", "dist/artifact2": "world\n", "extra": "extra\n"},
+			err:      new(*errArtifactMismatch),
+		},
+		{
+			name:     "file outside directory",
+			artifact: "binaries",
+			files:    map[string]string{"artifact1": "hello\n", "../dist/artifact2": "world\n"},
+			err:      new(*errArtifactMismatch),
+		},
+		{
+			name:     "artifact not found",
+			artifact: "other",
+			files:    map[string]string{"artifact1": "hello\n", "dist/artifact2": "world\n"},
+			err:      new(*errArtifactDownload),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			client := newFakeArtifactsServer(t, tc.files)
+
+			d, err := newArtifactDownload("owner/repo", "123")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			err = d.Download(context.Background(), client, tc.artifact, "out", subjects)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile(filepath.Join(dir, "out", "dist", "artifact2"))
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := "world\n", string(b); want != got {
+				t.Errorf("unexpected contents, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+func Test_newArtifactDownload(t *testing.T) {
+	testCases := []struct {
+		name       string
+		repository string
+		runID      string
+		err        bool
+	}{
+		{
+			name:       "valid",
+			repository: "owner/repo",
+			runID:      "123",
+		},
+		{
+			name:       "invalid repository",
+			repository: "repo",
+			runID:      "123",
+			err:        true,
+		},
+		{
+			name:       "invalid run ID",
+			repository: "owner/repo",
+			runID:      "",
+			err:        true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newArtifactDownload(tc.repository, tc.runID)
+			if got := errors.As(err, new(*errArtifactDownload)); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
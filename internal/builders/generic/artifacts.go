@@ -0,0 +1,201 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// defaultArtifactSizeWarning is the default file size in bytes above which a
+// warning is printed when hashing artifacts.
+const defaultArtifactSizeWarning int64 = 5 << 30
+
+// defaultHashWorkers is the default number of files hashed in parallel.
+const defaultHashWorkers = 4
+
+// checksumAlgorithms are the algorithms supported by --checksum-algorithm to
+// hash artifact files, by name.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// errChecksumAlgorithm indicates an unsupported --checksum-algorithm.
+type errChecksumAlgorithm struct {
+	errors.ErrInput
+}
+
+// checkChecksumAlgorithm returns an errChecksumAlgorithm if alg is not one of
+// the checksumAlgorithms.
+func checkChecksumAlgorithm(alg string) error {
+	if _, ok := checksumAlgorithms[alg]; !ok {
+		return errors.Errorf(&errChecksumAlgorithm{}, "unsupported checksum algorithm %q", alg)
+	}
+	return nil
+}
+
+// errArtifactDir indicates an error reading the artifact directory.
+type errArtifactDir struct {
+	errors.ErrFilesystem
+}
+
+// errHashWorkers indicates an invalid number of hash workers.
+type errHashWorkers struct {
+	errors.ErrInput
+}
+
+// subjectsFromDir returns a subject for each regular file in the directory
+// tree rooted at dir. Subject names are the slash separated path of the file
+// relative to dir, in lexical order. Files are hashed with the checksum
+// algorithm alg by the given number of workers in parallel. A warning is
+// written to w for files larger than warnSize.
+func subjectsFromDir(dir, alg string, warnSize int64, workers int, w io.Writer) ([]intoto.Subject, error) {
+	// NOTE: The directory is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(dir); err != nil {
+		return nil, err
+	}
+	if err := checkChecksumAlgorithm(alg); err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		return nil, errors.Errorf(&errHashWorkers{}, "invalid number of hash workers: %d", workers)
+	}
+
+	// NOTE: WalkDir walks files in lexical order so the subjects are sorted
+	// by name.
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			// Skip directories, symlinks, etc.
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > warnSize {
+			github.Warning(w, nil, fmt.Sprintf("%q is larger than %d bytes (%d bytes)", path, warnSize, info.Size()))
+		}
+
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Errorf(&errArtifactDir{}, "reading artifact directory %q: %w", dir, err)
+	}
+
+	digests, err := hashFiles(paths, alg, workers)
+	if err != nil {
+		return nil, errors.Errorf(&errArtifactDir{}, "reading artifact directory %q: %w", dir, err)
+	}
+
+	var subjects []intoto.Subject
+	for i, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, errors.Errorf(&errArtifactDir{}, "reading artifact directory %q: %w", dir, err)
+		}
+
+		subjects = append(subjects, intoto.Subject{
+			Name: filepath.ToSlash(rel),
+			Digest: slsacommon.DigestSet{
+				alg: digests[i],
+			},
+		})
+	}
+
+	return subjects, nil
+}
+
+// hashFiles returns the hex encoded digests of the files with the checksum
+// algorithm alg, in the same order as paths. The files are hashed by the
+// given number of workers in parallel. The first error encountered is
+// returned.
+func hashFiles(paths []string, alg string, workers int) ([]string, error) {
+	digests := make([]string, len(paths))
+	errs := make([]error, len(paths))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range indexes {
+				// NOTE: Each worker writes to distinct indexes.
+				digests[j], errs[j] = fileDigest(paths[j], alg)
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return digests, nil
+}
+
+// fileSHA256 returns the hex encoded SHA-256 digest of the file.
+func fileSHA256(path string) (string, error) {
+	return fileDigest(path, "sha256")
+}
+
+// fileDigest returns the hex encoded digest of the file with the checksum
+// algorithm alg.
+func fileDigest(path, alg string) (string, error) {
+	newHash, ok := checksumAlgorithms[alg]
+	if !ok {
+		return "", errors.Errorf(&errChecksumAlgorithm{}, "unsupported checksum algorithm %q", alg)
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+func Test_subjectsFromDir(t *testing.T) {
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	files := map[string]string{
+		"artifacts/myartifact/file.tar.gz": "foo\n",
+		"artifacts/other/bin/app":          "bar\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+	}
+
+	t.Run("nested files", func(t *testing.T) {
+		var stderr bytes.Buffer
+		got, err := subjectsFromDir("artifacts", "sha256", defaultArtifactSizeWarning, defaultHashWorkers, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+
+		want := []intoto.Subject{
+			{
+				Name: "myartifact/file.tar.gz",
+				Digest: slsacommon.DigestSet{
+					// echo "foo" | sha256sum
+					"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+				},
+			},
+			{
+				Name: "other/bin/app",
+				Digest: slsacommon.DigestSet{
+					// echo "bar" | sha256sum
+					"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730",
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("unexpected warning: %s", stderr.String())
+		}
+	})
+
+	t.Run("large file warning", func(t *testing.T) {
+		var stderr bytes.Buffer
+		got, err := subjectsFromDir("artifacts/myartifact", "sha256", 1, defaultHashWorkers, &stderr)
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if want := 1; len(got) != want {
+			t.Errorf("unexpected number of subjects, want: %d, got: %d", want, len(got))
+		}
+		if !strings.Contains(stderr.String(), "WARNING") {
+			t.Errorf("expected a warning, got: %q", stderr.String())
+		}
+	})
+
+	t.Run("checksum algorithms", func(t *testing.T) {
+		for alg, digest := range map[string]string{
+			// echo "foo" | sha256sum
+			"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+			// echo "foo" | sha384sum
+			"sha384": "8effdabfe14416214a250f935505250bd991f106065d899db6e19bdc8bf648f3ac0f1935c4f65fe8f798289b1a0d1e06",
+			// echo "foo" | sha512sum
+			"sha512": "0cf9180a764aba863a67b6d72f0918bc131c6772642cb2dce5a34f0a702f9470" +
+				"ddc2bf125c12198b1995c233c34b4afd346c54a2334c350a948a51b6e8b4e6b6",
+		} {
+			got, err := subjectsFromDir("artifacts/myartifact", alg, defaultArtifactSizeWarning, defaultHashWorkers,
+				&bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			want := []intoto.Subject{
+				{
+					Name:   "file.tar.gz",
+					Digest: slsacommon.DigestSet{alg: digest},
+				},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected subjects for %s (-want +got):\n%s", alg, diff)
+			}
+		}
+	})
+
+	t.Run("unsupported checksum algorithm", func(t *testing.T) {
+		_, err := subjectsFromDir("artifacts", "md5", defaultArtifactSizeWarning, defaultHashWorkers, &bytes.Buffer{})
+		errAlg := &errChecksumAlgorithm{}
+		if !errors.As(err, &errAlg) {
+			t.Errorf("expected %v but got %v", &errChecksumAlgorithm{}, err)
+		}
+	})
+
+	t.Run("outside current directory", func(t *testing.T) {
+		_, err := subjectsFromDir("/", "sha256", defaultArtifactSizeWarning, defaultHashWorkers, &bytes.Buffer{})
+		errInvalidPath := &utils.ErrInvalidPath{}
+		if !errors.As(err, &errInvalidPath) {
+			t.Errorf("expected %v but got %v", &utils.ErrInvalidPath{}, err)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := subjectsFromDir("missing", "sha256", defaultArtifactSizeWarning, defaultHashWorkers, &bytes.Buffer{})
+		errDir := &errArtifactDir{}
+		if !errors.As(err, &errDir) {
+			t.Errorf("expected %v but got %v", &errArtifactDir{}, err)
+		}
+	})
+
+	t.Run("invalid hash workers", func(t *testing.T) {
+		_, err := subjectsFromDir("artifacts", "sha256", defaultArtifactSizeWarning, 0, &bytes.Buffer{})
+		errWorkers := &errHashWorkers{}
+		if !errors.As(err, &errWorkers) {
+			t.Errorf("expected %v but got %v", &errHashWorkers{}, err)
+		}
+	})
+}
+
+// Test_subjectsFromDir_workers tests that hashing files in parallel returns
+// the same subjects as hashing them sequentially. Run with -race to check for
+// data races.
+func Test_subjectsFromDir_workers(t *testing.T) {
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	if err := os.MkdirAll("artifacts/sub", 0o755); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		name := filepath.Join("artifacts", fmt.Sprintf("file%02d", i))
+		if i%2 == 1 {
+			name = filepath.Join("artifacts", "sub", fmt.Sprintf("file%02d", i))
+		}
+		content := strings.Repeat(fmt.Sprintf("content %d\n", i), 1000*(i+1))
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+	}
+
+	want, err := subjectsFromDir("artifacts", "sha256", defaultArtifactSizeWarning, 1, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if len(want) != 20 {
+		t.Fatalf("unexpected number of subjects, want: %d, got: %d", 20, len(want))
+	}
+
+	for _, workers := range []int{4, 32} {
+		got, err := subjectsFromDir("artifacts", "sha256", defaultArtifactSizeWarning, workers, &bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected subjects with %d workers (-want +got):\n%s", workers, diff)
+		}
+	}
+}
@@ -15,55 +15,496 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/signal"
 	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1.0"
 	"github.com/spf13/cobra"
 
 	"github.com/slsa-framework/slsa-github-generator/github"
-	"github.com/slsa-framework/slsa-github-generator/internal/builders/common"
 	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/signers/file"
+	"github.com/slsa-framework/slsa-github-generator/internal/signers/fulcio"
+	"github.com/slsa-framework/slsa-github-generator/internal/signers/gcpkms"
+	"github.com/slsa-framework/slsa-github-generator/internal/transparencylog"
 	"github.com/slsa-framework/slsa-github-generator/internal/utils"
 	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/signing/nop"
+	"github.com/slsa-framework/slsa-github-generator/signing/sigstore"
 	"github.com/slsa-framework/slsa-github-generator/slsa"
 )
 
+// newExtraRekor returns the transparency log for an --extra-rekor-url. It is
+// replaced in tests.
+var newExtraRekor = func(rekorURL string) signing.TransparencyLog {
+	return sigstore.NewRekor(rekorURL)
+}
+
+// statementFlags are the flags that select the subjects, materials or
+// predicate of the provenance. They are mutually exclusive with --statement,
+// since the statement already records them and is signed as is.
+var statementFlags = []string{
+	"subjects", "subjects-purl", "subjects-filename", "github-artifact-dir", "artifacts",
+	"subjects-from-github-release", "subjects-from-matrix-output", "matrix-output-key", "buildx-metadata-file",
+	"remote-subject", "subject-use-uri", "remote-subject-max-size", "remote-subject-timeout",
+	"subjects-strip-prefix", "subject-prefix", "strip-subject-extensions", "sort-subjects",
+	"normalize-subject-names", "subject-basename", "case-insensitive-names", "checksum-algorithm",
+	"artifact-size-warning", "subjects-hash-workers", "image-manifest", "git-commit-of-sources",
+	"workflow-inputs", "build-invocation-id", "redact-github-context", "redact-pattern", "statement-version",
+	"predicate-file", "predicate-type",
+	// --no-transparency-log records the opt-out in the predicate.
+	"no-transparency-log",
+}
+
+// rekorEntryPath returns the path of the transparency log entry written next
+// to the provenance at attPath, i.e. <name>.rekor.json for <name>.intoto.jsonl.
+func rekorEntryPath(attPath string) string {
+	return strings.TrimSuffix(attPath, ".intoto.jsonl") + ".rekor.json"
+}
+
+// unsignedAttestationPath returns the path of the unsigned attestation
+// written instead of the provenance at attPath when signing is skipped, i.e.
+// <name>.unsigned.intoto.json for <name>.intoto.jsonl.
+func unsignedAttestationPath(attPath string) string {
+	return strings.TrimSuffix(attPath, ".intoto.jsonl") + ".unsigned.intoto.json"
+}
+
+// errCertIdentity indicates that the signing certificate does not have the
+// expected identity.
+type errCertIdentity struct {
+	errors.ErrSigning
+}
+
+// checkCertIdentity returns an errCertIdentity if the identity in the PEM
+// encoded signing certificate does not match the expected source repository,
+// of the form owner/repo[@ref], or the expected workflow path, e.g.
+// owner/repo/.github/workflows/release.yml. Empty expectations are not
+// checked.
+func checkCertIdentity(cert []byte, expectedSource, expectedWorkflow string) error {
+	if expectedSource == "" && expectedWorkflow == "" {
+		return nil
+	}
+	id, err := slsa.ParseCertIdentity(cert)
+	if err != nil {
+		return errors.Errorf(&errCertIdentity{}, "reading signing certificate identity: %w", err)
+	}
+
+	if expectedSource != "" {
+		repo, ref, hasRef := strings.Cut(expectedSource, "@")
+		if id.Repository != repo {
+			return errors.Errorf(&errCertIdentity{}, "unexpected source repository in signing certificate, want: %q, got: %q",
+				repo, id.Repository)
+		}
+		if hasRef && id.Ref != ref {
+			return errors.Errorf(&errCertIdentity{}, "unexpected source ref in signing certificate, want: %q, got: %q",
+				ref, id.Ref)
+		}
+	}
+	if expectedWorkflow != "" && id.Workflow() != expectedWorkflow {
+		return errors.Errorf(&errCertIdentity{}, "unexpected workflow in signing certificate, want: %q, got: %q",
+			expectedWorkflow, id.Workflow())
+	}
+	return nil
+}
+
+// errUnexpectedRekorIndex indicates that the transparency log entry does not
+// have the expected log index.
+type errUnexpectedRekorIndex struct {
+	errors.ErrTransparencyLog
+}
+
+// checkRekorIndex compares the log index of entry with want. A mismatch is
+// written to w as a warning, or returned as an error if strict is set.
+func checkRekorIndex(entry signing.LogEntry, want int64, strict bool, w io.Writer) error {
+	if got := entry.LogIndex(); got != want {
+		if strict {
+			return errors.Errorf(&errUnexpectedRekorIndex{}, "unexpected log index, want: %d, got: %d", want, got)
+		}
+		github.Warning(w, nil, fmt.Sprintf("unexpected log index, want: %d, got: %d", want, got))
+	}
+	return nil
+}
+
+// validateRekorURL checks that an --extra-rekor-url is an absolute HTTP(S)
+// URL.
+func validateRekorURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid --extra-rekor-url %q: expected an HTTP(S) URL", s)
+	}
+	return nil
+}
+
+// errLint indicates a provenance statement with lint warnings.
+type errLint struct {
+	errors.ErrInput
+}
+
+// lintStatement writes the lint warnings of the statement to w. If strict is
+// set, an error is returned if there are any warnings.
+func lintStatement(s *intoto.Statement, strict bool, w io.Writer) error {
+	warnings := slsa.LintProvenance(*s)
+	for _, lw := range warnings {
+		github.Warning(w, nil, fmt.Sprintf("lint %s", lw))
+	}
+	if strict && len(warnings) > 0 {
+		return errors.Errorf(&errLint{}, "provenance has %d lint warning(s)", len(warnings))
+	}
+	return nil
+}
+
+// errUnsafeEvent indicates a workflow run whose provenance should not be
+// signed because of the event that triggered it.
+type errUnsafeEvent struct {
+	errors.ErrInput
+}
+
+// checkUnsafeEvent returns an error if the workflow run was triggered by a
+// pull_request event from a fork, since the workflow then runs untrusted code.
+func checkUnsafeEvent(c *github.WorkflowContext) error {
+	if c.EventName != "pull_request" {
+		return nil
+	}
+	if pr := c.PullRequest(); pr != nil && pr.FromFork() {
+		return errors.Errorf(&errUnsafeEvent{}, "refusing to sign provenance for a pull_request from fork %q", pr.HeadRepository)
+	}
+	return nil
+}
+
+// errActorNotAllowed indicates a workflow run triggered by an actor that is
+// not in --github-actor-allow-list.
+type errActorNotAllowed struct {
+	errors.ErrInput
+}
+
+// checkActorAllowed returns an error if the actor that triggered the workflow
+// run is not in the allow list. An empty allow list allows all actors.
+func checkActorAllowed(c *github.WorkflowContext, allowList []string) error {
+	if len(allowList) == 0 {
+		return nil
+	}
+	for _, actor := range allowList {
+		if actor == c.Actor {
+			return nil
+		}
+	}
+	return errors.Errorf(&errActorNotAllowed{}, "refusing to sign provenance for actor %q, allowed actors are %q",
+		c.Actor, allowList)
+}
+
+// buildID returns the build invocation ID of the provenance, or the one
+// recorded by default for the workflow run if it has none. The ID is at
+// predicate.metadata.buildInvocationID in SLSA v0.2 and at
+// predicate.runDetails.metadata.invocationID in SLSA v1.0.
+func buildID(s *intoto.Statement, c *github.WorkflowContext) string {
+	var predicate struct {
+		Metadata struct {
+			BuildInvocationID string `json:"buildInvocationID"`
+		} `json:"metadata"`
+		RunDetails struct {
+			Metadata struct {
+				InvocationID string `json:"invocationID"`
+			} `json:"metadata"`
+		} `json:"runDetails"`
+	}
+	if b, err := json.Marshal(s.Predicate); err == nil && json.Unmarshal(b, &predicate) == nil {
+		if id := predicate.Metadata.BuildInvocationID; id != "" {
+			return id
+		}
+		if id := predicate.RunDetails.Metadata.InvocationID; id != "" {
+			return id
+		}
+	}
+	if c.RunAttempt != "" {
+		return c.RunID + "-" + c.RunAttempt
+	}
+	return c.RunID
+}
+
+// recordNoTransparencyLog records in the build metadata of the predicate of s
+// that the transparency log was skipped on purpose, so that verifiers don't
+// mistake it for a failed upload. The metadata is at predicate.metadata in
+// SLSA v0.2 and custom predicates, and at predicate.runDetails.metadata in
+// SLSA v1.0.
+func recordNoTransparencyLog(s *intoto.Statement) error {
+	b, err := json.Marshal(s.Predicate)
+	if err != nil {
+		return fmt.Errorf("marshalling predicate: %w", err)
+	}
+	// NOTE: Numbers are kept as is so that custom predicates don't lose
+	// precision.
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var predicate map[string]interface{}
+	if err := d.Decode(&predicate); err != nil || predicate == nil {
+		return errors.Errorf(&errPredicate{}, "predicate is not a JSON object")
+	}
+
+	parent := predicate
+	if s.PredicateType == slsa1.PredicateSLSAProvenance {
+		runDetails, ok := predicate["runDetails"].(map[string]interface{})
+		if !ok {
+			return errors.Errorf(&errPredicate{}, "predicate has no runDetails")
+		}
+		parent = runDetails
+	}
+	if parent["metadata"] == nil {
+		parent["metadata"] = map[string]interface{}{}
+	}
+	metadata, ok := parent["metadata"].(map[string]interface{})
+	if !ok {
+		return errors.Errorf(&errPredicate{}, "predicate metadata is not a JSON object")
+	}
+	metadata["transparency_log"] = "none"
+
+	b, err = json.Marshal(predicate)
+	if err != nil {
+		return fmt.Errorf("marshalling predicate: %w", err)
+	}
+	s.Predicate = json.RawMessage(b)
+	return nil
+}
+
+// errTimeout indicates that attest did not finish within --timeout.
+type errTimeout struct {
+	errors.WrappableError
+}
+
+// errWorkspace indicates an invalid --workspace.
+type errWorkspace struct {
+	errors.ErrInput
+}
+
+// enterWorkspace makes dir the working directory, so that the checks that
+// files are read and written under the current directory keep all file
+// operations within dir.
+func enterWorkspace(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return errors.Errorf(&errWorkspace{}, "workspace: %w", err)
+	}
+	if !info.IsDir() {
+		return errors.Errorf(&errWorkspace{}, "workspace %q is not a directory", dir)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return errors.Errorf(&errWorkspace{}, "entering workspace: %w", err)
+	}
+	return nil
+}
+
 // attestCmd returns the 'attest' command.
 func attestCmd(provider slsa.ClientProvider, check func(error),
-	signer signing.Signer, tlog signing.TransparencyLog,
+	signer signing.Signer, tlog signing.TransparencyLog, newRegistry registryProvider,
 ) *cobra.Command {
+	var opts statementOptions
 	var attPath string
-	var subjects string
+	var statementPath string
+	var statementSHA256 string
+	var signerName string
+	var kmsKeyResource string
+	var signingKey string
+	var additionalSigners []string
+	var fulcioURL string
+	var noTransparencyLog bool
+	var strictNaming bool
+	var sanitizeName bool
+	var rekorRetryCount int
+	var rekorRetryBaseDelay time.Duration
+	var uploadRelease string
+	var overwriteAsset bool
+	var rekorCertChain string
+	var transparencyLogName string
+	var logFile string
+	var expectRekorIndex int64
+	var strictRekorIndex bool
+	var pushToRegistry string
+	var forbidUnsafeEvents bool
+	var actorAllowList []string
+	var timeout time.Duration
+	var provenanceNameTemplate string
+	var configPath string
+	var verifyRekorInclusion bool
+	var extraRekorURLs []string
+	var requireAllRekor bool
+	var expectedSource string
+	var expectedWorkflow string
+	var allowAttestationSubjects bool
+	var noStepSummary bool
+	var emitAttestationPath bool
+	var insecureSkipSigning bool
+	var selfVerifyProvenance bool
+	var emitVSA bool
+	var minSubjectCount int
+	var maxSubjectCount int
+	var lintWarnings bool
+	var lintErrors bool
+	var downloadArtifact string
+	var downloadArtifactDir string
+	var workspace string
 
 	c := &cobra.Command{
 		Use:   "attest",
 		Short: "Create a signed SLSA provenance attestation from a Github Action",
 		Long: `Generate and sign SLSA provenance from a Github Action to form an attestation
 and upload to a Rekor transparency log. This command assumes that it is being
-run in the context of a Github Actions workflow.`,
+run in the context of a Github Actions workflow.
+
+If --statement is set, the unsigned statement written by the 'generate' command
+is signed instead of generating a new one.
+
+If --config is set, the options are read from a YAML config file. Flags
+override the values in the config file. Use 'config validate' to check a
+config file.`,
+
+		PreRun: func(cmd *cobra.Command, args []string) {
+			check(applyConfigFile(cmd.Flags(), configPath))
+			// NOTE: The config file is read before entering the workspace,
+			// so that --config is relative to the directory attest runs in.
+			if workspace != "" {
+				check(enterWorkspace(workspace))
+			}
+		},
 
 		Run: func(cmd *cobra.Command, args []string) {
+			// NOTE: The runner sends SIGINT and then SIGTERM when a job is
+			// canceled, so that pending requests are canceled as well.
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			// written are the files written so far. They are removed if the
+			// command is canceled so that no partial outputs are left behind.
+			var written []string
+			check := func(err error) {
+				if err != nil && ctx.Err() != nil {
+					for _, p := range written {
+						_ = os.Remove(p)
+					}
+					if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+						err = errors.Errorf(&errTimeout{}, "attest did not finish within --timeout %s: %w", timeout, err)
+					}
+				}
+				check(err)
+			}
+
 			ghContext, err := github.GetWorkflowContext()
 			check(err)
 
-			parsedSubjects, err := parseSubjects(subjects)
+			var nameTemplate *template.Template
+			if provenanceNameTemplate != "" {
+				nameTemplate, err = parseProvenanceNameTemplate(provenanceNameTemplate)
+				check(err)
+			}
+
+			if forbidUnsafeEvents {
+				check(checkUnsafeEvent(&ghContext))
+			}
+			check(checkActorAllowed(&ghContext, actorAllowList))
+
+			if strictRekorIndex && !cmd.Flags().Changed("expect-rekor-index") {
+				check(errors.New("--strict-rekor-index requires --expect-rekor-index"))
+			}
+			if requireAllRekor && len(extraRekorURLs) == 0 {
+				check(errors.New("--require-all-rekor requires --extra-rekor-url"))
+			}
+			for _, u := range extraRekorURLs {
+				check(validateRekorURL(u))
+			}
+
+			if emitVSA && !selfVerifyProvenance {
+				check(errors.Errorf(&errors.ErrInput{}, "--emit-vsa requires --self-verify"))
+			}
+
+			if insecureSkipSigning {
+				if uploadRelease != "" {
+					check(errors.New("--insecure-skip-signing cannot be used with --upload-to-release"))
+				}
+				if pushToRegistry != "" {
+					check(errors.New("--insecure-skip-signing cannot be used with --push-to-registry"))
+				}
+				if cmd.Flags().Changed("signer") || cmd.Flags().Changed("transparency-log") ||
+					len(extraRekorURLs) > 0 || len(additionalSigners) > 0 {
+					check(errors.New("--insecure-skip-signing cannot be used with --signer, --additional-signer, " +
+						"--transparency-log or --extra-rekor-url"))
+				}
+				if selfVerifyProvenance {
+					check(errors.Errorf(&errors.ErrInput{}, "--insecure-skip-signing cannot be used with --self-verify"))
+				}
+				github.Warning(cmd.ErrOrStderr(), nil, "--insecure-skip-signing is set. "+
+					"The provenance is NOT signed and NOT uploaded to a transparency log. "+
+					"It must not be published.")
+				signer = nop.Signer{}
+				tlog = nop.TransparencyLog{}
+				noTransparencyLog = true
+			}
+
+			// NOTE: The clients are shared by the build type and generator so
+			// that the OIDC token is only requested once per audience.
+			clients, err := opts.clients(provider)
 			check(err)
 
-			if len(parsedSubjects) == 0 {
-				check(errors.New("expected at least one subject"))
+			var s *intoto.Statement
+			var payload []byte
+			if statementPath != "" {
+				// NOTE: The statement file is signed as is, so that the
+				// signed payload is the one that matches its sha256.
+				s, payload, err = readStatement(statementPath, statementSHA256)
+				check(err)
+				check(opts.checkPolicy(s.Subject))
+			} else {
+				p, err := opts.statement(ctx, cmd, &ghContext, clients)
+				check(err)
+				s, err = opts.outputStatement(p)
+				check(err)
+				// NOTE: This is done once the predicate is final, so that it
+				// also applies to --predicate-file.
+				if noTransparencyLog {
+					check(recordNoTransparencyLog(s))
+				}
+				payload, err = json.Marshal(s)
+				check(err)
 			}
+			parsedSubjects := s.Subject
+
+			// NOTE: The statement is hashed once it is complete, and the
+			// signer checks the hash, so that a statement modified before
+			// signing is never signed.
+			statementHash := sha256.Sum256(payload)
 
 			// NOTE: The provenance file path is untrusted and should be
 			// validated. This is done by CreateNewFileUnderCurrentDirectory.
+			if attPath == "" && nameTemplate != nil {
+				attPath, err = renderProvenanceName(nameTemplate, provenanceNameData{
+					BuildID:          buildID(s, &ghContext),
+					Date:             time.Now().UTC().Format("2006-01-02"),
+					RunID:            ghContext.RunID,
+					Repo:             ghContext.Repository,
+					FirstSubjectName: path.Base(parsedSubjects[0].Name),
+				}, sanitizeName)
+				check(err)
+			}
 			if attPath == "" {
 				if len(parsedSubjects) == 1 {
-					filename := path.Base(parsedSubjects[0].Name)
-					attPath = fmt.Sprintf("%s.intoto.jsonl", filename)
+					attPath = attestationName(path.Base(parsedSubjects[0].Name), sanitizeName)
 				} else {
 					// len(parsedSubjects) > 1
 					attPath = "multiple.intoto.jsonl"
@@ -74,57 +515,303 @@ run in the context of a Github Actions workflow.`,
 			err = utils.VerifyAttestationPath(attPath)
 			check(err)
 
-			ctx := context.Background()
+			if minSubjectCount < 0 || maxSubjectCount < 0 || (maxSubjectCount > 0 && minSubjectCount > maxSubjectCount) {
+				check(fmt.Errorf("invalid subject count range: --min-subject-count %d, --max-subject-count %d",
+					minSubjectCount, maxSubjectCount))
+			}
+			check(checkSubjectCount(parsedSubjects, minSubjectCount, maxSubjectCount))
 
-			b := common.GenericBuild{
-				GithubActionsBuild: slsa.NewGithubActionsBuild(parsedSubjects, &ghContext),
-				BuildTypeURI:       provenanceOnlyBuildType,
+			if !allowAttestationSubjects {
+				check(checkAttestationSubjects(parsedSubjects, attPath))
 			}
-			if provider != nil {
-				b.WithClients(provider)
-			} else if utils.IsPresubmitTests() {
-				// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
-				b.WithClients(&slsa.NilClientProvider{})
+
+			if strictNaming {
+				check(verifyAttestationName(attPath, parsedSubjects))
 			}
 
-			g := slsa.NewHostedActionsGenerator(&b)
-			if provider != nil {
-				g.WithClients(provider)
-			} else if utils.IsPresubmitTests() {
-				// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
-				g.WithClients(&slsa.NilClientProvider{})
+			// NOTE: The path is renamed after it is validated so that the
+			// unsigned attestation can't be mistaken for a signed one.
+			if insecureSkipSigning {
+				attPath = unsignedAttestationPath(attPath)
 			}
 
-			p, err := g.Generate(ctx)
-			check(err)
+			// NOTE: The artifact is verified before signing so that files
+			// altered in transit are never attested.
+			if downloadArtifact != "" {
+				dir := downloadArtifactDir
+				if dir == "" {
+					dir = downloadArtifact
+				}
+				d, err := newArtifactDownload(ghContext.Repository, ghContext.RunID)
+				check(err)
+				ghClient, err := clients.GithubClient(ctx)
+				check(err)
+				check(d.Download(ctx, ghClient, downloadArtifact, dir, parsedSubjects))
+			}
 
 			// Note: the path is validated within CreateNewFileUnderCurrentDirectory().
 			var attBytes []byte
+			var entryBytes []byte
+			var entry signing.LogEntry
+			var cert []byte
+			var vsaSigner signing.Signer
+
+			if lintWarnings || lintErrors {
+				check(lintStatement(s, lintErrors, cmd.ErrOrStderr()))
+			}
 			if utils.IsPresubmitTests() {
-				attBytes, err = json.Marshal(p)
+				attBytes, err = slsa.CanonicalizeStatement(*s)
 				check(err)
 			} else {
-				att, err := signer.Sign(ctx, &intoto.Statement{
-					StatementHeader: p.StatementHeader,
-					Predicate:       p.Predicate,
-				})
-				check(err)
+				// newSigner returns the signer with the given name.
+				defaultSigner := signer
+				var closers []io.Closer
+				newSigner := func(name string) signing.Signer {
+					switch name {
+					case "sigstore":
+						// Use the default signer.
+						return defaultSigner
+					case "gcpkms":
+						s, err := gcpkms.NewGCPKMSSigner(ctx, kmsKeyResource)
+						check(err)
+						closers = append(closers, s)
+						return s
+					case "fulcio":
+						oidcClient, err := github.NewOIDCClient()
+						check(err)
+						token, err := opts.oidcBearerToken()
+						check(err)
+						if token != "" {
+							oidcClient.WithBearerToken(token)
+						}
+						oidcClient.WithTimeout(opts.oidcTokenTimeout)
+						s, err := fulcio.NewFulcioSigner(oidcClient, fulcioURL)
+						check(err)
+						return s
+					case "file":
+						if signingKey == "" {
+							check(fmt.Errorf("--signer %s requires --signing-key", name))
+						}
+						s, err := file.NewFileSigner(signingKey)
+						check(err)
+						return s
+					default:
+						check(fmt.Errorf("unknown signer %q", name))
+						return nil
+					}
+				}
+				defer func() {
+					for _, c := range closers {
+						c.Close()
+					}
+				}()
 
-				_, err = tlog.Upload(ctx, att)
-				check(err)
+				signer = newSigner(signerName)
+				// NOTE: Each signer adds a signature over the identical
+				// payload, in order. The first one is used for the
+				// transparency log and the certificate identity check.
+				if len(additionalSigners) > 0 {
+					signers := []signing.Signer{signer}
+					seen := map[string]bool{signerName: true}
+					for _, name := range additionalSigners {
+						if seen[name] {
+							check(fmt.Errorf("duplicate signer %q", name))
+						}
+						seen[name] = true
+						signers = append(signers, newSigner(name))
+					}
+					signer = signing.NewMultiSigner(signers...)
+				}
+				if _, ok := signer.(signing.PayloadSigner); statementPath != "" && !ok {
+					check(errors.Errorf(&errors.ErrInput{}, "--statement requires a signer that signs the statement as is"))
+				}
+
+				switch transparencyLogName {
+				case "rekor":
+					// Use the default transparency log.
+					if logFile != "" {
+						check(errors.New("--log-file requires --transparency-log local"))
+					}
+				case "local":
+					if logFile == "" {
+						check(errors.New("--transparency-log local requires --log-file"))
+					}
+					if len(extraRekorURLs) > 0 {
+						check(errors.New("--extra-rekor-url requires the Rekor transparency log"))
+					}
+					// NOTE: The log file path is untrusted and should be
+					// validated.
+					check(utils.PathIsUnderCurrentDirectory(logFile))
+					tlog = transparencylog.NewLocalFileTransparencyLog(logFile)
+				default:
+					check(fmt.Errorf("unknown transparency log %q", transparencyLogName))
+				}
+
+				if rekorCertChain != "" {
+					r, ok := tlog.(*sigstore.Rekor)
+					if !ok {
+						check(errors.New("--rekor-cert-chain requires the Rekor transparency log"))
+					}
+					certs, err := sigstore.LoadCertChain(rekorCertChain)
+					check(err)
+					r.WithCertChain(certs)
+				}
+
+				if verifyRekorInclusion {
+					r, ok := tlog.(*sigstore.Rekor)
+					if !ok {
+						check(errors.New("--verify-rekor-inclusion requires the Rekor transparency log"))
+					}
+					r.WithInclusionVerification(true)
+				}
+
+				if rekorRetryCount < 0 {
+					check(fmt.Errorf("invalid --rekor-retry-count: %d", rekorRetryCount))
+				}
+				// NOTE: The integrated time is checked outside of the retries
+				// since uploading the identical entry again returns the same
+				// integrated time.
+				tlog = slsa.NewIntegratedTimeCheckingTransparencyLog(
+					slsa.NewRetryingTransparencyLog(tlog, rekorRetryCount, rekorRetryBaseDelay))
+
+				// NOTE: The extra logs use the same retries but not the
+				// --rekor-cert-chain, which is specific to the primary log.
+				if len(extraRekorURLs) > 0 {
+					var extra []slsa.NamedTransparencyLog
+					for _, u := range extraRekorURLs {
+						l := newExtraRekor(u)
+						if r, ok := l.(*sigstore.Rekor); ok && verifyRekorInclusion {
+							r.WithInclusionVerification(true)
+						}
+						extra = append(extra, slsa.NamedTransparencyLog{
+							Name: u,
+							Log: slsa.NewIntegratedTimeCheckingTransparencyLog(
+								slsa.NewRetryingTransparencyLog(l, rekorRetryCount, rekorRetryBaseDelay)),
+						})
+					}
+					tlog = slsa.NewMultiTransparencyLog(tlog, extra, requireAllRekor, cmd.ErrOrStderr())
+				}
+
+				// NOTE: The VSA is signed by the same signers, but not
+				// against the hash of the provenance statement.
+				vsaSigner = signer
+				hashSigner := signing.NewHashVerifyingSigner(signer).WithExpectedHash(hex.EncodeToString(statementHash[:]))
+				var att signing.Attestation
+				if statementPath != "" {
+					att, err = hashSigner.SignPayload(ctx, payload)
+				} else {
+					att, err = hashSigner.Sign(ctx, s)
+				}
+				if err != nil {
+					check(errors.Errorf(&errors.ErrSigning{}, "signing provenance: %w", err))
+				}
+				// NOTE: The identity is checked before uploading so that
+				// provenance signed by the wrong workflow is never logged.
+				check(checkCertIdentity(att.Cert(), expectedSource, expectedWorkflow))
+				if selfVerifyProvenance {
+					check(selfVerify(att.Bytes(), payload, expectedSource, expectedWorkflow))
+				}
+
+				if !noTransparencyLog {
+					entry, err = tlog.Upload(ctx, att)
+					if err != nil {
+						check(errors.Errorf(&errors.ErrTransparencyLog{}, "uploading provenance: %w", err))
+					}
+					if cmd.Flags().Changed("expect-rekor-index") {
+						check(checkRekorIndex(entry, expectRekorIndex, strictRekorIndex, cmd.ErrOrStderr()))
+					}
+					// Keep the log entry so that the provenance can be
+					// verified offline.
+					entryBytes, err = signing.MarshalLogEntry(entry)
+					check(err)
+				}
 
 				attBytes = att.Bytes()
+				cert = att.Cert()
 			}
 
 			f, err := utils.CreateNewFileUnderCurrentDirectory(attPath, os.O_WRONLY)
 			check(err)
+			written = append(written, attPath)
 
-			_, err = f.Write(attBytes)
-			check(err)
+			if _, err := f.Write(attBytes); err != nil {
+				check(errors.Errorf(&errors.ErrFilesystem{}, "writing provenance: %w", err))
+			}
+
+			var entryPath string
+			if entryBytes != nil {
+				entryPath = rekorEntryPath(attPath)
+				f, err := utils.CreateNewFileUnderCurrentDirectory(entryPath, os.O_WRONLY)
+				check(err)
+				written = append(written, entryPath)
+
+				if _, err := f.Write(entryBytes); err != nil {
+					check(errors.Errorf(&errors.ErrFilesystem{}, "writing transparency log entry: %w", err))
+				}
+			}
+
+			// NOTE: The VSA is not written in presubmit tests since the
+			// provenance is not signed.
+			var vsaName string
+			if emitVSA && vsaSigner != nil {
+				v := newVSAStatement(s, attPath, attBytes, cert, &ghContext, time.Now())
+				vsaAtt, err := vsaSigner.Sign(ctx, v)
+				if err != nil {
+					check(errors.Errorf(&errors.ErrSigning{}, "signing VSA: %w", err))
+				}
+				vsaName = vsaPath(attPath)
+				f, err := utils.CreateNewFileUnderCurrentDirectory(vsaName, os.O_WRONLY)
+				check(err)
+				written = append(written, vsaName)
+
+				if _, err := f.Write(vsaAtt.Bytes()); err != nil {
+					check(errors.Errorf(&errors.ErrFilesystem{}, "writing VSA: %w", err))
+				}
+			}
+
+			if uploadRelease != "" {
+				u, err := newReleaseUploader(clients, ghContext.Repository, uploadRelease, overwriteAsset)
+				check(err)
+				check(u.Upload(ctx, attPath))
+				if entryPath != "" {
+					check(u.Upload(ctx, entryPath))
+				}
+			}
+
+			// NOTE: The attestation is not pushed in presubmit tests since it
+			// is not signed.
+			if pushToRegistry != "" && !utils.IsPresubmitTests() {
+				desc, err := pushAttestation(ctx, newRegistry, pushToRegistry, attBytes)
+				check(err)
+				check(github.SetOutput("attestation-digest", desc.Digest.String()))
+			}
 
 			// Print the provenance name and sha256 so it can be used by the workflow.
 			check(github.SetOutput("provenance-name", attPath))
 			check(github.SetOutput("provenance-sha256", fmt.Sprintf("%x", sha256.Sum256(attBytes))))
+			if entryPath != "" {
+				check(github.SetOutput("rekor-entry-name", entryPath))
+			}
+			if vsaName != "" {
+				check(github.SetOutput("vsa-name", vsaName))
+			}
+			if emitAttestationPath {
+				// NOTE: The path is absolute so that it can be used by
+				// steps with another working directory, e.g. with
+				// --workspace.
+				absPath, err := filepath.Abs(attPath)
+				check(err)
+				if os.Getenv("GITHUB_OUTPUT") == "" {
+					github.Warning(cmd.ErrOrStderr(), nil, "GITHUB_OUTPUT is not set, the attestation-path "+
+						"output is not written.")
+				} else {
+					check(github.SetOutput("attestation-path", absPath))
+				}
+			}
+
+			if !noStepSummary {
+				check(github.AppendStepSummary(attestSummary(parsedSubjects, attPath, entry, cert)))
+			}
 		},
 	}
 
@@ -132,10 +819,209 @@ run in the context of a Github Actions workflow.`,
 		&attPath, "signature", "g", "",
 		"Path to write the signed provenance.",
 	)
-	c.Flags().StringVarP(
-		&subjects, "subjects", "s", "",
-		"Formatted list of subjects in the same format as sha256sum (base64 encoded).",
+	c.Flags().StringVar(
+		&provenanceNameTemplate, "provenance-name-template", "",
+		"Go template of the signed provenance file name, e.g. \"{{.BuildID}}-{{.Date}}.intoto.jsonl\". "+
+			"The fields are BuildID, Date, RunID, Repo and FirstSubjectName. Cannot be used with --signature.",
+	)
+	c.Flags().BoolVar(
+		&strictNaming, "strict-naming", false,
+		"Require the signature file name to be <subject>.intoto.jsonl for a single subject, "+
+			"and to not collide with any subject for multiple subjects.",
+	)
+	c.Flags().BoolVar(
+		&sanitizeName, "sanitize-name", true,
+		"Replace characters that are invalid on Windows and shorten long names in the default signature file name. "+
+			"The final name is written to the provenance-name output.",
+	)
+	c.Flags().BoolVar(
+		&insecureSkipSigning, "insecure-skip-signing", false,
+		"Write an unsigned attestation to <name>.unsigned.intoto.json instead of signing it. "+
+			"For testing only. Cannot be used with --upload-to-release.",
+	)
+	c.Flags().IntVar(
+		&minSubjectCount, "min-subject-count", 0,
+		"Fail if there are fewer subjects than this.",
+	)
+	c.Flags().IntVar(
+		&maxSubjectCount, "max-subject-count", 0,
+		"Fail if there are more subjects than this. 0 means no limit.",
+	)
+	c.Flags().BoolVar(
+		&allowAttestationSubjects, "allow-attestation-subjects", false,
+		"Allow subjects whose name ends in .intoto.jsonl or is the signature file name.",
+	)
+	c.Flags().StringVar(
+		&downloadArtifact, "download-artifact", "",
+		"The name of a workflow artifact of the current workflow run to download and verify against the subjects "+
+			"before signing. Every file must be a subject with a matching digest, and every subject must be a file.",
+	)
+	c.Flags().StringVar(
+		&downloadArtifactDir, "download-artifact-dir", "",
+		"The directory the artifact given by --download-artifact is extracted to. Defaults to the artifact name.",
+	)
+	c.Flags().BoolVar(
+		&lintWarnings, "lint-warnings", false,
+		"Check the provenance for common mistakes before signing, and print a warning for each.",
+	)
+	c.Flags().BoolVar(
+		&lintErrors, "lint-errors", false,
+		"Check the provenance for common mistakes before signing, and fail if any are found.",
+	)
+	c.Flags().BoolVar(
+		&noStepSummary, "no-step-summary", false,
+		"Do not write a summary of the attested subjects to the GitHub Actions job summary.",
+	)
+	c.Flags().BoolVar(
+		&emitAttestationPath, "emit-attestation-to-github-env", false,
+		"Write the absolute path of the signed provenance to the attestation-path output in GITHUB_OUTPUT.",
+	)
+	c.Flags().StringVar(
+		&signerName, "signer", "sigstore",
+		"The signer used to sign the provenance. One of \"sigstore\", \"gcpkms\", \"fulcio\" or \"file\".",
+	)
+	c.Flags().StringArrayVar(
+		&additionalSigners, "additional-signer", nil,
+		"Another signer, in addition to --signer, that adds its signature over the same payload to the "+
+			"envelope, e.g. \"gcpkms\" for a KMS signature together with the default keyless signature. "+
+			"May be repeated. The signatures are in the order of the signers.",
+	)
+	c.Flags().StringVar(
+		&fulcioURL, "fulcio-url", fulcio.DefaultFulcioURL,
+		"The URL of the Fulcio instance used by the fulcio signer.",
+	)
+	c.Flags().StringVar(
+		&kmsKeyResource, "kms-key-resource", "",
+		"The resource name of the Cloud KMS key version used by the gcpkms signer. "+
+			"e.g. projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*",
+	)
+	c.Flags().StringVar(
+		&signingKey, "signing-key", "",
+		"Path to the PKCS#8 PEM encoded Ed25519 private key used by the file signer.",
+	)
+	c.Flags().StringVar(
+		&uploadRelease, "upload-to-release", "",
+		"The tag name or ID of a GitHub release to upload the signed provenance to. "+
+			"A numeric value is used as a release ID only if there is no release with that tag.",
 	)
+	c.Flags().StringVar(
+		&pushToRegistry, "push-to-registry", "",
+		"An image reference with a tag or digest to attach the signed provenance to as an OCI artifact.",
+	)
+	c.Flags().BoolVar(
+		&overwriteAsset, "overwrite", false,
+		"Replace an existing release asset with the same name when using --upload-to-release.",
+	)
+	c.Flags().StringVar(
+		&rekorCertChain, "rekor-cert-chain", "",
+		"Path to a PEM encoded certificate chain for the expected Rekor signing key.",
+	)
+	c.Flags().StringVar(
+		&transparencyLogName, "transparency-log", "rekor",
+		"The transparency log the signed provenance is uploaded to. One of \"rekor\" or \"local\".",
+	)
+	c.Flags().StringVar(
+		&logFile, "log-file", "",
+		"Path of the file the local transparency log appends entries to. It must be under the current directory.",
+	)
+	c.Flags().BoolVar(
+		&noTransparencyLog, "no-transparency-log", false,
+		"Skip uploading the signed provenance to the transparency log, and record that in the build metadata of the predicate.",
+	)
+	c.Flags().StringVar(
+		&expectedSource, "expected-source", "",
+		"Fail unless the signing certificate was issued for this source repository, of the form owner/repo[@ref].",
+	)
+	c.Flags().StringVar(
+		&expectedWorkflow, "expected-workflow", "",
+		"Fail unless the signing certificate was issued for this workflow, "+
+			"e.g. owner/repo/.github/workflows/release.yml.",
+	)
+	c.Flags().BoolVar(
+		&selfVerifyProvenance, "self-verify", false,
+		"Verify the signed provenance before uploading it: its payload must be the statement and it must have "+
+			"a valid signature by the certificate embedded in it, checked against --expected-source and "+
+			"--expected-workflow.",
+	)
+	c.Flags().BoolVar(
+		&emitVSA, "emit-vsa", false,
+		"Sign a SLSA Verification Summary Attestation for the subjects once --self-verify passed, and write it "+
+			"to <name>.vsa.intoto.jsonl. Its name is written to the vsa-name output.",
+	)
+	c.Flags().BoolVar(
+		&verifyRekorInclusion, "verify-rekor-inclusion", false,
+		"Verify the Merkle inclusion proof of the Rekor entry against the signed tree head after uploading.",
+	)
+	c.Flags().StringArrayVar(
+		&extraRekorURLs, "extra-rekor-url", nil,
+		"URL of an additional Rekor instance, e.g. a private one, the signed provenance is uploaded to in "+
+			"parallel. May be repeated. A failed upload only prints a warning unless --require-all-rekor is set. "+
+			"The entry of the primary transparency log is the one recorded.",
+	)
+	c.Flags().BoolVar(
+		&requireAllRekor, "require-all-rekor", false,
+		"Fail if the upload to any --extra-rekor-url fails.",
+	)
+	c.Flags().IntVar(
+		&rekorRetryCount, "rekor-retry-count", 3,
+		"The number of times to retry an upload to the transparency log that failed with a network error or "+
+			"a 429 or 5xx response. Other failures, e.g. of the log entry verification, are not retried.",
+	)
+	c.Flags().DurationVar(
+		&rekorRetryBaseDelay, "rekor-retry-base-delay", time.Second,
+		"The delay before the first retry of a failed upload. The delay doubles with each retry.",
+	)
+	c.Flags().Int64Var(
+		&expectRekorIndex, "expect-rekor-index", 0,
+		"The log index the transparency log entry is expected to have. A different index prints a warning.",
+	)
+	c.Flags().BoolVar(
+		&strictRekorIndex, "strict-rekor-index", false,
+		"Fail if the transparency log entry does not have the index given by --expect-rekor-index.",
+	)
+	c.Flags().BoolVar(
+		&forbidUnsafeEvents, "forbid-unsafe-events", false,
+		"Refuse to sign provenance for workflow runs triggered by a pull_request event from a fork.",
+	)
+	c.Flags().DurationVar(
+		&timeout, "timeout", 5*time.Minute,
+		"Maximum duration of the command. Pending requests, e.g. to the transparency log, are canceled "+
+			"when it expires. 0 means no timeout.",
+	)
+	c.Flags().StringSliceVar(
+		&actorAllowList, "github-actor-allow-list", nil,
+		"Comma separated list of GitHub users allowed to trigger the workflow run. Provenance is not signed "+
+			"for other actors. Defaults to allowing all actors.",
+	)
+	c.Flags().StringVar(
+		&workspace, "workspace", "",
+		"Directory that all files are read from and written to. Relative paths in other flags are "+
+			"relative to it, and paths outside of it are rejected. Defaults to the current directory.",
+	)
+	c.Flags().StringVar(
+		&statementPath, "statement", "",
+		"Path to an unsigned provenance statement written by the 'generate' command to sign.",
+	)
+	c.Flags().StringVar(
+		&statementSHA256, "statement-sha256", "",
+		"The expected sha256 of the --statement file, as output by the 'generate' command.",
+	)
+	c.Flags().StringVar(
+		&configPath, "config", "",
+		"Path to a YAML config file with the options. Flags override the values in the file.",
+	)
+	opts.addFlags(c)
+	c.MarkFlagsRequiredTogether("statement", "statement-sha256")
+	for _, f := range statementFlags {
+		c.MarkFlagsMutuallyExclusive("statement", f)
+	}
+	c.MarkFlagsMutuallyExclusive("signature", "provenance-name-template")
+	c.MarkFlagsMutuallyExclusive("no-transparency-log", "rekor-cert-chain")
+	c.MarkFlagsMutuallyExclusive("no-transparency-log", "transparency-log")
+	c.MarkFlagsMutuallyExclusive("no-transparency-log", "log-file")
+	c.MarkFlagsMutuallyExclusive("no-transparency-log", "expect-rekor-index")
+	c.MarkFlagsMutuallyExclusive("no-transparency-log", "verify-rekor-inclusion")
+	c.MarkFlagsMutuallyExclusive("no-transparency-log", "extra-rekor-url")
 
 	return c
 }
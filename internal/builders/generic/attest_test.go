@@ -2,19 +2,34 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	cryptorand "crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/spf13/cobra"
 
 	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/signers/file"
 	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/internal/transparencylog"
 	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/signing"
 	"github.com/slsa-framework/slsa-github-generator/slsa"
 )
 
@@ -23,6 +38,92 @@ const (
 )
 
 // TestParseSubjects tests the parseSubjects function.
+func Test_decodeSubjects(t *testing.T) {
+	// The standard base64 encoding of the subjects contains "+" and padding.
+	subjects := []byte("2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2  fuga>>\n")
+	// The subjects are also valid without padding and with the URL-safe
+	// alphabet, and decode the same in every encoding.
+	shared := []byte("2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2  hoge1\n")
+
+	testCases := []struct {
+		name     string
+		str      string
+		expected []byte
+		err      bool
+	}{
+		{
+			name:     "standard",
+			str:      base64.StdEncoding.EncodeToString(subjects),
+			expected: subjects,
+		},
+		{
+			name:     "url-safe",
+			str:      base64.URLEncoding.EncodeToString(subjects),
+			expected: subjects,
+		},
+		{
+			name:     "unpadded",
+			str:      base64.RawStdEncoding.EncodeToString(subjects),
+			expected: subjects,
+		},
+		{
+			name:     "url-safe unpadded",
+			str:      base64.RawURLEncoding.EncodeToString(subjects),
+			expected: subjects,
+		},
+		{
+			name:     "valid in multiple encodings",
+			str:      base64.RawURLEncoding.EncodeToString(shared),
+			expected: shared,
+		},
+		{
+			name: "not utf-8",
+			str:  base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0xfd}),
+			err:  true,
+		},
+		{
+			name: "mixed alphabets",
+			str:  "ab+c-d==",
+			err:  true,
+		},
+		{
+			name: "not base64",
+			str:  "this is not base64",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeSubjects(tc.str)
+			if tc.err {
+				if !errors.As(err, new(*errBase64)) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if diff := cmp.Diff(string(tc.expected), string(got)); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Sanity check that the vectors exercise each alphabet and padding.
+	if std := base64.StdEncoding.EncodeToString(subjects); !strings.Contains(std, "+") || !strings.HasSuffix(std, "=") {
+		t.Errorf("unexpected standard encoding, want \"+\" and padding, got: %q", std)
+	}
+	for _, enc := range subjectsEncodings {
+		if _, err := enc.DecodeString(base64.RawURLEncoding.EncodeToString(shared)); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}
+}
+
 func TestParseSubjects(t *testing.T) {
 	errNoNameFunc := func(got error) {
 		want := &errNoName{}
@@ -45,6 +146,13 @@ func TestParseSubjects(t *testing.T) {
 		}
 	}
 
+	errSubjectURIFunc := func(got error) {
+		want := &errSubjectURI{}
+		if !errors.As(got, &want) {
+			t.Fatalf("unexpected error: %v", cmp.Diff(got, want, cmpopts.EquateErrors()))
+		}
+	}
+
 	errBase64Func := func(got error) {
 		want := &errBase64{}
 		if !errors.As(got, &want) {
@@ -173,6 +281,65 @@ func TestParseSubjects(t *testing.T) {
 			str:  "this is not base64",
 			err:  errBase64Func,
 		},
+		{
+			name: "sha256 prefix",
+			// echo "sha256:2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2 hoge" | base64 -w0
+			str: "c2hhMjU2OjJlMDM5MGViMDI0YTUyOTYzZGI3Yjk1ZTg0YTljMmIxMmMwMDQwNTRhN2JhZDlhOTdlYzBjN2M4OWQ0NjgxZDIgaG9nZQo=",
+			expected: []intoto.Subject{
+				{
+					Name: "hoge",
+					Digest: slsacommon.DigestSet{
+						"sha256": "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2",
+					},
+				},
+			},
+		},
+		{
+			name: "sha512 prefix",
+			// echo "sha512:cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e \
+			// pkg:npm/hoge" | base64 -w0
+			str: "c2hhNTEyOmNmODNlMTM1N2VlZmI4YmRmMTU0Mjg1MGQ2NmQ4MDA3ZDYyMGU0MDUwYjU3MTVkYzgzZjRhOTIxZDM2Y2U5Y2U0N2Qw" +
+				"ZDEzYzVkODVmMmIwZmY4MzE4ZDI4NzdlZWMyZjYzYjkzMWJkNDc0MTdhODFhNTM4MzI3YWY5MjdkYTNlIHBrZzpucG0vaG9nZQo=",
+			expected: []intoto.Subject{
+				{
+					Name: "pkg:npm/hoge",
+					Digest: slsacommon.DigestSet{
+						"sha512": "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+					},
+				},
+			},
+		},
+		{
+			name: "unsupported algorithm",
+			// echo "md5:2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2 hoge" | base64 -w0
+			str: "bWQ1OjJlMDM5MGViMDI0YTUyOTYzZGI3Yjk1ZTg0YTljMmIxMmMwMDQwNTRhN2JhZDlhOTdlYzBjN2M4OWQ0NjgxZDIgaG9nZQo=",
+			err: errShaFunc,
+		},
+		{
+			name: "invalid sha512 hash",
+			// echo "sha512:2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2 hoge" | base64 -w0
+			str: "c2hhNTEyOjJlMDM5MGViMDI0YTUyOTYzZGI3Yjk1ZTg0YTljMmIxMmMwMDQwNTRhN2JhZDlhOTdlYzBjN2M4OWQ0NjgxZDIgaG9nZQo=",
+			err: errShaFunc,
+		},
+		{
+			name: "resource URI",
+			// echo "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2 https://example.com/api/hoge" | base64 -w0
+			str: "MmUwMzkwZWIwMjRhNTI5NjNkYjdiOTVlODRhOWMyYjEyYzAwNDA1NGE3YmFkOWE5N2VjMGM3Yzg5ZDQ2ODFkMiBodHRwczovL2V4YW1wbGUuY29tL2FwaS9ob2dlCg==",
+			expected: []intoto.Subject{
+				{
+					Name: "https://example.com/api/hoge",
+					Digest: slsacommon.DigestSet{
+						"sha256": "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2",
+					},
+				},
+			},
+		},
+		{
+			name: "invalid resource URI",
+			// echo "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2 https://" | base64 -w0
+			str: "MmUwMzkwZWIwMjRhNTI5NjNkYjdiOTVlODRhOWMyYjEyYzAwNDA1NGE3YmFkOWE5N2VjMGM3Yzg5ZDQ2ODFkMiBodHRwczovLwo=",
+			err: errSubjectURIFunc,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -194,252 +361,3226 @@ func TestParseSubjects(t *testing.T) {
 	}
 }
 
-// Test_attestCmd tests the attest command.
-func Test_attestCmd_default_single_artifact(t *testing.T) {
-	t.Setenv("GITHUB_CONTEXT", "{}")
-
-	// Change to temporary dir
-	currentDir, err := os.Getwd()
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	dir, err := os.MkdirTemp("", "")
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	defer os.RemoveAll(dir)
-	if err := os.Chdir(dir); err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	defer func() {
-		if err := os.Chdir(currentDir); err != nil {
-			t.Errorf("unexpected failure: %v", err)
-		}
-	}()
-
-	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{})
-	c.SetOut(new(bytes.Buffer))
-	c.SetArgs([]string{
-		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
-	})
-	if err := c.Execute(); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+// Test_parseWorkflowInputs tests the parseWorkflowInputs function.
+func Test_parseWorkflowInputs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		str      string
+		err      interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "valid inputs",
+			str:  base64.StdEncoding.EncodeToString([]byte(`{"release_version": "v1.2.3", "dry-run": true}`)),
+			expected: map[string]interface{}{
+				"release_version": "v1.2.3",
+				"dry-run":         true,
+			},
+		},
+		{
+			name: "sensitive key",
+			str:  base64.StdEncoding.EncodeToString([]byte(`{"release_version": "v1.2.3", "Token_value": "hoge"}`)),
+			err:  new(*errSensitiveInputKey),
+		},
+		{
+			name: "secret key",
+			str:  base64.StdEncoding.EncodeToString([]byte(`{"secret": "hoge"}`)),
+			err:  new(*errSensitiveInputKey),
+		},
+		{
+			name: "invalid key",
+			str:  base64.StdEncoding.EncodeToString([]byte(`{"hoge fuga": "piyo"}`)),
+			err:  new(*errInputs),
+		},
+		{
+			name: "not a map",
+			str:  base64.StdEncoding.EncodeToString([]byte(`["hoge"]`)),
+			err:  new(*errInputs),
+		},
+		{
+			name: "not base64",
+			str:  "this is not base64",
+			err:  new(*errBase64),
+		},
 	}
 
-	// check that the expected file exists.
-	if _, err := os.Stat(filepath.Join(dir, "artifact1.intoto.jsonl")); err != nil {
-		t.Errorf("error checking file: %v", err)
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseWorkflowInputs(tc.str)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("expected %T but got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected inputs (-want +got):\n%s", diff)
+			}
+		})
 	}
 }
 
-func Test_attestCmd_default_multi_artifact(t *testing.T) {
-	t.Setenv("GITHUB_CONTEXT", "{}")
+func Test_attestationName(t *testing.T) {
+	long := strings.Repeat("a", 200)
 
-	// Change to temporary dir
-	currentDir, err := os.Getwd()
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	dir, err := os.MkdirTemp("", "")
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	defer os.RemoveAll(dir)
-	if err := os.Chdir(dir); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+	testCases := []struct {
+		name     string
+		subject  string
+		sanitize bool
+		expected string
+	}{
+		{
+			name:     "valid name",
+			subject:  "app.tar.gz",
+			sanitize: true,
+			expected: "app.tar.gz.intoto.jsonl",
+		},
+		{
+			name:     "invalid characters",
+			subject:  "app:linux*amd64?",
+			sanitize: true,
+			expected: "app_linux_amd64_.intoto.jsonl",
+		},
+		{
+			name:     "control characters",
+			subject:  "app\tv1",
+			sanitize: true,
+			expected: "app_v1.intoto.jsonl",
+		},
+		{
+			name:     "reserved name",
+			subject:  "con.tar.gz",
+			sanitize: true,
+			expected: "_con.tar.gz.intoto.jsonl",
+		},
+		{
+			name:     "long name",
+			subject:  long,
+			sanitize: true,
+			expected: long[:106] + "-c2a908d9.intoto.jsonl",
+		},
+		{
+			name:     "long multi-byte name",
+			subject:  strings.Repeat("é", 100),
+			sanitize: true,
+			expected: strings.Repeat("é", 53) + "-f42ec48e.intoto.jsonl",
+		},
+		{
+			name:     "no sanitization",
+			subject:  "app:linux",
+			expected: "app:linux.intoto.jsonl",
+		},
 	}
-	defer func() {
-		if err := os.Chdir(currentDir); err != nil {
-			t.Errorf("unexpected failure: %v", err)
-		}
-	}()
 
-	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{})
-	c.SetOut(new(bytes.Buffer))
-	c.SetArgs([]string{
-		"--subjects", base64.StdEncoding.EncodeToString([]byte(
-			`b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  artifact1
-b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  artifact2`)),
-	})
-	if err := c.Execute(); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := attestationName(tc.subject, tc.sanitize)
+			if want := tc.expected; want != got {
+				t.Errorf("unexpected name, want: %q, got: %q", want, got)
+			}
+			if tc.sanitize && len(got) > maxAttestationNameLength {
+				t.Errorf("name %q is longer than %d bytes", got, maxAttestationNameLength)
+			}
+		})
 	}
 
-	// check that the expected file exists.
-	if _, err := os.Stat(filepath.Join(dir, "multiple.intoto.jsonl")); err != nil {
-		t.Errorf("error checking file: %v", err)
+	// Truncated names stay unique.
+	if attestationName(long+"1", true) == attestationName(long+"2", true) {
+		t.Errorf("expected different names for different subjects")
 	}
 }
 
-func Test_attestCmd_custom_provenance_name(t *testing.T) {
-	t.Setenv("GITHUB_CONTEXT", "{}")
+func Test_verifyAttestationName(t *testing.T) {
+	single := []intoto.Subject{{Name: "dist/bar.tar.gz"}}
+	multi := []intoto.Subject{{Name: "foo"}, {Name: "dist/bar.intoto.jsonl"}}
 
-	// Change to temporary dir
-	currentDir, err := os.Getwd()
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	dir, err := os.MkdirTemp("", "")
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	defer os.RemoveAll(dir)
-	if err := os.Chdir(dir); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+	testCases := []struct {
+		name     string
+		attPath  string
+		subjects []intoto.Subject
+		err      bool
+	}{
+		{
+			name:     "single match",
+			attPath:  "bar.tar.gz.intoto.jsonl",
+			subjects: single,
+		},
+		{
+			name:     "single match in directory",
+			attPath:  "out/bar.tar.gz.intoto.jsonl",
+			subjects: single,
+		},
+		{
+			name:     "single mismatch",
+			attPath:  "foo.intoto.jsonl",
+			subjects: single,
+			err:      true,
+		},
+		{
+			name:     "single sanitized match",
+			attPath:  "app_v1.intoto.jsonl",
+			subjects: []intoto.Subject{{Name: "dist/app:v1"}},
+		},
+		{
+			name:     "multiple no collision",
+			attPath:  "multiple.intoto.jsonl",
+			subjects: multi,
+		},
+		{
+			name:     "multiple collision",
+			attPath:  "bar.intoto.jsonl",
+			subjects: multi,
+			err:      true,
+		},
 	}
-	defer func() {
-		if err := os.Chdir(currentDir); err != nil {
-			t.Errorf("unexpected failure: %v", err)
-		}
-	}()
 
-	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{})
-	c.SetOut(new(bytes.Buffer))
-	c.SetArgs([]string{
-		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
-		"--signature", "custom.intoto.jsonl",
-	})
-	if err := c.Execute(); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyAttestationName(tc.attPath, tc.subjects)
+			errName := &errAttestationName{}
+			if got := errors.As(err, &errName); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
 	}
+}
 
-	// check that the file exists.
-	if _, err := os.Stat("custom.intoto.jsonl"); err != nil {
-		t.Errorf("error checking file: %v", err)
+func Test_stripSubjectsPrefix(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prefix string
+		names  []string
+		want   []string
+		err    bool
+	}{
+		{
+			name:   "full match",
+			prefix: "/workspace/build/",
+			names:  []string{"/workspace/build/dist/myapp", "/workspace/build/myapp.tar.gz"},
+			want:   []string{"dist/myapp", "myapp.tar.gz"},
+		},
+		{
+			name:   "partial match",
+			prefix: "/workspace/build/",
+			names:  []string{"/workspace/build/myapp", "/tmp/other"},
+			want:   []string{"myapp", "/tmp/other"},
+		},
+		{
+			name:   "no match",
+			prefix: "/workspace/",
+			names:  []string{"dist/myapp"},
+			want:   []string{"dist/myapp"},
+		},
+		{
+			name:   "empty result",
+			prefix: "/workspace/build/myapp",
+			names:  []string{"/workspace/build/myapp"},
+			err:    true,
+		},
 	}
-}
 
-func Test_attestCmd_invalid_extension(t *testing.T) {
-	t.Setenv("GITHUB_CONTEXT", "{}")
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var subjects []intoto.Subject
+			for _, n := range tc.names {
+				subjects = append(subjects, intoto.Subject{Name: n})
+			}
+
+			err := stripSubjectsPrefix(subjects, tc.prefix)
+			errEmpty := &errEmptySubjectName{}
+			if got := errors.As(err, &errEmpty); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if tc.err {
+				return
+			}
+
+			var got []string
+			for _, s := range subjects {
+				got = append(got, s.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected names (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_addSubjectsPrefix(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prefix string
+		names  []string
+		want   []string
+		err    bool
+	}{
+		{
+			name:   "directory",
+			prefix: "projectA/",
+			names:  []string{"app.tar.gz", "dist/app.zip"},
+			want:   []string{"projectA/app.tar.gz", "projectA/dist/app.zip"},
+		},
+		{
+			name:   "uris unchanged",
+			prefix: "projectA/",
+			names:  []string{"app.tar.gz", "https://example.com/app", "pkg:npm/app@1.0.0"},
+			want:   []string{"projectA/app.tar.gz", "https://example.com/app", "pkg:npm/app@1.0.0"},
+		},
+		{
+			name:   "absolute path",
+			prefix: "/projectA/",
+			names:  []string{"app.tar.gz"},
+			err:    true,
+		},
+		{
+			name:   "windows absolute path",
+			prefix: "C:\\projectA\\",
+			names:  []string{"app.tar.gz"},
+			err:    true,
+		},
+		{
+			name:   "parent directory",
+			prefix: "projectA/../",
+			names:  []string{"app.tar.gz"},
+			err:    true,
+		},
+		{
+			name:   "windows parent directory",
+			prefix: "..\\projectA\\",
+			names:  []string{"app.tar.gz"},
+			err:    true,
+		},
+		{
+			name:   "control character",
+			prefix: "project\nA/",
+			names:  []string{"app.tar.gz"},
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var subjects []intoto.Subject
+			for _, n := range tc.names {
+				subjects = append(subjects, intoto.Subject{Name: n})
+			}
+
+			err := addSubjectsPrefix(subjects, tc.prefix)
+			errPrefix := &errSubjectPrefix{}
+			if got := errors.As(err, &errPrefix); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if tc.err {
+				return
+			}
+
+			var got []string
+			for _, s := range subjects {
+				got = append(got, s.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected names (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_stripSubjectsExtensions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		extensions []string
+		names      []string
+		want       []string
+		err        bool
+	}{
+		{
+			name:       "multi-extension archive",
+			extensions: []string{".gz", ".tar.gz", ".zip"},
+			names:      []string{"myapp-1.0.tar.gz", "dist/myapp-1.0.zip", "myapp-1.0.gz"},
+			want:       []string{"myapp-1.0", "dist/myapp-1.0", "myapp-1.0"},
+		},
+		{
+			name:       "unknown extension",
+			extensions: []string{".tar.gz", ".zip"},
+			names:      []string{"myapp-1.0.tar.xz", "myapp"},
+			want:       []string{"myapp-1.0.tar.xz", "myapp"},
+		},
+		{
+			name:       "extension is the entire name",
+			extensions: []string{".tar.gz"},
+			names:      []string{"myapp-1.0.tar.gz", ".tar.gz"},
+			err:        true,
+		},
+		{
+			name:       "extension is the entire file name",
+			extensions: []string{".zip"},
+			names:      []string{"dist/.zip"},
+			err:        true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var subjects []intoto.Subject
+			for _, n := range tc.names {
+				subjects = append(subjects, intoto.Subject{Name: n})
+			}
+
+			err := stripSubjectsExtensions(subjects, tc.extensions)
+			errEmpty := &errEmptySubjectName{}
+			if got := errors.As(err, &errEmpty); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if tc.err {
+				return
+			}
+
+			var got []string
+			for _, s := range subjects {
+				got = append(got, s.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected names (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_checkCaseInsensitiveNames(t *testing.T) {
+	testCases := []struct {
+		name  string
+		names []string
+		err   bool
+	}{
+		{
+			name:  "distinct names",
+			names: []string{"artifact1", "artifact2"},
+		},
+		{
+			name:  "differently cased names",
+			names: []string{"Artifact.tar.gz", "artifact.tar.gz"},
+			err:   true,
+		},
+		{
+			name:  "differently cased directories",
+			names: []string{"dist/app", "Dist/app"},
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var subjects []intoto.Subject
+			for _, n := range tc.names {
+				subjects = append(subjects, intoto.Subject{Name: n})
+			}
+
+			err := checkCaseInsensitiveNames(subjects)
+			errDuplicate := &errDuplicateSubject{}
+			if got := errors.As(err, &errDuplicate); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func Test_lintStatement(t *testing.T) {
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+	// A statement without subjects or predicate type.
+	s := &intoto.Statement{}
+
+	testCases := []struct {
+		name   string
+		strict bool
+		err    bool
+	}{
+		{
+			name: "warnings",
+		},
+		{
+			name:   "errors",
+			strict: true,
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := lintStatement(s, tc.strict, &out)
+			if got := errors.As(err, new(*errLint)); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if want := "WARNING: lint " + slsa.LintNoSubjects + ": "; !strings.HasPrefix(out.String(), want) {
+				t.Errorf("unexpected output, want prefix: %q, got: %q", want, out.String())
+			}
+		})
+	}
+}
+
+func Test_normalizeSubjectNames(t *testing.T) {
+	testCases := []struct {
+		name     string
+		basename bool
+		names    []string
+		want     []string
+		err      bool
+	}{
+		{
+			name:  "leading dot slash",
+			names: []string{"./dist/app.tar.gz", "././app", "app2"},
+			want:  []string{"dist/app.tar.gz", "app", "app2"},
+		},
+		{
+			name:  "backslashes",
+			names: []string{`dist\app.tar.gz`, `.\dist\app.zip`},
+			want:  []string{"dist/app.tar.gz", "dist/app.zip"},
+		},
+		{
+			name:  "uri",
+			names: []string{`https://example.com/./a\b`},
+			want:  []string{`https://example.com/./a\b`},
+		},
+		{
+			name:     "basename",
+			basename: true,
+			names:    []string{"./dist/app.tar.gz", `bin\app.exe`, "app"},
+			want:     []string{"app.tar.gz", "app.exe", "app"},
+		},
+		{
+			name:  "empty after normalization",
+			names: []string{"./"},
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var subjects []intoto.Subject
+			for _, n := range tc.names {
+				subjects = append(subjects, intoto.Subject{Name: n})
+			}
+
+			err := normalizeSubjectNames(subjects, tc.basename)
+			errEmpty := &errEmptySubjectName{}
+			if got := errors.As(err, &errEmpty); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if tc.err {
+				return
+			}
+
+			var got []string
+			for _, s := range subjects {
+				got = append(got, s.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected names (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_statementOptions_parseSubjects_normalized_duplicates(t *testing.T) {
+	subjects := base64.StdEncoding.EncodeToString([]byte(
+		"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  ./artifact1\n" +
+			"7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  artifact1\n"))
+
+	testCases := []struct {
+		name      string
+		normalize bool
+		basename  bool
+		duplicate bool
+	}{
+		{
+			name:      "normalized",
+			normalize: true,
+			duplicate: true,
+		},
+		{
+			name: "not normalized",
+		},
+		{
+			name:      "basename",
+			basename:  true,
+			duplicate: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			o := statementOptions{
+				subjects:        []string{subjects},
+				normalizeNames:  tc.normalize,
+				subjectBasename: tc.basename,
+			}
+			_, err := o.parseSubjects(context.Background(), &cobra.Command{}, nil)
+			errDuplicate := &errDuplicateSubject{}
+			if got := errors.As(err, &errDuplicate); got != tc.duplicate {
+				t.Fatalf("unexpected error, want duplicate: %v, got: %v", tc.duplicate, err)
+			}
+		})
+	}
+}
+
+func Test_validatePURL(t *testing.T) {
+	testCases := []struct {
+		name string
+		purl string
+		err  bool
+	}{
+		{
+			name: "npm",
+			purl: "pkg:npm/foo@1.0.0",
+		},
+		{
+			name: "npm scope",
+			purl: "pkg:npm/@scope/foo@1.0.0",
+		},
+		{
+			name: "maven with qualifiers",
+			purl: "pkg:maven/org.example/foo@1.0.0?type=jar#sub/path",
+		},
+		{
+			name: "missing version",
+			purl: "pkg:npm/@scope/foo",
+			err:  true,
+		},
+		{
+			name: "empty version",
+			purl: "pkg:npm/foo@",
+			err:  true,
+		},
+		{
+			name: "missing name",
+			purl: "pkg:npm@1.0.0",
+			err:  true,
+		},
+		{
+			name: "empty namespace",
+			purl: "pkg:maven//foo@1.0.0",
+			err:  true,
+		},
+		{
+			name: "invalid type",
+			purl: "pkg:1npm/foo@1.0.0",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePURL(tc.purl)
+			if !tc.err {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, new(*errSubjectPURL)) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_statementOptions_parseSubjects_purl(t *testing.T) {
+	testCases := []struct {
+		name     string
+		subjects string
+		purls    string
+		expected []intoto.Subject
+		err      bool
+	}{
+		{
+			name:  "npm",
+			purls: "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  pkg:npm/@scope/foo@1.0.0\n",
+			expected: []intoto.Subject{
+				{
+					Name:   "pkg:npm/@scope/foo@1.0.0",
+					Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+				},
+			},
+		},
+		{
+			name:  "malformed",
+			purls: "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  pkg:npm/@scope/foo\n",
+			err:   true,
+		},
+		{
+			name:  "not a purl",
+			purls: "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  foo-1.0.0.tgz\n",
+			err:   true,
+		},
+		{
+			name:     "mixed with filename",
+			subjects: "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  ./dist/foo-1.0.0.tgz\n",
+			purls:    "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  pkg:npm/foo@1.0.0\n",
+			expected: []intoto.Subject{
+				{
+					Name:   "dist/foo-1.0.0.tgz",
+					Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+				},
+				{
+					Name:   "pkg:npm/foo@1.0.0",
+					Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			o := statementOptions{
+				subjects:       []string{base64.StdEncoding.EncodeToString([]byte(tc.subjects))},
+				subjectsPURL:   base64.StdEncoding.EncodeToString([]byte(tc.purls)),
+				normalizeNames: true,
+				sortSubjects:   true,
+			}
+			got, err := o.parseSubjects(context.Background(), &cobra.Command{}, nil)
+			if tc.err {
+				if !errors.As(err, new(*errSubjectPURL)) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_statementOptions_parseSubjects_repeated(t *testing.T) {
+	b64 := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	const (
+		foo = "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  foo\n"
+		bar = "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  bar\n"
+	)
+
+	testCases := []struct {
+		name      string
+		subjects  []string
+		files     map[string]string
+		filenames []string
+		expected  []string
+		err       string
+	}{
+		{
+			name:     "subjects concatenated in order",
+			subjects: []string{b64(foo), b64(bar)},
+			expected: []string{"foo", "bar"},
+		},
+		{
+			name:     "duplicate across subjects",
+			subjects: []string{b64(foo), b64(bar + foo)},
+			err:      `duplicate subject "foo" from --subjects #1 line 1 and --subjects #2 line 2`,
+		},
+		{
+			name:     "duplicate in subjects",
+			subjects: []string{b64(foo + "\n" + foo)},
+			err:      `--subjects #1 line 3: duplicate subject "foo", first on line 1`,
+		},
+		{
+			name:     "bad line in second subjects",
+			subjects: []string{b64(foo), b64(bar + "not-a-digest  baz\n")},
+			err:      "--subjects #2 line 2: ",
+		},
+		{
+			name:     "bad encoding in second subjects",
+			subjects: []string{b64(foo), "not base64!"},
+			err:      "--subjects #2: ",
+		},
+		{
+			name:      "files concatenated in order",
+			files:     map[string]string{"a.txt": bar, "b.txt": foo},
+			filenames: []string{"a.txt", "b.txt"},
+			expected:  []string{"bar", "foo"},
+		},
+		{
+			name:      "bad line in second file",
+			files:     map[string]string{"a.txt": bar, "b.txt": "foo\n"},
+			filenames: []string{"a.txt", "b.txt"},
+			err:       `--subjects-filename #2 ("b.txt") line 1: `,
+		},
+		{
+			name:      "duplicate across files",
+			files:     map[string]string{"a.txt": bar, "b.txt": foo + bar},
+			filenames: []string{"a.txt", "b.txt"},
+			err:       `duplicate subject "bar" from --subjects-filename #1 ("a.txt") line 1 and --subjects-filename #2 ("b.txt") line 2`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+			for name, contents := range tc.files {
+				writeTestFile(t, name, contents)
+			}
+
+			o := statementOptions{
+				subjects:         tc.subjects,
+				subjectsFilename: tc.filenames,
+			}
+			got, err := o.parseSubjects(context.Background(), &cobra.Command{}, nil)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("unexpected error, want: %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			var names []string
+			for _, s := range got {
+				names = append(names, s.Name)
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_statementOptions_parseSubjects_debug tests that --debug prints the
+// source of each subject.
+func Test_statementOptions_parseSubjects_debug(t *testing.T) {
+	subjects := base64.StdEncoding.EncodeToString([]byte(
+		"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  ./foo\n\n" +
+			"7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  bar\n"))
+	purl := base64.StdEncoding.EncodeToString([]byte(
+		"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  pkg:npm/foo@1.0.0\n"))
+
+	o := statementOptions{
+		subjects:       []string{subjects},
+		subjectsPURL:   purl,
+		normalizeNames: true,
+		sortSubjects:   true,
+		debug:          true,
+	}
+	var stderr bytes.Buffer
+	c := &cobra.Command{}
+	c.SetErr(&stderr)
+	if _, err := o.parseSubjects(context.Background(), c, nil); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := `DEBUG: subject "foo" sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c from --subjects #1 line 1
+DEBUG: subject "bar" sha256:7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730 from --subjects #1 line 3
+DEBUG: subject "pkg:npm/foo@1.0.0" sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c from --subjects-purl line 1
+`
+	if diff := cmp.Diff(want, stderr.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func Test_checkSubjectCount(t *testing.T) {
+	testCases := []struct {
+		name  string
+		count int
+		min   int
+		max   int
+		err   bool
+	}{
+		{
+			name:  "exactly min",
+			count: 2,
+			min:   2,
+			max:   4,
+		},
+		{
+			name:  "exactly max",
+			count: 4,
+			min:   2,
+			max:   4,
+		},
+		{
+			name:  "below min",
+			count: 1,
+			min:   2,
+			max:   4,
+			err:   true,
+		},
+		{
+			name:  "above max",
+			count: 5,
+			min:   2,
+			max:   4,
+			err:   true,
+		},
+		{
+			name:  "no max",
+			count: 100,
+			min:   2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			subjects := make([]intoto.Subject, tc.count)
+			err := checkSubjectCount(subjects, tc.min, tc.max)
+			if !tc.err {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				return
+			}
+
+			var errCount *errSubjectCountOutOfRange
+			if !errors.As(err, &errCount) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if errCount.count != tc.count || errCount.min != tc.min || errCount.max != tc.max {
+				t.Errorf("unexpected range, want: %d [%d, %d], got: %d [%d, %d]",
+					tc.count, tc.min, tc.max, errCount.count, errCount.min, errCount.max)
+			}
+		})
+	}
+}
+
+func Test_checkAttestationSubjects(t *testing.T) {
+	testCases := []struct {
+		name    string
+		names   []string
+		attPath string
+		err     bool
+	}{
+		{
+			name:    "artifacts",
+			names:   []string{"artifact1", "dist/artifact2.tar.gz"},
+			attPath: "multiple.intoto.jsonl",
+		},
+		{
+			name:    "previous attestation",
+			names:   []string{"artifact1", "artifact1.intoto.jsonl"},
+			attPath: "multiple.intoto.jsonl",
+			err:     true,
+		},
+		{
+			name:    "previous attestation in directory",
+			names:   []string{"artifact1", "dist/artifact1.intoto.jsonl"},
+			attPath: "multiple.intoto.jsonl",
+			err:     true,
+		},
+		{
+			name:    "signature file name",
+			names:   []string{"artifact1", "provenance.json"},
+			attPath: "provenance.json",
+			err:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var subjects []intoto.Subject
+			for _, n := range tc.names {
+				subjects = append(subjects, intoto.Subject{Name: n})
+			}
+
+			err := checkAttestationSubjects(subjects, tc.attPath)
+			errAttestation := &errAttestationSubject{}
+			if got := errors.As(err, &errAttestation); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_attestation_subjects tests that attestations are rejected as
+// subjects unless --allow-attestation-subjects is set.
+func Test_attestCmd_attestation_subjects(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	subjects := base64.StdEncoding.EncodeToString([]byte(
+		"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  artifact1\n" +
+			"7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  artifact1.intoto.jsonl\n"))
+
+	testCases := []struct {
+		name string
+		args []string
+		// attestation indicates that errAttestationSubject is expected.
+		attestation bool
+	}{
+		{
+			name:        "attestation subject",
+			args:        []string{"--subjects", subjects},
+			attestation: true,
+		},
+		{
+			name: "allow attestation subjects",
+			args: []string{"--subjects", subjects, "--allow-attestation-subjects"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					errAttestation := &errAttestationSubject{}
+					if !tc.attestation || !errors.As(err, &errAttestation) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.attestation {
+				t.Fatalf("expected an error to occur.")
+			}
+			if _, err := os.Stat("multiple.intoto.jsonl"); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_case_insensitive_names tests that subjects only differing in
+// case are rejected with --case-insensitive-names.
+func Test_attestCmd_case_insensitive_names(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	subjects := base64.StdEncoding.EncodeToString([]byte(
+		"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  Artifact\n" +
+			"7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730  artifact\n"))
+
+	testCases := []struct {
+		name string
+		args []string
+		// duplicate indicates that errDuplicateSubject is expected.
+		duplicate bool
+	}{
+		{
+			name: "case sensitive",
+			args: []string{"--subjects", subjects, "--signature", "sensitive.intoto.jsonl"},
+		},
+		{
+			name:      "case insensitive",
+			args:      []string{"--subjects", subjects, "--signature", "insensitive.intoto.jsonl", "--case-insensitive-names"},
+			duplicate: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(err error) {
+				if err != nil {
+					errDuplicate := &errDuplicateSubject{}
+					if !tc.duplicate || !errors.As(err, &errDuplicate) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.duplicate {
+				t.Fatalf("expected an error to occur.")
+			}
+		})
+	}
+}
+
+func Test_validateBuildInvocationID(t *testing.T) {
+	testCases := []struct {
+		name string
+		id   string
+		err  bool
+	}{
+		{
+			name: "run id and attempt",
+			id:   "12345-1",
+		},
+		{
+			name: "max length",
+			id:   strings.Repeat("a", 256),
+		},
+		{
+			name: "too long",
+			id:   strings.Repeat("a", 257),
+			err:  true,
+		},
+		{
+			name: "non-printable",
+			id:   "12345\n1",
+			err:  true,
+		},
+		{
+			name: "non-ascii",
+			id:   "12345-é",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateBuildInvocationID(tc.id)
+			errID := &errInvocationID{}
+			if got := errors.As(err, &errID); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func Test_sourceMaterial(t *testing.T) {
+	const commit = "8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c"
+
+	testCases := []struct {
+		name       string
+		serverURL  string
+		repository string
+		commit     string
+		expected   string
+		err        bool
+	}{
+		{
+			name:       "github.com",
+			serverURL:  "https://github.com",
+			repository: "owner/repo",
+			commit:     commit,
+			expected:   "git+https://github.com/owner/repo",
+		},
+		{
+			name:       "default server",
+			repository: "owner/repo",
+			commit:     commit,
+			expected:   "git+https://github.com/owner/repo",
+		},
+		{
+			name:       "uppercase",
+			repository: "owner/repo",
+			commit:     strings.ToUpper(commit),
+			err:        true,
+		},
+		{
+			name:       "short",
+			repository: "owner/repo",
+			commit:     commit[:7],
+			err:        true,
+		},
+		{
+			name:   "missing repository",
+			commit: commit,
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := sourceMaterial(tc.serverURL, tc.repository, tc.commit)
+			errCommit := &errSourceCommit{}
+			if got := errors.As(err, &errCommit); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if tc.err {
+				return
+			}
+			want := slsacommon.ProvenanceMaterial{
+				URI:    tc.expected,
+				Digest: slsacommon.DigestSet{"sha1": tc.commit},
+			}
+			if diff := cmp.Diff(want, m); diff != "" {
+				t.Errorf("unexpected material (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_attestCmd tests the attest command.
+func Test_attestCmd_default_single_artifact(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// check that the expected file exists.
+	if _, err := os.Stat(filepath.Join(dir, "artifact1.intoto.jsonl")); err != nil {
+		t.Errorf("error checking file: %v", err)
+	}
+}
+
+// Test_attestCmd_missing_github_context tests that attest fails with an error,
+// rather than panicking or writing an attestation with an empty context, when
+// GITHUB_CONTEXT is not set.
+func Test_attestCmd_missing_github_context(t *testing.T) {
+	// Use t.Setenv so that the original value is restored after the test.
+	t.Setenv("GITHUB_CONTEXT", "")
+	if err := os.Unsetenv("GITHUB_CONTEXT"); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	dir := chdirTemp(t)
+
+	var checkErr error
+	check := func(err error) {
+		if err != nil {
+			checkErr = err
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	defer func() {
+		if checkErr == nil {
+			return
+		}
+		if want := "GITHUB_CONTEXT"; !strings.Contains(checkErr.Error(), want) {
+			t.Errorf("unexpected error, want: %q, got: %q", want, checkErr)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "artifact1.intoto.jsonl")); !os.IsNotExist(err) {
+			t.Errorf("unexpected attestation file, want: not exist, got: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	t.Fatalf("expected an error to occur.")
+}
+
+func Test_attestCmd_default_multi_artifact(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(
+			`b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  artifact1
+b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  artifact2`)),
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// check that the expected file exists.
+	if _, err := os.Stat(filepath.Join(dir, "multiple.intoto.jsonl")); err != nil {
+		t.Errorf("error checking file: %v", err)
+	}
+}
+
+// Test_attestCmd_emit_attestation_path tests that the path of the provenance
+// is written to GITHUB_OUTPUT with --emit-attestation-to-github-env.
+func Test_attestCmd_emit_attestation_path(t *testing.T) {
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+	testCases := []struct {
+		name      string
+		setOutput bool
+	}{
+		{
+			name:      "GITHUB_OUTPUT set",
+			setOutput: true,
+		},
+		{
+			name: "GITHUB_OUTPUT unset",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_CONTEXT", "{}")
+			dir := chdirTemp(t)
+
+			outputPath := filepath.Join(t.TempDir(), "github-output")
+			if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if tc.setOutput {
+				t.Setenv("GITHUB_OUTPUT", outputPath)
+			} else {
+				t.Setenv("GITHUB_OUTPUT", "")
+			}
+
+			var stderr bytes.Buffer
+			c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetErr(&stderr)
+			c.SetArgs([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--emit-attestation-to-github-env",
+			})
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if !tc.setOutput {
+				if !strings.Contains(stderr.String(), "WARNING: GITHUB_OUTPUT is not set") {
+					t.Errorf("expected a warning, got: %q", stderr.String())
+				}
+				return
+			}
+			outputs := readOutputs(t, outputPath)
+			want := filepath.Join(dir, "artifact1.intoto.jsonl")
+			if got := outputs["attestation-path"]; got != want {
+				t.Errorf("unexpected attestation-path, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+func Test_attestCmd_custom_provenance_name(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--signature", "custom.intoto.jsonl",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// check that the file exists.
+	if _, err := os.Stat("custom.intoto.jsonl"); err != nil {
+		t.Errorf("error checking file: %v", err)
+	}
+}
+
+func Test_renderProvenanceName(t *testing.T) {
+	data := provenanceNameData{
+		BuildID:          "1234-2",
+		Date:             "2023-04-01",
+		RunID:            "1234",
+		Repo:             "org/repo",
+		FirstSubjectName: "artifact1",
+	}
+
+	testCases := []struct {
+		name     string
+		template string
+		sanitize bool
+		expected string
+		err      bool
+	}{
+		{
+			name:     "build id and date",
+			template: "{{.BuildID}}-{{.Date}}.intoto.jsonl",
+			expected: "1234-2-2023-04-01.intoto.jsonl",
+		},
+		{
+			name:     "extension added",
+			template: "{{.FirstSubjectName}}-{{.RunID}}",
+			expected: "artifact1-1234.intoto.jsonl",
+		},
+		{
+			name:     "sanitized repo",
+			template: "{{.Repo}}.intoto.jsonl",
+			sanitize: true,
+			expected: "org_repo.intoto.jsonl",
+		},
+		{
+			name:     "invalid syntax",
+			template: "{{.BuildID",
+			err:      true,
+		},
+		{
+			name:     "unknown field",
+			template: "{{.Commit}}.intoto.jsonl",
+			err:      true,
+		},
+		{
+			name:     "empty name",
+			template: "{{if false}}x{{end}}",
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := parseProvenanceNameTemplate(tc.template)
+			var name string
+			if err == nil {
+				name, err = renderProvenanceName(tmpl, data, tc.sanitize)
+			}
+			if tc.err {
+				if !errors.As(err, new(*errInvalidTemplate)) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if name != tc.expected {
+				t.Errorf("unexpected name, want: %q, got: %q", tc.expected, name)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_provenance_name_template tests that the provenance file name
+// is rendered from --provenance-name-template.
+func Test_attestCmd_provenance_name_template(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", `{"repository": "org/repo", "run_id": "1234", "run_attempt": "2"}`)
+	chdirTemp(t)
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--provenance-name-template", "{{.FirstSubjectName}}-{{.BuildID}}.intoto.jsonl",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	if _, err := os.Stat("artifact1-1234-2.intoto.jsonl"); err != nil {
+		t.Errorf("error checking file: %v", err)
+	}
+}
+
+func Test_attestCmd_invalid_extension(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	// A custom check function that checks the error type is the expected error type.
+	check := func(err error) {
+		if err != nil {
+			errInvalidPath := &utils.ErrInvalidPath{}
+			if !errors.As(err, &errInvalidPath) {
+				t.Fatalf("expected %v but got %v", &utils.ErrInvalidPath{}, err)
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--signature", "invalid_name",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+	t.Errorf("expected an error to occur.")
+}
+
+func Test_attestCmd_invalid_path(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	// A custom check function that checks the error type is the expected error type.
+	check := func(err error) {
+		if err != nil {
+			errInvalidPath := &utils.ErrInvalidPath{}
+			if !errors.As(err, &errInvalidPath) {
+				t.Fatalf("expected %v but got %v", &utils.ErrInvalidPath{}, err)
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--signature", "/provenance.intoto.jsonl",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+	t.Errorf("expected an error to occur.")
+}
+
+// Test_attestCmd_subdirectory_artifact tests the attest command when provided
+// subjects in subdirectories.
+func Test_attestCmd_subdirectory_artifact(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// check that the expected file exists.
+	if _, err := os.Stat(filepath.Join(dir, "artifact1.intoto.jsonl")); err != nil {
+		t.Errorf("error checking file: %v", err)
+	}
+}
+
+// recordingSigner is a Signer that records the signed statement.
+type recordingSigner struct {
+	testutil.TestSigner
+	statement *intoto.Statement
+}
+
+func (s *recordingSigner) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	s.statement = p
+	return s.TestSigner.Sign(ctx, p)
+}
+
+// payloadRecordingSigner is a PayloadSigner that records the signed payload.
+type payloadRecordingSigner struct {
+	testutil.TestSigner
+	payload []byte
+}
+
+func (s *payloadRecordingSigner) SignPayload(ctx context.Context, payload []byte) (signing.Attestation, error) {
+	s.payload = payload
+	return s.TestSigner.Sign(ctx, nil)
+}
+
+// Test_attestCmd_no_transparency_log tests that the transparency log upload
+// is skipped and recorded in the build metadata of the predicate.
+func Test_attestCmd_no_transparency_log(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	testCases := []struct {
+		name string
+		args []string
+		// metadata is the path of the build metadata in the predicate.
+		metadata []string
+		// want are other predicate fields that are expected to be kept.
+		want map[string]interface{}
+		// wantMetadata are other metadata fields that are expected to be kept.
+		wantMetadata map[string]interface{}
+	}{
+		{
+			name:     "v0.2",
+			metadata: []string{"metadata"},
+		},
+		{
+			name:     "v1",
+			args:     []string{"--statement-version", statementVersionV1},
+			metadata: []string{"runDetails", "metadata"},
+		},
+		{
+			name:     "predicate file",
+			args:     []string{"--predicate-file", "predicate.json", "--predicate-type", "https://example.com/custom/v1"},
+			metadata: []string{"metadata"},
+			want:     map[string]interface{}{"result": "pass", "count": json.Number("12345678901234567890")},
+		},
+		{
+			name:         "predicate file with metadata",
+			args:         []string{"--predicate-file", "metadata.json", "--predicate-type", "https://example.com/custom/v1"},
+			metadata:     []string{"metadata"},
+			want:         map[string]interface{}{"result": "pass"},
+			wantMetadata: map[string]interface{}{"owner": "release-team"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+			writeTestFile(t, "predicate.json", `{"result": "pass", "count": 12345678901234567890}`)
+			writeTestFile(t, "metadata.json", `{"result": "pass", "metadata": {"owner": "release-team"}}`)
+
+			// TransparencyLogWithErr fails the command if Upload is called.
+			signer := &recordingSigner{}
+			c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), signer, &testutil.TransparencyLogWithErr{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--no-transparency-log",
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if signer.statement == nil {
+				t.Fatalf("provenance was not signed")
+			}
+			b, err := json.Marshal(signer.statement.Predicate)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			d := json.NewDecoder(bytes.NewReader(b))
+			d.UseNumber()
+			var predicate map[string]interface{}
+			if err := d.Decode(&predicate); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			metadata := predicate
+			for _, k := range tc.metadata {
+				metadata, _ = metadata[k].(map[string]interface{})
+			}
+			if want, got := "none", metadata["transparency_log"]; want != got {
+				t.Errorf("unexpected transparency_log, want: %q, got: %q", want, got)
+			}
+			for k, want := range tc.want {
+				if got := predicate[k]; want != got {
+					t.Errorf("unexpected %s, want: %v, got: %v", k, want, got)
+				}
+			}
+			for k, want := range tc.wantMetadata {
+				if got := metadata[k]; want != got {
+					t.Errorf("unexpected metadata %s, want: %v, got: %v", k, want, got)
+				}
+			}
+		})
+	}
+}
+
+// Test_attestCmd_subjects_stdin tests reading subjects from stdin.
+func Test_attestCmd_subjects_stdin(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	testCases := []struct {
+		name    string
+		args    []string
+		stdin   string
+		attPath string
+		// noSubjects indicates that errNoSubjects is expected.
+		noSubjects bool
+	}{
+		{
+			name:    "subjects",
+			args:    []string{"--subjects", "-", "--signature", "subjects.intoto.jsonl"},
+			stdin:   testHash + "\n",
+			attPath: "subjects.intoto.jsonl",
+		},
+		{
+			name:    "subjects filename",
+			args:    []string{"--subjects-filename", "-", "--signature", "filename.intoto.jsonl"},
+			stdin:   testHash + "\n",
+			attPath: "filename.intoto.jsonl",
+		},
+		{
+			name:       "empty stdin",
+			args:       []string{"--subjects", "-"},
+			noSubjects: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(err error) {
+				if err != nil {
+					errNone := &errNoSubjects{}
+					if !tc.noSubjects || !errors.As(err, &errNone) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetIn(strings.NewReader(tc.stdin))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.noSubjects {
+				t.Fatalf("expected an error to occur.")
+			}
+
+			// check that the expected file exists.
+			if _, err := os.Stat(filepath.Join(dir, tc.attPath)); err != nil {
+				t.Errorf("error checking file: %v", err)
+			}
+		})
+	}
+}
+
+func Test_attestCmd_exit_code(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	subjects := base64.StdEncoding.EncodeToString([]byte(testHash))
+
+	testCases := []struct {
+		name   string
+		args   []string
+		signer signing.Signer
+		tlog   signing.TransparencyLog
+		code   int
+	}{
+		{
+			name:   "invalid base64",
+			args:   []string{"--subjects", "not base64!"},
+			signer: &testutil.TestSigner{},
+			tlog:   &testutil.TestTransparencyLog{},
+			code:   errors.ExitCodeInput,
+		},
+		{
+			name:   "invalid path",
+			args:   []string{"--subjects", subjects, "--signature", "../out.intoto.jsonl"},
+			signer: &testutil.TestSigner{},
+			tlog:   &testutil.TestTransparencyLog{},
+			code:   errors.ExitCodeInput,
+		},
+		{
+			name:   "signing failure",
+			args:   []string{"--subjects", subjects, "--signature", "signing.intoto.jsonl"},
+			signer: &testutil.SignerWithErr{},
+			tlog:   &testutil.TestTransparencyLog{},
+			code:   errors.ExitCodeSigning,
+		},
+		{
+			name:   "transparency log failure",
+			args:   []string{"--subjects", subjects, "--signature", "tlog.intoto.jsonl", "--rekor-retry-count", "0"},
+			signer: &testutil.TestSigner{},
+			tlog:   &testutil.TransparencyLogWithErr{},
+			code:   errors.ExitCodeTransparencyLog,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(err error) {
+				if err != nil {
+					if want, got := tc.code, errors.ExitCode(err); want != got {
+						t.Fatalf("unexpected exit code, want: %d, got: %d (%v)", want, got, err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, tc.signer, tc.tlog, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+			t.Errorf("expected an error to occur.")
+		})
+	}
+}
+
+// Test_attestCmd_error_types tests that invalid arguments reach the check
+// callback as the expected typed errors.
+func Test_attestCmd_error_types(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	subjects := encode(testHash)
+
+	testCases := []struct {
+		name string
+		args []string
+		err  interface{}
+	}{
+		{
+			name: "invalid base64",
+			args: []string{"--subjects", "not base64!"},
+			err:  new(*errBase64),
+		},
+		{
+			name: "invalid sha",
+			args: []string{"--subjects", encode("abcdef  artifact1")},
+			err:  new(*errSha),
+		},
+		{
+			name: "missing name",
+			args: []string{"--subjects", encode(strings.Fields(testHash)[0])},
+			err:  new(*errNoName),
+		},
+		{
+			name: "duplicate subject",
+			args: []string{"--subjects", encode(testHash + "\n" + testHash)},
+			err:  new(*errDuplicateSubject),
+		},
+		{
+			// NOTE: An empty subjects string, e.g. from a misconfigured
+			// upstream step, must fail rather than skip the attestation.
+			name: "empty subjects",
+			args: []string{"--subjects", ""},
+			err:  new(*errNoSubjects),
+		},
+		{
+			name: "blank subjects",
+			args: []string{"--subjects", encode("\n\n")},
+			err:  new(*errNoSubjects),
+		},
+		{
+			name: "invalid signature extension",
+			args: []string{"--subjects", subjects, "--signature", "invalid_name"},
+			err:  new(*utils.ErrInvalidPath),
+		},
+		{
+			name: "signature outside current directory",
+			args: []string{"--subjects", subjects, "--signature", "../out.intoto.jsonl"},
+			err:  new(*utils.ErrInvalidPath),
+		},
+		{
+			name: "unsupported statement version",
+			args: []string{"--subjects", subjects, "--statement-version", "v0.2"},
+			err:  new(*errStatementVersion),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					if !errors.As(err, tc.err) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if want, got := errors.ExitCodeInput, errors.ExitCode(err); want != got {
+						t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+			t.Errorf("expected an error to occur.")
+		})
+	}
+}
+
+// Test_attestCmd_build_invocation_id tests that the build invocation ID is
+// recorded in the provenance metadata.
+func Test_attestCmd_build_invocation_id(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	signer := &recordingSigner{}
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), signer, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--build-invocation-id", "custom-id",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	if signer.statement == nil {
+		t.Fatalf("provenance was not signed")
+	}
+	b, err := json.Marshal(signer.statement)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := `"buildInvocationID":"custom-id"`, string(b); !strings.Contains(got, want) {
+		t.Errorf("unexpected provenance, want: %q in %q", want, got)
+	}
+}
+
+// Test_attestCmd_rekor_entry tests that the transparency log entry is written
+// next to the provenance.
+func Test_attestCmd_rekor_entry(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "artifact1.rekor.json"))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var got struct {
+		UUID                 string                  `json:"uuid"`
+		LogIndex             int64                   `json:"logIndex"`
+		IntegratedTime       int64                   `json:"integratedTime"`
+		SignedEntryTimestamp []byte                  `json:"signedEntryTimestamp"`
+		InclusionProof       *signing.InclusionProof `json:"inclusionProof"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := testutil.NewTestLogEntry()
+	if want, got := want.UUID(), got.UUID; want != got {
+		t.Errorf("unexpected uuid, want: %q, got: %q", want, got)
+	}
+	if want, got := want.LogIndex(), got.LogIndex; want != got {
+		t.Errorf("unexpected log index, want: %d, got: %d", want, got)
+	}
+	if want, got := want.IntegratedTime(), got.IntegratedTime; want != got {
+		t.Errorf("unexpected integrated time, want: %d, got: %d", want, got)
+	}
+	if want, got := want.SignedEntryTimestamp(), got.SignedEntryTimestamp; !bytes.Equal(want, got) {
+		t.Errorf("unexpected signed entry timestamp, want: %q, got: %q", want, got)
+	}
+	if diff := cmp.Diff(want.InclusionProof(), got.InclusionProof); diff != "" {
+		t.Errorf("unexpected inclusion proof (-want +got):\n%s", diff)
+	}
+}
+
+// Test_attestCmd_local_transparency_log tests that the signed provenance is
+// appended to the local log file with --transparency-log local.
+func Test_attestCmd_local_transparency_log(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	logFile := filepath.Join("logs", "tlog.jsonl")
+	if err := os.Mkdir("logs", 0o700); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	signer := &testutil.TestSigner{Att: testutil.TestAttestation{BytesVal: []byte("attestation")}}
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), signer, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--transparency-log", "local",
+		"--log-file", logFile,
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	b, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var got transparencylog.LocalFileEntry
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := "attestation", string(got.Payload); want != got {
+		t.Errorf("unexpected payload, want: %q, got: %q", want, got)
+	}
+}
+
+// Test_attestCmd_local_transparency_log_path tests that the local log file
+// must be under the current directory.
+func Test_attestCmd_local_transparency_log_path(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	testCases := []struct {
+		name    string
+		logFile string
+	}{
+		{
+			name:    "parent directory",
+			logFile: "../tlog.jsonl",
+		},
+		{
+			name:    "absolute path",
+			logFile: filepath.Join(os.TempDir(), "tlog.jsonl"),
+		},
+		{
+			name:    "escaping subdirectory",
+			logFile: "logs/../../tlog.jsonl",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					errInvalidPath := &utils.ErrInvalidPath{}
+					if !errors.As(err, &errInvalidPath) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			tlog := &testutil.CountingTransparencyLog{}
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, tlog, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--transparency-log", "local",
+				"--log-file", tc.logFile,
+			})
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Fatalf("expected an error to occur.")
+		})
+	}
+}
+
+// Test_attestCmd_no_rekor_entry tests that no transparency log entry is
+// written when the upload is skipped.
+func Test_attestCmd_no_rekor_entry(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--no-transparency-log",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "artifact1.rekor.json")); !os.IsNotExist(err) {
+		t.Errorf("unexpected transparency log entry: %v", err)
+	}
+}
+
+func Test_checkRekorIndex(t *testing.T) {
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+	testCases := []struct {
+		name    string
+		index   int64
+		strict  bool
+		warning bool
+		err     bool
+	}{
+		{
+			name:  "expected index",
+			index: 42,
+		},
+		{
+			name:    "unexpected index",
+			index:   7,
+			warning: true,
+		},
+		{
+			name:   "strict expected index",
+			index:  42,
+			strict: true,
+		},
+		{
+			name:   "strict unexpected index",
+			index:  7,
+			strict: true,
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			entry := &testutil.TestLogEntry{LogIndexVal: tc.index}
+			err := checkRekorIndex(entry, 42, tc.strict, &buf)
+
+			errIndex := &errUnexpectedRekorIndex{}
+			if got := errors.As(err, &errIndex); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if got := strings.Contains(buf.String(), "WARNING"); got != tc.warning {
+				t.Errorf("unexpected warning, want warning: %v, got: %q", tc.warning, buf.String())
+			}
+		})
+	}
+}
+
+// Test_attestCmd_strict_rekor_index tests that the command fails if the log
+// entry does not have the expected index with --strict-rekor-index.
+func Test_attestCmd_strict_rekor_index(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
 
 	// Change to temporary dir
 	currentDir, err := os.Getwd()
 	if err != nil {
 		t.Errorf("unexpected failure: %v", err)
 	}
-	dir, err := os.MkdirTemp("", "")
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	check := func(err error) {
+		if err != nil {
+			errIndex := &errUnexpectedRekorIndex{}
+			if !errors.As(err, &errIndex) {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := errors.ExitCodeTransparencyLog, errors.ExitCode(err); want != got {
+				t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	tlog := &testutil.TestTransparencyLog{Entry: &testutil.TestLogEntry{LogIndexVal: 7}}
+	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, tlog, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--expect-rekor-index", "42",
+		"--strict-rekor-index",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+	t.Errorf("expected an error to occur.")
+}
+
+// Test_attestCmd_extra_rekor_url tests that the provenance is uploaded to
+// every --extra-rekor-url and that their failures only fail the command with
+// --require-all-rekor.
+func Test_attestCmd_extra_rekor_url(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+
+	testCases := []struct {
+		name        string
+		extraFails  bool
+		requireAll  bool
+		wantWarning bool
+		err         bool
+	}{
+		{
+			name: "all succeed",
+		},
+		{
+			name:        "extra fails",
+			extraFails:  true,
+			wantWarning: true,
+		},
+		{
+			name:       "extra fails with require all",
+			extraFails: true,
+			requireAll: true,
+			err:        true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			primary := &testutil.CountingTransparencyLog{Entry: testutil.NewTestLogEntry()}
+			extra := map[string]*testutil.CountingTransparencyLog{
+				"https://rekor-a.example.com": {Entry: testutil.NewTestLogEntry()},
+				"https://rekor-b.example.com": {Entry: testutil.NewTestLogEntry()},
+			}
+			if tc.extraFails {
+				extra["https://rekor-b.example.com"].FailCount = 1
+			}
+			orig := newExtraRekor
+			newExtraRekor = func(rekorURL string) signing.TransparencyLog {
+				return extra[rekorURL]
+			}
+			t.Cleanup(func() { newExtraRekor = orig })
+
+			check := func(err error) {
+				if err != nil {
+					if !tc.err || !errors.Is(err, testutil.ErrTransparencyLog) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			args := []string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--extra-rekor-url", "https://rekor-a.example.com",
+				"--extra-rekor-url", "https://rekor-b.example.com",
+				"--rekor-retry-count", "0",
+			}
+			if tc.requireAll {
+				args = append(args, "--require-all-rekor")
+			}
+
+			var stderr bytes.Buffer
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, primary, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetErr(&stderr)
+			c.SetArgs(args)
+			defer func() {
+				// Every log should be uploaded to, even if another fails.
+				for name, l := range map[string]*testutil.CountingTransparencyLog{
+					"primary":                     primary,
+					"https://rekor-a.example.com": extra["https://rekor-a.example.com"],
+					"https://rekor-b.example.com": extra["https://rekor-b.example.com"],
+				} {
+					if want, got := 1, l.Calls; want != got {
+						t.Errorf("unexpected number of uploads to %s, want: %d, got: %d", name, want, got)
+					}
+				}
+			}()
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if tc.err {
+				t.Fatalf("expected an error to occur.")
+			}
+
+			warning := strings.Contains(stderr.String(), "WARNING: uploading to https://rekor-b.example.com")
+			if warning != tc.wantWarning {
+				t.Errorf("unexpected warning, want: %v, got: %q", tc.wantWarning, stderr.String())
+			}
+		})
+	}
+}
+
+// Test_attestCmd_extra_rekor_url_invalid tests that invalid uses of
+// --extra-rekor-url fail before signing.
+func Test_attestCmd_extra_rekor_url_invalid(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "not a URL",
+			args: []string{"--extra-rekor-url", "rekor.example.com"},
+		},
+		{
+			name: "local transparency log",
+			args: []string{"--extra-rekor-url", "https://rekor.example.com", "--transparency-log", "local",
+				"--log-file", "log.jsonl"},
+		},
+		{
+			name: "require all without extra",
+			args: []string{"--require-all-rekor"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			signer := &recordingSigner{}
+			check := func(err error) {
+				if err != nil {
+					if signer.statement != nil {
+						t.Errorf("unexpected signing before error")
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Fatalf("expected an error to occur.")
+		})
+	}
+}
+
+// Test_attestCmd_file_signer tests that the provenance is signed with the
+// key given by --signing-key.
+func Test_attestCmd_file_signer(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	keyDir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	privPath := filepath.Join(keyDir, "key.pem")
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	pubPath := filepath.Join(keyDir, "key.pub")
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	dir := chdirTemp(t)
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--signer", "file",
+		"--signing-key", privPath,
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "artifact1.intoto.jsonl"))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	v, err := file.NewFileVerifier(pubPath)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if _, err := v.Verify(b); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+}
+
+// Test_attestCmd_forbid_unsafe_events tests that provenance is not signed for
+// pull requests from forks with --forbid-unsafe-events.
+func Test_attestCmd_forbid_unsafe_events(t *testing.T) {
+	pullRequest := func(event, head string) string {
+		return `{"repository": "org/repo", "event_name": "` + event + `", "event": {"pull_request": {` +
+			`"head": {"repo": {"full_name": "` + head + `"}}, "base": {"repo": {"full_name": "org/repo"}}}}}`
+	}
+
+	testCases := []struct {
+		name    string
+		context string
+		err     bool
+	}{
+		{
+			name:    "push",
+			context: `{"repository": "org/repo", "event_name": "push"}`,
+		},
+		{
+			name:    "pull request from same repository",
+			context: pullRequest("pull_request", "org/repo"),
+		},
+		{
+			name:    "pull request from fork",
+			context: pullRequest("pull_request", "user/repo"),
+			err:     true,
+		},
+		{
+			name:    "pull request target from fork",
+			context: pullRequest("pull_request_target", "user/repo"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_CONTEXT", tc.context)
+			chdirTemp(t)
+
+			signer := &recordingSigner{}
+			check := func(err error) {
+				if err != nil {
+					if !tc.err || !errors.As(err, new(*errUnsafeEvent)) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if signer.statement != nil {
+						t.Errorf("unexpected signing before error")
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--forbid-unsafe-events",
+			})
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.err {
+				t.Errorf("expected an error to occur.")
+			}
+		})
+	}
+}
+
+// Test_attestCmd_github_actor_allow_list tests that provenance is only signed
+// for the actors in --github-actor-allow-list.
+func Test_attestCmd_github_actor_allow_list(t *testing.T) {
+	testCases := []struct {
+		name      string
+		allowList string
+		err       bool
+	}{
+		{
+			name: "empty list",
+		},
+		{
+			name:      "allowed actor",
+			allowList: "alice,charlie",
+		},
+		{
+			name:      "actor not allowed",
+			allowList: "alice,bob",
+			err:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_CONTEXT", `{"repository": "org/repo", "actor": "charlie"}`)
+			chdirTemp(t)
+
+			signer := &recordingSigner{}
+			check := func(err error) {
+				if err != nil {
+					if !tc.err || !errors.As(err, new(*errActorNotAllowed)) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if signer.statement != nil {
+						t.Errorf("unexpected signing before error")
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--github-actor-allow-list", tc.allowList,
+			})
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.err {
+				t.Errorf("expected an error to occur.")
+			}
+			if signer.statement == nil {
+				t.Errorf("expected the provenance to be signed")
+			}
+		})
+	}
+}
+
+// blockingRegistry is a registryClient whose requests block until the
+// context is done.
+type blockingRegistry struct{}
+
+func (blockingRegistry) Resolve(ctx context.Context, _ string) (ocispec.Descriptor, error) {
+	<-ctx.Done()
+	return ocispec.Descriptor{}, ctx.Err()
+}
+
+func (blockingRegistry) Push(ctx context.Context, _ ocispec.Descriptor, _ io.Reader) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Test_attestCmd_timeout tests that attest returns promptly when --timeout
+// expires and removes the files it wrote.
+func Test_attestCmd_timeout(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tlog     signing.TransparencyLog
+		registry registryProvider
+		args     []string
+	}{
+		{
+			name: "transparency log",
+			tlog: testutil.BlockingTransparencyLog{},
+		},
+		{
+			name: "registry",
+			tlog: &testutil.TestTransparencyLog{},
+			registry: func(string) (registryClient, error) {
+				return blockingRegistry{}, nil
+			},
+			args: []string{"--push-to-registry", "ghcr.io/org/image:v1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_CONTEXT", "{}")
+			dir := chdirTemp(t)
+
+			start := time.Now()
+			check := func(err error) {
+				if err != nil {
+					if !errors.As(err, new(*errTimeout)) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if d := time.Since(start); d > 5*time.Second {
+						t.Errorf("unexpected duration: %s", d)
+					}
+					entries, err := os.ReadDir(dir)
+					if err != nil {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					for _, e := range entries {
+						t.Errorf("unexpected file: %q", e.Name())
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, tc.tlog, tc.registry)
+			c.SetOut(new(bytes.Buffer))
+			c.SetErr(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--timeout", "100ms",
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Fatalf("expected an error to occur.")
+		})
+	}
+}
+
+// Test_attestCmd_insecure_skip_signing tests that --insecure-skip-signing
+// writes an unsigned attestation and can't be used to publish it.
+func Test_attestCmd_insecure_skip_signing(t *testing.T) {
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	chdirTemp(t)
+
+	subjects := base64.StdEncoding.EncodeToString([]byte(testHash))
+
+	testCases := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{
+			name: "unsigned",
+			args: []string{"--subjects", subjects, "--insecure-skip-signing"},
+		},
+		{
+			name: "upload to release",
+			args: []string{"--subjects", subjects, "--insecure-skip-signing", "--upload-to-release", "v1.0.0"},
+			err:  true,
+		},
+		{
+			name: "signer",
+			args: []string{"--subjects", subjects, "--insecure-skip-signing", "--signer", "gcpkms"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(err error) {
+				if err != nil {
+					if !tc.err || !strings.Contains(err.Error(), "--insecure-skip-signing") {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			var stderr bytes.Buffer
+			c := attestCmd(&slsa.NilClientProvider{}, check, testutil.SignerWithErr{}, testutil.TransparencyLogWithErr{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetErr(&stderr)
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.err {
+				t.Fatalf("expected an error to occur.")
+			}
+
+			if !strings.Contains(stderr.String(), "WARNING: --insecure-skip-signing") {
+				t.Errorf("unexpected warning, got: %q", stderr.String())
+			}
+			b, err := os.ReadFile("artifact1.unsigned.intoto.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var s intoto.Statement
+			if err := json.Unmarshal(b, &s); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := "artifact1", s.Subject[0].Name; want != got {
+				t.Errorf("unexpected subject, want: %q, got: %q", want, got)
+			}
+			for _, name := range []string{"artifact1.intoto.jsonl", "artifact1.unsigned.rekor.json"} {
+				if _, err := os.Stat(name); !os.IsNotExist(err) {
+					t.Errorf("unexpected file %q: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_checkCertIdentity(t *testing.T) {
+	cert, err := testutil.NewTestCert(
+		"https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0",
+		certificate.Extensions{
+			Issuer:                   "https://token.actions.githubusercontent.com",
+			GithubWorkflowRepository: "owner/repo",
+			GithubWorkflowRef:        "refs/tags/v1.0.0",
+		})
 	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
+		t.Fatalf("unexpected failure: %v", err)
 	}
-	defer os.RemoveAll(dir)
-	if err := os.Chdir(dir); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+
+	testCases := []struct {
+		name     string
+		cert     []byte
+		source   string
+		workflow string
+		err      bool
+	}{
+		{
+			name: "no expectations",
+		},
+		{
+			name:     "match",
+			cert:     cert,
+			source:   "owner/repo@refs/tags/v1.0.0",
+			workflow: "owner/repo/.github/workflows/release.yml",
+		},
+		{
+			name:   "repository without ref",
+			cert:   cert,
+			source: "owner/repo",
+		},
+		{
+			name:   "wrong repository",
+			cert:   cert,
+			source: "owner/other",
+			err:    true,
+		},
+		{
+			name:   "wrong ref",
+			cert:   cert,
+			source: "owner/repo@refs/heads/main",
+			err:    true,
+		},
+		{
+			name:     "wrong workflow",
+			cert:     cert,
+			workflow: "owner/repo/.github/workflows/other.yml",
+			err:      true,
+		},
+		{
+			name:   "no certificate",
+			source: "owner/repo",
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkCertIdentity(tc.cert, tc.source, tc.workflow)
+			errIdentity := &errCertIdentity{}
+			if got := errors.As(err, &errIdentity); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_expected_source tests that provenance signed with a
+// certificate for another repository is not uploaded.
+func Test_attestCmd_expected_source(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	chdirTemp(t)
+
+	cert, err := testutil.NewTestCert(
+		"https://github.com/owner/other/.github/workflows/release.yml@refs/heads/main",
+		certificate.Extensions{
+			Issuer:                   "https://token.actions.githubusercontent.com",
+			GithubWorkflowRepository: "owner/other",
+		})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
 	}
+
+	tlog := &testutil.CountingTransparencyLog{Entry: testutil.NewTestLogEntry()}
 	defer func() {
-		if err := os.Chdir(currentDir); err != nil {
-			t.Errorf("unexpected failure: %v", err)
+		if tlog.Calls != 0 {
+			t.Errorf("unexpected uploads, want: 0, got: %d", tlog.Calls)
 		}
 	}()
 
-	// A custom check function that checks the error type is the expected error type.
 	check := func(err error) {
 		if err != nil {
-			errInvalidPath := &utils.ErrInvalidPath{}
-			if !errors.As(err, &errInvalidPath) {
-				t.Fatalf("expected %v but got %v", &utils.ErrInvalidPath{}, err)
+			errIdentity := &errCertIdentity{}
+			if !errors.As(err, &errIdentity) {
+				t.Fatalf("unexpected failure: %v", err)
 			}
 			// Check should exit the program so we skip the rest of the test if we got the expected error.
 			t.SkipNow()
 		}
 	}
 
-	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{})
+	signer := &testutil.TestSigner{Att: testutil.TestAttestation{CertVal: cert}}
+	c := attestCmd(&slsa.NilClientProvider{}, check, signer, tlog, nil)
 	c.SetOut(new(bytes.Buffer))
 	c.SetArgs([]string{
 		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
-		"--signature", "invalid_name",
+		"--expected-source", "owner/repo",
 	})
 	if err := c.Execute(); err != nil {
 		t.Errorf("unexpected failure: %v", err)
 	}
-
-	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
-	t.Errorf("expected an error to occur.")
+	t.Fatalf("expected an error to occur.")
 }
 
-func Test_attestCmd_invalid_path(t *testing.T) {
+// Test_attestCmd_integrated_time_out_of_range tests that attest fails if the
+// log entry was integrated outside the certificate validity.
+func Test_attestCmd_integrated_time_out_of_range(t *testing.T) {
 	t.Setenv("GITHUB_CONTEXT", "{}")
+	chdirTemp(t)
 
-	// Change to temporary dir
-	currentDir, err := os.Getwd()
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
-	}
-	dir, err := os.MkdirTemp("", "")
+	cert, err := testutil.NewTestCert(
+		"https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+		certificate.Extensions{})
 	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
+		t.Fatalf("unexpected failure: %v", err)
 	}
-	defer os.RemoveAll(dir)
-	if err := os.Chdir(dir); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+
+	tlog := &testutil.CountingTransparencyLog{
+		Entry: testutil.NewTestLogEntryAt(time.Unix(testutil.NewTestLogEntry().IntegratedTimeVal, 0).Add(time.Hour)),
 	}
 	defer func() {
-		if err := os.Chdir(currentDir); err != nil {
-			t.Errorf("unexpected failure: %v", err)
+		// Uploading the identical entry again would return the same
+		// integrated time, so it is not retried.
+		if want, got := 1, tlog.Calls; want != got {
+			t.Errorf("unexpected uploads, want: %d, got: %d", want, got)
 		}
 	}()
 
-	// A custom check function that checks the error type is the expected error type.
 	check := func(err error) {
 		if err != nil {
-			errInvalidPath := &utils.ErrInvalidPath{}
-			if !errors.As(err, &errInvalidPath) {
-				t.Fatalf("expected %v but got %v", &utils.ErrInvalidPath{}, err)
+			if want, got := errors.ExitCodeTransparencyLog, errors.ExitCode(err); want != got {
+				t.Fatalf("unexpected failure: %v", err)
 			}
 			// Check should exit the program so we skip the rest of the test if we got the expected error.
 			t.SkipNow()
 		}
 	}
 
-	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{})
+	signer := &testutil.TestSigner{Att: testutil.TestAttestation{CertVal: cert}}
+	c := attestCmd(&slsa.NilClientProvider{}, check, signer, tlog, nil)
 	c.SetOut(new(bytes.Buffer))
 	c.SetArgs([]string{
 		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
-		"--signature", "/provenance.intoto.jsonl",
+		"--rekor-retry-count", "1",
+		"--rekor-retry-base-delay", "1ms",
 	})
 	if err := c.Execute(); err != nil {
 		t.Errorf("unexpected failure: %v", err)
 	}
-
-	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
-	t.Errorf("expected an error to occur.")
+	t.Fatalf("expected an error to occur.")
 }
 
-// Test_attestCmd_subdirectory_artifact tests the attest command when provided
-// subjects in subdirectories.
-func Test_attestCmd_subdirectory_artifact(t *testing.T) {
+// Test_attestCmd_workspace tests that attest only writes files within
+// --workspace.
+func Test_attestCmd_workspace(t *testing.T) {
 	t.Setenv("GITHUB_CONTEXT", "{}")
 
-	// Change to temporary dir
-	currentDir, err := os.Getwd()
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
+	testCases := []struct {
+		name      string
+		signature string
+		err       bool
+	}{
+		{
+			name:      "within workspace",
+			signature: "artifact1.intoto.jsonl",
+		},
+		{
+			name:      "escape workspace",
+			signature: "../escape.intoto.jsonl",
+			err:       true,
+		},
 	}
-	dir, err := os.MkdirTemp("", "")
-	if err != nil {
-		t.Errorf("unexpected failure: %v", err)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			if err := os.Mkdir("workspace", 0o700); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			check := func(err error) {
+				if err != nil {
+					errPath := &utils.ErrInvalidPath{}
+					if !tc.err || !errors.As(err, &errPath) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					if _, err := os.Stat(filepath.Join(dir, "escape.intoto.jsonl")); !os.IsNotExist(err) {
+						t.Errorf("unexpected file outside of the workspace: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--workspace", "workspace",
+				"--signature", tc.signature,
+			})
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if tc.err {
+				t.Fatalf("expected an error to occur.")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "workspace", tc.signature)); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+		})
 	}
-	defer os.RemoveAll(dir)
-	if err := os.Chdir(dir); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+}
+
+// Test_attestCmd_predicate_file tests that the predicate of --predicate-file
+// is signed as is.
+func Test_attestCmd_predicate_file(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	predicate, err := os.ReadFile(filepath.Join("testdata", "predicate", "custom.json"))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
 	}
-	defer func() {
-		if err := os.Chdir(currentDir); err != nil {
-			t.Errorf("unexpected failure: %v", err)
-		}
-	}()
+	const predicateType = "https://example.com/scan/v1"
 
-	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{})
-	c.SetOut(new(bytes.Buffer))
-	c.SetArgs([]string{
-		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
-	})
-	if err := c.Execute(); err != nil {
-		t.Errorf("unexpected failure: %v", err)
+	testCases := []struct {
+		name      string
+		args      []string
+		predicate []byte
+		err       bool
+	}{
+		{
+			name:      "predicate file",
+			args:      []string{"--predicate-file", "predicate.json", "--predicate-type", predicateType},
+			predicate: predicate,
+		},
+		{
+			name:      "missing predicate type",
+			args:      []string{"--predicate-file", "predicate.json"},
+			predicate: predicate,
+			err:       true,
+		},
+		{
+			name: "missing predicate file",
+			args: []string{"--predicate-type", predicateType},
+			err:  true,
+		},
+		{
+			name:      "relative predicate type",
+			args:      []string{"--predicate-file", "predicate.json", "--predicate-type", "scan/v1"},
+			predicate: predicate,
+			err:       true,
+		},
+		{
+			name:      "not an object",
+			args:      []string{"--predicate-file", "predicate.json", "--predicate-type", predicateType},
+			predicate: []byte(`["not", "an", "object"]`),
+			err:       true,
+		},
 	}
 
-	// check that the expected file exists.
-	if _, err := os.Stat(filepath.Join(dir, "artifact1.intoto.jsonl")); err != nil {
-		t.Errorf("error checking file: %v", err)
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+			if tc.predicate != nil {
+				if err := os.WriteFile("predicate.json", tc.predicate, 0o600); err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+			}
+
+			check := func(err error) {
+				if err != nil {
+					if !tc.err || !errors.As(err, new(*errPredicate)) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			signer := &recordingSigner{}
+			c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if tc.err {
+				t.Fatalf("expected an error to occur.")
+			}
+			if signer.statement == nil {
+				t.Fatalf("provenance was not signed")
+			}
+			if want, got := predicateType, signer.statement.PredicateType; want != got {
+				t.Errorf("unexpected predicate type, want: %q, got: %q", want, got)
+			}
+			if want, got := 1, len(signer.statement.Subject); want != got {
+				t.Errorf("unexpected number of subjects, want: %d, got: %d", want, got)
+			}
+
+			b, err := json.Marshal(signer.statement)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var got struct {
+				Predicate json.RawMessage `json:"predicate"`
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var want bytes.Buffer
+			if err := json.Compact(&want, predicate); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(want.String(), string(got.Predicate)); diff != "" {
+				t.Errorf("unexpected predicate (-want +got):\n%s", diff)
+			}
+		})
 	}
 }
@@ -0,0 +1,101 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// errBuildxMetadata indicates a buildx metadata file that cannot be parsed.
+type errBuildxMetadata struct {
+	errors.ErrInput
+}
+
+// buildxMetadata holds the fields of the file written by
+// 'docker buildx build --metadata-file' used for the subjects.
+type buildxMetadata struct {
+	ImageDigest string `json:"containerimage.digest"`
+	// ImageName is the comma separated list of the image names given with
+	// --tag, if any.
+	ImageName string `json:"image.name"`
+}
+
+// subjectsFromBuildxMetadata returns a subject for each image repository in
+// the buildx metadata file at path, with the digest of the image. The
+// repositories are read from the image names without their tag, so that an
+// image pushed with several tags is a single subject.
+func subjectsFromBuildxMetadata(path string) ([]intoto.Subject, error) {
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errBuildxMetadata{}, "reading buildx metadata file: %w", err)
+	}
+
+	var m buildxMetadata
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Errorf(&errBuildxMetadata{}, "parsing buildx metadata file %q: %w", path, err)
+	}
+	if m.ImageDigest == "" {
+		return nil, errors.Errorf(&errBuildxMetadata{}, "buildx metadata file %q has no containerimage.digest", path)
+	}
+	d, err := digest.Parse(m.ImageDigest)
+	if err != nil {
+		return nil, errors.Errorf(&errBuildxMetadata{}, "invalid containerimage.digest %q in buildx metadata file %q: %w",
+			m.ImageDigest, path, err)
+	}
+
+	var subjects []intoto.Subject
+	seen := map[string]bool{}
+	for _, name := range strings.Split(m.ImageName, ",") {
+		repo := imageRepository(strings.TrimSpace(name))
+		if repo == "" || seen[repo] {
+			continue
+		}
+		seen[repo] = true
+		subjects = append(subjects, intoto.Subject{
+			Name:   repo,
+			Digest: slsacommon.DigestSet{d.Algorithm().String(): d.Encoded()},
+		})
+	}
+	if len(subjects) == 0 {
+		return nil, errors.Errorf(&errNoSubjects{}, "buildx metadata file %q has no image.name, "+
+			"expected the image to be built with --tag", path)
+	}
+	return subjects, nil
+}
+
+// imageRepository returns the image name without its tag or digest, e.g.
+// "ghcr.io/owner/app" for "ghcr.io/owner/app:v1.0.0". A port of the registry
+// host is kept, e.g. "localhost:5000/app".
+func imageRepository(name string) string {
+	name, _, _ = strings.Cut(name, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+func Test_subjectsFromBuildxMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		expected []intoto.Subject
+		err      interface{}
+	}{
+		{
+			name: "metadata",
+			path: "testdata/buildx/metadata.json",
+			expected: []intoto.Subject{
+				{
+					Name:   "ghcr.io/owner/app",
+					Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+				},
+				{
+					Name:   "localhost:5000/app",
+					Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+				},
+			},
+		},
+		{
+			name: "no digest",
+			path: "testdata/buildx/no-digest.json",
+			err:  new(*errBuildxMetadata),
+		},
+		{
+			name: "invalid digest",
+			path: "testdata/buildx/invalid-digest.json",
+			err:  new(*errBuildxMetadata),
+		},
+		{
+			name: "no image name",
+			path: "testdata/buildx/no-name.json",
+			err:  new(*errNoSubjects),
+		},
+		{
+			name: "not json",
+			path: "testdata/matrix/output.txt",
+			err:  new(*errBuildxMetadata),
+		},
+		{
+			name: "missing file",
+			path: "testdata/buildx/missing.json",
+			err:  new(*errBuildxMetadata),
+		},
+		{
+			name: "outside of the current directory",
+			path: "../metadata.json",
+			err:  new(*utils.ErrInvalidPath),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			subjects, err := subjectsFromBuildxMetadata(tc.path)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, subjects); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_imageRepository(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "app", expected: "app"},
+		{name: "ghcr.io/owner/app:v1.0.0", expected: "ghcr.io/owner/app"},
+		{name: "localhost:5000/app", expected: "localhost:5000/app"},
+		{name: "localhost:5000/app:latest", expected: "localhost:5000/app"},
+		{
+			name:     "ghcr.io/owner/app:v1@sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+			expected: "ghcr.io/owner/app",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if got := imageRepository(tc.name); got != tc.expected {
+				t.Errorf("unexpected repository, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
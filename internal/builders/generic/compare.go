@@ -0,0 +1,163 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// Output formats of the 'compare' command.
+const (
+	compareFormatText = "text"
+	compareFormatJSON = "json"
+)
+
+// errSubjectsDiffer indicates that the expected and actual subjects differ.
+// It is unclassified so that the command exits with code 1.
+type errSubjectsDiffer struct {
+	errors.WrappableError
+}
+
+// errCompareFormat indicates an unknown output format.
+type errCompareFormat struct {
+	errors.ErrInput
+}
+
+// subjectDiffEntry is a line of the output of the 'compare' command.
+type subjectDiffEntry struct {
+	Status   string               `json:"status"`
+	Name     string               `json:"name"`
+	Expected slsacommon.DigestSet `json:"expected,omitempty"`
+	Actual   slsacommon.DigestSet `json:"actual,omitempty"`
+}
+
+// subjectDiffEntries returns the entries of the report with the expected
+// subjects as the old list, in the order added, removed, changed.
+func subjectDiffEntries(r slsa.SubjectDiffReport) []subjectDiffEntry {
+	entries := []subjectDiffEntry{}
+	for _, s := range r.Added {
+		entries = append(entries, subjectDiffEntry{Status: "added", Name: s.Name, Actual: s.Digest})
+	}
+	for _, s := range r.Removed {
+		entries = append(entries, subjectDiffEntry{Status: "removed", Name: s.Name, Expected: s.Digest})
+	}
+	for _, c := range r.Changed {
+		entries = append(entries, subjectDiffEntry{
+			Status:   "changed",
+			Name:     c.Name,
+			Expected: c.OldDigest,
+			Actual:   c.NewDigest,
+		})
+	}
+	return entries
+}
+
+// formatDigestSet returns the digests as a comma separated list of
+// <alg>:<hex> sorted by algorithm, or "-" if there are none.
+func formatDigestSet(d slsacommon.DigestSet) string {
+	if len(d) == 0 {
+		return "-"
+	}
+	var digests []string
+	for alg, v := range d {
+		digests = append(digests, alg+":"+v)
+	}
+	sort.Strings(digests)
+	return strings.Join(digests, ",")
+}
+
+// writeSubjectDiff writes the entries to w in the given format.
+func writeSubjectDiff(w io.Writer, entries []subjectDiffEntry, format string) error {
+	switch format {
+	case compareFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case compareFormatText:
+		if len(entries) == 0 {
+			_, err := fmt.Fprintln(w, "The subjects are identical.")
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "STATUS\tNAME\tEXPECTED\tACTUAL")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Status, e.Name, formatDigestSet(e.Expected), formatDigestSet(e.Actual))
+		}
+		return tw.Flush()
+	default:
+		return errors.Errorf(&errCompareFormat{}, "unknown format %q", format)
+	}
+}
+
+// compareCmd returns the 'compare' command.
+func compareCmd(check func(error)) *cobra.Command {
+	var expectedSubjects string
+	var actualSubjects string
+	var format string
+
+	c := &cobra.Command{
+		Use:   "compare",
+		Short: "Compare two lists of subjects",
+		Long: `Compare the expected and actual lists of subjects and print the artifacts
+that were added, removed or whose digest changed. Subjects are matched by name.
+
+The command exits with code 0 if the subjects are identical, 1 if they differ
+and 2 if they cannot be parsed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != compareFormatText && format != compareFormatJSON {
+				check(errors.Errorf(&errCompareFormat{}, "unknown format %q", format))
+			}
+
+			expected, err := parseSubjects(expectedSubjects)
+			check(err)
+			actual, err := parseSubjects(actualSubjects)
+			check(err)
+
+			r := slsa.SubjectDiff(expected, actual)
+			check(writeSubjectDiff(cmd.OutOrStdout(), subjectDiffEntries(r), format))
+			if !r.Empty() {
+				check(errors.Errorf(&errSubjectsDiffer{}, "%d added, %d removed and %d changed subjects",
+					len(r.Added), len(r.Removed), len(r.Changed)))
+			}
+		},
+	}
+
+	c.Flags().StringVar(
+		&expectedSubjects, "expected-subjects", "",
+		"The expected subjects in the same format as sha256sum (base64 encoded).",
+	)
+	c.Flags().StringVar(
+		&actualSubjects, "actual-subjects", "",
+		"The actual subjects in the same format as sha256sum (base64 encoded).",
+	)
+	c.Flags().StringVar(
+		&format, "format", compareFormatText,
+		"The output format. One of \""+compareFormatText+"\" or \""+compareFormatJSON+"\".",
+	)
+	check(c.MarkFlagRequired("expected-subjects"))
+	check(c.MarkFlagRequired("actual-subjects"))
+	return c
+}
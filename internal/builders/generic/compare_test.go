@@ -0,0 +1,185 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	testCompareDigest1 = "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"
+	testCompareDigest2 = "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"
+)
+
+// runCompareCmd runs the 'compare' command and returns its output and the
+// error passed to check, if any.
+func runCompareCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var checkErr error
+	check := func(err error) {
+		if err != nil && checkErr == nil {
+			checkErr = err
+		}
+	}
+
+	var out bytes.Buffer
+	c := compareCmd(check)
+	c.SetOut(&out)
+	c.SetArgs(args)
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return out.String(), checkErr
+}
+
+func Test_compareCmd(t *testing.T) {
+	expected := testCompareDigest1 + "  artifact1\n" + testCompareDigest2 + "  artifact2\n"
+
+	testCases := []struct {
+		name     string
+		actual   string
+		expected []subjectDiffEntry
+		lines    []string
+		exitCode int
+	}{
+		{
+			name:     "identical",
+			actual:   testCompareDigest2 + "  artifact2\n" + testCompareDigest1 + "  artifact1\n",
+			expected: []subjectDiffEntry{},
+			lines:    []string{"The subjects are identical."},
+		},
+		{
+			name:   "added",
+			actual: expected + testCompareDigest1 + "  artifact3\n",
+			expected: []subjectDiffEntry{
+				{Status: "added", Name: "artifact3", Actual: slsacommon.DigestSet{"sha256": testCompareDigest1}},
+			},
+			lines: []string{
+				"STATUS  NAME       EXPECTED  ACTUAL",
+				"added   artifact3  -         sha256:" + testCompareDigest1,
+			},
+			exitCode: errors.ExitCodeFailure,
+		},
+		{
+			name:   "removed",
+			actual: testCompareDigest1 + "  artifact1\n",
+			expected: []subjectDiffEntry{
+				{Status: "removed", Name: "artifact2", Expected: slsacommon.DigestSet{"sha256": testCompareDigest2}},
+			},
+			lines: []string{
+				"STATUS   NAME       EXPECTED                                                                 ACTUAL",
+				"removed  artifact2  sha256:" + testCompareDigest2 + "  -",
+			},
+			exitCode: errors.ExitCodeFailure,
+		},
+		{
+			name:   "changed",
+			actual: testCompareDigest1 + "  artifact1\n" + testCompareDigest1 + "  artifact2\n",
+			expected: []subjectDiffEntry{
+				{
+					Status:   "changed",
+					Name:     "artifact2",
+					Expected: slsacommon.DigestSet{"sha256": testCompareDigest2},
+					Actual:   slsacommon.DigestSet{"sha256": testCompareDigest1},
+				},
+			},
+			lines: []string{
+				"changed  artifact2  sha256:" + testCompareDigest2 + "  sha256:" + testCompareDigest1,
+			},
+			exitCode: errors.ExitCodeFailure,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			args := []string{
+				"--expected-subjects", base64.StdEncoding.EncodeToString([]byte(expected)),
+				"--actual-subjects", base64.StdEncoding.EncodeToString([]byte(tc.actual)),
+			}
+
+			out, err := runCompareCmd(t, args...)
+			if tc.exitCode == 0 && err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if tc.exitCode != 0 && !errors.As(err, new(*errSubjectsDiffer)) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := errors.ExitCode(err); err != nil && got != tc.exitCode {
+				t.Errorf("unexpected exit code, want: %d, got: %d", tc.exitCode, got)
+			}
+			lines := strings.Split(out, "\n")
+			for _, want := range tc.lines {
+				if !containsLine(lines, want) {
+					t.Errorf("unexpected output, want line: %q, got:\n%s", want, out)
+				}
+			}
+
+			out, _ = runCompareCmd(t, append(args, "--format", "json")...)
+			var got []subjectDiffEntry
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected entries (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_compareCmd_errors(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString([]byte(testCompareDigest1 + "  artifact1\n"))
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "invalid expected subjects",
+			args: []string{"--expected-subjects", "not base64!", "--actual-subjects", valid},
+		},
+		{
+			name: "invalid actual digest",
+			args: []string{
+				"--expected-subjects", valid,
+				"--actual-subjects", base64.StdEncoding.EncodeToString([]byte("abcdef  artifact1\n")),
+			},
+		},
+		{
+			name: "unknown format",
+			args: []string{"--expected-subjects", valid, "--actual-subjects", valid, "--format", "yaml"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runCompareCmd(t, tc.args...)
+			if want, got := errors.ExitCodeInput, errors.ExitCode(err); err == nil || want != got {
+				t.Errorf("unexpected exit code, want: %d, got: %d (%v)", want, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// configVersion is the supported version of the attest config file.
+const configVersion = 1
+
+// errConfig indicates an invalid attest config file.
+type errConfig struct {
+	errors.ErrInput
+}
+
+// configField is a field of the attest config file and the flag it sets.
+type configField struct {
+	name string
+	flag string
+	list bool
+	// repeated fields are either a scalar or a list, for flags that may be
+	// given more than once.
+	repeated bool
+}
+
+// configSection is a section of the attest config file.
+type configSection struct {
+	name   string
+	fields []configField
+}
+
+// configSections is the schema of the attest config file, e.g.
+//
+//	version: 1
+//	subjects:
+//	  artifacts: [dist/foo, dist/bar]
+//	signing:
+//	  signer: gcpkms
+var configSections = []configSection{
+	{
+		name: "subjects",
+		fields: []configField{
+			{name: "base64", flag: "subjects", repeated: true},
+			{name: "purl", flag: "subjects-purl"},
+			{name: "remote", flag: "remote-subject", repeated: true},
+			{name: "use-uri", flag: "subject-use-uri"},
+			{name: "remote-max-size", flag: "remote-subject-max-size"},
+			{name: "remote-timeout", flag: "remote-subject-timeout"},
+			{name: "file", flag: "subjects-filename", repeated: true},
+			{name: "github-artifact-dir", flag: "github-artifact-dir"},
+			{name: "artifacts", flag: "artifacts", list: true},
+			{name: "github-release", flag: "subjects-from-github-release"},
+			{name: "matrix-output", flag: "subjects-from-matrix-output"},
+			{name: "matrix-output-key", flag: "matrix-output-key"},
+			{name: "buildx-metadata-file", flag: "buildx-metadata-file"},
+			{name: "download-artifact", flag: "download-artifact"},
+			{name: "download-artifact-dir", flag: "download-artifact-dir"},
+			{name: "strip-prefix", flag: "subjects-strip-prefix"},
+			{name: "prefix", flag: "subject-prefix"},
+			{name: "strip-extensions", flag: "strip-subject-extensions", list: true},
+			{name: "sort", flag: "sort-subjects"},
+			{name: "normalize-names", flag: "normalize-subject-names"},
+			{name: "basename", flag: "subject-basename"},
+			{name: "case-insensitive-names", flag: "case-insensitive-names"},
+			{name: "hash-workers", flag: "subjects-hash-workers"},
+			{name: "checksum-algorithm", flag: "checksum-algorithm"},
+			{name: "artifact-size-warning", flag: "artifact-size-warning"},
+			{name: "min-count", flag: "min-subject-count"},
+			{name: "max-count", flag: "max-subject-count"},
+			{name: "debug", flag: "debug"},
+		},
+	},
+	{
+		name: "output",
+		fields: []configField{
+			{name: "signature", flag: "signature"},
+			{name: "provenance-name-template", flag: "provenance-name-template"},
+			{name: "workspace", flag: "workspace"},
+			{name: "strict-naming", flag: "strict-naming"},
+			{name: "sanitize-name", flag: "sanitize-name"},
+			{name: "allow-attestation-subjects", flag: "allow-attestation-subjects"},
+			{name: "no-step-summary", flag: "no-step-summary"},
+			{name: "emit-attestation-path", flag: "emit-attestation-to-github-env"},
+		},
+	},
+	{
+		name: "provenance",
+		fields: []configField{
+			{name: "statement-version", flag: "statement-version"},
+			{name: "predicate-file", flag: "predicate-file"},
+			{name: "predicate-type", flag: "predicate-type"},
+			{name: "build-invocation-id", flag: "build-invocation-id"},
+			{name: "workflow-inputs", flag: "workflow-inputs"},
+			{name: "image-manifest", flag: "image-manifest"},
+			{name: "git-commit-of-sources", flag: "git-commit-of-sources"},
+			{name: "policy", flag: "policy"},
+			{name: "redact-github-context", flag: "redact-github-context"},
+			{name: "redact-patterns", flag: "redact-pattern", list: true},
+			{name: "forbid-unsafe-events", flag: "forbid-unsafe-events"},
+			{name: "github-actor-allow-list", flag: "github-actor-allow-list", list: true},
+			{name: "lint-warnings", flag: "lint-warnings"},
+			{name: "lint-errors", flag: "lint-errors"},
+		},
+	},
+	{
+		name: "signing",
+		fields: []configField{
+			{name: "signer", flag: "signer"},
+			{name: "additional-signers", flag: "additional-signer", repeated: true},
+			{name: "fulcio-url", flag: "fulcio-url"},
+			{name: "kms-key-resource", flag: "kms-key-resource"},
+			{name: "signing-key", flag: "signing-key"},
+			{name: "oidc-audience", flag: "oidc-audience"},
+			{name: "oidc-token-timeout", flag: "oidc-token-timeout"},
+			{name: "insecure-skip-signing", flag: "insecure-skip-signing"},
+			{name: "expected-source", flag: "expected-source"},
+			{name: "expected-workflow", flag: "expected-workflow"},
+		},
+	},
+	{
+		name: "upload",
+		fields: []configField{
+			{name: "release", flag: "upload-to-release"},
+			{name: "overwrite", flag: "overwrite"},
+			{name: "registry", flag: "push-to-registry"},
+			{name: "transparency-log", flag: "transparency-log"},
+			{name: "log-file", flag: "log-file"},
+			{name: "no-transparency-log", flag: "no-transparency-log"},
+			{name: "rekor-cert-chain", flag: "rekor-cert-chain"},
+			{name: "verify-rekor-inclusion", flag: "verify-rekor-inclusion"},
+			{name: "extra-rekor-urls", flag: "extra-rekor-url", repeated: true},
+			{name: "require-all-rekor", flag: "require-all-rekor"},
+			{name: "rekor-retry-count", flag: "rekor-retry-count"},
+			{name: "rekor-retry-base-delay", flag: "rekor-retry-base-delay"},
+			{name: "timeout", flag: "timeout"},
+			{name: "expect-rekor-index", flag: "expect-rekor-index"},
+			{name: "strict-rekor-index", flag: "strict-rekor-index"},
+		},
+	},
+}
+
+// configValue is a value set in the attest config file.
+type configValue struct {
+	field  configField
+	path   string
+	values []string
+	line   int
+}
+
+// attestConfig is a parsed attest config file.
+type attestConfig struct {
+	file   string
+	values []configValue
+}
+
+// readConfig reads the attest config file at path.
+func readConfig(path string) (*attestConfig, error) {
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "reading config: %w", err)
+	}
+	return parseConfig(path, b)
+}
+
+// parseConfig parses an attest config file. Errors name the offending field
+// and its line in file.
+func parseConfig(file string, b []byte) (*attestConfig, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Errorf(&errConfig{}, "%s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.Errorf(&errConfig{}, "%s: empty config", file)
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.Errorf(&errConfig{}, "%s:%d: expected a mapping", file, root.Line)
+	}
+
+	cfg := &attestConfig{file: file}
+	hasVersion := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == "version" {
+			v, err := strconv.Atoi(value.Value)
+			if value.Kind != yaml.ScalarNode || err != nil || v != configVersion {
+				return nil, errors.Errorf(&errConfig{}, "%s:%d: unsupported version %q", file, value.Line, value.Value)
+			}
+			hasVersion = true
+			continue
+		}
+
+		section := findConfigSection(key.Value)
+		if section == nil {
+			return nil, errors.Errorf(&errConfig{}, "%s:%d: unknown field %q", file, key.Line, key.Value)
+		}
+		values, err := parseConfigSection(file, section, value)
+		if err != nil {
+			return nil, err
+		}
+		cfg.values = append(cfg.values, values...)
+	}
+	if !hasVersion {
+		return nil, errors.Errorf(&errConfig{}, "%s: missing field \"version\"", file)
+	}
+	return cfg, nil
+}
+
+// parseConfigSection parses the fields of a section of the config file.
+func parseConfigSection(file string, section *configSection, n *yaml.Node) ([]configValue, error) {
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return nil, nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil, errors.Errorf(&errConfig{}, "%s:%d: field %q must be a mapping", file, n.Line, section.name)
+	}
+
+	var values []configValue
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		path := section.name + "." + key.Value
+		field := section.findField(key.Value)
+		if field == nil {
+			return nil, errors.Errorf(&errConfig{}, "%s:%d: unknown field %q", file, key.Line, path)
+		}
+
+		v := configValue{field: *field, path: path, line: value.Line}
+		switch {
+		case (field.list || field.repeated) && value.Kind == yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, errors.Errorf(&errConfig{}, "%s:%d: field %q must be a list of strings", file, item.Line, path)
+				}
+				v.values = append(v.values, item.Value)
+			}
+		case field.list:
+			return nil, errors.Errorf(&errConfig{}, "%s:%d: field %q must be a list", file, value.Line, path)
+		case value.Kind == yaml.ScalarNode:
+			v.values = []string{value.Value}
+		default:
+			return nil, errors.Errorf(&errConfig{}, "%s:%d: field %q must be a scalar", file, value.Line, path)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func findConfigSection(name string) *configSection {
+	for i := range configSections {
+		if configSections[i].name == name {
+			return &configSections[i]
+		}
+	}
+	return nil
+}
+
+func (s *configSection) findField(name string) *configField {
+	for i := range s.fields {
+		if s.fields[i].name == name {
+			return &s.fields[i]
+		}
+	}
+	return nil
+}
+
+// apply sets the flags for the values in the config file. Flags that were
+// set on the command line override the config file.
+func (c *attestConfig) apply(fs *pflag.FlagSet) error {
+	for _, v := range c.values {
+		f := fs.Lookup(v.field.flag)
+		if f == nil {
+			return fmt.Errorf("no flag %q for config field %q", v.field.flag, v.path)
+		}
+		if f.Changed {
+			continue
+		}
+		for _, s := range v.values {
+			if err := fs.Set(v.field.flag, s); err != nil {
+				return errors.Errorf(&errConfig{}, "%s:%d: invalid value %q for field %q: %w", c.file, v.line, s, v.path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyConfigFile reads the config file at path, if any, and applies it to
+// the flags.
+func applyConfigFile(fs *pflag.FlagSet, path string) error {
+	if path == "" {
+		return nil
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	return cfg.apply(fs)
+}
+
+// writeEffectiveConfig writes the config file equivalent to the flags that
+// were set, either on the command line or by a config file.
+func writeEffectiveConfig(w io.Writer, fs *pflag.FlagSet) error {
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content, scalar("!!str", "version"), scalar("!!int", strconv.Itoa(configVersion)))
+	for _, section := range configSections {
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, field := range section.fields {
+			f := fs.Lookup(field.flag)
+			if f == nil {
+				return fmt.Errorf("no flag %q for config field %q", field.flag, section.name+"."+field.name)
+			}
+			if !f.Changed {
+				continue
+			}
+
+			var value *yaml.Node
+			switch f.Value.Type() {
+			case "stringSlice", "stringArray":
+				value = &yaml.Node{Kind: yaml.SequenceNode}
+				items, _ := f.Value.(pflag.SliceValue)
+				for _, item := range items.GetSlice() {
+					value.Content = append(value.Content, scalar("!!str", item))
+				}
+			case "bool":
+				value = scalar("!!bool", f.Value.String())
+			case "int", "int64":
+				value = scalar("!!int", f.Value.String())
+			default:
+				value = scalar("!!str", f.Value.String())
+			}
+			n.Content = append(n.Content, scalar("!!str", field.name), value)
+		}
+		if len(n.Content) > 0 {
+			root.Content = append(root.Content, scalar("!!str", section.name), n)
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// configCmd returns the 'config' command.
+func configCmd(check func(error)) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "Manage attest config files",
+	}
+	c.AddCommand(configValidateCmd(check))
+	return c
+}
+
+// configValidateCmd returns the 'config validate' command.
+func configValidateCmd(check func(error)) *cobra.Command {
+	var configPath string
+
+	// The command accepts the same flags as 'attest' so that the effective
+	// configuration can be checked.
+	attest := attestCmd(nil, check, nil, nil, nil)
+
+	c := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate an attest config file",
+		Long: `Validate an attest config file and print the effective configuration
+after merging it with the given 'attest' flags. Flags override the values in
+the config file.`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			check(applyConfigFile(cmd.Flags(), configPath))
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			check(writeEffectiveConfig(cmd.OutOrStdout(), cmd.Flags()))
+		},
+	}
+	attest.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name != "config" {
+			c.Flags().AddFlag(f)
+		}
+	})
+	c.Flags().StringVar(&configPath, "config", "", "Path to the attest config file.")
+	check(c.MarkFlagRequired("config"))
+	return c
+}
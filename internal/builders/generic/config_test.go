@@ -0,0 +1,290 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+func Test_parseConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected map[string][]string
+		err      string
+	}{
+		{
+			name: "valid",
+			config: `version: 1
+subjects:
+  artifacts:
+    - dist/foo
+    - dist/bar
+output:
+  signature: foo.intoto.jsonl
+signing:
+  signer: gcpkms
+upload:
+  rekor-retry-count: 5
+`,
+			expected: map[string][]string{
+				"artifacts":         {"dist/foo", "dist/bar"},
+				"signature":         {"foo.intoto.jsonl"},
+				"signer":            {"gcpkms"},
+				"rekor-retry-count": {"5"},
+			},
+		},
+		{
+			name:   "repeated field",
+			config: "version: 1\nsubjects:\n  base64: [Zm9v, YmFy]\n",
+			expected: map[string][]string{
+				"subjects": {"Zm9v", "YmFy"},
+			},
+		},
+		{
+			name:     "empty section",
+			config:   "version: 1\nsigning:\n",
+			expected: map[string][]string{},
+		},
+		{
+			name:   "missing version",
+			config: "signing:\n  signer: gcpkms\n",
+			err:    `config.yml: missing field "version"`,
+		},
+		{
+			name:   "unsupported version",
+			config: "version: 2\n",
+			err:    `config.yml:1: unsupported version "2"`,
+		},
+		{
+			name:   "unknown section",
+			config: "version: 1\nsign:\n  signer: gcpkms\n",
+			err:    `config.yml:2: unknown field "sign"`,
+		},
+		{
+			name:   "unknown field",
+			config: "version: 1\nsigning:\n  signer: gcpkms\n  key: foo\n",
+			err:    `config.yml:4: unknown field "signing.key"`,
+		},
+		{
+			name:   "section not a mapping",
+			config: "version: 1\nsigning: gcpkms\n",
+			err:    `config.yml:2: field "signing" must be a mapping`,
+		},
+		{
+			name:   "list for scalar",
+			config: "version: 1\nsigning:\n  signer: [gcpkms]\n",
+			err:    `config.yml:3: field "signing.signer" must be a scalar`,
+		},
+		{
+			name:   "scalar for list",
+			config: "version: 1\nsubjects:\n  artifacts: dist/foo\n",
+			err:    `config.yml:3: field "subjects.artifacts" must be a list`,
+		},
+		{
+			name:   "invalid yaml",
+			config: "version: 1\n  signing: [\n",
+			err:    "config.yml: yaml:",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parseConfig("config.yml", []byte(tc.config))
+			if tc.err != "" {
+				if !errors.As(err, new(*errConfig)) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.HasPrefix(err.Error(), tc.err) {
+					t.Errorf("unexpected error, want: %q, got: %q", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			got := map[string][]string{}
+			for _, v := range cfg.values {
+				got[v.field.flag] = v.values
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_attestConfig_apply(t *testing.T) {
+	cfg, err := parseConfig("config.yml", []byte(`version: 1
+signing:
+  signer: gcpkms
+  kms-key-resource: projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1
+upload:
+  rekor-retry-count: not a number
+`))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), nil, nil, nil)
+	if err := c.ParseFlags([]string{"--signer", "file"}); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	err = cfg.apply(c.Flags())
+	if !errors.As(err, new(*errConfig)) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `config.yml:6: invalid value "not a number" for field "upload.rekor-retry-count"`; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("unexpected error, want: %q, got: %q", want, err)
+	}
+
+	// Flags override the config file.
+	if want, got := "file", c.Flags().Lookup("signer").Value.String(); want != got {
+		t.Errorf("unexpected signer, want: %q, got: %q", want, got)
+	}
+	if want, got := "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1",
+		c.Flags().Lookup("kms-key-resource").Value.String(); want != got {
+		t.Errorf("unexpected kms-key-resource, want: %q, got: %q", want, got)
+	}
+}
+
+// Test_attestCmd_config tests that attest reads its options from --config.
+func Test_attestCmd_config(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	dir := chdirTemp(t)
+	config := "version: 1\n" +
+		"subjects:\n" +
+		"  base64: " + base64.StdEncoding.EncodeToString([]byte(testHash)) + "\n" +
+		"output:\n" +
+		"  signature: from-config.intoto.jsonl\n"
+	if err := os.WriteFile("config.yml", []byte(config), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "config",
+			args:     []string{"--config", "config.yml"},
+			expected: "from-config.intoto.jsonl",
+		},
+		{
+			name:     "flag overrides config",
+			args:     []string{"--config", "config.yml", "--signature", "from-flag.intoto.jsonl"},
+			expected: "from-flag.intoto.jsonl",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, tc.expected)); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+		})
+	}
+}
+
+// Test_configValidateCmd tests that 'config validate' prints the merged
+// configuration.
+func Test_configValidateCmd(t *testing.T) {
+	chdirTemp(t)
+	config := `version: 1
+subjects:
+  artifacts: [dist/foo]
+signing:
+  signer: gcpkms
+`
+	if err := os.WriteFile("config.yml", []byte(config), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var out bytes.Buffer
+	c := configCmd(checkTest(t))
+	c.SetOut(&out)
+	c.SetArgs([]string{"validate", "--config", "config.yml", "--rekor-retry-count", "5"})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var got struct {
+		Version  int `yaml:"version"`
+		Subjects struct {
+			Artifacts []string `yaml:"artifacts"`
+			Base64    *string  `yaml:"base64"`
+		} `yaml:"subjects"`
+		Signing struct {
+			Signer string `yaml:"signer"`
+		} `yaml:"signing"`
+		Upload struct {
+			RekorRetryCount int `yaml:"rekor-retry-count"`
+		} `yaml:"upload"`
+	}
+	if err := yaml.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want := configVersion; got.Version != want {
+		t.Errorf("unexpected version, want: %d, got: %d", want, got.Version)
+	}
+	if diff := cmp.Diff([]string{"dist/foo"}, got.Subjects.Artifacts); diff != "" {
+		t.Errorf("unexpected artifacts (-want +got):\n%s", diff)
+	}
+	if got.Subjects.Base64 != nil {
+		t.Errorf("unexpected base64, want: unset, got: %q", *got.Subjects.Base64)
+	}
+	if want := "gcpkms"; got.Signing.Signer != want {
+		t.Errorf("unexpected signer, want: %q, got: %q", want, got.Signing.Signer)
+	}
+	if want := 5; got.Upload.RekorRetryCount != want {
+		t.Errorf("unexpected rekor-retry-count, want: %d, got: %d", want, got.Upload.RekorRetryCount)
+	}
+
+	// The effective configuration is itself a valid config file.
+	if err := os.WriteFile("out.yml", out.Bytes(), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	c = configCmd(checkTest(t))
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{"validate", "--config", "out.yml"})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+}
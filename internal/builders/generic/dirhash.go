@@ -0,0 +1,148 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// dirHashAlgorithm is the digest algorithm key of directory digests computed
+// by dirHash1.
+const dirHashAlgorithm = "dirHash1"
+
+// errSymlink indicates a symbolic link in an artifact.
+type errSymlink struct {
+	errors.ErrInput
+}
+
+// dirHash1 returns the hex encoded dirHash1 digest of the directory tree
+// rooted at dir. The digest is computed as follows, similarly to the "h1" hash
+// of golang.org/x/mod/sumdb/dirhash:
+//
+//  1. List every regular file in the tree. The name of a file is its path
+//     relative to dir, with "/" as the separator.
+//  2. Sort the names in increasing byte order.
+//  3. For each file, append the line "<sha256>  <name>\n" to a summary, where
+//     <sha256> is the lowercase hex encoded SHA-256 digest of the file
+//     contents, followed by two spaces (the format of sha256sum).
+//  4. The digest is the lowercase hex encoded SHA-256 digest of the summary.
+//
+// Directories only contribute through the files they contain, so empty
+// directories are ignored. Symbolic links and other non-regular files are
+// rejected, as are names containing a newline.
+func dirHash1(dir string) (string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return errors.Errorf(&errSymlink{}, "symbolic link %q is not allowed", path)
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%q is not a regular file", path)
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if strings.Contains(name, "\n") {
+			return fmt.Errorf("file name %q contains a newline", name)
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(names)
+
+	summary := sha256.New()
+	for _, name := range names {
+		digest, err := fileSHA256(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(summary, "%s  %s\n", digest, name)
+	}
+	return hex.EncodeToString(summary.Sum(nil)), nil
+}
+
+// subjectsFromPaths returns a subject for each of the given paths. A regular
+// file is recorded with its digest with the checksum algorithm fileAlg and a
+// directory with its dirHash1 digest. Subject names are the slash separated
+// cleaned paths.
+func subjectsFromPaths(paths []string, fileAlg string) ([]intoto.Subject, error) {
+	if err := checkChecksumAlgorithm(fileAlg); err != nil {
+		return nil, err
+	}
+
+	var subjects []intoto.Subject
+	for _, p := range paths {
+		// NOTE: The paths are untrusted and should be validated.
+		if err := utils.PathIsUnderCurrentDirectory(p); err != nil {
+			return nil, err
+		}
+
+		info, err := os.Lstat(p)
+		if err != nil {
+			return nil, errors.Errorf(&errArtifactDir{}, "reading artifact %q: %w", p, err)
+		}
+
+		var alg, digest string
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			return nil, errors.Errorf(&errSymlink{}, "symbolic link %q is not allowed", p)
+		case info.IsDir():
+			alg = dirHashAlgorithm
+			digest, err = dirHash1(p)
+		case info.Mode().IsRegular():
+			alg = fileAlg
+			digest, err = fileDigest(p, alg)
+		default:
+			return nil, errors.Errorf(&errArtifactDir{}, "%q is not a regular file or directory", p)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hashing artifact %q: %w", p, err)
+		}
+
+		subjects = append(subjects, intoto.Subject{
+			Name: filepath.ToSlash(filepath.Clean(p)),
+			Digest: slsacommon.DigestSet{
+				alg: digest,
+			},
+		})
+	}
+	return subjects, nil
+}
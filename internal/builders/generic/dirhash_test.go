@@ -0,0 +1,144 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// testDirHash is the dirHash1 digest of testdata/dirhash/plugin. It locks the
+// hashing scheme and can be reproduced with:
+//
+//	cd testdata/dirhash/plugin && find . -type f | sed 's|^\./||' | LC_ALL=C sort |
+//	  while read f; do printf '%s  %s\n' "$(sha256sum "$f" | cut -d' ' -f1)" "$f"; done |
+//	  sha256sum
+const testDirHash = "10aaf3dde94abbf7a2c7f1333ae74915929ff99d8bcde16bbb632905646eba68"
+
+// copyDir copies the regular files of the directory tree rooted at src to dst.
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), b, 0o600)
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+}
+
+func Test_dirHash1(t *testing.T) {
+	t.Run("golden", func(t *testing.T) {
+		got, err := dirHash1("testdata/dirhash/plugin")
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if want := testDirHash; want != got {
+			t.Errorf("unexpected digest, want: %q, got: %q", want, got)
+		}
+	})
+
+	t.Run("empty directories are ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		copyDir(t, "testdata/dirhash/plugin", dir)
+		if err := os.MkdirAll(filepath.Join(dir, "empty", "nested"), 0o755); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+
+		got, err := dirHash1(dir)
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if want := testDirHash; want != got {
+			t.Errorf("unexpected digest, want: %q, got: %q", want, got)
+		}
+	})
+
+	t.Run("renamed file", func(t *testing.T) {
+		dir := t.TempDir()
+		copyDir(t, "testdata/dirhash/plugin", dir)
+		if err := os.Rename(filepath.Join(dir, "lib", "b.txt"), filepath.Join(dir, "lib", "c.txt")); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+
+		got, err := dirHash1(dir)
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if testDirHash == got {
+			t.Errorf("unexpected digest, got: %q", got)
+		}
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		dir := t.TempDir()
+		copyDir(t, "testdata/dirhash/plugin", dir)
+		if err := os.Symlink("plugin.yaml", filepath.Join(dir, "link.yaml")); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+
+		_, err := dirHash1(dir)
+		errLink := &errSymlink{}
+		if !errors.As(err, &errLink) {
+			t.Errorf("expected %v but got %v", &errSymlink{}, err)
+		}
+	})
+}
+
+func Test_subjectsFromPaths(t *testing.T) {
+	got, err := subjectsFromPaths([]string{"testdata/dirhash/plugin", "./testdata/dirhash/plugin/lib/a.so"}, "sha256")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := []intoto.Subject{
+		{
+			Name: "testdata/dirhash/plugin",
+			Digest: slsacommon.DigestSet{
+				dirHashAlgorithm: testDirHash,
+			},
+		},
+		{
+			Name: "testdata/dirhash/plugin/lib/a.so",
+			Digest: slsacommon.DigestSet{
+				// echo "foo" | sha256sum
+				"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+	"github.com/slsa-framework/slsa-github-generator/signing/sigstore"
+)
+
+// subjectDigestRe matches the --subject-digest of the 'fetch' command.
+var subjectDigestRe = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// errFetchDigest indicates an invalid --subject-digest.
+type errFetchDigest struct {
+	errors.ErrInput
+}
+
+// errNoMatchingEntry indicates that the transparency log has no in-toto
+// entry for the subject.
+type errNoMatchingEntry struct {
+	errors.ErrTransparencyLog
+}
+
+// errEntryEnvelope indicates a log entry from which the signed envelope
+// can't be rebuilt.
+type errEntryEnvelope struct {
+	errors.ErrTransparencyLog
+}
+
+// entrySearcher searches a transparency log for entries by digest.
+type entrySearcher interface {
+	SearchByDigest(ctx context.Context, digest string) ([]sigstore.SearchEntry, error)
+}
+
+// rekorIntotoBody is the body of an in-toto log entry. Only version 0.0.2
+// records the envelope signatures.
+type rekorIntotoBody struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Spec       struct {
+		Content struct {
+			Envelope *struct {
+				PayloadType string `json:"payloadType"`
+				Signatures  []struct {
+					Sig       []byte `json:"sig"`
+					PublicKey []byte `json:"publicKey"`
+				} `json:"signatures"`
+			} `json:"envelope"`
+		} `json:"content"`
+	} `json:"spec"`
+}
+
+// hasSubject returns whether the statement has a subject with the name and
+// sha256 digest.
+func hasSubject(statement []byte, name, digest string) bool {
+	var s intoto.StatementHeader
+	if err := json.Unmarshal(statement, &s); err != nil {
+		return false
+	}
+	for _, subject := range s.Subject {
+		if subject.Name == name && subject.Digest["sha256"] == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// entryEnvelope returns the JSON encoded signed envelope of the in-toto log
+// entry, or nil if the entry isn't an in-toto entry for the subject.
+func entryEnvelope(e sigstore.SearchEntry, name, digest string) ([]byte, error) {
+	encoded, ok := e.Entry.Body.(string)
+	if !ok || e.Entry.Attestation == nil {
+		return nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Errorf(&errEntryEnvelope{}, "decoding log entry %s: %w", e.UUID, err)
+	}
+	var body rekorIntotoBody
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, errors.Errorf(&errEntryEnvelope{}, "decoding log entry %s: %w", e.UUID, err)
+	}
+	statement := []byte(e.Entry.Attestation.Data)
+	if body.Kind != "intoto" || !hasSubject(statement, name, digest) {
+		return nil, nil
+	}
+
+	env := body.Spec.Content.Envelope
+	if env == nil || len(env.Signatures) == 0 {
+		return nil, errors.Errorf(&errEntryEnvelope{},
+			"log entry %s (intoto %s) does not record the envelope signatures", e.UUID, body.APIVersion)
+	}
+	out := envelope.Envelope{
+		PayloadType: env.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(statement),
+	}
+	for _, sig := range env.Signatures {
+		out.Signatures = append(out.Signatures, envelope.Signature{
+			Sig:  string(sig.Sig),
+			Cert: string(sig.PublicKey),
+		})
+	}
+	return json.Marshal(out)
+}
+
+// fetchEnvelope returns the signed envelope of the first in-toto log entry,
+// by log index, with a subject of the name and digest.
+func fetchEnvelope(ctx context.Context, s entrySearcher, name, digest string) ([]byte, string, error) {
+	if !subjectDigestRe.MatchString(digest) {
+		return nil, "", errors.Errorf(&errFetchDigest{}, "invalid subject digest %q, expected sha256:<hex>", digest)
+	}
+
+	entries, err := s.SearchByDigest(ctx, digest)
+	if err != nil {
+		return nil, "", err
+	}
+	hexDigest := strings.TrimPrefix(digest, "sha256:")
+	for _, e := range entries {
+		b, err := entryEnvelope(e, name, hexDigest)
+		if err != nil {
+			return nil, "", err
+		}
+		if b != nil {
+			return b, e.UUID, nil
+		}
+	}
+	return nil, "", errors.Errorf(&errNoMatchingEntry{}, "no in-toto log entry for subject %q with digest %s",
+		name, digest)
+}
+
+// fetchCmd returns the 'fetch' command.
+func fetchCmd(check func(error), s entrySearcher) *cobra.Command {
+	var subjectName string
+	var subjectDigest string
+	var outputPath string
+
+	c := &cobra.Command{
+		Use:   "fetch",
+		Short: "Download the provenance of a subject from the transparency log",
+		Long: `Search the transparency log for the in-toto entries of a subject and write the
+signed provenance of the first entry as a .intoto.jsonl file. The provenance is
+not verified; use slsa-verifier to verify it.`,
+		Args: cobra.NoArgs,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			b, uuid, err := fetchEnvelope(cmd.Context(), s, subjectName, subjectDigest)
+			check(err)
+
+			if outputPath == "" {
+				outputPath = path.Base(subjectName) + ".intoto.jsonl"
+			}
+			// NOTE: The path is untrusted and is validated by
+			// CreateNewFileUnderCurrentDirectory.
+			f, err := utils.CreateNewFileUnderCurrentDirectory(outputPath, os.O_WRONLY)
+			check(err)
+
+			if _, err := f.Write(append(b, '\n')); err != nil {
+				check(errors.Errorf(&errors.ErrFilesystem{}, "writing provenance: %w", err))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Fetched log entry %s to %q.\n", uuid, outputPath)
+		},
+	}
+
+	c.Flags().StringVar(
+		&subjectName, "subject-name", "",
+		"The name of the subject.",
+	)
+	c.Flags().StringVar(
+		&subjectDigest, "subject-digest", "",
+		"The digest of the subject as sha256:<hex>.",
+	)
+	c.Flags().StringVarP(
+		&outputPath, "output", "o", "",
+		"Path to write the provenance to. Defaults to <subject name>.intoto.jsonl.",
+	)
+	check(c.MarkFlagRequired("subject-name"))
+	check(c.MarkFlagRequired("subject-digest"))
+	return c
+}
@@ -0,0 +1,284 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+	"github.com/slsa-framework/slsa-github-generator/signing/sigstore"
+)
+
+const testFetchDigest = "5b3513f580c8397212ff2c8f459c199efc0c90e4354a5f3533adf0a3fff3a530"
+
+// testLogEntry is a log entry served by the fake Rekor server.
+type testLogEntry struct {
+	uuid      string
+	logIndex  int64
+	body      interface{}
+	statement string
+}
+
+// testStatement returns an in-toto statement with a subject of the name and
+// digest.
+func testStatement(name, digest string) string {
+	return `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2",` +
+		`"subject":[{"name":"` + name + `","digest":{"sha256":"` + digest + `"}}],"predicate":{}}`
+}
+
+// testIntotoBody returns the body of an in-toto v0.0.2 log entry with the
+// signature.
+func testIntotoBody(sig string) map[string]interface{} {
+	return map[string]interface{}{
+		"kind":       "intoto",
+		"apiVersion": "0.0.2",
+		"spec": map[string]interface{}{
+			"content": map[string]interface{}{
+				"envelope": map[string]interface{}{
+					"payloadType": "application/vnd.in-toto+json",
+					"signatures": []map[string]interface{}{{
+						"sig":       base64.StdEncoding.EncodeToString([]byte(sig)),
+						"publicKey": base64.StdEncoding.EncodeToString([]byte("CERT")),
+					}},
+				},
+			},
+		},
+	}
+}
+
+// newFakeRekorSearchServer returns a Rekor instance for a fake Rekor server
+// that returns the entries for any searched digest.
+func newFakeRekorSearchServer(t *testing.T, logEntries []testLogEntry) *sigstore.Rekor {
+	t.Helper()
+
+	byUUID := map[string]map[string]interface{}{}
+	uuids := []string{}
+	for _, e := range logEntries {
+		body, err := json.Marshal(e.body)
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		byUUID[e.uuid] = map[string]interface{}{
+			"body":           base64.StdEncoding.EncodeToString(body),
+			"integratedTime": 1672531200,
+			"logID":          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+			"logIndex":       e.logIndex,
+			"attestation": map[string]interface{}{
+				"data": base64.StdEncoding.EncodeToString([]byte(e.statement)),
+			},
+		}
+		uuids = append(uuids, e.uuid)
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/index/retrieve":
+			var q struct {
+				Hash string `json:"hash"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&q); err != nil || q.Hash != "sha256:"+testFetchDigest {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(uuids)
+		case "/api/v1/log/entries/retrieve":
+			var q struct {
+				EntryUUIDs []string `json:"entryUUIDs"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp := []map[string]interface{}{}
+			for _, uuid := range q.EntryUUIDs {
+				resp = append(resp, map[string]interface{}{uuid: byUUID[uuid]})
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return sigstore.NewRekor(s.URL)
+}
+
+func Test_fetchCmd(t *testing.T) {
+	statement := testStatement("artifact1", testFetchDigest)
+	logEntries := []testLogEntry{
+		{
+			uuid:      "later",
+			logIndex:  9,
+			body:      testIntotoBody("later-sig"),
+			statement: statement,
+		},
+		{
+			uuid:     "blob",
+			logIndex: 5,
+			body:     map[string]interface{}{"kind": "hashedrekord", "apiVersion": "0.0.1"},
+		},
+		{
+			uuid:      "other-name",
+			logIndex:  6,
+			body:      testIntotoBody("other-sig"),
+			statement: testStatement("artifact2", testFetchDigest),
+		},
+		{
+			uuid:      "first",
+			logIndex:  7,
+			body:      testIntotoBody("first-sig"),
+			statement: statement,
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		args   []string
+		output string
+	}{
+		{
+			name:   "default output",
+			args:   []string{"--subject-name", "artifact1", "--subject-digest", "sha256:" + testFetchDigest},
+			output: "artifact1.intoto.jsonl",
+		},
+		{
+			name: "output",
+			args: []string{
+				"--subject-name", "artifact1", "--subject-digest", "sha256:" + testFetchDigest,
+				"--output", "provenance.intoto.jsonl",
+			},
+			output: "provenance.intoto.jsonl",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			c := fetchCmd(checkTest(t), newFakeRekorSearchServer(t, logEntries))
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile(tc.output)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if !strings.HasSuffix(string(b), "}\n") {
+				t.Errorf("expected a single line of JSON, got: %q", b)
+			}
+			var got envelope.Envelope
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			want := envelope.Envelope{
+				PayloadType: "application/vnd.in-toto+json",
+				Payload:     base64.StdEncoding.EncodeToString([]byte(statement)),
+				Signatures:  []envelope.Signature{{Sig: "first-sig", Cert: "CERT"}},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected envelope (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_fetchCmd_errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		digest     string
+		logEntries []testLogEntry
+		err        interface{}
+	}{
+		{
+			name:   "no entries",
+			digest: "sha256:" + testFetchDigest,
+			err:    new(*errNoMatchingEntry),
+		},
+		{
+			name:   "no matching entry",
+			digest: "sha256:" + testFetchDigest,
+			logEntries: []testLogEntry{
+				{
+					uuid:      "other-name",
+					logIndex:  6,
+					body:      testIntotoBody("other-sig"),
+					statement: testStatement("artifact2", testFetchDigest),
+				},
+			},
+			err: new(*errNoMatchingEntry),
+		},
+		{
+			name:   "no envelope signatures",
+			digest: "sha256:" + testFetchDigest,
+			logEntries: []testLogEntry{
+				{
+					uuid:     "v001",
+					logIndex: 6,
+					body: map[string]interface{}{
+						"kind":       "intoto",
+						"apiVersion": "0.0.1",
+						"spec":       map[string]interface{}{"content": map[string]interface{}{}},
+					},
+					statement: testStatement("artifact1", testFetchDigest),
+				},
+			},
+			err: new(*errEntryEnvelope),
+		},
+		{
+			name:   "invalid digest",
+			digest: testFetchDigest,
+			err:    new(*errFetchDigest),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					if !errors.As(err, tc.err) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := fetchCmd(check, newFakeRekorSearchServer(t, tc.logEntries))
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{"--subject-name", "artifact1", "--subject-digest", tc.digest})
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Fatalf("expected an error to occur.")
+		})
+	}
+}
@@ -0,0 +1,815 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/httpclient"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/provenance"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// defaultOIDCTokenTimeout is the default maximum time to wait for a GitHub
+// OIDC token.
+const defaultOIDCTokenTimeout = 30 * time.Second
+
+// errStatement indicates an invalid pre-generated provenance statement.
+type errStatement struct {
+	errors.ErrInput
+}
+
+// errStatementDigest indicates that a pre-generated provenance statement does
+// not match its expected digest.
+type errStatementDigest struct {
+	errors.ErrInput
+}
+
+// Statement versions supported by --statement-version.
+const (
+	// statementVersionV01 is an in-toto v0.1 statement with a SLSA v0.2
+	// provenance predicate.
+	statementVersionV01 = "v0.1"
+
+	// statementVersionV1 is an in-toto v1 statement with a SLSA v1.0
+	// provenance predicate.
+	statementVersionV1 = "v1"
+)
+
+// errStatementVersion indicates an unsupported --statement-version.
+type errStatementVersion struct {
+	errors.ErrInput
+}
+
+// errPredicate indicates an invalid --predicate-file or --predicate-type.
+type errPredicate struct {
+	errors.ErrInput
+}
+
+// errRedactPattern indicates an invalid --redact-pattern.
+type errRedactPattern struct {
+	errors.ErrInput
+}
+
+// statementOptions are the options used to generate the provenance
+// statement. They are shared by the 'generate' and 'attest' commands.
+type statementOptions struct {
+	subjects            []string
+	subjectsPURL        string
+	remoteSubjects      []string
+	subjectUseURI       bool
+	remoteMaxSize       int64
+	remoteTimeout       time.Duration
+	subjectsFilename    []string
+	subjectsStripPrefix string
+	subjectPrefix       string
+	stripExtensions     []string
+	sortSubjects        bool
+	normalizeNames      bool
+	subjectBasename     bool
+	caseInsensitive     bool
+	policyPath          string
+	oidcAudience        string
+	oidcTokenTimeout    time.Duration
+	githubTokenFile     string
+	githubToken         string
+	releaseSubjects     string
+	matrixOutput        string
+	matrixOutputKey     string
+	buildxMetadataFile  string
+	redactContext       bool
+	redactPatterns      []string
+	statementVersion    string
+	predicateFile       string
+	predicateType       string
+	artifactDir         string
+	artifactPaths       []string
+	artifactSizeWarning int64
+	hashWorkers         int
+	checksumAlgorithm   string
+	workflowInputs      string
+	imageManifest       string
+	sourceCommit        string
+	buildInvocationID   string
+	debug               bool
+}
+
+// subjectFlags are the flags that select the subjects of the provenance.
+var subjectFlags = []string{
+	"subjects", "subjects-filename", "github-artifact-dir", "artifacts", "subjects-from-github-release",
+	"subjects-from-matrix-output", "buildx-metadata-file",
+}
+
+// flagAliases maps alternative flag names to the name of the flag they set.
+var flagAliases = map[string]string{
+	"subjects-sort": "sort-subjects",
+}
+
+// normalizeFlagName resolves the flag aliases.
+func normalizeFlagName(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	if n, ok := flagAliases[name]; ok {
+		name = n
+	}
+	return pflag.NormalizedName(name)
+}
+
+// addFlags adds the flags for the options to the command.
+func (o *statementOptions) addFlags(c *cobra.Command) {
+	c.Flags().SetNormalizeFunc(normalizeFlagName)
+	c.Flags().StringArrayVarP(
+		&o.subjects, "subjects", "s", nil,
+		"Formatted list of subjects in the same format as sha256sum (base64 encoded). "+
+			"If \"-\", the list is read from stdin without base64 encoding. "+
+			"May be repeated, in which case the lists are concatenated in order.",
+	)
+	c.Flags().StringVar(
+		&o.subjectsPURL, "subjects-purl", "",
+		"Formatted list of subjects named by package URLs, e.g. \"<sha256> pkg:npm/@scope/name@1.0.0\" "+
+			"(base64 encoded). The subjects are added to those selected by the other subject flags and "+
+			"their names are recorded unchanged.",
+	)
+	c.Flags().StringArrayVar(
+		&o.remoteSubjects, "remote-subject", nil,
+		"A subject downloaded from an HTTP(S) URL, as \"<url> <sha256>\". The content is downloaded and must "+
+			"have the given digest. May be repeated. The subjects are added to those selected by the other "+
+			"subject flags and are named by the base name of the URL path.",
+	)
+	c.Flags().BoolVar(
+		&o.subjectUseURI, "subject-use-uri", false,
+		"Name the subjects of --remote-subject by their URL, without query or fragment, instead of its base name.",
+	)
+	c.Flags().Int64Var(
+		&o.remoteMaxSize, "remote-subject-max-size", defaultRemoteSubjectMaxSize,
+		"The maximum size in bytes of the content of a --remote-subject.",
+	)
+	c.Flags().DurationVar(
+		&o.remoteTimeout, "remote-subject-timeout", defaultRemoteSubjectTimeout,
+		"The maximum time to download a --remote-subject. 0 disables the timeout.",
+	)
+	c.Flags().StringArrayVar(
+		&o.subjectsFilename, "subjects-filename", nil,
+		"Path to a file with a list of subjects in the same format as sha256sum. If \"-\", the list is read from stdin. "+
+			"May be repeated, in which case the lists are concatenated in order.",
+	)
+	c.Flags().StringVar(
+		&o.subjectsStripPrefix, "subjects-strip-prefix", "",
+		"Remove this prefix from the name of each subject, e.g. to avoid recording build paths.",
+	)
+	c.Flags().StringVar(
+		&o.subjectPrefix, "subject-prefix", "",
+		"Prepend this prefix to the name of each subject, e.g. \"projectA/\" to namespace the subjects of a "+
+			"sub-project. Applied after the other name transforms. Must not be an absolute path or contain \"..\".",
+	)
+	c.Flags().StringSliceVar(
+		&o.stripExtensions, "strip-subject-extensions", nil,
+		"Comma separated list of extensions, e.g. .tar.gz,.zip, removed from the name of each subject. "+
+			"Only the longest matching extension is removed.",
+	)
+	c.Flags().BoolVar(
+		&o.sortSubjects, "sort-subjects", true,
+		"Sort subjects by name and digest so the provenance is deterministic. Also accepted as --subjects-sort.",
+	)
+	c.Flags().BoolVar(
+		&o.normalizeNames, "normalize-subject-names", true,
+		"Convert backslashes in subject names to forward slashes and remove any leading \"./\".",
+	)
+	c.Flags().BoolVar(
+		&o.subjectBasename, "subject-basename", false,
+		"Only keep the last element of the path of each subject name, e.g. \"app.tar.gz\" for \"dist/app.tar.gz\".",
+	)
+	c.Flags().BoolVar(
+		&o.caseInsensitive, "case-insensitive-names", false,
+		"Reject subjects whose names only differ in case, as they collide on case-insensitive file systems.",
+	)
+	c.Flags().BoolVar(
+		&o.debug, "debug", false,
+		"Print each subject and the input it was read from, e.g. the flag and line, to stderr.",
+	)
+	c.Flags().StringVar(
+		&o.policyPath, "policy", "",
+		"Path to a YAML or JSON policy file listing required subject name patterns and the minimum digest algorithm.",
+	)
+	c.Flags().StringVar(
+		&o.oidcAudience, "oidc-audience", "",
+		"Override the audience of the GitHub OIDC token used to generate the provenance.",
+	)
+	c.Flags().DurationVar(
+		&o.oidcTokenTimeout, "oidc-token-timeout", defaultOIDCTokenTimeout,
+		"The maximum time to wait for a GitHub OIDC token to be issued and verified. 0 disables the timeout.",
+	)
+	c.Flags().StringVar(
+		&o.githubTokenFile, "github-token-file", "",
+		"Path to a file with the token used to request GitHub OIDC tokens, instead of "+
+			"$ACTIONS_ID_TOKEN_REQUEST_TOKEN. The file must have mode 0600.",
+	)
+	c.Flags().StringVar(
+		&o.releaseSubjects, "subjects-from-github-release", "",
+		"A GitHub release of the form owner/repo@tag. Each release asset with a <name>"+checksumSuffix+
+			" checksum asset is used as a subject. Requires --github-token or $GITHUB_TOKEN.",
+	)
+	c.Flags().StringVar(
+		&o.matrixOutput, "subjects-from-matrix-output", "",
+		"Path to a GitHub Actions matrix output file, either JSON or name=value lines. The subjects are read from "+
+			"the entries named --matrix-output-key, in the same format as sha256sum and optionally base64 encoded.",
+	)
+	c.Flags().StringVar(
+		&o.matrixOutputKey, "matrix-output-key", defaultMatrixOutputKey,
+		"The name of the entries of --subjects-from-matrix-output holding subjects.",
+	)
+	c.Flags().StringVar(
+		&o.buildxMetadataFile, "buildx-metadata-file", "",
+		"Path to the file written by 'docker buildx build --metadata-file'. The image is used as a subject, "+
+			"named by each tagged repository without the tag and with the containerimage.digest digest.",
+	)
+	c.Flags().StringVar(
+		&o.githubToken, "github-token", "",
+		"The token used to access the GitHub API. Defaults to $GITHUB_TOKEN.",
+	)
+	c.Flags().StringVar(
+		&o.artifactDir, "github-artifact-dir", "",
+		"Path to a directory of downloaded GitHub Actions artifacts. Each file is used as a subject.",
+	)
+	c.Flags().StringSliceVar(
+		&o.artifactPaths, "artifacts", nil,
+		"Comma separated list of artifact paths to use as subjects. Files are recorded with their sha256 digest, "+
+			"and directories with a \""+dirHashAlgorithm+"\" digest of their contents.",
+	)
+	c.Flags().Int64Var(
+		&o.artifactSizeWarning, "artifact-size-warning", defaultArtifactSizeWarning,
+		"Print a warning for artifacts in --github-artifact-dir larger than this size in bytes.",
+	)
+	c.Flags().IntVar(
+		&o.hashWorkers, "subjects-hash-workers", defaultHashWorkers,
+		"The number of files in --github-artifact-dir hashed in parallel.",
+	)
+	c.Flags().StringVar(
+		&o.checksumAlgorithm, "checksum-algorithm", "sha256",
+		"The algorithm used to hash the files of --github-artifact-dir and --artifacts. "+
+			"One of \"sha256\", \"sha384\" or \"sha512\".",
+	)
+	c.Flags().StringVar(
+		&o.sourceCommit, "git-commit-of-sources", "",
+		"The 40 character git commit of the sources, recorded as a material of the source repository. "+
+			"Defaults to the sha of the GitHub context.",
+	)
+	c.Flags().StringVar(
+		&o.workflowInputs, "workflow-inputs", "",
+		"JSON map of the workflow_dispatch inputs to record in the provenance (base64 encoded).",
+	)
+	c.Flags().StringVar(
+		&o.imageManifest, "image-manifest", "",
+		"Path to an OCI image manifest. Each layer is recorded as a material with the URI oci://layer/<digest>.",
+	)
+	c.Flags().StringVar(
+		&o.buildInvocationID, "build-invocation-id", "",
+		"A stable identifier of this invocation recorded in the provenance metadata. "+
+			"Defaults to \"$GITHUB_RUN_ID-$GITHUB_RUN_ATTEMPT\".",
+	)
+	c.Flags().BoolVar(
+		&o.redactContext, "redact-github-context", false,
+		"Replace the values of GitHub context keys matching --redact-pattern with \""+slsa.RedactedValue+
+			"\" in the provenance.",
+	)
+	c.Flags().StringArrayVar(
+		&o.redactPatterns, "redact-pattern", slsa.DefaultContextRedactPatterns,
+		"A case-insensitive glob pattern of the GitHub context keys redacted by --redact-github-context. "+
+			"Can be repeated. Replaces the default patterns.",
+	)
+	c.Flags().StringVar(
+		&o.statementVersion, "statement-version", statementVersionV01,
+		"The in-toto statement version. One of \""+statementVersionV01+"\" for a SLSA v0.2 predicate or \""+
+			statementVersionV1+"\" for a SLSA v1.0 predicate.",
+	)
+	c.Flags().StringVar(
+		&o.predicateFile, "predicate-file", "",
+		"Path to a JSON file with a custom predicate recorded as is instead of the provenance predicate. "+
+			"The predicate is not validated. Requires --predicate-type.",
+	)
+	c.Flags().StringVar(
+		&o.predicateType, "predicate-type", "",
+		"The predicate type URI of --predicate-file.",
+	)
+	c.MarkFlagsMutuallyExclusive(subjectFlags...)
+}
+
+// oidcBearerToken returns the token read from the token file, if any, to use
+// when requesting OIDC tokens.
+func (o *statementOptions) oidcBearerToken() (string, error) {
+	if o.githubTokenFile == "" {
+		return "", nil
+	}
+	return readTokenFile(o.githubTokenFile)
+}
+
+// clients returns the client provider used to generate the provenance. If
+// provider is not nil, it is returned as is.
+func (o *statementOptions) clients(provider slsa.ClientProvider) (slsa.ClientProvider, error) {
+	// NOTE: The token file is always checked so that an insecure file is
+	// reported even if it isn't used.
+	token, err := o.oidcBearerToken()
+	if err != nil {
+		return nil, err
+	}
+
+	githubToken := o.githubToken
+	if githubToken == "" {
+		githubToken = os.Getenv("GITHUB_TOKEN")
+	}
+	if o.releaseSubjects != "" && githubToken == "" {
+		return nil, errors.Errorf(&errMissingGithubToken{},
+			"--subjects-from-github-release requires --github-token or $GITHUB_TOKEN")
+	}
+
+	if provider != nil {
+		return provider, nil
+	}
+	if utils.IsPresubmitTests() {
+		// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
+		return &slsa.NilClientProvider{}, nil
+	}
+
+	clients := &slsa.DefaultClientProvider{}
+	if o.oidcAudience != "" {
+		clients.WithOIDCAudience(o.oidcAudience)
+	}
+	if token != "" {
+		clients.WithOIDCBearerToken(token)
+	}
+	clients.WithOIDCTokenTimeout(o.oidcTokenTimeout)
+	if githubToken != "" {
+		clients.WithGithubToken(githubToken)
+	}
+	return clients, nil
+}
+
+// checkPolicy checks the subjects against the policy file, if any.
+func (o *statementOptions) checkPolicy(subjects []intoto.Subject) error {
+	if o.policyPath == "" {
+		return nil
+	}
+	pol, err := loadPolicy(o.policyPath)
+	if err != nil {
+		return err
+	}
+	return pol.check(subjects)
+}
+
+// parseSubjects returns the subjects selected by the options.
+func (o *statementOptions) parseSubjects(ctx context.Context, cmd *cobra.Command,
+	clients slsa.ClientProvider,
+) ([]intoto.Subject, error) {
+	sourced, err := o.sourcedSubjects(ctx, cmd, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: The names are transformed in place, so sources[i] remains the
+	// source of parsedSubjects[i] until the subjects are sorted.
+	parsedSubjects := subjectsOf(sourced)
+	sources := make([]subjectSource, 0, len(sourced))
+	for _, s := range sourced {
+		sources = append(sources, s.source)
+	}
+
+	if o.normalizeNames || o.subjectBasename {
+		if err := normalizeSubjectNames(parsedSubjects, o.subjectBasename); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.subjectsStripPrefix != "" {
+		if err := stripSubjectsPrefix(parsedSubjects, o.subjectsStripPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(o.stripExtensions) > 0 {
+		if err := stripSubjectsExtensions(parsedSubjects, o.stripExtensions); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.subjectPrefix != "" {
+		if err := addSubjectsPrefix(parsedSubjects, o.subjectPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	// NOTE: Package URL subjects are added after the names of the other
+	// subjects are transformed, so that they are recorded unchanged.
+	if o.subjectsPURL != "" {
+		purlSubjects, err := parsePURLSubjects(o.subjectsPURL)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range purlSubjects {
+			parsedSubjects = append(parsedSubjects, s.Subject)
+			sources = append(sources, s.source)
+		}
+	}
+
+	// NOTE: The remote subjects are downloaded and verified here so that a
+	// digest mismatch fails before anything is signed.
+	if len(o.remoteSubjects) > 0 {
+		client, err := httpclient.New()
+		if err != nil {
+			return nil, err
+		}
+		remote, err := subjectsFromRemote(ctx, client, o.remoteSubjects, o.subjectUseURI, o.remoteMaxSize,
+			o.remoteTimeout)
+		if err != nil {
+			return nil, err
+		}
+		for i, s := range remote {
+			parsedSubjects = append(parsedSubjects, s)
+			sources = append(sources, subjectSource{input: fmt.Sprintf("--remote-subject #%d", i+1)})
+		}
+	}
+
+	if len(parsedSubjects) == 0 {
+		return nil, errors.Errorf(&errNoSubjects{}, "expected at least one subject")
+	}
+
+	// NOTE: Duplicates are checked again since different names may be the
+	// same after normalization, e.g. "./a" and "a".
+	if err := checkDuplicateSubjects(parsedSubjects, sources); err != nil {
+		return nil, err
+	}
+
+	if o.caseInsensitive {
+		if err := checkCaseInsensitiveNames(parsedSubjects); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.checkPolicy(parsedSubjects); err != nil {
+		return nil, err
+	}
+
+	if o.debug {
+		for i, s := range parsedSubjects {
+			fmt.Fprintf(cmd.ErrOrStderr(), "DEBUG: subject %q %s from %s\n", s.Name, formatDigestSet(s.Digest),
+				sources[i])
+		}
+	}
+
+	if o.sortSubjects {
+		slsa.SortSubjects(parsedSubjects)
+	}
+
+	return parsedSubjects, nil
+}
+
+// sourcedSubjects returns the subjects selected by the subject flags, before
+// their names are transformed, and the input each was read from.
+func (o *statementOptions) sourcedSubjects(ctx context.Context, cmd *cobra.Command,
+	clients slsa.ClientProvider,
+) ([]sourcedSubject, error) {
+	switch {
+	case o.artifactDir != "":
+		subjects, err := subjectsFromDir(o.artifactDir, o.checksumAlgorithm, o.artifactSizeWarning, o.hashWorkers,
+			cmd.ErrOrStderr())
+		return withSource(subjects, "--github-artifact-dir"), err
+	case len(o.artifactPaths) > 0:
+		subjects, err := subjectsFromPaths(o.artifactPaths, o.checksumAlgorithm)
+		return withSource(subjects, "--artifacts"), err
+	case o.releaseSubjects != "":
+		ghClient, err := clients.GithubClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		subjects, err := subjectsFromRelease(ctx, ghClient, o.releaseSubjects, cmd.ErrOrStderr())
+		return withSource(subjects, "--subjects-from-github-release"), err
+	case o.matrixOutput != "":
+		subjects, err := subjectsFromMatrixOutput(o.matrixOutput, o.matrixOutputKey)
+		return withSource(subjects, "--subjects-from-matrix-output"), err
+	case o.buildxMetadataFile != "":
+		subjects, err := subjectsFromBuildxMetadata(o.buildxMetadataFile)
+		return withSource(subjects, "--buildx-metadata-file"), err
+	case len(o.subjectsFilename) > 0:
+		return readSubjectsFiles(o.subjectsFilename, cmd.InOrStdin())
+	default:
+		return parseSubjectsValues(o.subjects, cmd.InOrStdin())
+	}
+}
+
+// statement generates the unsigned provenance statement for the subjects
+// selected by the options.
+func (o *statementOptions) statement(ctx context.Context, cmd *cobra.Command,
+	ghContext *github.WorkflowContext, clients slsa.ClientProvider,
+) (*intoto.ProvenanceStatement, error) {
+	if err := o.checkStatementVersion(); err != nil {
+		return nil, err
+	}
+
+	parsedSubjects, err := o.parseSubjects(ctx, cmd, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.buildInvocationID != "" {
+		if err := validateBuildInvocationID(o.buildInvocationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.redactContext {
+		if err := slsa.ValidateRedactPatterns(o.redactPatterns); err != nil {
+			return nil, errors.Errorf(&errRedactPattern{}, "%w", err)
+		}
+	}
+
+	var inputs map[string]interface{}
+	if o.workflowInputs != "" {
+		inputs, err = parseWorkflowInputs(o.workflowInputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var source *slsacommon.ProvenanceMaterial
+	if commit := o.sourceCommit; commit != "" || ghContext.SHA != "" {
+		if commit == "" {
+			commit = ghContext.SHA
+		}
+		m, err := sourceMaterial(ghContext.ServerURL, ghContext.Repository, commit)
+		if err != nil {
+			return nil, err
+		}
+		source = &m
+	}
+
+	// NOTE: The workflow file is recorded so that verifiers know exactly
+	// which version of the workflow ran. It is omitted if the GitHub API is
+	// not available.
+	var workflow *slsacommon.ProvenanceMaterial
+	if ghContext.WorkflowRef != "" && ghContext.WorkflowSHA != "" {
+		ghClient, err := clients.GithubClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		workflow, err = workflowMaterial(ctx, ghClient, ghContext.ServerURL, ghContext.WorkflowRef, ghContext.WorkflowSHA)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var layers []slsacommon.ProvenanceMaterial
+	if o.imageManifest != "" {
+		layers, err = imageLayerMaterials(o.imageManifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b := provenance.Builder{
+		WorkflowContext: ghContext,
+		Subjects:        parsedSubjects,
+		BuildType:       provenanceOnlyBuildType,
+		Clients:         clients,
+	}
+	p, err := b.StatementV02(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.buildInvocationID != "" {
+		p.Predicate.Metadata.BuildInvocationID = o.buildInvocationID
+	}
+
+	if source != nil {
+		p.Predicate.Materials = addSourceMaterial(p.Predicate.Materials, *source)
+	}
+	if workflow != nil {
+		p.Predicate.Materials = append(p.Predicate.Materials, *workflow)
+	}
+	p.Predicate.Materials = append(p.Predicate.Materials, layers...)
+
+	if inputs != nil {
+		p.Predicate.Invocation.Parameters = slsa.WorkflowParameters{
+			EventInputs: inputs,
+		}
+	}
+
+	if o.redactContext {
+		p.Predicate.Invocation.Environment = slsa.RedactContext(p.Predicate.Invocation.Environment, o.redactPatterns)
+		if params, ok := p.Predicate.Invocation.Parameters.(slsa.WorkflowParameters); ok {
+			params.EventInputs = slsa.RedactContext(params.EventInputs, o.redactPatterns)
+			p.Predicate.Invocation.Parameters = params
+		}
+	}
+
+	return p, nil
+}
+
+// checkStatementVersion returns an error if the statement version is not
+// supported.
+func (o *statementOptions) checkStatementVersion() error {
+	switch o.statementVersion {
+	case "", statementVersionV01, statementVersionV1:
+		return nil
+	default:
+		return errors.Errorf(&errStatementVersion{}, "unsupported statement version %q", o.statementVersion)
+	}
+}
+
+// outputStatement returns the statement to sign or write for p in the
+// statement version selected by the options. If --predicate-file is set, the
+// statement has the subjects of p and the custom predicate.
+func (o *statementOptions) outputStatement(p *intoto.ProvenanceStatement) (*intoto.Statement, error) {
+	if err := o.checkStatementVersion(); err != nil {
+		return nil, err
+	}
+	if o.predicateFile != "" || o.predicateType != "" {
+		predicate, err := readPredicate(o.predicateFile, o.predicateType)
+		if err != nil {
+			return nil, err
+		}
+		statementType := intoto.StatementInTotoV01
+		if o.statementVersion == statementVersionV1 {
+			statementType = provenance.StatementInTotoV1
+		}
+		return &intoto.Statement{
+			StatementHeader: intoto.StatementHeader{
+				Type:          statementType,
+				PredicateType: o.predicateType,
+				Subject:       p.Subject,
+			},
+			Predicate: predicate,
+		}, nil
+	}
+	if o.statementVersion == statementVersionV1 {
+		s := provenance.ConvertV1(p)
+		s.Type = provenance.StatementInTotoV1
+		return &intoto.Statement{
+			StatementHeader: s.StatementHeader,
+			Predicate:       s.Predicate,
+		}, nil
+	}
+	return &intoto.Statement{
+		StatementHeader: p.StatementHeader,
+		Predicate:       p.Predicate,
+	}, nil
+}
+
+// readPredicate reads the custom predicate of type predicateType at path. The
+// predicate must be a JSON object but its schema is not validated.
+func readPredicate(path, predicateType string) (json.RawMessage, error) {
+	if path == "" {
+		return nil, errors.Errorf(&errPredicate{}, "--predicate-type requires --predicate-file")
+	}
+	if predicateType == "" {
+		return nil, errors.Errorf(&errPredicate{}, "--predicate-file requires --predicate-type")
+	}
+	if u, err := url.Parse(predicateType); err != nil || !u.IsAbs() {
+		return nil, errors.Errorf(&errPredicate{}, "predicate type %q is not an absolute URI", predicateType)
+	}
+
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "reading predicate: %w", err)
+	}
+	if !json.Valid(b) || !bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return nil, errors.Errorf(&errPredicate{}, "predicate %q is not a JSON object", path)
+	}
+	return json.RawMessage(b), nil
+}
+
+// readStatement reads a provenance statement written by the 'generate'
+// command and checks that its hex encoded SHA-256 digest is wantSHA256. The
+// statement is an in-toto v0.1 or v1 statement with a SLSA provenance
+// predicate or, if it was generated with --predicate-file, a custom one. The
+// file is returned along with the parsed statement so that the bytes that
+// were hashed are the ones that are signed.
+func readStatement(path, wantSHA256 string) (*intoto.Statement, []byte, error) {
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, nil, err
+	}
+
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, errors.Errorf(&errors.ErrFilesystem{}, "reading statement: %w", err)
+	}
+
+	digest := sha256.Sum256(b)
+	if got := hex.EncodeToString(digest[:]); got != strings.ToLower(wantSHA256) {
+		return nil, nil, errors.Errorf(&errStatementDigest{}, "statement %q has sha256 %q, want %q", path, got, wantSHA256)
+	}
+
+	var s struct {
+		intoto.StatementHeader
+		Predicate json.RawMessage `json:"predicate"`
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, nil, errors.Errorf(&errStatement{}, "parsing statement: %w", err)
+	}
+	if s.Type != intoto.StatementInTotoV01 && s.Type != provenance.StatementInTotoV1 {
+		return nil, nil, errors.Errorf(&errStatement{}, "unexpected statement type %q", s.Type)
+	}
+	if u, err := url.Parse(s.PredicateType); err != nil || !u.IsAbs() {
+		return nil, nil, errors.Errorf(&errStatement{}, "predicate type %q is not an absolute URI", s.PredicateType)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(s.Predicate), []byte("{")) {
+		return nil, nil, errors.Errorf(&errStatement{}, "statement %q has no predicate", path)
+	}
+	if len(s.Subject) == 0 {
+		return nil, nil, errors.Errorf(&errNoSubjects{}, "statement has no subjects")
+	}
+
+	return &intoto.Statement{
+		StatementHeader: s.StatementHeader,
+		Predicate:       s.Predicate,
+	}, b, nil
+}
+
+// generateCmd returns the 'generate' command.
+func generateCmd(provider slsa.ClientProvider, check func(error)) *cobra.Command {
+	var opts statementOptions
+	var outputPath string
+
+	c := &cobra.Command{
+		Use:   "generate",
+		Short: "Create an unsigned SLSA provenance statement from a Github Action",
+		Long: `Generate an unsigned SLSA provenance statement from a Github Action, so that
+it can be signed externally or by the 'attest' command with --statement. The
+sha256 digest of the statement is written to the "statement-sha256" output.
+This command assumes that it is being run in the context of a Github Actions
+workflow.`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			ghContext, err := github.GetWorkflowContext()
+			check(err)
+
+			clients, err := opts.clients(provider)
+			check(err)
+
+			p, err := opts.statement(context.Background(), cmd, &ghContext, clients)
+			check(err)
+
+			s, err := opts.outputStatement(p)
+			check(err)
+
+			b, err := slsa.CanonicalizeStatement(*s)
+			check(err)
+
+			// NOTE: The path is untrusted and is validated by
+			// CreateNewFileUnderCurrentDirectory.
+			f, err := utils.CreateNewFileUnderCurrentDirectory(outputPath, os.O_WRONLY)
+			check(err)
+
+			if _, err := f.Write(b); err != nil {
+				check(errors.Errorf(&errors.ErrFilesystem{}, "writing statement: %w", err))
+			}
+
+			// Print the statement name and sha256 so that the workflow can
+			// pass them to the signing step.
+			digest := sha256.Sum256(b)
+			check(github.SetOutput("statement-name", outputPath))
+			check(github.SetOutput("statement-sha256", hex.EncodeToString(digest[:])))
+		},
+	}
+
+	opts.addFlags(c)
+	c.Flags().StringVarP(
+		&outputPath, "output", "o", "statement.json",
+		"Path to write the unsigned provenance statement.",
+	)
+
+	return c
+}
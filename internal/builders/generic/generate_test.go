@@ -0,0 +1,651 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1.0"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/provenance"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// readOutputs parses a GITHUB_OUTPUT file.
+func readOutputs(t *testing.T, path string) map[string]string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	outputs := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		name, value, _ := strings.Cut(line, "=")
+		outputs[name] = value
+	}
+	return outputs
+}
+
+// Test_generateCmd_attest tests that a statement written by the 'generate'
+// command is signed by the 'attest' command only if its digest matches.
+func Test_generateCmd_attest(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	// Change to temporary dir
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+	defer func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	}()
+
+	outputPath := filepath.Join(dir, "github-output")
+	if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Setenv("GITHUB_OUTPUT", outputPath)
+
+	g := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+	g.SetOut(new(bytes.Buffer))
+	g.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--output", "artifact1.json",
+	})
+	if err := g.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	outputs := readOutputs(t, outputPath)
+	if want, got := "artifact1.json", outputs["statement-name"]; want != got {
+		t.Errorf("unexpected statement-name, want: %q, got: %q", want, got)
+	}
+	digest := outputs["statement-sha256"]
+	if _, _, err := readStatement("artifact1.json", digest); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		digest string
+		// mismatch indicates that errStatementDigest is expected.
+		mismatch bool
+	}{
+		{
+			name:   "matching digest",
+			digest: digest,
+		},
+		{
+			name:     "mismatched digest",
+			digest:   strings.Repeat("0", 64),
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(err error) {
+				if err != nil {
+					errDigest := &errStatementDigest{}
+					if !tc.mismatch || !errors.As(err, &errDigest) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			signer := &payloadRecordingSigner{}
+			c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--statement", "artifact1.json",
+				"--statement-sha256", tc.digest,
+				"--signature", tc.name + ".intoto.jsonl",
+			})
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			if tc.mismatch {
+				t.Fatalf("expected an error to occur.")
+			}
+			want, err := os.ReadFile("artifact1.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(string(want), string(signer.payload)); diff != "" {
+				t.Errorf("unexpected signed payload (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_generateCmd_attest_round_trip tests that each statement written by the
+// 'generate' command is signed unchanged by the 'attest' command.
+func Test_generateCmd_attest_round_trip(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	testCases := []struct {
+		name          string
+		args          []string
+		statementType string
+		predicateType string
+	}{
+		{
+			name:          "v0.1",
+			statementType: intoto.StatementInTotoV01,
+			predicateType: slsa02.PredicateSLSAProvenance,
+		},
+		{
+			name:          "v1",
+			args:          []string{"--statement-version", statementVersionV1},
+			statementType: provenance.StatementInTotoV1,
+			predicateType: slsa1.PredicateSLSAProvenance,
+		},
+		{
+			name:          "custom predicate",
+			args:          []string{"--predicate-file", "predicate.json", "--predicate-type", "https://example.com/custom/v1"},
+			statementType: intoto.StatementInTotoV01,
+			predicateType: "https://example.com/custom/v1",
+		},
+		{
+			name: "v1 custom predicate",
+			args: []string{
+				"--statement-version", statementVersionV1,
+				"--predicate-file", "predicate.json", "--predicate-type", "https://example.com/custom/v1",
+			},
+			statementType: provenance.StatementInTotoV1,
+			predicateType: "https://example.com/custom/v1",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			writeTestFile(t, "predicate.json", `{"result": "pass", "details": {"count": 2}}`)
+			outputPath := filepath.Join(dir, "github-output")
+			if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Setenv("GITHUB_OUTPUT", outputPath)
+
+			g := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+			g.SetOut(new(bytes.Buffer))
+			g.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--output", "statement.json",
+			}, tc.args...))
+			if err := g.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			tlog := &testutil.TestTransparencyLog{Entry: testutil.NewTestLogEntryAt(time.Now())}
+			c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), newKeylessTestSigner(t), tlog, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--statement", "statement.json",
+				"--statement-sha256", readOutputs(t, outputPath)["statement-sha256"],
+			})
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile("artifact1.intoto.jsonl")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var env envelope.Envelope
+			if err := json.Unmarshal(b, &env); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			got, err := base64.StdEncoding.DecodeString(env.Payload)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			var header intoto.StatementHeader
+			if err := json.Unmarshal(got, &header); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := tc.statementType, header.Type; want != got {
+				t.Errorf("unexpected statement type, want: %q, got: %q", want, got)
+			}
+			if want, got := tc.predicateType, header.PredicateType; want != got {
+				t.Errorf("unexpected predicate type, want: %q, got: %q", want, got)
+			}
+
+			// The DSSE payload must be the exact bytes that were hashed.
+			want, err := os.ReadFile("statement.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("unexpected signed payload (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_statement_signer tests that --statement fails with a signer
+// that can only sign a re-encoding of the statement.
+func Test_attestCmd_statement_signer(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	dir := chdirTemp(t)
+	outputPath := filepath.Join(dir, "github-output")
+	if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Setenv("GITHUB_OUTPUT", outputPath)
+
+	g := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+	g.SetOut(new(bytes.Buffer))
+	g.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--output", "statement.json",
+	})
+	if err := g.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	check := func(err error) {
+		if err != nil {
+			if !errors.As(err, new(*errors.ErrInput)) {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	signer := &recordingSigner{}
+	c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--statement", "statement.json",
+		"--statement-sha256", readOutputs(t, outputPath)["statement-sha256"],
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Fatalf("expected an error to occur.")
+}
+
+// Test_attestCmd_statement_flags tests that the flags shaping the statement
+// cannot be used with --statement.
+func Test_attestCmd_statement_flags(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	for _, f := range statementFlags {
+		f := f
+		t.Run(f, func(t *testing.T) {
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+			}
+			c := attestCmd(&slsa.NilClientProvider{}, check, &recordingSigner{}, &testutil.TestTransparencyLog{}, nil)
+			flag := c.Flags().Lookup(f)
+			if flag == nil {
+				t.Fatalf("unknown flag --%s", f)
+			}
+			value := "value"
+			switch flag.Value.Type() {
+			case "bool":
+				value = "true"
+			case "int", "int64":
+				value = "1"
+			case "duration":
+				value = "1s"
+			}
+
+			c.SetOut(new(bytes.Buffer))
+			c.SetErr(new(bytes.Buffer))
+			c.SetArgs([]string{
+				"--statement", "statement.json",
+				"--statement-sha256", strings.Repeat("0", 64),
+				"--" + f + "=" + value,
+			})
+			if err := c.Execute(); err == nil {
+				t.Fatalf("expected an error to occur.")
+			}
+		})
+	}
+}
+
+// Test_generateCmd_redact_github_context tests that secrets in the GitHub
+// context are redacted from the statement.
+func Test_generateCmd_redact_github_context(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", `{"event_name": "workflow_dispatch", "event": {"inputs": {"secret_key": "hunter2", "version": "v1"}}}`)
+
+	testCases := []struct {
+		name     string
+		args     []string
+		redacted bool
+	}{
+		{
+			name: "no redaction",
+		},
+		{
+			name:     "default patterns",
+			args:     []string{"--redact-github-context"},
+			redacted: true,
+		},
+		{
+			name: "custom pattern",
+			args: []string{"--redact-github-context", "--redact-pattern", "version"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			c := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--output", "artifact1.json",
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile("artifact1.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var p struct {
+				Predicate struct {
+					Invocation struct {
+						Environment struct {
+							Event struct {
+								Inputs map[string]string `json:"inputs"`
+							} `json:"github_event_payload"`
+						} `json:"environment"`
+						Parameters struct {
+							Inputs map[string]string `json:"event_inputs"`
+						} `json:"parameters"`
+					} `json:"invocation"`
+				} `json:"predicate"`
+			}
+			if err := json.Unmarshal(b, &p); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			want := "hunter2"
+			if tc.redacted {
+				want = slsa.RedactedValue
+			}
+			for _, inputs := range []map[string]string{
+				p.Predicate.Invocation.Environment.Event.Inputs,
+				p.Predicate.Invocation.Parameters.Inputs,
+			} {
+				if got := inputs["secret_key"]; want != got {
+					t.Errorf("unexpected secret_key, want: %q, got: %q", want, got)
+				}
+			}
+		})
+	}
+}
+
+// Test_generateCmd_statement_version tests that --statement-version selects
+// the in-toto statement and SLSA predicate versions.
+func Test_generateCmd_statement_version(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	testCases := []struct {
+		name          string
+		version       string
+		statementType string
+		predicateType string
+	}{
+		{
+			name:          "default",
+			statementType: intoto.StatementInTotoV01,
+			predicateType: slsa02.PredicateSLSAProvenance,
+		},
+		{
+			name:          "v0.1",
+			version:       "v0.1",
+			statementType: intoto.StatementInTotoV01,
+			predicateType: slsa02.PredicateSLSAProvenance,
+		},
+		{
+			name:          "v1",
+			version:       "v1",
+			statementType: provenance.StatementInTotoV1,
+			predicateType: slsa1.PredicateSLSAProvenance,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			args := []string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--output", "artifact1.json",
+			}
+			if tc.version != "" {
+				args = append(args, "--statement-version", tc.version)
+			}
+
+			c := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(args)
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile("artifact1.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var s intoto.StatementHeader
+			if err := json.Unmarshal(b, &s); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := tc.statementType, s.Type; want != got {
+				t.Errorf("unexpected statement type, want: %q, got: %q", want, got)
+			}
+			if want, got := tc.predicateType, s.PredicateType; want != got {
+				t.Errorf("unexpected predicate type, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+// Test_generateCmd_git_commit_of_sources tests that --git-commit-of-sources
+// adds a material for the commit of the source repository.
+func Test_generateCmd_git_commit_of_sources(t *testing.T) {
+	const contextSHA = "0123456789abcdef0123456789abcdef01234567"
+	t.Setenv("GITHUB_CONTEXT", `{"repository": "owner/repo", "server_url": "https://github.com", `+
+		`"ref": "refs/heads/main", "sha": "`+contextSHA+`"}`)
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []slsacommon.ProvenanceMaterial
+		err      bool
+	}{
+		{
+			name: "default",
+			expected: []slsacommon.ProvenanceMaterial{
+				{
+					URI:    "git+https://github.com/owner/repo@refs/heads/main",
+					Digest: slsacommon.DigestSet{"sha1": contextSHA},
+				},
+			},
+		},
+		{
+			name: "valid commit",
+			args: []string{"--git-commit-of-sources", "8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c"},
+			expected: []slsacommon.ProvenanceMaterial{
+				{
+					URI:    "git+https://github.com/owner/repo@refs/heads/main",
+					Digest: slsacommon.DigestSet{"sha1": contextSHA},
+				},
+				{
+					URI:    "git+https://github.com/owner/repo",
+					Digest: slsacommon.DigestSet{"sha1": "8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c"},
+				},
+			},
+		},
+		{
+			name: "invalid commit",
+			args: []string{"--git-commit-of-sources", "8f1a2b3"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					errCommit := &errSourceCommit{}
+					if !tc.err || !errors.As(err, &errCommit) {
+						t.Fatalf("unexpected failure: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := generateCmd(&slsa.NilClientProvider{}, check)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--output", "artifact1.json",
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if tc.err {
+				t.Fatalf("expected an error to occur.")
+			}
+
+			b, err := os.ReadFile("artifact1.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var p intoto.ProvenanceStatement
+			if err := json.Unmarshal(b, &p); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, p.Predicate.Materials); diff != "" {
+				t.Errorf("unexpected materials (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_generateCmd_subjects_sort tests that subjects are sorted by name
+// unless sorting is disabled.
+func Test_generateCmd_subjects_sort(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	subjects := "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2  piyo\n" +
+		"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c  hoge\n" +
+		"e712aff3705ac314b9a890e0ec208faa20054eee514d86ab913d768f94e01279  fuga\n"
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "subjects-sort",
+			args:     []string{"--subjects-sort"},
+			expected: []string{"fuga", "hoge", "piyo"},
+		},
+		{
+			name:     "sort-subjects",
+			args:     []string{"--sort-subjects"},
+			expected: []string{"fuga", "hoge", "piyo"},
+		},
+		{
+			name:     "no sort",
+			args:     []string{"--subjects-sort=false"},
+			expected: []string{"piyo", "hoge", "fuga"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+
+			c := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(subjects)),
+				"--output", "provenance.json",
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile("provenance.json")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var p intoto.ProvenanceStatement
+			if err := json.Unmarshal(b, &p); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var got []string
+			for _, s := range p.Subject {
+				got = append(got, s.Name)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
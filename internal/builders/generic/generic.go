@@ -17,22 +17,32 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
 )
 
 func checkExit(err error) {
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(errors.WriteExitError(os.Stderr, err))
 	}
 }
 
@@ -49,49 +59,426 @@ var (
 	// characters long.
 	shaCheck = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
 
+	// hexCheck verifies a digest only has lowercase hexadecimal digits.
+	hexCheck = regexp.MustCompile(`^[a-f0-9]+$`)
+
+	// digestHexLengths are the lengths of the hex encoded digests of the
+	// algorithms that may prefix a subject digest, e.g. sha512:<hex>.
+	digestHexLengths = map[string]int{
+		"sha256": 64,
+		"sha512": 128,
+	}
+
 	// wsSplit is used to split lines in the subjects input.
 	wsSplit = regexp.MustCompile(`[\t ]`)
 
+	// inputKeyCheck verifies a workflow input key only has alphanumeric
+	// characters, underscores, and hyphens.
+	inputKeyCheck = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+	// sensitiveInputPrefixes are prefixes of workflow input keys that are
+	// likely to hold sensitive values.
+	sensitiveInputPrefixes = []string{"secret", "token"}
+
 	// provenanceOnlyBuildType is the URI for provenance only SLSA generation.
 	provenanceOnlyBuildType = "https://github.com/slsa-framework/slsa-github-generator/generic@v1"
 )
 
 // errBase64 indicates a base64 error in the subject.
 type errBase64 struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 // errSha indicates a error in the hash format.
 type errSha struct {
-	errors.WrappableError
+	errors.ErrInput
+}
+
+// errSubjectURI indicates a subject name that is an invalid URI.
+type errSubjectURI struct {
+	errors.ErrInput
+}
+
+// errSubjectPURL indicates a subject name that is an invalid package URL.
+type errSubjectPURL struct {
+	errors.ErrInput
 }
 
 // errNoName indicates a missing subject name.
 type errNoName struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 // errDuplicateSubject indicates a duplicate subject name.
 type errDuplicateSubject struct {
-	errors.WrappableError
+	errors.ErrInput
+}
+
+// errAttestationSubject indicates a subject that is itself an attestation.
+type errAttestationSubject struct {
+	errors.ErrInput
+}
+
+// errSubjectCountOutOfRange indicates that the number of subjects is outside
+// of the expected range.
+type errSubjectCountOutOfRange struct {
+	errors.ErrInput
+
+	// count is the number of subjects.
+	count int
+
+	// min and max are the expected bounds. Zero means unbounded.
+	min, max int
 }
 
 // errScan is an error scanning the SHA digest data.
 type errScan struct {
-	errors.WrappableError
+	errors.ErrInput
+}
+
+// errNoSubjects indicates that no subjects were given.
+type errNoSubjects struct {
+	errors.ErrInput
+}
+
+// errInputs indicates an error in the workflow inputs.
+type errInputs struct {
+	errors.ErrInput
+}
+
+// errSensitiveInputKey indicates a workflow input key that may hold a
+// sensitive value.
+type errSensitiveInputKey struct {
+	errors.ErrInput
+}
+
+// errEmptySubjectName indicates that a subject name is empty after stripping
+// its prefix.
+type errEmptySubjectName struct {
+	errors.ErrInput
+}
+
+// errSubjectPrefix indicates an invalid --subject-prefix.
+type errSubjectPrefix struct {
+	errors.ErrInput
+}
+
+// errInvocationID indicates an invalid build invocation ID.
+type errInvocationID struct {
+	errors.ErrInput
+}
+
+// errSourceCommit indicates an invalid source commit.
+type errSourceCommit struct {
+	errors.ErrInput
+}
+
+// errAttestationName indicates that the attestation file name does not match
+// the subjects.
+type errAttestationName struct {
+	errors.ErrInput
+}
+
+// parseWorkflowInputs parses the value given to the workflow-inputs option.
+func parseWorkflowInputs(b64str string) (map[string]interface{}, error) {
+	b, err := base64.StdEncoding.DecodeString(b64str)
+	if err != nil {
+		return nil, errors.Errorf(&errBase64{}, "error decoding workflow inputs (is it base64 encoded?): %w", err)
+	}
+
+	var inputs map[string]interface{}
+	if err := json.Unmarshal(b, &inputs); err != nil {
+		return nil, errors.Errorf(&errInputs{}, "parsing workflow inputs: %w", err)
+	}
+
+	for k := range inputs {
+		if !inputKeyCheck.MatchString(k) {
+			return nil, errors.Errorf(&errInputs{}, "invalid workflow input key %q", k)
+		}
+		for _, prefix := range sensitiveInputPrefixes {
+			if strings.HasPrefix(strings.ToLower(k), prefix) {
+				return nil, errors.Errorf(&errSensitiveInputKey{}, "workflow input key %q may contain sensitive data", k)
+			}
+		}
+	}
+
+	return inputs, nil
+}
+
+// subjectsEncodings are the base64 encodings accepted for the subjects
+// option, in the order they are tried. A string that is valid in more than
+// one of them decodes to the same bytes in each.
+var subjectsEncodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.URLEncoding,
+	base64.RawStdEncoding,
+	base64.RawURLEncoding,
+}
+
+// decodeSubjects decodes the value given to the subjects option, which may
+// be standard or URL-safe base64, with or without padding. The decoded
+// subjects must be valid UTF-8 so that corrupted input is not mistaken for
+// another encoding.
+func decodeSubjects(b64str string) ([]byte, error) {
+	var firstErr error
+	for _, enc := range subjectsEncodings {
+		b, err := enc.DecodeString(b64str)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.Errorf(&errBase64{}, "error decoding subjects: decoded subjects are not valid UTF-8")
+		}
+		return b, nil
+	}
+	return nil, errors.Errorf(&errBase64{}, "error decoding subjects (is it base64 encoded?): %w", firstErr)
+}
+
+// subjectSource is the input a subject was read from. It is used in error
+// messages and in the --debug output.
+type subjectSource struct {
+	// input is the option or file the subject was read from, e.g.
+	// "--subjects #2".
+	input string
+
+	// line is the line of the subject in the input, starting at 1. It is 0
+	// if the input is not a list of subjects.
+	line int
+}
+
+func (s subjectSource) String() string {
+	if s.line > 0 {
+		return fmt.Sprintf("%s line %d", s.input, s.line)
+	}
+	return s.input
+}
+
+// sourcedSubject is a subject and the input it was read from.
+type sourcedSubject struct {
+	intoto.Subject
+	source subjectSource
+}
+
+// withSource returns the subjects, all read from input.
+func withSource(subjects []intoto.Subject, input string) []sourcedSubject {
+	var sourced []sourcedSubject
+	for _, s := range subjects {
+		sourced = append(sourced, sourcedSubject{Subject: s, source: subjectSource{input: input}})
+	}
+	return sourced
+}
+
+// subjectsOf returns the subjects without their sources.
+func subjectsOf(sourced []sourcedSubject) []intoto.Subject {
+	var subjects []intoto.Subject
+	for _, s := range sourced {
+		subjects = append(subjects, s.Subject)
+	}
+	return subjects
 }
 
 // parseSubjects parses the value given to the subjects option.
 func parseSubjects(b64str string) ([]intoto.Subject, error) {
-	var parsed []intoto.Subject
+	subjects, err := decodeSubjects(b64str)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSubjectsReader(bytes.NewReader(subjects))
+}
+
+// parseSubjectsValues parses the values given to the repeated subjects
+// option, in order. A value of "-" reads the subjects from stdin. Duplicate
+// subjects across the values are detected later, once names are normalized.
+func parseSubjectsValues(values []string, stdin io.Reader) ([]sourcedSubject, error) {
+	var parsed []sourcedSubject
+	for i, v := range values {
+		input := fmt.Sprintf("--subjects #%d", i+1)
+		var r io.Reader = stdin
+		if v != "-" {
+			b, err := decodeSubjects(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", input, err)
+			}
+			r = bytes.NewReader(b)
+		}
+		subjects, err := parseSourcedSubjects(r, input)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, subjects...)
+	}
+	return parsed, nil
+}
+
+// readSubjectsFiles parses the subjects in the files at the given paths, in
+// order.
+func readSubjectsFiles(paths []string, stdin io.Reader) ([]sourcedSubject, error) {
+	var parsed []sourcedSubject
+	for i, path := range paths {
+		subjects, err := readSubjectsFile(path, fmt.Sprintf("--subjects-filename #%d (%q)", i+1, path), stdin)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, subjects...)
+	}
+	return parsed, nil
+}
+
+// readSubjectsFile parses the subjects in the file at the given path, in the
+// same format as sha256sum. If the path is "-", the subjects are read from
+// stdin instead.
+func readSubjectsFile(path, input string, stdin io.Reader) ([]sourcedSubject, error) {
+	if path == "-" {
+		return parseSourcedSubjects(stdin, input)
+	}
+
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, fmt.Errorf("%s: %w", input, err)
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errScan{}, "%s: opening subjects file: %w", input, err)
+	}
+	defer f.Close()
+
+	return parseSourcedSubjects(f, input)
+}
+
+// parseDigest parses a lowercase subject digest, which is either a bare
+// sha256 hex digest or a hex digest prefixed by its algorithm, e.g.
+// sha512:<hex>. It returns the algorithm and the hex digest.
+func parseDigest(s string) (alg, digest string, err error) {
+	alg, digest = "sha256", s
+	if a, d, ok := strings.Cut(s, ":"); ok {
+		alg, digest = a, d
+	}
+	// Do a sanity check on the digest to make sure it's a proper hex digest.
+	n, ok := digestHexLengths[alg]
+	if !ok {
+		return "", "", errors.Errorf(&errSha{}, "unsupported digest algorithm %q", alg)
+	}
+	if len(digest) != n || !hexCheck.MatchString(digest) {
+		return "", "", errors.Errorf(&errSha{}, "unexpected %s hash format for %q", alg, s)
+	}
+	return alg, digest, nil
+}
 
-	subjects, err := base64.StdEncoding.DecodeString(b64str)
+// validateSubjectName checks that a subject name that looks like a URI, e.g.
+// the URI of a deployed resource, is a valid absolute URI, and that a name
+// that starts with "pkg:" is a valid package URL.
+func validateSubjectName(name string) error {
+	if isPURL(name) {
+		return validatePURL(name)
+	}
+	if !strings.Contains(name, "://") {
+		return nil
+	}
+	u, err := url.Parse(name)
 	if err != nil {
-		return nil, errors.Errorf(&errBase64{}, "error decoding subjects (is it base64 encoded?): %w", err)
+		return errors.Errorf(&errSubjectURI{}, "invalid subject URI %q: %w", name, err)
+	}
+	if u.Scheme == "" || (u.Host == "" && u.Path == "") {
+		return errors.Errorf(&errSubjectURI{}, "invalid subject URI %q", name)
+	}
+	return nil
+}
+
+// purlTypeRe matches the type of a package URL.
+var purlTypeRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9.+-]*$`)
+
+// isPURL returns whether the subject name is a package URL, e.g.
+// pkg:npm/@scope/name@1.0.0.
+func isPURL(name string) bool {
+	return strings.HasPrefix(name, "pkg:")
+}
+
+// validatePURL checks that name is a package URL with a type, a name and a
+// version, i.e. pkg:<type>/[<namespace>/]<name>@<version>, optionally followed
+// by qualifiers and a subpath.
+func validatePURL(name string) error {
+	rest := strings.TrimPrefix(name, "pkg:")
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	// NOTE: The namespace may contain an unescaped "@", e.g. an npm scope, so
+	// the version starts after the last "@" of the last path segment.
+	version := ""
+	if i := strings.LastIndex(rest, "@"); i > strings.LastIndex(rest, "/") {
+		rest, version = rest[:i], rest[i+1:]
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(subjects))
-	for scanner.Scan() {
+	segments := strings.Split(rest, "/")
+	if !purlTypeRe.MatchString(segments[0]) {
+		return errors.Errorf(&errSubjectPURL{}, "invalid package URL %q: invalid or missing type", name)
+	}
+	if len(segments) < 2 {
+		return errors.Errorf(&errSubjectPURL{}, "invalid package URL %q: missing name", name)
+	}
+	for _, s := range segments[1:] {
+		if s == "" {
+			return errors.Errorf(&errSubjectPURL{}, "invalid package URL %q: empty namespace or name", name)
+		}
+	}
+	if version == "" {
+		return errors.Errorf(&errSubjectPURL{}, "invalid package URL %q: missing version", name)
+	}
+	return nil
+}
+
+// parsePURLSubjects parses the value given to the subjects-purl option. Every
+// subject name must be a package URL.
+func parsePURLSubjects(b64str string) ([]sourcedSubject, error) {
+	const input = "--subjects-purl"
+	b, err := decodeSubjects(b64str)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", input, err)
+	}
+	subjects, err := parseSourcedSubjects(bytes.NewReader(b), input)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subjects {
+		if !isPURL(s.Name) {
+			return nil, errors.Errorf(&errSubjectPURL{}, "%s: subject %q is not a package URL", s.source, s.Name)
+		}
+	}
+	return subjects, nil
+}
+
+// parseSubjectsReader parses subjects in the same format as sha256sum. The
+// digest may be prefixed by its algorithm, e.g. sha512:<hex> <name>.
+func parseSubjectsReader(r io.Reader) ([]intoto.Subject, error) {
+	subjects, err := parseSourcedSubjects(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return subjectsOf(subjects), nil
+}
+
+// parseSourcedSubjects parses subjects in the same format as
+// parseSubjectsReader and records the input and line each subject was read
+// from. Errors are prefixed by the input and line, unless input is empty.
+func parseSourcedSubjects(r io.Reader, input string) ([]sourcedSubject, error) {
+	var parsed []sourcedSubject
+
+	// wrap prefixes err with the source of the current line.
+	wrap := func(src subjectSource, err error) error {
+		if input == "" {
+			return err
+		}
+		return fmt.Errorf("%s: %w", src, err)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		src := subjectSource{input: input, line: line}
+
 		// Split by whitespace, and get values.
 		parts := wsSplit.Split(strings.TrimSpace(scanner.Text()), 2)
 
@@ -101,33 +488,392 @@ func parseSubjects(b64str string) ([]intoto.Subject, error) {
 			// Ignore empty lines.
 			continue
 		}
-		// Do a sanity check on the SHA to make sure it's a proper hex digest.
-		if !shaCheck.MatchString(shaDigest) {
-			return nil, errors.Errorf(&errSha{}, "unexpected sha256 hash format for %q", shaDigest)
+		alg, digest, err := parseDigest(shaDigest)
+		if err != nil {
+			return nil, wrap(src, err)
 		}
 
 		// Check for the subject name.
 		if len(parts) == 1 {
-			return nil, errors.Errorf(&errNoName{}, "expected subject name for hash %q", shaDigest)
+			return nil, wrap(src, errors.Errorf(&errNoName{}, "expected subject name for hash %q", shaDigest))
 		}
 		name := strings.TrimSpace(parts[1])
+		if err := validateSubjectName(name); err != nil {
+			return nil, wrap(src, err)
+		}
 
 		for _, p := range parsed {
 			if p.Name == name {
-				return nil, errors.Errorf(&errDuplicateSubject{}, "duplicate subject %q", name)
+				return nil, wrap(src, errors.Errorf(&errDuplicateSubject{}, "duplicate subject %q, first on line %d",
+					name, p.source.line))
 			}
 		}
 
-		parsed = append(parsed, intoto.Subject{
-			Name: name,
-			Digest: slsacommon.DigestSet{
-				"sha256": shaDigest,
+		parsed = append(parsed, sourcedSubject{
+			Subject: intoto.Subject{
+				Name: name,
+				Digest: slsacommon.DigestSet{
+					alg: digest,
+				},
 			},
+			source: src,
 		})
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, errors.Errorf(&errScan{}, "reading digest: %w", err)
+		return nil, wrap(subjectSource{input: input}, errors.Errorf(&errScan{}, "reading digest: %w", err))
 	}
 
 	return parsed, nil
 }
+
+// maxInvocationIDLength is the maximum length of a build invocation ID.
+const maxInvocationIDLength = 256
+
+// validateBuildInvocationID checks that id only has printable ASCII
+// characters and is at most maxInvocationIDLength characters long.
+func validateBuildInvocationID(id string) error {
+	if len(id) > maxInvocationIDLength {
+		return errors.Errorf(&errInvocationID{}, "build invocation ID is longer than %d characters", maxInvocationIDLength)
+	}
+	for _, c := range []byte(id) {
+		if c < 0x20 || c > 0x7e {
+			return errors.Errorf(&errInvocationID{}, "build invocation ID %q has a non-printable ASCII character", id)
+		}
+	}
+	return nil
+}
+
+// normalizeSubjectNames converts the backslashes in the name of each subject
+// to forward slashes and removes any leading "./", e.g. "dist/app.tar.gz" for
+// `.\dist\app.tar.gz`, so that names match those computed by verifiers. If
+// basename is true, only the last element of each name is kept. Names that
+// are URIs or package URLs are left unchanged.
+func normalizeSubjectNames(subjects []intoto.Subject, basename bool) error {
+	for i := range subjects {
+		name := subjects[i].Name
+		if strings.Contains(name, "://") || isPURL(name) {
+			continue
+		}
+
+		name = strings.ReplaceAll(name, "\\", "/")
+		for strings.HasPrefix(name, "./") {
+			name = strings.TrimLeft(strings.TrimPrefix(name, "./"), "/")
+		}
+		if basename && name != "" {
+			name = path.Base(name)
+		}
+		if name == "" || name == "." || name == "/" {
+			return errors.Errorf(&errEmptySubjectName{}, "subject name %q is empty after normalization", subjects[i].Name)
+		}
+		subjects[i].Name = name
+	}
+	return nil
+}
+
+// checkDuplicateSubjects returns an errDuplicateSubject if two subjects have
+// the same name. sources[i] is the source of subjects[i].
+func checkDuplicateSubjects(subjects []intoto.Subject, sources []subjectSource) error {
+	names := make(map[string]int, len(subjects))
+	for i, s := range subjects {
+		if j, ok := names[s.Name]; ok {
+			return errors.Errorf(&errDuplicateSubject{}, "duplicate subject %q from %s and %s", s.Name, sources[j],
+				sources[i])
+		}
+		names[s.Name] = i
+	}
+	return nil
+}
+
+// sourceCommitRe matches a full git commit SHA-1.
+var sourceCommitRe = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// sourceMaterial returns the material for the commit of the source repository,
+// e.g. git+https://github.com/owner/repo with a sha1 digest. The commit must be
+// a 40 character lowercase hex string.
+func sourceMaterial(serverURL, repository, commit string) (slsacommon.ProvenanceMaterial, error) {
+	if !sourceCommitRe.MatchString(commit) {
+		return slsacommon.ProvenanceMaterial{}, errors.Errorf(&errSourceCommit{},
+			"invalid source commit %q: expected a 40 character lowercase hex string", commit)
+	}
+	if repository == "" {
+		return slsacommon.ProvenanceMaterial{}, errors.Errorf(&errSourceCommit{},
+			"source commit %q: unknown source repository", commit)
+	}
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	return slsacommon.ProvenanceMaterial{
+		URI:    "git+" + strings.TrimSuffix(serverURL, "/") + "/" + repository,
+		Digest: slsacommon.DigestSet{"sha1": commit},
+	}, nil
+}
+
+// addSourceMaterial adds m to materials unless a material for the same commit
+// of the repository is already recorded, e.g. by the build type with the ref
+// in its URI.
+func addSourceMaterial(materials []slsacommon.ProvenanceMaterial,
+	m slsacommon.ProvenanceMaterial,
+) []slsacommon.ProvenanceMaterial {
+	for _, other := range materials {
+		if other.Digest["sha1"] == m.Digest["sha1"] &&
+			(other.URI == m.URI || strings.HasPrefix(other.URI, m.URI+"@")) {
+			return materials
+		}
+	}
+	return append(materials, m)
+}
+
+// stripSubjectsPrefix removes prefix from the name of each subject. Names that
+// do not start with prefix are left unchanged.
+func stripSubjectsPrefix(subjects []intoto.Subject, prefix string) error {
+	for i := range subjects {
+		name := strings.TrimPrefix(subjects[i].Name, prefix)
+		if name == "" {
+			return errors.Errorf(&errEmptySubjectName{}, "subject name %q is empty after stripping prefix %q",
+				subjects[i].Name, prefix)
+		}
+		subjects[i].Name = name
+	}
+	return nil
+}
+
+// stripSubjectsExtensions removes the longest of the extensions that the name
+// of each subject ends with, e.g. ".tar.gz" rather than ".gz". Names that do
+// not end with any of the extensions are left unchanged.
+func stripSubjectsExtensions(subjects []intoto.Subject, extensions []string) error {
+	for i := range subjects {
+		ext := ""
+		for _, e := range extensions {
+			if strings.HasSuffix(subjects[i].Name, e) && len(e) > len(ext) {
+				ext = e
+			}
+		}
+		if ext == "" {
+			continue
+		}
+
+		name := strings.TrimSuffix(subjects[i].Name, ext)
+		if name == "" || strings.HasSuffix(name, "/") {
+			return errors.Errorf(&errEmptySubjectName{}, "subject name %q is empty after stripping extension %q",
+				subjects[i].Name, ext)
+		}
+		subjects[i].Name = name
+	}
+	return nil
+}
+
+// windowsVolumeRe matches a path starting with a Windows drive letter.
+var windowsVolumeRe = regexp.MustCompile(`^[a-zA-Z]:`)
+
+// validateSubjectPrefix returns an errSubjectPrefix if prefix is an absolute
+// path, has a ".." path element or contains control characters.
+func validateSubjectPrefix(prefix string) error {
+	if !utf8.ValidString(prefix) {
+		return errors.Errorf(&errSubjectPrefix{}, "subject prefix %q is not valid UTF-8", prefix)
+	}
+	for _, r := range prefix {
+		if unicode.IsControl(r) {
+			return errors.Errorf(&errSubjectPrefix{}, "subject prefix %q contains control characters", prefix)
+		}
+	}
+	// NOTE: Backslashes are treated as separators so that Windows paths are
+	// rejected as well.
+	p := strings.ReplaceAll(prefix, "\\", "/")
+	if path.IsAbs(p) || windowsVolumeRe.MatchString(p) {
+		return errors.Errorf(&errSubjectPrefix{}, "subject prefix %q is an absolute path", prefix)
+	}
+	for _, e := range strings.Split(p, "/") {
+		if e == ".." {
+			return errors.Errorf(&errSubjectPrefix{}, "subject prefix %q contains \"..\"", prefix)
+		}
+	}
+	return nil
+}
+
+// addSubjectsPrefix prepends prefix to the name of each subject, e.g.
+// "projectA/app.tar.gz" for "app.tar.gz" with the prefix "projectA/". Names
+// that are URIs are left unchanged.
+func addSubjectsPrefix(subjects []intoto.Subject, prefix string) error {
+	if err := validateSubjectPrefix(prefix); err != nil {
+		return err
+	}
+	for i := range subjects {
+		if strings.Contains(subjects[i].Name, "://") || isPURL(subjects[i].Name) {
+			continue
+		}
+		subjects[i].Name = prefix + subjects[i].Name
+	}
+	return nil
+}
+
+// checkCaseInsensitiveNames returns an errDuplicateSubject if two subject
+// names only differ in case, since they refer to the same file on
+// case-insensitive file systems such as those of macOS and Windows.
+func checkCaseInsensitiveNames(subjects []intoto.Subject) error {
+	names := make(map[string]string, len(subjects))
+	for _, s := range subjects {
+		lower := strings.ToLower(s.Name)
+		if other, ok := names[lower]; ok {
+			return errors.Errorf(&errDuplicateSubject{}, "subjects %q and %q differ only in case", other, s.Name)
+		}
+		names[lower] = s.Name
+	}
+	return nil
+}
+
+// checkSubjectCount returns an errSubjectCountOutOfRange if the number of
+// subjects is less than min or, if max is not zero, more than max.
+func checkSubjectCount(subjects []intoto.Subject, min, max int) error {
+	n := len(subjects)
+	if n < min || (max > 0 && n > max) {
+		bounds := fmt.Sprintf("at least %d", min)
+		if max > 0 {
+			bounds = fmt.Sprintf("between %d and %d", min, max)
+		}
+		return errors.Errorf(&errSubjectCountOutOfRange{count: n, min: min, max: max},
+			"got %d subject(s), expected %s", n, bounds)
+	}
+	return nil
+}
+
+// checkAttestationSubjects returns an errAttestationSubject if a subject is
+// an attestation, i.e. its name ends in ".intoto.jsonl" or it has the same
+// base name as the attestation file at attPath. Such a subject is usually an
+// attestation from a previous run that was included by mistake, and makes
+// the attestation refer to itself.
+func checkAttestationSubjects(subjects []intoto.Subject, attPath string) error {
+	for _, s := range subjects {
+		name := path.Base(s.Name)
+		if strings.HasSuffix(name, ".intoto.jsonl") || (attPath != "" && name == path.Base(attPath)) {
+			return errors.Errorf(&errAttestationSubject{},
+				"subject %q is an attestation; use --allow-attestation-subjects to attest it anyway", s.Name)
+		}
+	}
+	return nil
+}
+
+// maxAttestationNameLength is the maximum length in bytes of a sanitized
+// attestation file name. It leaves room for the directory on Windows, where
+// paths are limited to 260 characters by default.
+const maxAttestationNameLength = 128
+
+// invalidNameChars are the characters that are not allowed in file names on
+// Windows.
+const invalidNameChars = `<>:"/\|?*`
+
+// windowsReservedNames are the device names that cannot be used as the name of
+// a file, before its extension, on Windows.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// attestationName returns the attestation file name for a subject with the
+// given base name, i.e. <name>.intoto.jsonl. If sanitize is true, characters
+// that are invalid on Windows are replaced by '_', Windows device names are
+// prefixed by '_', and the name is truncated to maxAttestationNameLength with
+// a suffix derived from the digest of name so that it stays unique.
+func attestationName(name string, sanitize bool) string {
+	const ext = ".intoto.jsonl"
+	if !sanitize {
+		return name + ext
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(invalidNameChars, r) {
+			b.WriteRune('_')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	sanitized := b.String()
+
+	stem, _, _ := strings.Cut(sanitized, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		sanitized = "_" + sanitized
+	}
+
+	if len(sanitized)+len(ext) > maxAttestationNameLength {
+		digest := sha256.Sum256([]byte(name))
+		suffix := "-" + hex.EncodeToString(digest[:])[:8]
+		n := maxAttestationNameLength - len(ext) - len(suffix)
+		// Don't split a multi-byte character.
+		for n > 0 && !utf8.RuneStart(sanitized[n]) {
+			n--
+		}
+		sanitized = sanitized[:n] + suffix
+	}
+
+	return sanitized + ext
+}
+
+// errInvalidTemplate indicates an invalid --provenance-name-template.
+type errInvalidTemplate struct {
+	errors.ErrInput
+}
+
+// provenanceNameData is the data of a --provenance-name-template.
+type provenanceNameData struct {
+	// BuildID is the build invocation ID of the provenance.
+	BuildID string
+	// Date is the current UTC date as YYYY-MM-DD.
+	Date string
+	// RunID is the ID of the workflow run.
+	RunID string
+	// Repo is the repository as owner/repo.
+	Repo string
+	// FirstSubjectName is the base name of the first subject.
+	FirstSubjectName string
+}
+
+// parseProvenanceNameTemplate parses a --provenance-name-template.
+func parseProvenanceNameTemplate(text string) (*template.Template, error) {
+	t, err := template.New("provenance-name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Errorf(&errInvalidTemplate{}, "parsing provenance name template: %w", err)
+	}
+	return t, nil
+}
+
+// renderProvenanceName returns the provenance file name rendered from the
+// template. The .intoto.jsonl extension is added if the rendered name does
+// not have it, and the name is sanitized as by attestationName.
+func renderProvenanceName(t *template.Template, data provenanceNameData, sanitize bool) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", errors.Errorf(&errInvalidTemplate{}, "rendering provenance name template: %w", err)
+	}
+	name := b.String()
+	if name == "" {
+		return "", errors.Errorf(&errInvalidTemplate{}, "provenance name template %q renders an empty name",
+			t.Root.String())
+	}
+	return attestationName(strings.TrimSuffix(name, ".intoto.jsonl"), sanitize), nil
+}
+
+// verifyAttestationName checks that the attestation file name matches the
+// subjects. For a single subject, the file name must be the base name of the
+// subject followed by ".intoto.jsonl", or its sanitized form. For multiple
+// subjects, the file name must not be the same as the name of any subject.
+func verifyAttestationName(attPath string, subjects []intoto.Subject) error {
+	name := path.Base(attPath)
+	if len(subjects) == 1 {
+		want := attestationName(path.Base(subjects[0].Name), false)
+		if name != want && name != attestationName(path.Base(subjects[0].Name), true) {
+			return errors.Errorf(&errAttestationName{}, "attestation name %q does not match subject %q: want %q",
+				name, subjects[0].Name, want)
+		}
+		return nil
+	}
+
+	for _, s := range subjects {
+		if name == path.Base(s.Name) {
+			return errors.Errorf(&errAttestationName{}, "attestation name %q collides with subject %q", name, s.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// errHashPattern indicates a path or glob of the 'hash' command that matches
+// no files.
+type errHashPattern struct {
+	errors.ErrInput
+}
+
+// errHashNotFile indicates a path of the 'hash' command that is not a
+// regular file.
+type errHashNotFile struct {
+	errors.ErrInput
+}
+
+// errHashRoundTrip indicates subjects that would not be parsed back the same
+// by --subjects, e.g. because of a name with a newline.
+type errHashRoundTrip struct {
+	errors.ErrInput
+}
+
+// expandHashPatterns returns the files matched by the paths or globs, in
+// order and without duplicates.
+func expandHashPatterns(patterns []string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, errors.Errorf(&errHashPattern{}, "invalid pattern %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf(&errHashPattern{}, "no files match %q", p)
+		}
+		for _, m := range matches {
+			if seen[filepath.Clean(m)] {
+				continue
+			}
+			seen[filepath.Clean(m)] = true
+			paths = append(paths, m)
+		}
+	}
+	return paths, nil
+}
+
+// hashSubjects returns the SHA-256 subjects of the files matched by the paths
+// or globs. The digests are computed as for --artifacts, so files outside of
+// the current directory are refused.
+func hashSubjects(patterns []string) ([]intoto.Subject, error) {
+	paths, err := expandHashPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	subjects, err := subjectsFromPaths(paths, "sha256")
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subjects {
+		if _, ok := s.Digest["sha256"]; !ok {
+			return nil, errors.Errorf(&errHashNotFile{}, "%q is not a regular file", s.Name)
+		}
+	}
+	return subjects, nil
+}
+
+// formatSubjects returns the subjects in the format expected by --subjects,
+// i.e. base64 encoded lines of "<sha256>  <name>" as written by sha256sum.
+// An error is returned if the result would not be parsed back to the same
+// subjects.
+func formatSubjects(subjects []intoto.Subject) (string, error) {
+	var b strings.Builder
+	for _, s := range subjects {
+		fmt.Fprintf(&b, "%s  %s\n", s.Digest["sha256"], s.Name)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(b.String()))
+
+	// NOTE: The subjects are parsed back so that this command and
+	// --subjects can never disagree on the format.
+	parsed, err := parseSubjects(encoded)
+	if err != nil {
+		return "", errors.Errorf(&errHashRoundTrip{}, "formatting subjects: %w", err)
+	}
+	if len(parsed) != len(subjects) {
+		return "", errors.Errorf(&errHashRoundTrip{}, "formatting subjects: got %d subjects back, want %d",
+			len(parsed), len(subjects))
+	}
+	for i := range subjects {
+		if parsed[i].Name != subjects[i].Name || parsed[i].Digest["sha256"] != subjects[i].Digest["sha256"] {
+			return "", errors.Errorf(&errHashRoundTrip{}, "subject name %q can't be used with --subjects",
+				subjects[i].Name)
+		}
+	}
+	return encoded, nil
+}
+
+// hashCmd returns the 'hash' command.
+func hashCmd(check func(error)) *cobra.Command {
+	var outputPath string
+
+	c := &cobra.Command{
+		Use:   "hash <path or glob>...",
+		Short: "Print the subjects of files in the format of --subjects",
+		Long: `Compute the sha256 digest of the files matched by the paths or globs and print
+the base64 encoded subjects expected by 'attest --subjects'. Files outside of
+the current directory are refused.`,
+		Args: cobra.MinimumNArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			subjects, err := hashSubjects(args)
+			check(err)
+
+			encoded, err := formatSubjects(subjects)
+			check(err)
+
+			if outputPath == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), encoded)
+				return
+			}
+
+			// NOTE: The path is untrusted and is validated by
+			// CreateNewFileUnderCurrentDirectory.
+			f, err := utils.CreateNewFileUnderCurrentDirectory(outputPath, os.O_WRONLY)
+			check(err)
+
+			if _, err := f.Write([]byte(encoded)); err != nil {
+				check(errors.Errorf(&errors.ErrFilesystem{}, "writing subjects: %w", err))
+			}
+		},
+	}
+
+	c.Flags().StringVarP(
+		&outputPath, "output", "o", "",
+		"Path to write the subjects to instead of printing them.",
+	)
+	return c
+}
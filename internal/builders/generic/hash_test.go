@@ -0,0 +1,192 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// Test_hashCmd tests that the output of 'hash' is parsed by --subjects to the
+// subjects of the files.
+func Test_hashCmd(t *testing.T) {
+	chdirTemp(t)
+	if err := os.Mkdir("dist", 0o700); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	digest1 := writeTestFile(t, "artifact1", "artifact1")
+	digest2 := writeTestFile(t, "dist/app.tar.gz", "app")
+	digest3 := writeTestFile(t, "dist/my app.zip", "app zip")
+
+	want := []intoto.Subject{
+		{Name: "artifact1", Digest: slsacommon.DigestSet{"sha256": digest1}},
+		{Name: "dist/app.tar.gz", Digest: slsacommon.DigestSet{"sha256": digest2}},
+		{Name: "dist/my app.zip", Digest: slsacommon.DigestSet{"sha256": digest3}},
+	}
+
+	t.Run("stdout", func(t *testing.T) {
+		var out bytes.Buffer
+		c := hashCmd(checkTest(t))
+		c.SetOut(&out)
+		c.SetArgs([]string{"artifact1", "dist/*", "./artifact1"})
+		if err := c.Execute(); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+
+		got, err := parseSubjects(strings.TrimSpace(out.String()))
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("output", func(t *testing.T) {
+		c := hashCmd(checkTest(t))
+		c.SetOut(new(bytes.Buffer))
+		c.SetArgs([]string{"--output", "subjects.b64", "artifact1", "dist/*"})
+		if err := c.Execute(); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+
+		b, err := os.ReadFile("subjects.b64")
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		got, err := parseSubjects(string(b))
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func Test_hashCmd_errors(t *testing.T) {
+	chdirTemp(t)
+	if err := os.Mkdir("dist", 0o700); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	writeTestFile(t, "artifact1", "artifact1")
+	outside := filepath.Join(t.TempDir(), "artifact2")
+	if err := os.WriteFile(outside, []byte("artifact2"), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		args []string
+		err  interface{}
+	}{
+		{
+			name: "no match",
+			args: []string{"*.zip"},
+			err:  new(*errHashPattern),
+		},
+		{
+			name: "missing file",
+			args: []string{"missing"},
+			err:  new(*errHashPattern),
+		},
+		{
+			name: "directory",
+			args: []string{"dist"},
+			err:  new(*errHashNotFile),
+		},
+		{
+			name: "outside current directory",
+			args: []string{outside},
+			err:  new(*utils.ErrInvalidPath),
+		},
+		{
+			name: "output outside current directory",
+			args: []string{"--output", "../subjects.b64", "artifact1"},
+			err:  new(*utils.ErrInvalidPath),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(err error) {
+				if err != nil {
+					if !errors.As(err, tc.err) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := hashCmd(check)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Fatalf("expected an error to occur.")
+		})
+	}
+}
+
+func Test_formatSubjects_roundTrip(t *testing.T) {
+	digest := strings.Repeat("a", 64)
+	testCases := []struct {
+		name string
+		err  bool
+	}{
+		{name: "dist/app.tar.gz"},
+		{name: "name with spaces"},
+		{name: "name\nwith newline", err: true},
+		{name: " leading space", err: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			subjects := []intoto.Subject{{Name: tc.name, Digest: slsacommon.DigestSet{"sha256": digest}}}
+			encoded, err := formatSubjects(subjects)
+			if tc.err {
+				if !errors.As(err, new(*errHashRoundTrip)) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			got, err := parseSubjects(encoded)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(subjects, got); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// imageLayerURIPrefix is the prefix of the URI of image layer materials.
+const imageLayerURIPrefix = "oci://layer/"
+
+// errImageManifest indicates an image manifest that cannot be parsed.
+type errImageManifest struct {
+	errors.ErrInput
+}
+
+// imageLayerMaterials returns a material for each layer of the OCI image
+// manifest at path. The URI of each material is oci://layer/<digest>. Since
+// materials have no media type field, the layer media type is recorded in
+// the mediaType query parameter of the URI.
+func imageLayerMaterials(path string) ([]slsacommon.ProvenanceMaterial, error) {
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errImageManifest{}, "reading image manifest: %w", err)
+	}
+
+	var m ocispec.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Errorf(&errImageManifest{}, "parsing image manifest %q: %w", path, err)
+	}
+	if len(m.Layers) == 0 {
+		return nil, errors.Errorf(&errImageManifest{}, "image manifest %q has no layers", path)
+	}
+
+	materials := make([]slsacommon.ProvenanceMaterial, 0, len(m.Layers))
+	for i, l := range m.Layers {
+		if err := l.Digest.Validate(); err != nil {
+			return nil, errors.Errorf(&errImageManifest{}, "invalid digest of layer %d in image manifest %q: %w", i, path, err)
+		}
+		uri := imageLayerURIPrefix + l.Digest.String()
+		if l.MediaType != "" {
+			uri += "?" + url.Values{"mediaType": {l.MediaType}}.Encode()
+		}
+		materials = append(materials, slsacommon.ProvenanceMaterial{
+			URI: uri,
+			Digest: slsacommon.DigestSet{
+				l.Digest.Algorithm().String(): l.Digest.Encoded(),
+			},
+		})
+	}
+	return materials, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// testImageLayerMaterials are the materials of the layers of
+// testdata/image/manifest.json.
+var testImageLayerMaterials = []slsacommon.ProvenanceMaterial{
+	{
+		URI:    "oci://layer/sha256:b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c?mediaType=application%2Fvnd.oci.image.layer.v1.tar%2Bgzip",
+		Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+	},
+	{
+		URI:    "oci://layer/sha256:7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730?mediaType=application%2Fvnd.oci.image.layer.v1.tar",
+		Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+	},
+}
+
+func Test_imageLayerMaterials(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		expected []slsacommon.ProvenanceMaterial
+		err      interface{}
+	}{
+		{
+			name:     "two layers",
+			path:     "testdata/image/manifest.json",
+			expected: testImageLayerMaterials,
+		},
+		{
+			name: "invalid digest",
+			path: "testdata/image/invalid-digest.json",
+			err:  new(*errImageManifest),
+		},
+		{
+			name: "not a manifest",
+			path: "testdata/matrix/output.txt",
+			err:  new(*errImageManifest),
+		},
+		{
+			name: "missing file",
+			path: "testdata/image/missing.json",
+			err:  new(*errImageManifest),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			materials, err := imageLayerMaterials(tc.path)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, materials); diff != "" {
+				t.Errorf("unexpected materials (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_generateCmd_image_manifest tests that the layers of --image-manifest
+// are recorded as materials.
+func Test_generateCmd_image_manifest(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	manifest, err := os.ReadFile("testdata/image/manifest.json")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	chdirTemp(t)
+	writeTestFile(t, "manifest.json", string(manifest))
+
+	c := generateCmd(&slsa.NilClientProvider{}, checkTest(t))
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--image-manifest", "manifest.json",
+		"--output", "artifact1.json",
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	b, err := os.ReadFile("artifact1.json")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var p struct {
+		Predicate struct {
+			Materials []slsacommon.ProvenanceMaterial `json:"materials"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var layers []slsacommon.ProvenanceMaterial
+	for _, m := range p.Predicate.Materials {
+		if strings.HasPrefix(m.URI, imageLayerURIPrefix) {
+			layers = append(layers, m)
+		}
+	}
+	if diff := cmp.Diff(testImageLayerMaterials, layers); diff != "" {
+		t.Errorf("unexpected materials (-want +got):\n%s", diff)
+	}
+}
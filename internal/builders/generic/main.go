@@ -16,27 +16,53 @@ package main
 
 import (
 	"errors"
+	"os"
 
 	// TODO: Allow use of other OIDC providers?
 	// Enable the github OIDC auth provider.
 	_ "github.com/sigstore/cosign/pkg/providers/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/httpclient"
 	"github.com/slsa-framework/slsa-github-generator/signing/sigstore"
 
 	"github.com/spf13/cobra"
 )
 
 func rootCmd() *cobra.Command {
+	var caBundle string
 	c := &cobra.Command{
 		Use:   "slsa-generator-generic",
 		Short: "Generate SLSA provenance for Github Actions",
 		Long: `Generate SLSA provenance for Github Actions.
 For more information on SLSA, visit https://slsa.dev`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if caBundle != "" {
+				if err := os.Setenv(httpclient.CABundleEnvKey, caBundle); err != nil {
+					return err
+				}
+			}
+			// NOTE: The Fulcio client doesn't accept an HTTP client so the
+			// default transport is replaced instead.
+			return httpclient.Install()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return errors.New("expected command")
 		},
 	}
+	c.PersistentFlags().StringVar(
+		&caBundle, "ca-bundle", "",
+		"Path to a PEM file of extra CA certificates to trust for Fulcio, Rekor and GitHub API connections. "+
+			"Defaults to the "+httpclient.CABundleEnvKey+" environment variable.",
+	)
 	c.AddCommand(versionCmd())
-	c.AddCommand(attestCmd(nil, checkExit, sigstore.NewDefaultFulcio(), sigstore.NewDefaultRekor()))
+	c.AddCommand(attestCmd(nil, checkExit, sigstore.NewDefaultFulcio(), sigstore.NewDefaultRekor(), newRemoteRegistry))
+	c.AddCommand(generateCmd(nil, checkExit))
+	c.AddCommand(attestSBOMCmd(checkExit, sigstore.NewDefaultFulcio(), sigstore.NewDefaultRekor()))
+	c.AddCommand(configCmd(checkExit))
+	c.AddCommand(compareCmd(checkExit))
+	c.AddCommand(hashCmd(checkExit))
+	c.AddCommand(fetchCmd(checkExit, sigstore.NewDefaultRekor()))
+	c.AddCommand(reportCmd(checkExit))
+	c.AddCommand(verifyCmd(checkExit))
 	return c
 }
 
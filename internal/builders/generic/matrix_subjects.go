@@ -0,0 +1,165 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// defaultMatrixOutputKey is the default key of the matrix output entries
+// holding subjects.
+const defaultMatrixOutputKey = "digest"
+
+// errMatrixOutput indicates a matrix output file that cannot be parsed.
+type errMatrixOutput struct {
+	errors.ErrInput
+}
+
+// subjectsFromMatrixOutput returns the subjects listed in the entries with
+// the given key of a GitHub Actions matrix output file. The file is either
+// JSON, e.g. the output of toJSON(needs), in which case entries with the key
+// are searched at any depth, or key=value lines in the format of
+// $GITHUB_OUTPUT. Each entry holds subjects in the same format as sha256sum,
+// optionally base64 encoded.
+func subjectsFromMatrixOutput(path, key string) ([]intoto.Subject, error) {
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(path); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errMatrixOutput{}, "reading matrix output file: %w", err)
+	}
+
+	var values []string
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var v interface{}
+		if err := json.Unmarshal(trimmed, &v); err != nil {
+			return nil, errors.Errorf(&errMatrixOutput{}, "parsing matrix output file %q: %w", path, err)
+		}
+		values = matrixJSONValues(v, key)
+	} else {
+		values, err = matrixOutputValues(b, key)
+		if err != nil {
+			return nil, errors.Errorf(&errMatrixOutput{}, "parsing matrix output file %q: %w", path, err)
+		}
+	}
+	if len(values) == 0 {
+		return nil, errors.Errorf(&errNoSubjects{}, "no %q entries in matrix output file %q", key, path)
+	}
+
+	for i, v := range values {
+		values[i] = decodeMatrixValue(v)
+	}
+	return parseSubjectsReader(strings.NewReader(strings.Join(values, "\n")))
+}
+
+// matrixJSONValues returns the string values of the entries with the given
+// key in v, at any depth. Values of object entries are returned in key order
+// so that the result is deterministic.
+func matrixJSONValues(v interface{}, key string) []string {
+	var values []string
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if s, ok := v[k].(string); ok && k == key {
+				values = append(values, s)
+				continue
+			}
+			values = append(values, matrixJSONValues(v[k], key)...)
+		}
+	case []interface{}:
+		for _, e := range v {
+			values = append(values, matrixJSONValues(e, key)...)
+		}
+	}
+	return values
+}
+
+// matrixOutputValues returns the values of the entries with the given key in
+// b, in the format of $GITHUB_OUTPUT: name=value lines, or multiline values
+// written as name<<DELIMITER followed by the value and the delimiter.
+func matrixOutputValues(b []byte, key string) ([]string, error) {
+	var values []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if name, delim, ok := strings.Cut(line, "<<"); ok && !strings.Contains(name, "=") {
+			var lines []string
+			closed := false
+			for scanner.Scan() {
+				if scanner.Text() == delim {
+					closed = true
+					break
+				}
+				lines = append(lines, scanner.Text())
+			}
+			if !closed {
+				return nil, errors.Errorf(&errMatrixOutput{}, "missing delimiter %q for %q", delim, name)
+			}
+			if name == key {
+				values = append(values, strings.Join(lines, "\n"))
+			}
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, errors.Errorf(&errMatrixOutput{}, "expected name=value, got %q", line)
+		}
+		if name == key {
+			values = append(values, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// decodeMatrixValue returns the base64 decoded value, if it is base64
+// encoded, or else the value as is. sha256sum lines always contain
+// whitespace, so they are never mistaken for base64.
+func decodeMatrixValue(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.ContainsAny(v, " \t\n") {
+		return v
+	}
+	if b, err := base64.StdEncoding.DecodeString(v); err == nil {
+		return string(b)
+	}
+	return v
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+func Test_subjectsFromMatrixOutput(t *testing.T) {
+	want := []intoto.Subject{
+		{
+			Name:   "artifact-linux-amd64",
+			Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+		},
+		{
+			Name:   "artifact-linux-arm64",
+			Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		key      string
+		expected []intoto.Subject
+		err      interface{}
+	}{
+		{
+			name:     "json",
+			path:     "testdata/matrix/needs.json",
+			key:      "digest",
+			expected: want,
+		},
+		{
+			name:     "name=value lines",
+			path:     "testdata/matrix/output.txt",
+			key:      "digest",
+			expected: want,
+		},
+		{
+			name: "custom key",
+			path: "testdata/matrix/output.txt",
+			key:  "hashes",
+			err:  new(*errNoSubjects),
+		},
+		{
+			name: "no matching keys",
+			path: "testdata/matrix/no-digest.txt",
+			key:  "digest",
+			err:  new(*errNoSubjects),
+		},
+		{
+			name: "invalid subjects",
+			path: "testdata/matrix/needs.json",
+			key:  "name",
+			err:  new(*errSha),
+		},
+		{
+			name: "missing file",
+			path: "testdata/matrix/missing.txt",
+			key:  "digest",
+			err:  new(*errMatrixOutput),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			subjects, err := subjectsFromMatrixOutput(tc.path, tc.key)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, subjects); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_matrixOutputValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "single line",
+			output:   "digest=abc  foo\nname=foo\n",
+			expected: []string{"abc  foo"},
+		},
+		{
+			name:     "multiline",
+			output:   "digest<<EOF\nabc  foo\ndef  bar\nEOF\n",
+			expected: []string{"abc  foo\ndef  bar"},
+		},
+		{
+			name:   "missing delimiter",
+			output: "digest<<EOF\nabc  foo\n",
+			err:    true,
+		},
+		{
+			name:   "not name=value",
+			output: "abc  foo\n",
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := matrixOutputValues([]byte(tc.output), "digest")
+			if tc.err {
+				if !errors.As(err, new(*errMatrixOutput)) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, values); diff != "" {
+				t.Errorf("unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+// digestStrength orders the supported digest algorithms by strength.
+var digestStrength = map[string]int{
+	"sha1":   1,
+	"sha256": 2,
+	"sha384": 3,
+	"sha512": 4,
+
+	// dirHash1 digests are built from sha256 digests.
+	dirHashAlgorithm: 2,
+}
+
+// errPolicy indicates an invalid policy file.
+type errPolicy struct {
+	errors.ErrInput
+}
+
+// errPolicyViolation indicates that the subjects do not satisfy the policy.
+type errPolicyViolation struct {
+	errors.ErrInput
+}
+
+// policy lists requirements that the subjects of a provenance must satisfy.
+// Policy files can be written in YAML or JSON.
+type policy struct {
+	// RequiredSubjects are glob patterns that must each match at least one
+	// subject. Patterns are matched against the subject name and its base
+	// name.
+	RequiredSubjects []string `yaml:"required_subjects"`
+
+	// MinDigestAlgorithm is the weakest digest algorithm accepted for
+	// subjects. Every subject must have a digest at least this strong.
+	MinDigestAlgorithm string `yaml:"min_digest_algorithm"`
+}
+
+// loadPolicy reads and validates the policy file at the given path.
+func loadPolicy(p string) (*policy, error) {
+	if err := utils.PathIsUnderCurrentDirectory(p); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(filepath.Clean(p))
+	if err != nil {
+		return nil, errors.Errorf(&errPolicy{}, "reading policy file: %w", err)
+	}
+
+	return parsePolicy(b)
+}
+
+// parsePolicy parses and validates a YAML or JSON policy.
+func parsePolicy(b []byte) (*policy, error) {
+	var pol policy
+	if err := yaml.Unmarshal(b, &pol); err != nil {
+		return nil, errors.Errorf(&errPolicy{}, "parsing policy: %w", err)
+	}
+
+	for _, pattern := range pol.RequiredSubjects {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Errorf(&errPolicy{}, "invalid subject pattern %q: %w", pattern, err)
+		}
+	}
+
+	if pol.MinDigestAlgorithm != "" {
+		if _, ok := digestStrength[pol.MinDigestAlgorithm]; !ok {
+			return nil, errors.Errorf(&errPolicy{}, "unsupported digest algorithm %q", pol.MinDigestAlgorithm)
+		}
+	}
+
+	return &pol, nil
+}
+
+// check returns an error if the subjects do not satisfy the policy.
+func (pol *policy) check(subjects []intoto.Subject) error {
+	for _, pattern := range pol.RequiredSubjects {
+		if !anySubjectMatches(pattern, subjects) {
+			return errors.Errorf(&errPolicyViolation{}, "no subject matches required pattern %q", pattern)
+		}
+	}
+
+	if pol.MinDigestAlgorithm != "" {
+		min := digestStrength[pol.MinDigestAlgorithm]
+		for _, s := range subjects {
+			ok := false
+			for alg := range s.Digest {
+				if digestStrength[alg] >= min {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				return errors.Errorf(&errPolicyViolation{}, "subject %q has no digest at least as strong as %s",
+					s.Name, pol.MinDigestAlgorithm)
+			}
+		}
+	}
+
+	return nil
+}
+
+// anySubjectMatches returns whether the pattern matches the name or base
+// name of any subject.
+func anySubjectMatches(pattern string, subjects []intoto.Subject) bool {
+	for _, s := range subjects {
+		// NOTE: The pattern was validated by parsePolicy.
+		if ok, _ := path.Match(pattern, s.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(s.Name)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+func Test_parsePolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+		err    bool
+	}{
+		{
+			name: "yaml",
+			policy: `required_subjects:
+  - "*.tar.gz"
+min_digest_algorithm: sha256
+`,
+		},
+		{
+			name:   "json",
+			policy: `{"required_subjects": ["*.tar.gz"], "min_digest_algorithm": "sha512"}`,
+		},
+		{
+			name:   "empty",
+			policy: "",
+		},
+		{
+			name:   "invalid pattern",
+			policy: `required_subjects: ["[a-"]`,
+			err:    true,
+		},
+		{
+			name:   "unsupported digest algorithm",
+			policy: `min_digest_algorithm: md5`,
+			err:    true,
+		},
+		{
+			name:   "invalid yaml",
+			policy: `required_subjects: "*.tar.gz`,
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parsePolicy([]byte(tc.policy))
+			errPol := &errPolicy{}
+			if got := errors.As(err, &errPol); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func Test_policy_check(t *testing.T) {
+	tarball := intoto.Subject{
+		Name:   "dist/foo.tar.gz",
+		Digest: map[string]string{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+	}
+	binary := intoto.Subject{
+		Name:   "foo",
+		Digest: map[string]string{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+	}
+
+	testCases := []struct {
+		name      string
+		policy    policy
+		subjects  []intoto.Subject
+		violation bool
+	}{
+		{
+			name:     "required subject present",
+			policy:   policy{RequiredSubjects: []string{"*.tar.gz"}},
+			subjects: []intoto.Subject{binary, tarball},
+		},
+		{
+			name:      "required subject missing",
+			policy:    policy{RequiredSubjects: []string{"*.tar.gz"}},
+			subjects:  []intoto.Subject{binary},
+			violation: true,
+		},
+		{
+			name:     "full name pattern",
+			policy:   policy{RequiredSubjects: []string{"dist/*"}},
+			subjects: []intoto.Subject{tarball},
+		},
+		{
+			name:     "digest strong enough",
+			policy:   policy{MinDigestAlgorithm: "sha1"},
+			subjects: []intoto.Subject{binary, tarball},
+		},
+		{
+			name:      "digest too weak",
+			policy:    policy{MinDigestAlgorithm: "sha512"},
+			subjects:  []intoto.Subject{binary, tarball},
+			violation: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.check(tc.subjects)
+			errViolation := &errPolicyViolation{}
+			if got := errors.As(err, &errViolation); got != tc.violation {
+				t.Errorf("unexpected error, want violation: %v, got: %v", tc.violation, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	// mediaTypeInToto is the media type of the attestation layer.
+	mediaTypeInToto = "application/vnd.in-toto+json"
+
+	// attestationArtifactType is the artifact type of the manifest that
+	// attaches the attestation to the image.
+	attestationArtifactType = "application/vnd.slsa.provenance.config.v1+json"
+)
+
+// errInvalidImageRef indicates an image reference that can't be parsed.
+type errInvalidImageRef struct {
+	errors.ErrInput
+}
+
+// errRegistry is a generic error interacting with the OCI registry.
+type errRegistry struct {
+	errors.WrappableError
+}
+
+// registryClient is the OCI repository client used to attach attestations to
+// an image.
+type registryClient interface {
+	content.Pusher
+
+	// Resolve returns the descriptor of the manifest with the given tag or
+	// digest.
+	Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error)
+}
+
+// registryProvider returns the client for a repository, e.g.
+// "ghcr.io/org/image".
+type registryProvider func(repository string) (registryClient, error)
+
+// newRemoteRegistry returns a client for a remote repository. The
+// REGISTRY_USERNAME and REGISTRY_PASSWORD environment variables are used as
+// credentials if set.
+func newRemoteRegistry(repository string) (registryClient, error) {
+	repo, err := remote.NewRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+	if username := os.Getenv("REGISTRY_USERNAME"); username != "" {
+		repo.Client = &auth.Client{
+			Client: auth.DefaultClient.Client,
+			Cache:  auth.NewCache(),
+			Credential: auth.StaticCredential(repo.Reference.Registry, auth.Credential{
+				Username: username,
+				Password: os.Getenv("REGISTRY_PASSWORD"),
+			}),
+		}
+	}
+	return repo, nil
+}
+
+// pushAttestation pushes attBytes as an application/vnd.in-toto+json layer
+// of a manifest whose subject is the image at imageRef. The image reference
+// must include a tag or digest. Registries that support the referrers API
+// list the manifest as a referrer of the image.
+func pushAttestation(ctx context.Context, newClient registryProvider, imageRef string, attBytes []byte) (ocispec.Descriptor, error) {
+	ref, err := registry.ParseReference(imageRef)
+	if err != nil {
+		return ocispec.Descriptor{}, errors.Errorf(&errInvalidImageRef{}, "invalid image reference %q: %w", imageRef, err)
+	}
+	if ref.Reference == "" {
+		return ocispec.Descriptor{}, errors.Errorf(&errInvalidImageRef{}, "image reference %q has no tag or digest", imageRef)
+	}
+
+	client, err := newClient(ref.Registry + "/" + ref.Repository)
+	if err != nil {
+		return ocispec.Descriptor{}, errors.Errorf(&errRegistry{}, "creating registry client: %w", err)
+	}
+
+	subject, err := client.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return ocispec.Descriptor{}, errors.Errorf(&errRegistry{}, "resolving %q: %w", imageRef, err)
+	}
+
+	layer := content.NewDescriptorFromBytes(mediaTypeInToto, attBytes)
+	if err := client.Push(ctx, layer, bytes.NewReader(attBytes)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return ocispec.Descriptor{}, errors.Errorf(&errRegistry{}, "pushing attestation: %w", err)
+	}
+
+	// NOTE: An image manifest is used rather than an artifact manifest since
+	// more registries accept it.
+	desc, err := oras.Pack(ctx, client, attestationArtifactType, []ocispec.Descriptor{layer}, oras.PackOptions{
+		Subject:           &subject,
+		PackImageManifest: true,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, errors.Errorf(&errRegistry{}, "pushing attestation manifest: %w", err)
+	}
+	return desc, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// testRegistry is a registryClient that records the pushed content.
+type testRegistry struct {
+	repository string
+	subject    ocispec.Descriptor
+	pushed     []ocispec.Descriptor
+	contents   map[string][]byte
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{
+		subject:  content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, []byte("image")),
+		contents: map[string][]byte{},
+	}
+}
+
+func (r *testRegistry) provider(repository string) (registryClient, error) {
+	r.repository = repository
+	return r, nil
+}
+
+func (r *testRegistry) Resolve(_ context.Context, reference string) (ocispec.Descriptor, error) {
+	if reference != "v1" && reference != r.subject.Digest.String() {
+		return ocispec.Descriptor{}, errors.New("not found")
+	}
+	return r.subject, nil
+}
+
+func (r *testRegistry) Push(_ context.Context, desc ocispec.Descriptor, c io.Reader) error {
+	b, err := io.ReadAll(c)
+	if err != nil {
+		return err
+	}
+	r.pushed = append(r.pushed, desc)
+	r.contents[desc.Digest.String()] = b
+	return nil
+}
+
+func Test_pushAttestation(t *testing.T) {
+	att := []byte(`{"payloadType": "application/vnd.in-toto+json"}`)
+
+	r := newTestRegistry()
+	desc, err := pushAttestation(context.Background(), r.provider, "ghcr.io/org/image:v1", att)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	if want, got := "ghcr.io/org/image", r.repository; want != got {
+		t.Errorf("unexpected repository, want: %q, got: %q", want, got)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(r.contents[desc.Digest.String()], &manifest); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := ocispec.MediaTypeImageManifest, desc.MediaType; want != got {
+		t.Errorf("unexpected manifest media type, want: %q, got: %q", want, got)
+	}
+	if manifest.Subject == nil || manifest.Subject.Digest != r.subject.Digest {
+		t.Errorf("unexpected subject, want: %v, got: %v", r.subject, manifest.Subject)
+	}
+	if len(manifest.Layers) != 1 {
+		t.Fatalf("unexpected number of layers, want: 1, got: %d", len(manifest.Layers))
+	}
+	layer := manifest.Layers[0]
+	if want, got := mediaTypeInToto, layer.MediaType; want != got {
+		t.Errorf("unexpected layer media type, want: %q, got: %q", want, got)
+	}
+	if want, got := string(att), string(r.contents[layer.Digest.String()]); want != got {
+		t.Errorf("unexpected layer, want: %q, got: %q", want, got)
+	}
+}
+
+func Test_pushAttestation_errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		ref  string
+		err  interface{}
+	}{
+		{
+			name: "no tag or digest",
+			ref:  "ghcr.io/org/image",
+			err:  new(*errInvalidImageRef),
+		},
+		{
+			name: "invalid reference",
+			ref:  "not a reference",
+			err:  new(*errInvalidImageRef),
+		},
+		{
+			name: "unknown tag",
+			ref:  "ghcr.io/org/image:v2",
+			err:  new(*errRegistry),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRegistry()
+			_, err := pushAttestation(context.Background(), r.provider, tc.ref, []byte("{}"))
+			if !errors.As(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(r.pushed) != 0 {
+				t.Errorf("unexpected push before error")
+			}
+		})
+	}
+}
+
+// Test_attestCmd_push_to_registry tests that the signed provenance is pushed
+// as an in-toto layer.
+func Test_attestCmd_push_to_registry(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	dir := chdirTemp(t)
+
+	r := newTestRegistry()
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, r.provider)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--push-to-registry", "ghcr.io/org/image:v1",
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	att, err := os.ReadFile(filepath.Join(dir, "artifact1.intoto.jsonl"))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var layers []ocispec.Descriptor
+	for _, desc := range r.pushed {
+		if desc.MediaType == mediaTypeInToto {
+			layers = append(layers, desc)
+		}
+	}
+	if len(layers) != 1 {
+		t.Fatalf("unexpected number of in-toto layers, want: 1, got: %d", len(layers))
+	}
+	if want, got := string(att), string(r.contents[layers[0].Digest.String()]); want != got {
+		t.Errorf("unexpected layer, want: %q, got: %q", want, got)
+	}
+}
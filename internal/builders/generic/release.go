@@ -0,0 +1,189 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	githubapi "github.com/google/go-github/v50/github"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// errReleaseNotFound indicates that the release could not be found.
+type errReleaseNotFound struct {
+	errors.WrappableError
+}
+
+// errDraftRelease indicates that the release is a draft.
+type errDraftRelease struct {
+	errors.WrappableError
+}
+
+// errReleasePermission indicates that the token does not have permission to
+// upload release assets.
+type errReleasePermission struct {
+	errors.WrappableError
+}
+
+// errReleaseAssetExists indicates that a release asset with the same name
+// already exists.
+type errReleaseAssetExists struct {
+	errors.WrappableError
+}
+
+// errRelease is a generic error interacting with the GitHub releases API.
+type errRelease struct {
+	errors.WrappableError
+}
+
+// releaseUploader uploads attestations to a GitHub release.
+type releaseUploader struct {
+	clients slsa.ClientProvider
+
+	// owner is the owner of the repository.
+	owner string
+
+	// repo is the name of the repository.
+	repo string
+
+	// release is the tag name or ID of the release.
+	release string
+
+	// overwrite specifies whether an existing asset with the same name
+	// should be replaced.
+	overwrite bool
+}
+
+// newReleaseUploader returns a releaseUploader for the release in the given
+// repository. The repository should be given in the "owner/name" format.
+func newReleaseUploader(clients slsa.ClientProvider, repository, release string, overwrite bool) (*releaseUploader, error) {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) < 2 {
+		return nil, errors.Errorf(&errRelease{}, "unexpected repository: %q", repository)
+	}
+	return &releaseUploader{
+		clients:   clients,
+		owner:     parts[0],
+		repo:      parts[1],
+		release:   release,
+		overwrite: overwrite,
+	}, nil
+}
+
+// statusCode returns the HTTP status code of the response or zero if not
+// available.
+func statusCode(resp *githubapi.Response) int {
+	if resp == nil || resp.Response == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// getRelease retrieves the release by tag name. Tags may be numeric, e.g.
+// 20230101, so a numeric release is only looked up by ID if there is no
+// release with that tag.
+func (u *releaseUploader) getRelease(ctx context.Context, ghClient *githubapi.Client) (*githubapi.RepositoryRelease, error) {
+	r, resp, err := ghClient.Repositories.GetReleaseByTag(ctx, u.owner, u.repo, u.release)
+	if id, convErr := strconv.ParseInt(u.release, 10, 64); convErr == nil && statusCode(resp) == http.StatusNotFound {
+		r, resp, err = ghClient.Repositories.GetRelease(ctx, u.owner, u.repo, id)
+	}
+	if err != nil {
+		switch statusCode(resp) {
+		case http.StatusNotFound:
+			return nil, errors.Errorf(&errReleaseNotFound{}, "release %q not found in %s/%s: %w", u.release, u.owner, u.repo, err)
+		case http.StatusForbidden, http.StatusUnauthorized:
+			return nil, errors.Errorf(&errReleasePermission{}, "reading release %q; does your workflow have `contents: write` scope?: %w", u.release, err)
+		default:
+			return nil, errors.Errorf(&errRelease{}, "getting release %q: %w", u.release, err)
+		}
+	}
+	if r.GetDraft() {
+		return nil, errors.Errorf(&errDraftRelease{}, "release %q is a draft", u.release)
+	}
+	return r, nil
+}
+
+// Upload uploads the file at the given path as a release asset. The asset
+// name is the base name of the file. Upload is a no-op if no GitHub client
+// is available.
+func (u *releaseUploader) Upload(ctx context.Context, path string) error {
+	ghClient, err := u.clients.GithubClient(ctx)
+	if err != nil {
+		return errors.Errorf(&errRelease{}, "github client: %w", err)
+	}
+	if ghClient == nil {
+		return nil
+	}
+
+	r, err := u.getRelease(ctx, ghClient)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+
+	// Check for an existing asset with the same name.
+	opts := &githubapi.ListOptions{PerPage: 100}
+	for {
+		assets, resp, err := ghClient.Repositories.ListReleaseAssets(ctx, u.owner, u.repo, r.GetID(), opts)
+		if err != nil {
+			return errors.Errorf(&errRelease{}, "listing release assets: %w", err)
+		}
+		for _, a := range assets {
+			if a.GetName() != name {
+				continue
+			}
+			if !u.overwrite {
+				return errors.Errorf(&errReleaseAssetExists{}, "release asset %q already exists", name)
+			}
+			resp, err := ghClient.Repositories.DeleteReleaseAsset(ctx, u.owner, u.repo, a.GetID())
+			if err != nil {
+				if code := statusCode(resp); code == http.StatusForbidden || code == http.StatusUnauthorized {
+					return errors.Errorf(&errReleasePermission{}, "deleting release asset %q; does your workflow have `contents: write` scope?: %w", name, err)
+				}
+				return errors.Errorf(&errRelease{}, "deleting release asset %q: %w", name, err)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return errors.Errorf(&errRelease{}, "opening %q: %w", path, err)
+	}
+	defer f.Close()
+
+	_, resp, err := ghClient.Repositories.UploadReleaseAsset(ctx, u.owner, u.repo, r.GetID(), &githubapi.UploadOptions{
+		Name: name,
+	}, f)
+	if err != nil {
+		if code := statusCode(resp); code == http.StatusForbidden || code == http.StatusUnauthorized {
+			return errors.Errorf(&errReleasePermission{}, "uploading release asset %q; does your workflow have `contents: write` scope?: %w", name, err)
+		}
+		return errors.Errorf(&errRelease{}, "uploading release asset %q: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	githubapi "github.com/google/go-github/v50/github"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// checksumSuffix is the suffix of the sidecar asset with the checksum of a
+// release asset.
+const checksumSuffix = ".sha256"
+
+// maxChecksumSize is the maximum size of a checksum asset.
+const maxChecksumSize = 4096
+
+// errReleaseSpec indicates an invalid --subjects-from-github-release value.
+type errReleaseSpec struct {
+	errors.ErrInput
+}
+
+// errMissingGithubToken indicates that no token was given to access the
+// GitHub API.
+type errMissingGithubToken struct {
+	errors.ErrInput
+}
+
+// parseReleaseSpec parses a release of the form owner/repo@tag.
+func parseReleaseSpec(spec string) (owner, repo, tag string, err error) {
+	repository, tag, ok := strings.Cut(spec, "@")
+	owner, repo, ok2 := strings.Cut(repository, "/")
+	if !ok || !ok2 || owner == "" || repo == "" || tag == "" || strings.Contains(repo, "/") {
+		return "", "", "", errors.Errorf(&errReleaseSpec{}, "invalid release %q, expected owner/repo@tag", spec)
+	}
+	return owner, repo, tag, nil
+}
+
+// subjectsFromRelease returns a subject for each asset of the release given
+// as owner/repo@tag. The digest of an asset is read from its <name>.sha256
+// sidecar asset, which is in the same format as sha256sum. Assets without a
+// sidecar are skipped with a warning written to w.
+func subjectsFromRelease(ctx context.Context, ghClient *githubapi.Client, spec string, w io.Writer) ([]intoto.Subject, error) {
+	owner, repo, tag, err := parseReleaseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if ghClient == nil {
+		return nil, errors.Errorf(&errMissingGithubToken{}, "no GitHub client available to read release %q", spec)
+	}
+
+	r, _, err := ghClient.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	if err != nil {
+		return nil, errors.Errorf(&errRelease{}, "getting release %q: %w", spec, err)
+	}
+
+	var assets []*githubapi.ReleaseAsset
+	opts := &githubapi.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := ghClient.Repositories.ListReleaseAssets(ctx, owner, repo, r.GetID(), opts)
+		if err != nil {
+			return nil, errors.Errorf(&errRelease{}, "listing assets of release %q: %w", spec, err)
+		}
+		assets = append(assets, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	checksums := map[string]*githubapi.ReleaseAsset{}
+	for _, a := range assets {
+		if strings.HasSuffix(a.GetName(), checksumSuffix) {
+			checksums[a.GetName()] = a
+		}
+	}
+
+	var subjects []intoto.Subject
+	for _, a := range assets {
+		name := a.GetName()
+		if strings.HasSuffix(name, checksumSuffix) {
+			continue
+		}
+		c, ok := checksums[name+checksumSuffix]
+		if !ok {
+			github.Warning(w, nil, fmt.Sprintf("no %s asset for %q, skipping", checksumSuffix, name))
+			continue
+		}
+		digest, err := downloadChecksum(ctx, ghClient, owner, repo, c)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, intoto.Subject{
+			Name: name,
+			Digest: slsacommon.DigestSet{
+				"sha256": digest,
+			},
+		})
+	}
+	return subjects, nil
+}
+
+// downloadChecksum returns the digest in a checksum asset. The file name in
+// the asset, if any, is ignored.
+func downloadChecksum(ctx context.Context, ghClient *githubapi.Client, owner, repo string, a *githubapi.ReleaseAsset) (string, error) {
+	rc, _, err := ghClient.Repositories.DownloadReleaseAsset(ctx, owner, repo, a.GetID(), http.DefaultClient)
+	if err != nil {
+		return "", errors.Errorf(&errRelease{}, "downloading %q: %w", a.GetName(), err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(io.LimitReader(rc, maxChecksumSize))
+	if err != nil {
+		return "", errors.Errorf(&errRelease{}, "downloading %q: %w", a.GetName(), err)
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return "", errors.Errorf(&errSha{}, "empty checksum asset %q", a.GetName())
+	}
+	digest := strings.ToLower(fields[0])
+	if !shaCheck.MatchString(digest) {
+		return "", errors.Errorf(&errSha{}, "unexpected sha256 hash format in %q", a.GetName())
+	}
+	return digest, nil
+}
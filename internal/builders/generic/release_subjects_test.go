@@ -0,0 +1,195 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	githubapi "github.com/google/go-github/v50/github"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	testReleaseDigest1 = "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"
+	testReleaseDigest2 = "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"
+)
+
+// testReleaseAssets are the assets of the release served by
+// newFakeReleaseAssetsServer, by name.
+var testReleaseAssets = map[string]string{
+	"artifact1.tar.gz":        "",
+	"artifact1.tar.gz.sha256": testReleaseDigest1 + "  artifact1.tar.gz\n",
+	"artifact2.zip":           "",
+	"artifact2.zip.sha256":    strings.ToUpper(testReleaseDigest2) + "\n",
+	"no-checksum.txt":         "",
+}
+
+// newFakeReleaseAssetsServer returns a fake GitHub API server serving the
+// v1.0.0 release of owner/repo with the given assets. Assets are listed two
+// per page.
+func newFakeReleaseAssetsServer(t *testing.T, assets map[string]string) *githubapi.Client {
+	var names []string
+	for name := range assets {
+		names = append(names, name)
+	}
+	// Sort so that asset IDs are stable.
+	sort.Strings(names)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		if _, err := fmt.Sscanf(r.URL.Path, "/repos/owner/repo/releases/assets/%d", &id); err == nil {
+			if id < 100 || id-100 >= len(names) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			fmt.Fprint(w, assets[names[id-100]])
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/repos/owner/repo/releases/tags/v1.0.0":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0.0"}`)
+		case r.URL.Path == "/repos/owner/repo/releases/1/assets":
+			page := 1
+			if p := r.URL.Query().Get("page"); p != "" {
+				if _, err := fmt.Sscanf(p, "%d", &page); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+			start, end := (page-1)*2, page*2
+			if end < len(names) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+			} else {
+				end = len(names)
+			}
+			fmt.Fprint(w, "[")
+			for i := start; i < end; i++ {
+				if i > start {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, `{"id": %d, "name": %q}`, i+100, names[i])
+			}
+			fmt.Fprint(w, "]")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := githubapi.NewClient(nil)
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	client.BaseURL = u
+	return client
+}
+
+func Test_subjectsFromRelease(t *testing.T) {
+	// Warnings are written as plain text outside of GitHub Actions.
+	t.Setenv("GITHUB_ACTIONS", "")
+	client := newFakeReleaseAssetsServer(t, testReleaseAssets)
+
+	var stderr bytes.Buffer
+	subjects, err := subjectsFromRelease(context.Background(), client, "owner/repo@v1.0.0", &stderr)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := []intoto.Subject{
+		{Name: "artifact1.tar.gz", Digest: slsacommon.DigestSet{"sha256": testReleaseDigest1}},
+		{Name: "artifact2.zip", Digest: slsacommon.DigestSet{"sha256": testReleaseDigest2}},
+	}
+	if diff := cmp.Diff(want, subjects); diff != "" {
+		t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+	}
+	if want, got := `WARNING: no .sha256 asset for "no-checksum.txt", skipping`, strings.TrimSpace(stderr.String()); want != got {
+		t.Errorf("unexpected warning, want: %q, got: %q", want, got)
+	}
+}
+
+func Test_subjectsFromRelease_errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		spec   string
+		assets map[string]string
+		err    interface{}
+	}{
+		{
+			name: "missing tag",
+			spec: "owner/repo",
+			err:  new(*errReleaseSpec),
+		},
+		{
+			name: "missing repo",
+			spec: "owner@v1.0.0",
+			err:  new(*errReleaseSpec),
+		},
+		{
+			name: "release not found",
+			spec: "owner/repo@v2.0.0",
+			err:  new(*errRelease),
+		},
+		{
+			name: "invalid checksum",
+			spec: "owner/repo@v1.0.0",
+			assets: map[string]string{
+				"artifact1":        "",
+				"artifact1.sha256": "abcdef  artifact1",
+			},
+			err: new(*errSha),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeReleaseAssetsServer(t, tc.assets)
+			_, err := subjectsFromRelease(context.Background(), client, tc.spec, new(bytes.Buffer))
+			if !errors.As(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_statementOptions_clients_github_token(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+
+	o := statementOptions{releaseSubjects: "owner/repo@v1.0.0"}
+	if _, err := o.clients(nil); !errors.As(err, new(*errMissingGithubToken)) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "token")
+	if _, err := o.clients(nil); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+}
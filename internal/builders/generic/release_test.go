@@ -0,0 +1,231 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	githubapi "github.com/google/go-github/v50/github"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// testClientProvider provides a GitHub client that connects to a test server.
+type testClientProvider struct {
+	client *githubapi.Client
+}
+
+func (p *testClientProvider) OIDCClient() (*github.OIDCClient, error) {
+	return nil, nil
+}
+
+func (p *testClientProvider) GithubClient(context.Context) (*githubapi.Client, error) {
+	return p.client, nil
+}
+
+// fakeReleaseServer is a fake GitHub API server serving a single release.
+type fakeReleaseServer struct {
+	// status is returned for all requests if non-zero.
+	status int
+
+	draft  bool
+	assets []string
+
+	deleted  []int64
+	uploaded []string
+}
+
+func (s *fakeReleaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		fmt.Fprint(w, `{"message": "error"}`)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/tags/v1.0.0",
+		r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/1":
+		fmt.Fprintf(w, `{"id": 1, "tag_name": "v1.0.0", "draft": %t}`, s.draft)
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/tags/20230101":
+		fmt.Fprintf(w, `{"id": 2, "tag_name": "20230101", "draft": %t}`, s.draft)
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/2/assets":
+		fmt.Fprint(w, "[]")
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/releases/2/assets":
+		name := r.URL.Query().Get("name")
+		s.uploaded = append(s.uploaded, "2/"+name)
+		fmt.Fprintf(w, `{"id": 201, "name": %q}`, name)
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/1/assets":
+		fmt.Fprint(w, "[")
+		for i, a := range s.assets {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id": %d, "name": %q}`, i+100, a)
+		}
+		fmt.Fprint(w, "]")
+	case r.Method == http.MethodDelete:
+		var id int64
+		if _, err := fmt.Sscanf(r.URL.Path, "/repos/owner/repo/releases/assets/%d", &id); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.deleted = append(s.deleted, id)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/releases/1/assets":
+		name := r.URL.Query().Get("name")
+		s.uploaded = append(s.uploaded, name)
+		fmt.Fprintf(w, `{"id": 200, "name": %q}`, name)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	}
+}
+
+func Test_releaseUploader_Upload(t *testing.T) {
+	dir := t.TempDir()
+	attPath := filepath.Join(dir, "artifact1.intoto.jsonl")
+	if err := os.WriteFile(attPath, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		server    *fakeReleaseServer
+		release   string
+		overwrite bool
+		// err is a pointer to the expected error type.
+		err      interface{}
+		uploaded []string
+		deleted  []int64
+	}{
+		{
+			name:     "by tag",
+			server:   &fakeReleaseServer{},
+			release:  "v1.0.0",
+			uploaded: []string{"artifact1.intoto.jsonl"},
+		},
+		{
+			name:     "by id",
+			server:   &fakeReleaseServer{},
+			release:  "1",
+			uploaded: []string{"artifact1.intoto.jsonl"},
+		},
+		{
+			name:     "by numeric tag",
+			server:   &fakeReleaseServer{},
+			release:  "20230101",
+			uploaded: []string{"2/artifact1.intoto.jsonl"},
+		},
+		{
+			name:    "numeric release not found",
+			server:  &fakeReleaseServer{},
+			release: "3",
+			err:     new(*errReleaseNotFound),
+		},
+		{
+			name:    "release not found",
+			server:  &fakeReleaseServer{},
+			release: "v2.0.0",
+			err:     new(*errReleaseNotFound),
+		},
+		{
+			name:    "draft release",
+			server:  &fakeReleaseServer{draft: true},
+			release: "1",
+			err:     new(*errDraftRelease),
+		},
+		{
+			name:    "forbidden",
+			server:  &fakeReleaseServer{status: http.StatusForbidden},
+			release: "v1.0.0",
+			err:     new(*errReleasePermission),
+		},
+		{
+			name:    "asset exists",
+			server:  &fakeReleaseServer{assets: []string{"other", "artifact1.intoto.jsonl"}},
+			release: "v1.0.0",
+			err:     new(*errReleaseAssetExists),
+		},
+		{
+			name:      "asset exists overwrite",
+			server:    &fakeReleaseServer{assets: []string{"other", "artifact1.intoto.jsonl"}},
+			release:   "v1.0.0",
+			overwrite: true,
+			deleted:   []int64{101},
+			uploaded:  []string{"artifact1.intoto.jsonl"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.server)
+			defer srv.Close()
+
+			client := githubapi.NewClient(nil)
+			u, err := url.Parse(srv.URL + "/")
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			client.BaseURL = u
+			client.UploadURL = u
+
+			uploader, err := newReleaseUploader(&testClientProvider{client: client}, "owner/repo", tc.release, tc.overwrite)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			err = uploader.Upload(context.Background(), attPath)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("expected %T but got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if want, got := fmt.Sprint(tc.uploaded), fmt.Sprint(tc.server.uploaded); want != got {
+				t.Errorf("unexpected uploads, want: %s, got: %s", want, got)
+			}
+			if want, got := fmt.Sprint(tc.deleted), fmt.Sprint(tc.server.deleted); want != got {
+				t.Errorf("unexpected deletes, want: %s, got: %s", want, got)
+			}
+		})
+	}
+}
+
+func Test_releaseUploader_Upload_nil_client(t *testing.T) {
+	uploader, err := newReleaseUploader(&slsa.NilClientProvider{}, "owner/repo", "v1.0.0", false)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	// The file does not exist, but no error should occur because the upload
+	// is skipped when there is no client.
+	if err := uploader.Upload(context.Background(), "does-not-exist.intoto.jsonl"); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	// defaultRemoteSubjectMaxSize is the default maximum size of a remote
+	// subject.
+	defaultRemoteSubjectMaxSize = 1 << 30
+
+	// defaultRemoteSubjectTimeout is the default maximum time to download a
+	// remote subject.
+	defaultRemoteSubjectTimeout = 5 * time.Minute
+)
+
+// errRemoteSubject indicates an invalid --remote-subject.
+type errRemoteSubject struct {
+	errors.ErrInput
+}
+
+// errRemoteDownload indicates an error downloading a remote subject.
+type errRemoteDownload struct {
+	errors.WrappableError
+}
+
+// errRemoteDigestMismatch indicates a remote subject whose content does not
+// have the expected digest.
+type errRemoteDigestMismatch struct {
+	errors.ErrInput
+}
+
+// remoteSubject is a subject downloaded from a URL.
+type remoteSubject struct {
+	url    *url.URL
+	digest string
+}
+
+// parseRemoteSubject parses a --remote-subject of the form
+// "<url> <sha256>".
+func parseRemoteSubject(s string) (remoteSubject, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return remoteSubject{}, errors.Errorf(&errRemoteSubject{}, "invalid remote subject, expected \"<url> <sha256>\"")
+	}
+	u, err := url.Parse(fields[0])
+	if err != nil {
+		return remoteSubject{}, errors.Errorf(&errRemoteSubject{}, "invalid remote subject URL")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return remoteSubject{}, errors.Errorf(&errRemoteSubject{}, "remote subject URL %q is not an HTTP(S) URL", redactedURL(u))
+	}
+	digest := strings.ToLower(fields[1])
+	if !shaCheck.MatchString(digest) {
+		return remoteSubject{}, errors.Errorf(&errRemoteSubject{}, "invalid sha256 digest %q for %q", fields[1], redactedURL(u))
+	}
+	return remoteSubject{url: u, digest: digest}, nil
+}
+
+// redactedURL returns the URL without user info, query and fragment, which
+// may hold credentials, e.g. for signed URLs.
+func redactedURL(u *url.URL) string {
+	r := *u
+	r.User = nil
+	r.RawQuery = ""
+	r.ForceQuery = false
+	r.Fragment = ""
+	r.RawFragment = ""
+	return r.String()
+}
+
+// name returns the subject name for the remote subject. It is the base name
+// of the URL path, or the redacted URL if useURI is true.
+func (r remoteSubject) name(useURI bool) (string, error) {
+	if useURI {
+		return redactedURL(r.url), nil
+	}
+	base := path.Base(r.url.Path)
+	if base == "/" || base == "." {
+		return "", errors.Errorf(&errRemoteSubject{}, "remote subject URL %q has no file name, use --subject-use-uri",
+			redactedURL(r.url))
+	}
+	return base, nil
+}
+
+// remoteClient returns a copy of client that doesn't follow redirects to
+// another host or scheme.
+func remoteClient(client *http.Client) *http.Client {
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		orig := via[0].URL
+		if req.URL.Host != orig.Host || req.URL.Scheme != orig.Scheme {
+			return errors.Errorf(&errRemoteDownload{}, "refusing redirect from %q to %q", orig.Host,
+				req.URL.Scheme+"://"+req.URL.Host)
+		}
+		return nil
+	}
+	return &c
+}
+
+// downloadSHA256 downloads the URL and returns the sha256 digest of its
+// content. The download fails if the content is larger than maxSize or if it
+// takes longer than timeout.
+func downloadSHA256(ctx context.Context, client *http.Client, u *url.URL, maxSize int64,
+	timeout time.Duration,
+) (string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", errors.Errorf(&errRemoteDownload{}, "downloading %q: %w", redactedURL(u), err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Errorf(&errRemoteDownload{}, "downloading %q: %w", redactedURL(u), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf(&errRemoteDownload{}, "downloading %q: unexpected status: %s", redactedURL(u), resp.Status)
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", errors.Errorf(&errRemoteDownload{}, "downloading %q: %w", redactedURL(u), err)
+	}
+	if n > maxSize {
+		return "", errors.Errorf(&errRemoteDownload{}, "downloading %q: content is larger than %d bytes",
+			redactedURL(u), maxSize)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// subjectsFromRemote downloads the remote subjects and returns them once
+// their content has been verified against the expected digests.
+func subjectsFromRemote(ctx context.Context, client *http.Client, values []string, useURI bool,
+	maxSize int64, timeout time.Duration,
+) ([]intoto.Subject, error) {
+	client = remoteClient(client)
+
+	var subjects []intoto.Subject
+	for _, v := range values {
+		r, err := parseRemoteSubject(v)
+		if err != nil {
+			return nil, err
+		}
+		name, err := r.name(useURI)
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := downloadSHA256(ctx, client, r.url, maxSize, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if digest != r.digest {
+			return nil, errors.Errorf(&errRemoteDigestMismatch{}, "digest mismatch for %q: expected %s, got %s",
+				redactedURL(r.url), r.digest, digest)
+		}
+
+		subjects = append(subjects, intoto.Subject{
+			Name: name,
+			Digest: slsacommon.DigestSet{
+				"sha256": digest,
+			},
+		})
+	}
+	return subjects, nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+const testRemoteContent = "remote artifact"
+
+// newFakeRemoteServer returns a server serving testRemoteContent at
+// /dist/app.tar.gz, a response that never completes at /slow, a redirect to
+// /dist/app.tar.gz at /moved, and a redirect to other at /elsewhere.
+func newFakeRemoteServer(t *testing.T, other string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dist/app.tar.gz":
+			fmt.Fprint(w, testRemoteContent)
+		case "/slow":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/moved":
+			http.Redirect(w, r, "/dist/app.tar.gz", http.StatusFound)
+		case "/elsewhere":
+			http.Redirect(w, r, other+"/dist/app.tar.gz", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func Test_subjectsFromRemote(t *testing.T) {
+	sum := sha256.Sum256([]byte(testRemoteContent))
+	digest := hex.EncodeToString(sum[:])
+
+	other := newFakeRemoteServer(t, "")
+	s := newFakeRemoteServer(t, other.URL)
+
+	testCases := []struct {
+		name     string
+		value    string
+		useURI   bool
+		maxSize  int64
+		expected []intoto.Subject
+		err      interface{}
+	}{
+		{
+			name:  "base name",
+			value: s.URL + "/dist/app.tar.gz?token=secret " + digest,
+			expected: []intoto.Subject{
+				{Name: "app.tar.gz", Digest: slsacommon.DigestSet{"sha256": digest}},
+			},
+		},
+		{
+			name:   "uri",
+			value:  s.URL + "/dist/app.tar.gz?token=secret#frag " + digest,
+			useURI: true,
+			expected: []intoto.Subject{
+				{Name: s.URL + "/dist/app.tar.gz", Digest: slsacommon.DigestSet{"sha256": digest}},
+			},
+		},
+		{
+			name:  "same host redirect",
+			value: s.URL + "/moved " + digest,
+			expected: []intoto.Subject{
+				{Name: "moved", Digest: slsacommon.DigestSet{"sha256": digest}},
+			},
+		},
+		{
+			name:  "digest mismatch",
+			value: s.URL + "/dist/app.tar.gz " + testFetchDigest,
+			err:   new(*errRemoteDigestMismatch),
+		},
+		{
+			name:  "slow response",
+			value: s.URL + "/slow " + digest,
+			err:   new(*errRemoteDownload),
+		},
+		{
+			name:    "too large",
+			value:   s.URL + "/dist/app.tar.gz " + digest,
+			maxSize: 4,
+			err:     new(*errRemoteDownload),
+		},
+		{
+			name:  "cross host redirect",
+			value: s.URL + "/elsewhere " + digest,
+			err:   new(*errRemoteDownload),
+		},
+		{
+			name:  "not found",
+			value: s.URL + "/missing " + digest,
+			err:   new(*errRemoteDownload),
+		},
+		{
+			name:  "not http",
+			value: "ftp://example.com/app.tar.gz " + digest,
+			err:   new(*errRemoteSubject),
+		},
+		{
+			name:  "missing digest",
+			value: s.URL + "/dist/app.tar.gz",
+			err:   new(*errRemoteSubject),
+		},
+		{
+			name:  "no file name",
+			value: s.URL + "/ " + digest,
+			err:   new(*errRemoteSubject),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			maxSize := tc.maxSize
+			if maxSize == 0 {
+				maxSize = defaultRemoteSubjectMaxSize
+			}
+
+			start := time.Now()
+			subjects, err := subjectsFromRemote(context.Background(), http.DefaultClient, []string{tc.value},
+				tc.useURI, maxSize, 100*time.Millisecond)
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("unexpected duration: %s", d)
+			}
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, subjects); diff != "" {
+				t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_remote_subject_mismatch tests that a remote subject with an
+// unexpected digest fails before signing.
+func Test_attestCmd_remote_subject_mismatch(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	chdirTemp(t)
+	s := newFakeRemoteServer(t, "")
+
+	signer := &recordingSigner{}
+	check := func(err error) {
+		if err != nil {
+			if !errors.As(err, new(*errRemoteDigestMismatch)) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if signer.statement != nil {
+				t.Errorf("unexpected signing before error")
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--remote-subject", s.URL + "/dist/app.tar.gz " + testFetchDigest,
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Fatalf("expected an error to occur.")
+}
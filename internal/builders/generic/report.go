@@ -0,0 +1,212 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+// errReportAttestation indicates an attestation file that cannot be read.
+type errReportAttestation struct {
+	errors.ErrInput
+}
+
+// reportEntry is an attestation in the output of the 'report' command.
+type reportEntry struct {
+	File          string           `json:"file"`
+	Subjects      []intoto.Subject `json:"subjects"`
+	BuilderID     string           `json:"builder_id"`
+	RekorLogIndex *int64           `json:"rekor_log_index"`
+	SignedAt      *time.Time       `json:"signed_at"`
+}
+
+// reportStatement holds the fields of a provenance statement used in the
+// report. The builder ID is at predicate.builder.id in SLSA v0.2 and at
+// predicate.runDetails.builder.id in SLSA v1.0.
+type reportStatement struct {
+	Subject   []intoto.Subject `json:"subject"`
+	Predicate struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// reportLogEntry holds the fields of a .rekor.json log entry used in the
+// report.
+type reportLogEntry struct {
+	LogIndex       int64 `json:"logIndex"`
+	IntegratedTime int64 `json:"integratedTime"`
+}
+
+// readReportEntries returns an entry for each attestation in the file at
+// path. name is the path recorded in the entries.
+func readReportEntries(path, name string) ([]reportEntry, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "opening %q: %w", name, err)
+	}
+	defer f.Close()
+
+	logEntry, err := readReportLogEntry(rekorEntryPath(path), name)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []reportEntry
+	dec := json.NewDecoder(f)
+	for {
+		var env envelope.Envelope
+		if err := dec.Decode(&env); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Errorf(&errReportAttestation{}, "decoding %q: %w", name, err)
+		}
+		payload, err := base64.StdEncoding.DecodeString(env.Payload)
+		if err != nil {
+			return nil, errors.Errorf(&errReportAttestation{}, "decoding %q: %w", name, err)
+		}
+		var s reportStatement
+		if err := json.Unmarshal(payload, &s); err != nil {
+			return nil, errors.Errorf(&errReportAttestation{}, "decoding statement in %q: %w", name, err)
+		}
+
+		e := reportEntry{
+			File:      name,
+			Subjects:  s.Subject,
+			BuilderID: s.Predicate.Builder.ID,
+		}
+		if e.BuilderID == "" {
+			e.BuilderID = s.Predicate.RunDetails.Builder.ID
+		}
+		if e.Subjects == nil {
+			e.Subjects = []intoto.Subject{}
+		}
+		// NOTE: The integrated time of the log entry is when the
+		// attestation was logged. Without an entry, the start of the
+		// validity of the signing certificate is the closest time.
+		if logEntry != nil {
+			e.RekorLogIndex = &logEntry.LogIndex
+			t := time.Unix(logEntry.IntegratedTime, 0).UTC()
+			e.SignedAt = &t
+		} else if len(env.Signatures) > 0 {
+			if certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(env.Signatures[0].Cert)); err == nil &&
+				len(certs) > 0 {
+				t := certs[0].NotBefore.UTC()
+				e.SignedAt = &t
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readReportLogEntry returns the log entry written next to an attestation, or
+// nil if there is none.
+func readReportLogEntry(path, name string) (*reportLogEntry, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "reading log entry of %q: %w", name, err)
+	}
+	var e reportLogEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, errors.Errorf(&errReportAttestation{}, "decoding log entry of %q: %w", name, err)
+	}
+	return &e, nil
+}
+
+// report returns an entry for each attestation in the *.intoto.jsonl files
+// under dir, ordered by path. Symbolic links are not followed.
+func report(dir string) ([]reportEntry, error) {
+	// NOTE: The path is untrusted and should be validated.
+	if err := utils.PathIsUnderCurrentDirectory(dir); err != nil {
+		return nil, err
+	}
+
+	entries := []reportEntry{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.Errorf(&errors.ErrFilesystem{}, "scanning %q: %w", dir, err)
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), ".intoto.jsonl") {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return errors.Errorf(&errors.ErrFilesystem{}, "scanning %q: %w", dir, err)
+		}
+		e, err := readReportEntries(path, filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// reportCmd returns the 'report' command.
+func reportCmd(check func(error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "report [dir]",
+		Short: "Summarize the attestations in a directory as JSON",
+		Long: `Scan a directory, by default the current one, for *.intoto.jsonl files and
+print a JSON array with the file, subjects, builder ID, Rekor log index and
+signing time of each attestation. The log index and signing time are read from
+the <name>.rekor.json file written next to the attestation, if any.
+
+The attestations are not verified; use slsa-verifier to verify them.`,
+		Args: cobra.MaximumNArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			entries, err := report(dir)
+			check(err)
+
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			check(enc.Encode(entries))
+		},
+	}
+}
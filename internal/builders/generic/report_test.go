@@ -0,0 +1,177 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+)
+
+const (
+	// testReportStatementV02 is a SLSA v0.2 statement with two subjects.
+	testReportStatementV02 = `{
+  "_type": "https://in-toto.io/Statement/v0.1",
+  "predicateType": "https://slsa.dev/provenance/v0.2",
+  "subject": [
+    {"name": "app-linux", "digest": {"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"}},
+    {"name": "app-darwin", "digest": {"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"}}
+  ],
+  "predicate": {"builder": {"id": "https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0"}}
+}`
+
+	// testReportStatementV1 is a SLSA v1.0 statement with one subject.
+	testReportStatementV1 = `{
+  "_type": "https://in-toto.io/Statement/v1",
+  "predicateType": "https://slsa.dev/provenance/v1",
+  "subject": [
+    {"name": "lib.tar.gz", "digest": {"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"}}
+  ],
+  "predicate": {"runDetails": {"builder": {"id": "https://github.com/owner/repo/.github/workflows/lib.yml@refs/heads/main"}}}
+}`
+)
+
+// testReportEnvelope returns a DSSE envelope with the statement as payload.
+func testReportEnvelope(t *testing.T, statement string) string {
+	b, err := json.Marshal(map[string]interface{}{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString([]byte(statement)),
+		"signatures":  []map[string]string{{"keyid": "", "sig": "c2lnbmF0dXJl"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return string(b)
+}
+
+func Test_reportCmd(t *testing.T) {
+	chdirTemp(t)
+	if err := os.MkdirAll(filepath.Join("dist", "lib"), 0o700); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	writeTestFile(t, "dist/app.intoto.jsonl", testReportEnvelope(t, testReportStatementV02)+"\n")
+	writeTestFile(t, "dist/app.rekor.json", `{"uuid": "uuid", "logIndex": 42, "integratedTime": 1672531200}`)
+	writeTestFile(t, "dist/lib/lib.intoto.jsonl", testReportEnvelope(t, testReportStatementV1))
+	writeTestFile(t, "dist/notes.txt", "not an attestation")
+
+	var out bytes.Buffer
+	c := reportCmd(checkTest(t))
+	c.SetOut(&out)
+	c.SetArgs([]string{"dist"})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var got []reportEntry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	logIndex := int64(42)
+	signedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := []reportEntry{
+		{
+			File: "app.intoto.jsonl",
+			Subjects: []intoto.Subject{
+				{
+					Name:   "app-linux",
+					Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+				},
+				{
+					Name:   "app-darwin",
+					Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+				},
+			},
+			BuilderID:     "https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0",
+			RekorLogIndex: &logIndex,
+			SignedAt:      &signedAt,
+		},
+		{
+			File: "lib/lib.intoto.jsonl",
+			Subjects: []intoto.Subject{
+				{
+					Name:   "lib.tar.gz",
+					Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+				},
+			},
+			BuilderID: "https://github.com/owner/repo/.github/workflows/lib.yml@refs/heads/main",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+}
+
+func Test_report_errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		dir   string
+		files map[string]string
+		err   interface{}
+	}{
+		{
+			name:  "invalid envelope",
+			dir:   ".",
+			files: map[string]string{"a.intoto.jsonl": "not json"},
+			err:   new(*errReportAttestation),
+		},
+		{
+			name:  "invalid payload",
+			dir:   ".",
+			files: map[string]string{"a.intoto.jsonl": `{"payload": "not base64!"}`},
+			err:   new(*errReportAttestation),
+		},
+		{
+			name: "invalid log entry",
+			dir:  ".",
+			files: map[string]string{
+				"a.intoto.jsonl": `{"payload": ""}`,
+				"a.rekor.json":   "not json",
+			},
+			err: new(*errReportAttestation),
+		},
+		{
+			name: "outside of the current directory",
+			dir:  "..",
+			err:  new(*utils.ErrInvalidPath),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+			for name, contents := range tc.files {
+				writeTestFile(t, name, contents)
+			}
+
+			_, err := report(tc.dir)
+			if !errors.As(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
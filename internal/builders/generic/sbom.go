@@ -0,0 +1,317 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// sbomPredicateType is the predicate type of attestations that link an
+// artifact to its SBOM.
+const sbomPredicateType = "https://github.com/slsa-framework/slsa-github-generator/sbom-reference@v1"
+
+// SBOM media types.
+const (
+	mediaTypeCycloneDXJSON = "application/vnd.cyclonedx+json"
+	mediaTypeSPDXJSON      = "application/spdx+json"
+	mediaTypeSPDXTagValue  = "text/spdx"
+)
+
+// errSBOMPairs indicates that the artifacts and SBOMs can't be paired.
+type errSBOMPairs struct {
+	errors.ErrInput
+}
+
+// errSBOM indicates an SBOM or artifact that can't be read.
+type errSBOM struct {
+	errors.ErrInput
+}
+
+// errSBOMAttestations indicates that some SBOM attestations failed with
+// --continue-on-error.
+type errSBOMAttestations struct {
+	errors.WrappableError
+}
+
+// sbomReference is the SBOM in an SBOM reference predicate.
+type sbomReference struct {
+	Name      string               `json:"name"`
+	Digest    slsacommon.DigestSet `json:"digest"`
+	MediaType string               `json:"mediaType"`
+}
+
+// sbomPredicate is the predicate of an SBOM reference attestation.
+type sbomPredicate struct {
+	SBOM sbomReference `json:"sbom"`
+}
+
+// sbomMediaType returns the media type of an SBOM document. CycloneDX JSON,
+// SPDX JSON and SPDX tag-value documents are supported.
+func sbomMediaType(b []byte) (string, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("SPDXVersion:")) {
+		return mediaTypeSPDXTagValue, nil
+	}
+
+	var doc struct {
+		BOMFormat   string `json:"bomFormat"`
+		SPDXVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return "", err
+	}
+	switch {
+	case doc.BOMFormat == "CycloneDX":
+		return mediaTypeCycloneDXJSON, nil
+	case doc.SPDXVersion != "":
+		return mediaTypeSPDXJSON, nil
+	default:
+		return "", errors.New("not a CycloneDX or SPDX document")
+	}
+}
+
+// sbomStatement returns the SBOM reference statement for an artifact and its
+// SBOM. Both files are read before anything is signed.
+func sbomStatement(artifactPath, sbomPath string) (*intoto.Statement, error) {
+	digest, err := fileSHA256(artifactPath)
+	if err != nil {
+		return nil, errors.Errorf(&errSBOM{}, "reading artifact %q: %w", artifactPath, err)
+	}
+
+	b, err := os.ReadFile(filepath.Clean(sbomPath))
+	if err != nil {
+		return nil, errors.Errorf(&errSBOM{}, "reading SBOM %q: %w", sbomPath, err)
+	}
+	mediaType, err := sbomMediaType(b)
+	if err != nil {
+		return nil, errors.Errorf(&errSBOM{}, "reading SBOM %q: %w", sbomPath, err)
+	}
+	sum := sha256.Sum256(b)
+
+	return &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: sbomPredicateType,
+			Subject: []intoto.Subject{
+				{
+					Name: filepath.ToSlash(artifactPath),
+					Digest: slsacommon.DigestSet{
+						"sha256": digest,
+					},
+				},
+			},
+		},
+		Predicate: sbomPredicate{
+			SBOM: sbomReference{
+				Name: filepath.ToSlash(sbomPath),
+				Digest: slsacommon.DigestSet{
+					"sha256": hex.EncodeToString(sum[:]),
+				},
+				MediaType: mediaType,
+			},
+		},
+	}, nil
+}
+
+// sbomAttestationName returns the file name of the SBOM attestation for an
+// artifact, i.e. <artifact>.sbom.intoto.jsonl.
+func sbomAttestationName(artifactPath string) string {
+	name := attestationName(path.Base(filepath.ToSlash(artifactPath)), true)
+	return strings.TrimSuffix(name, ".intoto.jsonl") + ".sbom.intoto.jsonl"
+}
+
+// sbomResult is the result of attesting a single artifact.
+type sbomResult struct {
+	Artifact    string `json:"artifact"`
+	SBOM        string `json:"sbom"`
+	Attestation string `json:"attestation,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// sbomSummary is the summary written by attest-sbom with --continue-on-error.
+// The failed artifacts and their SBOMs can be passed to a follow-up run.
+type sbomSummary struct {
+	Succeeded []sbomResult `json:"succeeded"`
+	Failed    []sbomResult `json:"failed"`
+}
+
+// writeSBOMAttestation signs s, uploads it to the transparency log and writes
+// it to attPath.
+func writeSBOMAttestation(ctx context.Context, signer signing.Signer, tlog signing.TransparencyLog,
+	s *intoto.Statement, attPath string,
+) error {
+	var attBytes []byte
+	if utils.IsPresubmitTests() {
+		b, err := slsa.CanonicalizeStatement(*s)
+		if err != nil {
+			return err
+		}
+		attBytes = b
+	} else {
+		att, err := signer.Sign(ctx, s)
+		if err != nil {
+			return errors.Errorf(&errors.ErrSigning{}, "signing SBOM attestation: %w", err)
+		}
+		if _, err := tlog.Upload(ctx, att); err != nil {
+			return errors.Errorf(&errors.ErrTransparencyLog{}, "uploading SBOM attestation: %w", err)
+		}
+		attBytes = att.Bytes()
+	}
+
+	f, err := utils.CreateNewFileUnderCurrentDirectory(attPath, os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(attBytes); err != nil {
+		return errors.Errorf(&errors.ErrFilesystem{}, "writing SBOM attestation: %w", err)
+	}
+	return nil
+}
+
+// attestSBOMCmd returns the 'attest-sbom' command.
+func attestSBOMCmd(check func(error), signer signing.Signer, tlog signing.TransparencyLog) *cobra.Command {
+	var artifacts []string
+	var sboms []string
+	var continueOnError bool
+	var summaryPath string
+
+	c := &cobra.Command{
+		Use:   "attest-sbom",
+		Short: "Create signed attestations that link artifacts to their SBOMs",
+		Long: `Create a signed attestation for each artifact and SBOM pair and upload it to a
+Rekor transparency log. The subject of each attestation is the artifact and
+the predicate records the digest and media type of the SBOM.
+
+The n-th --artifact is paired with the n-th --sbom. CycloneDX JSON, SPDX JSON
+and SPDX tag-value SBOMs are supported.
+
+With --continue-on-error, a failed attestation doesn't stop the remaining
+artifacts. A JSON summary of the succeeded and failed artifacts is written to
+the --summary file and the sbom-attestation-summary output, and the command
+fails after all artifacts are processed.`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(artifacts) == 0 {
+				check(errors.Errorf(&errSBOMPairs{}, "no artifacts"))
+			}
+			if len(artifacts) != len(sboms) {
+				check(errors.Errorf(&errSBOMPairs{}, "got %d artifacts and %d SBOMs", len(artifacts), len(sboms)))
+			}
+
+			// Read all files before signing anything.
+			var statements []*intoto.Statement
+			var attPaths []string
+			for i := range artifacts {
+				s, err := sbomStatement(artifacts[i], sboms[i])
+				check(err)
+				statements = append(statements, s)
+
+				attPath := sbomAttestationName(artifacts[i])
+				check(utils.VerifyAttestationPath(attPath))
+				attPaths = append(attPaths, attPath)
+			}
+
+			ctx := context.Background()
+			summary := sbomSummary{
+				Succeeded: []sbomResult{},
+				Failed:    []sbomResult{},
+			}
+			var firstErr error
+			for i, s := range statements {
+				r := sbomResult{
+					Artifact: artifacts[i],
+					SBOM:     sboms[i],
+				}
+				if err := writeSBOMAttestation(ctx, signer, tlog, s, attPaths[i]); err != nil {
+					if !continueOnError {
+						check(err)
+					}
+					if firstErr == nil {
+						firstErr = err
+					}
+					r.Error = err.Error()
+					summary.Failed = append(summary.Failed, r)
+					continue
+				}
+				r.Attestation = attPaths[i]
+				summary.Succeeded = append(summary.Succeeded, r)
+			}
+
+			// The names are written as a JSON array that can be read with fromJSON.
+			names := []string{}
+			for _, r := range summary.Succeeded {
+				names = append(names, r.Attestation)
+			}
+			namesBytes, err := json.Marshal(names)
+			check(err)
+			check(github.SetOutput("sbom-attestation-names", string(namesBytes)))
+
+			if continueOnError {
+				summaryBytes, err := json.Marshal(summary)
+				check(err)
+				f, err := utils.CreateNewFileUnderCurrentDirectory(summaryPath, os.O_WRONLY)
+				check(err)
+				if _, err := f.Write(summaryBytes); err != nil {
+					check(errors.Errorf(&errors.ErrFilesystem{}, "writing summary: %w", err))
+				}
+				check(github.SetOutput("sbom-attestation-summary", string(summaryBytes)))
+			}
+
+			// Fail only after all outputs are written so that a follow-up
+			// job can retry the failed artifacts.
+			if firstErr != nil {
+				check(errors.Errorf(&errSBOMAttestations{}, "%d of %d SBOM attestations failed: %w",
+					len(summary.Failed), len(statements), firstErr))
+			}
+		},
+	}
+
+	c.Flags().StringArrayVar(
+		&artifacts, "artifact", nil,
+		"Path to an artifact. Can be repeated.",
+	)
+	c.Flags().StringArrayVar(
+		&sboms, "sbom", nil,
+		"Path to the SBOM of the artifact at the same position. Can be repeated.",
+	)
+	c.Flags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue with the remaining artifacts if an attestation fails, and write a summary. "+
+			"The command still fails at the end if any attestation failed.",
+	)
+	c.Flags().StringVar(
+		&summaryPath, "summary", "sbom-attestation-summary.json",
+		"Path to write the JSON summary of succeeded and failed attestations when using --continue-on-error.",
+	)
+
+	return c
+}
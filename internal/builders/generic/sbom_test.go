@@ -0,0 +1,353 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+const (
+	testCycloneDXSBOM = `{"bomFormat": "CycloneDX", "specVersion": "1.4", "components": []}`
+	testSPDXSBOM      = `{"spdxVersion": "SPDX-2.3", "name": "artifact"}`
+)
+
+func Test_sbomMediaType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sbom     string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "cyclonedx json",
+			sbom:     testCycloneDXSBOM,
+			expected: mediaTypeCycloneDXJSON,
+		},
+		{
+			name:     "spdx json",
+			sbom:     testSPDXSBOM,
+			expected: mediaTypeSPDXJSON,
+		},
+		{
+			name:     "spdx tag-value",
+			sbom:     "SPDXVersion: SPDX-2.3\nDataLicense: CC0-1.0\n",
+			expected: mediaTypeSPDXTagValue,
+		},
+		{
+			name: "other json",
+			sbom: `{"foo": "bar"}`,
+			err:  true,
+		},
+		{
+			name: "not an sbom",
+			sbom: "hello",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mediaType, err := sbomMediaType([]byte(tc.sbom))
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if want, got := tc.expected, mediaType; want != got {
+				t.Errorf("unexpected media type, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+// statementsSigner is a Signer that records all signed statements.
+type statementsSigner struct {
+	testutil.TestSigner
+	statements []*intoto.Statement
+}
+
+func (s *statementsSigner) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	s.statements = append(s.statements, p)
+	return s.TestSigner.Sign(ctx, p)
+}
+
+// chdirTemp changes to a new temporary directory for the duration of the test.
+func chdirTemp(t *testing.T) string {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(currentDir); err != nil {
+			t.Errorf("unexpected failure: %v", err)
+		}
+	})
+	return dir
+}
+
+func writeTestFile(t *testing.T, name, contents string) string {
+	if err := os.WriteFile(name, []byte(contents), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_attestSBOMCmd(t *testing.T) {
+	dir := chdirTemp(t)
+
+	artifact1 := writeTestFile(t, "artifact1", "artifact1")
+	artifact2 := writeTestFile(t, "artifact2", "artifact2")
+	sbom1 := writeTestFile(t, "artifact1.cdx.json", testCycloneDXSBOM)
+	sbom2 := writeTestFile(t, "artifact2.spdx.json", testSPDXSBOM)
+
+	signer := &statementsSigner{}
+	c := attestSBOMCmd(checkTest(t), signer, &testutil.TestTransparencyLog{})
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--artifact", "artifact1", "--sbom", "artifact1.cdx.json",
+		"--artifact", "artifact2", "--sbom", "artifact2.spdx.json",
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := []*intoto.Statement{
+		{
+			StatementHeader: intoto.StatementHeader{
+				Type:          intoto.StatementInTotoV01,
+				PredicateType: sbomPredicateType,
+				Subject: []intoto.Subject{
+					{Name: "artifact1", Digest: slsacommon.DigestSet{"sha256": artifact1}},
+				},
+			},
+			Predicate: sbomPredicate{
+				SBOM: sbomReference{
+					Name:      "artifact1.cdx.json",
+					Digest:    slsacommon.DigestSet{"sha256": sbom1},
+					MediaType: mediaTypeCycloneDXJSON,
+				},
+			},
+		},
+		{
+			StatementHeader: intoto.StatementHeader{
+				Type:          intoto.StatementInTotoV01,
+				PredicateType: sbomPredicateType,
+				Subject: []intoto.Subject{
+					{Name: "artifact2", Digest: slsacommon.DigestSet{"sha256": artifact2}},
+				},
+			},
+			Predicate: sbomPredicate{
+				SBOM: sbomReference{
+					Name:      "artifact2.spdx.json",
+					Digest:    slsacommon.DigestSet{"sha256": sbom2},
+					MediaType: mediaTypeSPDXJSON,
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, signer.statements); diff != "" {
+		t.Errorf("unexpected statements (-want +got):\n%s", diff)
+	}
+
+	for _, name := range []string{"artifact1.sbom.intoto.jsonl", "artifact2.sbom.intoto.jsonl"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("error checking file: %v", err)
+		}
+	}
+}
+
+// Test_attestSBOMCmd_errors tests that invalid pairs fail before anything is
+// signed.
+func Test_attestSBOMCmd_errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		err  interface{}
+	}{
+		{
+			name: "mismatched pairs",
+			args: []string{"--artifact", "artifact1", "--artifact", "artifact2", "--sbom", "sbom.json"},
+			err:  new(*errSBOMPairs),
+		},
+		{
+			name: "no artifacts",
+			err:  new(*errSBOMPairs),
+		},
+		{
+			name: "missing sbom",
+			args: []string{"--artifact", "artifact1", "--sbom", "missing.json"},
+			err:  new(*errSBOM),
+		},
+		{
+			name: "invalid sbom",
+			args: []string{"--artifact", "artifact1", "--sbom", "invalid.json"},
+			err:  new(*errSBOM),
+		},
+		{
+			name: "missing artifact",
+			args: []string{"--artifact", "missing", "--sbom", "sbom.json"},
+			err:  new(*errSBOM),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chdirTemp(t)
+			writeTestFile(t, "artifact1", "artifact1")
+			writeTestFile(t, "sbom.json", testCycloneDXSBOM)
+			writeTestFile(t, "invalid.json", `{"foo": "bar"}`)
+
+			signer := &statementsSigner{}
+			check := func(err error) {
+				if err != nil {
+					if !errors.As(err, tc.err) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if want, got := errors.ExitCodeInput, errors.ExitCode(err); want != got {
+						t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+					}
+					if len(signer.statements) != 0 {
+						t.Errorf("unexpected signing before error")
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestSBOMCmd(check, signer, &testutil.TestTransparencyLog{})
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(tc.args)
+			if err := c.Execute(); err != nil {
+				t.Errorf("unexpected failure: %v", err)
+			}
+
+			// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+			t.Errorf("expected an error to occur.")
+		})
+	}
+}
+
+// failingTransparencyLog is a TransparencyLog that fails the uploads with the
+// given indexes.
+type failingTransparencyLog struct {
+	testutil.TestTransparencyLog
+	fail    map[int]bool
+	uploads int
+}
+
+func (l *failingTransparencyLog) Upload(ctx context.Context, att signing.Attestation) (signing.LogEntry, error) {
+	i := l.uploads
+	l.uploads++
+	if l.fail[i] {
+		return nil, errors.New("upload failed")
+	}
+	return l.TestTransparencyLog.Upload(ctx, att)
+}
+
+// Test_attestSBOMCmd_continue_on_error tests that the remaining artifacts are
+// attested after a failure and that the failure is reported at the end.
+func Test_attestSBOMCmd_continue_on_error(t *testing.T) {
+	dir := chdirTemp(t)
+
+	var args []string
+	for _, name := range []string{"artifact1", "artifact2", "artifact3"} {
+		writeTestFile(t, name, name)
+		writeTestFile(t, name+".cdx.json", testCycloneDXSBOM)
+		args = append(args, "--artifact", name, "--sbom", name+".cdx.json")
+	}
+	outputPath := filepath.Join(dir, "github-output")
+	if err := os.WriteFile(outputPath, nil, 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Setenv("GITHUB_OUTPUT", outputPath)
+
+	// The failure is only reported by the last call to check.
+	var checkErr error
+	check := func(err error) {
+		if err != nil && checkErr == nil {
+			checkErr = err
+		}
+	}
+
+	tlog := &failingTransparencyLog{fail: map[int]bool{1: true}}
+	c := attestSBOMCmd(check, &statementsSigner{}, tlog)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs(append(args, "--continue-on-error"))
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	if !errors.As(checkErr, new(*errSBOMAttestations)) {
+		t.Fatalf("unexpected error: %v", checkErr)
+	}
+	if want, got := errors.ExitCodeTransparencyLog, errors.ExitCode(checkErr); want != got {
+		t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+	}
+	if want, got := 3, tlog.uploads; want != got {
+		t.Errorf("unexpected uploads, want: %d, got: %d", want, got)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "sbom-attestation-summary.json"))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var summary sbomSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	want := sbomSummary{
+		Succeeded: []sbomResult{
+			{Artifact: "artifact1", SBOM: "artifact1.cdx.json", Attestation: "artifact1.sbom.intoto.jsonl"},
+			{Artifact: "artifact3", SBOM: "artifact3.cdx.json", Attestation: "artifact3.sbom.intoto.jsonl"},
+		},
+		Failed: []sbomResult{
+			{Artifact: "artifact2", SBOM: "artifact2.cdx.json", Error: "uploading SBOM attestation: upload failed"},
+		},
+	}
+	if diff := cmp.Diff(want, summary); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+
+	outputs := readOutputs(t, outputPath)
+	if want, got := string(b), outputs["sbom-attestation-summary"]; want != got {
+		t.Errorf("unexpected sbom-attestation-summary, want: %q, got: %q", want, got)
+	}
+	if want, got := `["artifact1.sbom.intoto.jsonl","artifact3.sbom.intoto.jsonl"]`, outputs["sbom-attestation-names"]; want != got {
+		t.Errorf("unexpected sbom-attestation-names, want: %q, got: %q", want, got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "artifact2.sbom.intoto.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("unexpected attestation for failed artifact: %v", err)
+	}
+}
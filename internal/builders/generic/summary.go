@@ -0,0 +1,125 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// summaryDigestLength is the number of hex characters of the subject digests
+// shown in the job summary.
+const summaryDigestLength = 12
+
+// attestSummary returns a Markdown summary of the attestation written to
+// attPath for the job summary. entry and cert may be nil if the provenance
+// was not uploaded to the transparency log or not signed.
+func attestSummary(subjects []intoto.Subject, attPath string, entry signing.LogEntry, cert []byte) string {
+	var b strings.Builder
+
+	logIndex := "-"
+	if entry != nil {
+		logIndex = fmt.Sprint(entry.LogIndex())
+	}
+	identity := "-"
+	id, err := slsa.ParseCertIdentity(cert)
+	if err == nil && len(id.SANs) > 0 {
+		identity = strings.Join(id.SANs, ", ")
+	}
+
+	fmt.Fprintf(&b, "### SLSA provenance\n\n")
+	fmt.Fprintf(&b, "| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Attestation | `%s` |\n", summaryEscape(attPath))
+	fmt.Fprintf(&b, "| Subjects | %d |\n", len(subjects))
+	fmt.Fprintf(&b, "| Total size | %s |\n", subjectsSize(subjects))
+	fmt.Fprintf(&b, "| Digest algorithms | %s |\n", strings.Join(digestAlgorithms(subjects), ", "))
+	fmt.Fprintf(&b, "| Rekor log index | %s |\n", logIndex)
+	fmt.Fprintf(&b, "| Certificate identity | %s |\n", summaryEscape(identity))
+	if err == nil && id.Issuer != "" {
+		fmt.Fprintf(&b, "| Certificate issuer | %s |\n", summaryEscape(id.Issuer))
+		fmt.Fprintf(&b, "| Source repository | %s |\n", summaryEscape(id.Repository))
+		fmt.Fprintf(&b, "| Source ref | %s |\n", summaryEscape(id.Ref))
+		fmt.Fprintf(&b, "| Workflow trigger | %s |\n", summaryEscape(id.Trigger))
+	}
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, "| Subject | Digest |\n|---|---|\n")
+	for _, s := range subjects {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", summaryEscape(s.Name), summaryDigest(s))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// summaryDigest returns the truncated digest of the subject, preferring
+// SHA-256, as <alg>:<hex>.
+func summaryDigest(s intoto.Subject) string {
+	alg := "sha256"
+	if _, ok := s.Digest[alg]; !ok {
+		algs := digestAlgorithms([]intoto.Subject{s})
+		if len(algs) == 0 {
+			return "-"
+		}
+		alg = algs[0]
+	}
+	d := s.Digest[alg]
+	if len(d) > summaryDigestLength {
+		d = d[:summaryDigestLength] + "…"
+	}
+	return alg + ":" + d
+}
+
+// digestAlgorithms returns the sorted digest algorithms used by the subjects.
+func digestAlgorithms(subjects []intoto.Subject) []string {
+	seen := map[string]bool{}
+	var algs []string
+	for _, s := range subjects {
+		for alg := range s.Digest {
+			if !seen[alg] {
+				seen[alg] = true
+				algs = append(algs, alg)
+			}
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// subjectsSize returns the total size of the subjects in bytes. The size is
+// only known if every subject is a regular file in the current directory.
+func subjectsSize(subjects []intoto.Subject) string {
+	var total int64
+	for _, s := range subjects {
+		info, err := os.Stat(s.Name)
+		if err != nil || !info.Mode().IsRegular() {
+			return "unknown"
+		}
+		total += info.Size()
+	}
+	return fmt.Sprintf("%d bytes", total)
+}
+
+// summaryEscape escapes characters that would break a Markdown table cell.
+func summaryEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "`", "'", "\n", " ").Replace(s)
+}
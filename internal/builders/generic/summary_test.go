@@ -0,0 +1,172 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	"github.com/sigstore/fulcio/pkg/certificate"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+func Test_attestSummary(t *testing.T) {
+	chdirTemp(t)
+	writeTestFile(t, "artifact1", "hello\n")
+	writeTestFile(t, "artifact2", "world!\n")
+
+	identity := "https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0"
+	cert, err := testutil.NewTestCert(identity, certificate.Extensions{
+		Issuer:                   "https://token.actions.githubusercontent.com",
+		GithubWorkflowTrigger:    "push",
+		GithubWorkflowRepository: "owner/repo",
+		GithubWorkflowRef:        "refs/tags/v1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		subjects []intoto.Subject
+		entry    signing.LogEntry
+		cert     []byte
+		expected []string
+	}{
+		{
+			name: "signed and uploaded",
+			subjects: []intoto.Subject{
+				{Name: "artifact1", Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"}},
+				{Name: "artifact2", Digest: slsacommon.DigestSet{
+					"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730",
+					"sha512": "0cf9180a764aba863a67b6d72f0918bc131c6772642cb2dce5a34f0a702f9470ddc2bf125c12198b1995c233c34b4afd346c54a2334c350a948a51b6e8b4e6b6",
+				}},
+			},
+			entry: testutil.NewTestLogEntry(),
+			cert:  cert,
+			expected: []string{
+				"| Attestation | `multiple.intoto.jsonl` |",
+				"| Subjects | 2 |",
+				"| Total size | 13 bytes |",
+				"| Digest algorithms | sha256, sha512 |",
+				"| Rekor log index | 42 |",
+				"| Certificate identity | " + identity + " |",
+				"| Certificate issuer | https://token.actions.githubusercontent.com |",
+				"| Source repository | owner/repo |",
+				"| Source ref | refs/tags/v1.0.0 |",
+				"| Workflow trigger | push |",
+				"| `artifact1` | sha256:b5bb9d8014a0… |",
+				"| `artifact2` | sha256:7d865e959b24… |",
+			},
+		},
+		{
+			name: "not uploaded",
+			subjects: []intoto.Subject{
+				{Name: "dist/missing|file", Digest: slsacommon.DigestSet{"sha512": "0cf9180a764a"}},
+			},
+			expected: []string{
+				"| Subjects | 1 |",
+				"| Total size | unknown |",
+				"| Digest algorithms | sha512 |",
+				"| Rekor log index | - |",
+				"| Certificate identity | - |",
+				"| `dist/missing\\|file` | sha512:0cf9180a764a |",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := attestSummary(tc.subjects, "multiple.intoto.jsonl", tc.entry, tc.cert)
+
+			lines := strings.Split(got, "\n")
+			for _, want := range tc.expected {
+				if !containsLine(lines, want) {
+					t.Errorf("unexpected summary, want line: %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+// containsLine returns whether lines contains line.
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// Test_attestCmd_step_summary tests that attest writes the job summary unless
+// --no-step-summary is set.
+func Test_attestCmd_step_summary(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{
+			name:     "summary",
+			expected: true,
+		},
+		{
+			name: "no summary",
+			args: []string{"--no-step-summary"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			summaryPath := filepath.Join(dir, "summary.md")
+			if err := os.WriteFile(summaryPath, nil, 0o600); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+			c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			b, err := os.ReadFile(summaryPath)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if got := strings.Contains(string(b), "| `artifact1` | sha256:b5bb9d8014a0… |"); got != tc.expected {
+				t.Errorf("unexpected summary, want subject row: %v, got:\n%s", tc.expected, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// errTokenFile indicates that the token file could not be read.
+type errTokenFile struct {
+	errors.ErrInput
+}
+
+// errInsecureTokenFile indicates that the token file can be read by users
+// other than its owner.
+type errInsecureTokenFile struct {
+	errors.ErrInput
+}
+
+// readTokenFile reads a token written to a file, e.g. by a credential helper.
+// Surrounding whitespace is removed. The file must not be accessible by group
+// or others, i.e. it should have mode 0600.
+func readTokenFile(path string) (string, error) {
+	// NOTE: The token file is usually written outside of the workspace so the
+	// path is not required to be under the current directory.
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", errors.Errorf(&errTokenFile{}, "reading token file: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return "", errors.Errorf(&errInsecureTokenFile{}, "token file %q has mode %#o, want 0600", path, perm)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Errorf(&errTokenFile{}, "reading token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", errors.Errorf(&errTokenFile{}, "token file %q is empty", path)
+	}
+	return token, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+func Test_readTokenFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		mode     os.FileMode
+		missing  bool
+		expected string
+		// insecure indicates that errInsecureTokenFile is expected.
+		insecure bool
+		// err indicates that errTokenFile is expected.
+		err bool
+	}{
+		{
+			name:     "mode 0600",
+			contents: " token\n",
+			mode:     0o600,
+			expected: "token",
+		},
+		{
+			name:     "mode 0400",
+			contents: "token",
+			mode:     0o400,
+			expected: "token",
+		},
+		{
+			name:     "group readable",
+			contents: "token",
+			mode:     0o640,
+			insecure: true,
+		},
+		{
+			name:     "world readable",
+			contents: "token",
+			mode:     0o644,
+			insecure: true,
+		},
+		{
+			name:     "empty",
+			contents: " \n",
+			mode:     0o600,
+			err:      true,
+		},
+		{
+			name:    "missing",
+			missing: true,
+			err:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token")
+			if !tc.missing {
+				if err := os.WriteFile(path, []byte(tc.contents), 0o600); err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				// Set the mode explicitly since WriteFile is subject to umask.
+				if err := os.Chmod(path, tc.mode); err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+			}
+
+			token, err := readTokenFile(path)
+			switch {
+			case tc.insecure:
+				errInsecure := &errInsecureTokenFile{}
+				if !errors.As(err, &errInsecure) {
+					t.Fatalf("unexpected error, want errInsecureTokenFile, got: %v", err)
+				}
+			case tc.err:
+				errToken := &errTokenFile{}
+				if !errors.As(err, &errToken) {
+					t.Fatalf("unexpected error, want errTokenFile, got: %v", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			if want, got := tc.expected, token; want != got {
+				t.Errorf("unexpected token, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_insecure_token_file tests that the command fails if the
+// token file is readable by others.
+func Test_attestCmd_insecure_token_file(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("token"), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	check := func(err error) {
+		if err != nil {
+			errInsecure := &errInsecureTokenFile{}
+			if !errors.As(err, &errInsecure) {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := errors.ExitCodeInput, errors.ExitCode(err); want != got {
+				t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	c := attestCmd(&slsa.NilClientProvider{}, check, &testutil.TestSigner{}, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--github-token-file", path,
+	})
+	if err := c.Execute(); err != nil {
+		t.Errorf("unexpected failure: %v", err)
+	}
+
+	// If no error occurs we catch it here. SkipNow will exit the test process so this code should be unreachable.
+	t.Errorf("expected an error to occur.")
+}
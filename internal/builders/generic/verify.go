@@ -0,0 +1,211 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/spf13/cobra"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+// Values of the --require flag of the 'verify' command.
+const (
+	verifyRequireAll = "all"
+	verifyRequireAny = "any"
+)
+
+// errVerifyEnvelope indicates a provenance file that is not a valid envelope.
+type errVerifyEnvelope struct {
+	errors.ErrInput
+}
+
+// errVerifyKey indicates a public key that cannot be read.
+type errVerifyKey struct {
+	errors.ErrInput
+}
+
+// errVerifySignatures indicates that the required signatures are missing or
+// invalid. It is unclassified so that the command exits with code 1.
+type errVerifySignatures struct {
+	errors.WrappableError
+}
+
+// verifySignature returns whether sig is a valid base64 encoded signature of
+// pae by the public key.
+func verifySignature(pub crypto.PublicKey, pae []byte, sig string) bool {
+	b, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	v, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return false
+	}
+	return v.VerifySignature(bytes.NewReader(b), bytes.NewReader(pae)) == nil
+}
+
+// verifyKey returns an error unless the envelope has a valid signature by the
+// PEM encoded public key.
+func verifyKey(env *envelope.Envelope, pae, keyPEM []byte) error {
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(keyPEM)
+	if err != nil {
+		return errors.Errorf(&errVerifyKey{}, "parsing public key: %w", err)
+	}
+	for _, s := range env.Signatures {
+		if verifySignature(pub, pae, s.Sig) {
+			return nil
+		}
+	}
+	return errors.New("no valid signature by the key")
+}
+
+// verifyKeyless returns an error unless the envelope has a valid signature by
+// the certificate embedded in it, whose identity matches the expected source
+// and workflow. The certificate chain is not verified.
+func verifyKeyless(env *envelope.Envelope, pae []byte, expectedSource, expectedWorkflow string) error {
+	for _, s := range env.Signatures {
+		if s.Cert == "" {
+			continue
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(s.Cert))
+		if err != nil || len(certs) == 0 {
+			continue
+		}
+		if verifySignature(certs[0].PublicKey, pae, s.Sig) {
+			return checkCertIdentity([]byte(s.Cert), expectedSource, expectedWorkflow)
+		}
+	}
+	return errors.New("no valid keyless signature")
+}
+
+// verifyCmd returns the 'verify' command.
+func verifyCmd(check func(error)) *cobra.Command {
+	var keys []string
+	var keyless bool
+	var require string
+	var expectedSource string
+	var expectedWorkflow string
+
+	c := &cobra.Command{
+		Use:   "verify <provenance>",
+		Short: "Verify the signatures of a provenance envelope",
+		Long: `Verify the signatures of a signed provenance, e.g. one signed both keyless and
+with a KMS key using --additional-signer. Each --key and --keyless is a check
+that passes if the envelope has a valid signature by the key or by the
+certificate embedded in the envelope. With --require all, the default, every
+check must pass; with --require any, at least one.
+
+Only the signatures are checked. The certificate chain, the transparency log
+entry and the provenance itself are not; use slsa-verifier to verify them.`,
+		Args: cobra.ExactArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if require != verifyRequireAll && require != verifyRequireAny {
+				check(fmt.Errorf("invalid --require %q, expected %q or %q", require, verifyRequireAll, verifyRequireAny))
+			}
+			if len(keys) == 0 && !keyless {
+				check(errors.New("expected at least one of --key or --keyless"))
+			}
+			if (expectedSource != "" || expectedWorkflow != "") && !keyless {
+				check(errors.New("--expected-source and --expected-workflow require --keyless"))
+			}
+
+			// NOTE: The path is untrusted and should be validated.
+			check(utils.PathIsUnderCurrentDirectory(args[0]))
+			b, err := os.ReadFile(filepath.Clean(args[0]))
+			if err != nil {
+				check(errors.Errorf(&errors.ErrFilesystem{}, "reading provenance: %w", err))
+			}
+			env := &envelope.Envelope{}
+			if err := json.Unmarshal(b, env); err != nil {
+				check(errors.Errorf(&errVerifyEnvelope{}, "parsing envelope: %w", err))
+			}
+			payload, err := base64.StdEncoding.DecodeString(env.Payload)
+			if err != nil {
+				check(errors.Errorf(&errVerifyEnvelope{}, "decoding payload: %w", err))
+			}
+			pae := dsse.PAE(env.PayloadType, payload)
+
+			var passed, failed int
+			report := func(name string, err error) {
+				if err != nil {
+					// Unreadable keys are input errors rather than failed checks.
+					if errors.As(err, new(*errVerifyKey)) {
+						check(fmt.Errorf("%s: %w", name, err))
+					}
+					failed++
+					fmt.Fprintf(cmd.OutOrStdout(), "FAILED: %s: %v\n", name, err)
+					return
+				}
+				passed++
+				fmt.Fprintf(cmd.OutOrStdout(), "OK: %s\n", name)
+			}
+			for _, k := range keys {
+				// NOTE: The path is untrusted and should be validated.
+				check(utils.PathIsUnderCurrentDirectory(k))
+				keyPEM, err := os.ReadFile(filepath.Clean(k))
+				if err != nil {
+					check(errors.Errorf(&errVerifyKey{}, "reading key %q: %w", k, err))
+				}
+				report(fmt.Sprintf("key %q", k), verifyKey(env, pae, keyPEM))
+			}
+			if keyless {
+				report("keyless", verifyKeyless(env, pae, expectedSource, expectedWorkflow))
+			}
+
+			if (require == verifyRequireAll && failed > 0) || passed == 0 {
+				check(errors.Errorf(&errVerifySignatures{}, "%d of %d signature checks failed", failed,
+					passed+failed))
+			}
+		},
+	}
+
+	c.Flags().StringArrayVar(
+		&keys, "key", nil,
+		"Path to a PEM encoded public key, e.g. of the gcpkms or file signer, that must have signed the "+
+			"provenance. May be repeated.",
+	)
+	c.Flags().BoolVar(
+		&keyless, "keyless", false,
+		"Check for a signature by the certificate embedded in the envelope, e.g. of the sigstore or fulcio signer.",
+	)
+	c.Flags().StringVar(
+		&require, "require", verifyRequireAll,
+		"Whether \"all\" the checks or \"any\" of them must pass.",
+	)
+	c.Flags().StringVar(
+		&expectedSource, "expected-source", "",
+		"The source repository, of the form owner/repo[@ref], expected in the keyless signing certificate.",
+	)
+	c.Flags().StringVar(
+		&expectedWorkflow, "expected-workflow", "",
+		"The workflow path expected in the keyless signing certificate, "+
+			"e.g. owner/repo/.github/workflows/release.yml.",
+	)
+	return c
+}
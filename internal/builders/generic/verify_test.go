@@ -0,0 +1,259 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// keylessTestSigner is a Signer that signs with an ECDSA key and embeds a
+// self-signed certificate in the envelope, like the Fulcio signers.
+type keylessTestSigner struct {
+	key  *ecdsa.PrivateKey
+	cert []byte
+}
+
+func newKeylessTestSigner(t *testing.T) *keylessTestSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "keyless"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+	}
+	der, err := x509.CreateCertificate(cryptorand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return &keylessTestSigner{
+		key:  key,
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func (s *keylessTestSigner) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignPayload(ctx, payload)
+}
+
+func (s *keylessTestSigner) SignPayload(_ context.Context, payload []byte) (signing.Attestation, error) {
+	h := sha256.Sum256(dsse.PAE(intoto.PayloadType, payload))
+	sig, err := ecdsa.SignASN1(cryptorand.Reader, s.key, h[:])
+	if err != nil {
+		return nil, err
+	}
+	env, err := envelope.Marshal(intoto.PayloadType, payload, "", sig, s.cert)
+	if err != nil {
+		return nil, err
+	}
+	return &testutil.TestAttestation{CertVal: s.cert, BytesVal: env}, nil
+}
+
+// writeEd25519Key writes a new Ed25519 key pair to <name>.pem and
+// <name>.pub in the current directory.
+func writeEd25519Key(t *testing.T, name string) (privPath, pubPath string) {
+	pub, priv, err := ed25519.GenerateKey(cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	privPath, pubPath = name+".pem", name+".pub"
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return privPath, pubPath
+}
+
+// runVerifyCmd runs the 'verify' command and returns the error passed to
+// check, if any.
+func runVerifyCmd(t *testing.T, args ...string) error {
+	t.Helper()
+
+	var checkErr error
+	check := func(err error) {
+		if err != nil && checkErr == nil {
+			checkErr = err
+		}
+	}
+	c := verifyCmd(check)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs(args)
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return checkErr
+}
+
+// Test_attestCmd_additional_signer tests that --additional-signer adds a
+// signature over the same payload and that both signatures can be verified.
+func Test_attestCmd_additional_signer(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	chdirTemp(t)
+
+	privPath, pubPath := writeEd25519Key(t, "key")
+	_, otherPubPath := writeEd25519Key(t, "other")
+
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), newKeylessTestSigner(t), &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--additional-signer", "file",
+		"--signing-key", privPath,
+		"--no-transparency-log",
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	b, err := os.ReadFile("artifact1.intoto.jsonl")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	// The signatures are in the order of the signers.
+	if want, got := 2, len(env.Signatures); want != got {
+		t.Fatalf("unexpected number of signatures, want: %d, got: %d", want, got)
+	}
+	if env.Signatures[0].Cert == "" || env.Signatures[1].Cert != "" {
+		t.Errorf("unexpected signature order: %s", b)
+	}
+
+	testCases := []struct {
+		name string
+		args []string
+		err  interface{}
+	}{
+		{
+			name: "key and keyless",
+			args: []string{"--key", pubPath, "--keyless"},
+		},
+		{
+			name: "key",
+			args: []string{"--key", pubPath},
+		},
+		{
+			name: "wrong key",
+			args: []string{"--key", otherPubPath, "--keyless"},
+			err:  new(*errVerifySignatures),
+		},
+		{
+			name: "wrong key with any",
+			args: []string{"--key", otherPubPath, "--keyless", "--require", "any"},
+		},
+		{
+			name: "wrong key only with any",
+			args: []string{"--key", otherPubPath, "--require", "any"},
+			err:  new(*errVerifySignatures),
+		},
+		{
+			name: "unexpected keyless identity",
+			args: []string{"--keyless", "--expected-source", "owner/repo"},
+			err:  new(*errVerifySignatures),
+		},
+		{
+			name: "invalid key",
+			args: []string{"--key", "artifact1.intoto.jsonl"},
+			err:  new(*errVerifyKey),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := runVerifyCmd(t, append([]string{"artifact1.intoto.jsonl"}, tc.args...)...)
+			if tc.err == nil {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, tc.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test_attestCmd_additional_signer_duplicate tests that a signer can only be
+// given once.
+func Test_attestCmd_additional_signer_duplicate(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", "{}")
+	chdirTemp(t)
+
+	signer := &recordingSigner{}
+	check := func(err error) {
+		if err != nil {
+			if signer.statement != nil {
+				t.Errorf("unexpected signing before error")
+			}
+			// Check should exit the program so we skip the rest of the test if we got the expected error.
+			t.SkipNow()
+		}
+	}
+
+	c := attestCmd(&slsa.NilClientProvider{}, check, signer, &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--additional-signer", "sigstore",
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Fatalf("expected an error to occur.")
+}
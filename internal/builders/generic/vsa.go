@@ -0,0 +1,115 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// vsaPolicyURI is the policy recorded in the VSA: the SLSA v1.0 build levels.
+const vsaPolicyURI = "https://slsa.dev/spec/v1.0/levels"
+
+// errSelfVerify indicates that the signed provenance failed verification.
+type errSelfVerify struct {
+	errors.ErrSigning
+}
+
+// vsaPath returns the path of the VSA written for the provenance at attPath,
+// i.e. <name>.vsa.intoto.jsonl for <name>.intoto.jsonl.
+func vsaPath(attPath string) string {
+	return strings.TrimSuffix(attPath, ".intoto.jsonl") + ".vsa.intoto.jsonl"
+}
+
+// selfVerify returns an errSelfVerify unless the envelope attBytes has the
+// payload statement and a valid keyless signature whose certificate identity
+// matches the expected source and workflow.
+func selfVerify(attBytes, statement []byte, expectedSource, expectedWorkflow string) error {
+	env := &envelope.Envelope{}
+	if err := json.Unmarshal(attBytes, env); err != nil {
+		return errors.Errorf(&errSelfVerify{}, "parsing signed provenance: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return errors.Errorf(&errSelfVerify{}, "decoding signed provenance payload: %w", err)
+	}
+	if env.PayloadType != intoto.PayloadType || !bytes.Equal(payload, statement) {
+		return errors.Errorf(&errSelfVerify{}, "the signed provenance does not have the payload of the statement")
+	}
+	if err := verifyKeyless(env, dsse.PAE(env.PayloadType, payload), expectedSource, expectedWorkflow); err != nil {
+		return errors.Errorf(&errSelfVerify{}, "verifying signed provenance: %w", err)
+	}
+	return nil
+}
+
+// vsaVerifierID returns the URI of the workflow that verified the provenance:
+// the URI subject alternative name of the signing certificate if any, or else
+// the workflow ref of the GitHub Actions environment.
+func vsaVerifierID(cert []byte, ghContext *github.WorkflowContext) string {
+	if id, err := slsa.ParseCertIdentity(cert); err == nil {
+		for _, san := range id.SANs {
+			if strings.HasPrefix(san, "https://") {
+				return san
+			}
+		}
+	}
+	serverURL := ghContext.ServerURL
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	return serverURL + "/" + os.Getenv("GITHUB_WORKFLOW_REF")
+}
+
+// newVSAStatement returns the unsigned VSA for the subjects of the statement
+// s, whose signed provenance attBytes at attPath passed verification at now.
+func newVSAStatement(s *intoto.Statement, attPath string, attBytes, cert []byte,
+	ghContext *github.WorkflowContext, now time.Time,
+) *intoto.Statement {
+	digest := sha256.Sum256(attBytes)
+	return slsa.NewVSAStatement(s.Subject, &slsa.VSAPredicate{
+		Verifier: slsa.VSAVerifier{
+			ID: vsaVerifierID(cert, ghContext),
+		},
+		TimeVerified: now.UTC(),
+		ResourceURI:  ghContext.RepositoryURI(),
+		Policy: slsa.VSAResourceDescriptor{
+			URI: vsaPolicyURI,
+		},
+		InputAttestations: []slsa.VSAResourceDescriptor{
+			{
+				URI:    attPath,
+				Digest: slsacommon.DigestSet{"sha256": hex.EncodeToString(digest[:])},
+			},
+		},
+		VerificationResult: slsa.VSAResultPassed,
+		VerifiedLevels:     []string{slsa.BuildLevel3},
+		SLSAVersion:        "1.0",
+	})
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// Test_attestCmd_emit_vsa tests that --emit-vsa writes a signed VSA with the
+// fields required by https://slsa.dev/spec/v1.0/verification_summary.
+func Test_attestCmd_emit_vsa(t *testing.T) {
+	t.Setenv("GITHUB_CONTEXT", `{
+		"repository": "owner/repo",
+		"ref": "refs/heads/main",
+		"server_url": "https://github.com"
+	}`)
+	t.Setenv("GITHUB_WORKFLOW_REF", "owner/repo/.github/workflows/release.yml@refs/heads/main")
+	chdirTemp(t)
+
+	start := time.Now().UTC().Truncate(time.Second)
+	c := attestCmd(&slsa.NilClientProvider{}, checkTest(t), newKeylessTestSigner(t), &testutil.TestTransparencyLog{}, nil)
+	c.SetOut(new(bytes.Buffer))
+	c.SetArgs([]string{
+		"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+		"--no-transparency-log",
+		"--self-verify",
+		"--emit-vsa",
+	})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	attBytes, err := os.ReadFile("artifact1.intoto.jsonl")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	b, err := os.ReadFile("artifact1.vsa.intoto.jsonl")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if len(env.Signatures) != 1 {
+		t.Fatalf("unexpected number of signatures: %d", len(env.Signatures))
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	predicate, _ := got["predicate"].(map[string]interface{})
+	timeVerified, err := time.Parse(time.RFC3339, predicate["timeVerified"].(string))
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if timeVerified.Before(start) {
+		t.Errorf("unexpected timeVerified: %v", timeVerified)
+	}
+
+	attDigest := sha256.Sum256(attBytes)
+	want := map[string]interface{}{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/verification_summary/v1",
+		"subject": []interface{}{
+			map[string]interface{}{
+				"name":   "artifact1",
+				"digest": map[string]interface{}{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+			},
+		},
+		"predicate": map[string]interface{}{
+			"verifier": map[string]interface{}{
+				"id": "https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+			},
+			"resourceUri": "git+https://github.com/owner/repo@refs/heads/main",
+			"policy": map[string]interface{}{
+				"uri": "https://slsa.dev/spec/v1.0/levels",
+			},
+			"inputAttestations": []interface{}{
+				map[string]interface{}{
+					"uri":    "artifact1.intoto.jsonl",
+					"digest": map[string]interface{}{"sha256": hex.EncodeToString(attDigest[:])},
+				},
+			},
+			"verificationResult": "PASSED",
+			"verifiedLevels":     []interface{}{"SLSA_BUILD_LEVEL_3"},
+			"slsaVersion":        "1.0",
+		},
+	}
+	ignoreTime := cmpopts.IgnoreMapEntries(func(k string, _ interface{}) bool { return k == "timeVerified" })
+	if diff := cmp.Diff(want, got, ignoreTime); diff != "" {
+		t.Errorf("unexpected VSA (-want +got):\n%s", diff)
+	}
+}
+
+// Test_attestCmd_self_verify tests that provenance that fails verification is
+// not written, and that --emit-vsa requires --self-verify.
+func Test_attestCmd_self_verify(t *testing.T) {
+	// A signer whose certificate is not for its key.
+	badSigner := newKeylessTestSigner(t)
+	badSigner.cert = newKeylessTestSigner(t).cert
+
+	testCases := []struct {
+		name   string
+		signer *keylessTestSigner
+		args   []string
+		err    interface{}
+	}{
+		{
+			name:   "invalid signature",
+			signer: badSigner,
+			args:   []string{"--self-verify"},
+			err:    new(*errSelfVerify),
+		},
+		{
+			name:   "emit vsa without self verify",
+			signer: newKeylessTestSigner(t),
+			args:   []string{"--emit-vsa"},
+			err:    new(*errors.ErrInput),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_CONTEXT", "{}")
+			chdirTemp(t)
+
+			check := func(err error) {
+				if err != nil {
+					if tc.err != nil && !errors.As(err, tc.err) {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if _, err := os.Stat("artifact1.intoto.jsonl"); !os.IsNotExist(err) {
+						t.Errorf("unexpected provenance file: %v", err)
+					}
+					// Check should exit the program so we skip the rest of the test if we got the expected error.
+					t.SkipNow()
+				}
+			}
+
+			c := attestCmd(&slsa.NilClientProvider{}, check, tc.signer, &testutil.TestTransparencyLog{}, nil)
+			c.SetOut(new(bytes.Buffer))
+			c.SetArgs(append([]string{
+				"--subjects", base64.StdEncoding.EncodeToString([]byte(testHash)),
+				"--no-transparency-log",
+			}, tc.args...))
+			if err := c.Execute(); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			t.Fatalf("expected an error to occur.")
+		})
+	}
+}
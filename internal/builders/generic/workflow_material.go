@@ -0,0 +1,91 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	githubapi "github.com/google/go-github/v50/github"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// errWorkflowRef indicates an invalid workflow ref in the GitHub context.
+type errWorkflowRef struct {
+	errors.ErrInput
+}
+
+// errWorkflowContents indicates that the contents of the workflow file could
+// not be read.
+type errWorkflowContents struct {
+	errors.WrappableError
+}
+
+// parseWorkflowRef parses a workflow ref of the form
+// owner/repo/.github/workflows/build.yml@refs/heads/main.
+func parseWorkflowRef(workflowRef string) (owner, repo, path, ref string, err error) {
+	p, ref, ok := strings.Cut(workflowRef, "@")
+	parts := strings.SplitN(p, "/", 3)
+	if !ok || ref == "" || len(parts) != 3 || parts[0] == "" || parts[1] == "" ||
+		!strings.HasPrefix(parts[2], ".github/workflows/") {
+		return "", "", "", "", errors.Errorf(&errWorkflowRef{},
+			"invalid workflow ref %q, expected owner/repo/.github/workflows/<file>@<ref>", workflowRef)
+	}
+	return parts[0], parts[1], parts[2], ref, nil
+}
+
+// workflowMaterial returns a material recording the sha256 digest of the
+// workflow file at workflowRef, as of the commit workflowSHA. The file is
+// read with the GitHub contents API. If ghClient is nil, no material is
+// returned.
+func workflowMaterial(ctx context.Context, ghClient *githubapi.Client,
+	serverURL, workflowRef, workflowSHA string,
+) (*slsacommon.ProvenanceMaterial, error) {
+	owner, repo, path, ref, err := parseWorkflowRef(workflowRef)
+	if err != nil {
+		return nil, err
+	}
+	if ghClient == nil {
+		return nil, nil
+	}
+
+	f, _, _, err := ghClient.Repositories.GetContents(ctx, owner, repo, path,
+		&githubapi.RepositoryContentGetOptions{Ref: workflowSHA})
+	if err != nil {
+		return nil, errors.Errorf(&errWorkflowContents{}, "getting workflow %q: %w", workflowRef, err)
+	}
+	if f == nil {
+		return nil, errors.Errorf(&errWorkflowContents{}, "workflow %q is not a file", workflowRef)
+	}
+	content, err := f.GetContent()
+	if err != nil {
+		return nil, errors.Errorf(&errWorkflowContents{}, "decoding workflow %q: %w", workflowRef, err)
+	}
+
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	digest := sha256.Sum256([]byte(content))
+	return &slsacommon.ProvenanceMaterial{
+		URI: "git+" + strings.TrimSuffix(serverURL, "/") + "/" + owner + "/" + repo + "/" + path + "@" + ref,
+		Digest: slsacommon.DigestSet{
+			"sha256": hex.EncodeToString(digest[:]),
+		},
+	}, nil
+}
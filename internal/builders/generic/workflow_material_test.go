@@ -0,0 +1,134 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	githubapi "github.com/google/go-github/v50/github"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const (
+	testWorkflowSHA      = "8fa2e2fbf1a1ba4ea1b6ab5ec2e18b8fcc0a3b46"
+	testWorkflowContents = "name: release\non: push\n"
+)
+
+// newFakeContentsServer returns a client for a fake GitHub API server serving
+// the contents of .github/workflows/release.yml in owner/repo at
+// testWorkflowSHA.
+func newFakeContentsServer(t *testing.T) *githubapi.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/contents/.github/workflows/release.yml" ||
+			r.URL.Query().Get("ref") != testWorkflowSHA {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": %q}`,
+			base64.StdEncoding.EncodeToString([]byte(testWorkflowContents)))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := githubapi.NewClient(nil)
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	client.BaseURL = u
+	return client
+}
+
+func Test_workflowMaterial(t *testing.T) {
+	digest := sha256.Sum256([]byte(testWorkflowContents))
+
+	testCases := []struct {
+		name        string
+		nilClient   bool
+		workflowRef string
+		workflowSHA string
+		expected    *slsacommon.ProvenanceMaterial
+		err         interface{}
+	}{
+		{
+			name:        "workflow",
+			workflowRef: "owner/repo/.github/workflows/release.yml@refs/heads/main",
+			workflowSHA: testWorkflowSHA,
+			expected: &slsacommon.ProvenanceMaterial{
+				URI:    "git+https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+				Digest: slsacommon.DigestSet{"sha256": hex.EncodeToString(digest[:])},
+			},
+		},
+		{
+			name:        "no client",
+			nilClient:   true,
+			workflowRef: "owner/repo/.github/workflows/release.yml@refs/heads/main",
+			workflowSHA: testWorkflowSHA,
+		},
+		{
+			name:        "unknown commit",
+			workflowRef: "owner/repo/.github/workflows/release.yml@refs/heads/main",
+			workflowSHA: "0000000000000000000000000000000000000000",
+			err:         new(*errWorkflowContents),
+		},
+		{
+			name:        "missing ref",
+			workflowRef: "owner/repo/.github/workflows/release.yml",
+			workflowSHA: testWorkflowSHA,
+			err:         new(*errWorkflowRef),
+		},
+		{
+			name:        "not a workflow",
+			workflowRef: "owner/repo/README.md@refs/heads/main",
+			workflowSHA: testWorkflowSHA,
+			err:         new(*errWorkflowRef),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var client *githubapi.Client
+			if !tc.nilClient {
+				client = newFakeContentsServer(t)
+			}
+
+			m, err := workflowMaterial(context.Background(), client, "", tc.workflowRef, tc.workflowSHA)
+			if tc.err != nil {
+				if !errors.As(err, tc.err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, m); diff != "" {
+				t.Errorf("unexpected material (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
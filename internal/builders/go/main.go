@@ -31,6 +31,7 @@ import (
 	_ "github.com/sigstore/cosign/pkg/providers/github"
 
 	"github.com/slsa-framework/slsa-github-generator/internal/builders/go/pkg"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
 	"github.com/slsa-framework/slsa-github-generator/internal/utils"
 )
 
@@ -42,12 +43,11 @@ func usage(p string) {
 
 func check(e error) {
 	if e != nil {
-		fmt.Fprint(os.Stderr, e.Error())
-		os.Exit(1)
+		os.Exit(errors.WriteExitError(os.Stderr, e))
 	}
 }
 
-func runBuild(dry bool, configFile, evalEnvs string) error {
+func runBuild(dry bool, configFile, evalEnvs string, requireReproducible bool) error {
 	goc, err := exec.LookPath("go")
 	if err != nil {
 		return err
@@ -59,6 +59,15 @@ func runBuild(dry bool, configFile, evalEnvs string) error {
 	}
 	fmt.Println(cfg)
 
+	if requireReproducible {
+		if err := cfg.CheckReproducible(); err != nil {
+			return err
+		}
+	}
+	for _, w := range cfg.ReproducibilityWarnings() {
+		github.Warning(os.Stderr, &github.AnnotationLocation{File: configFile}, w)
+	}
+
 	gobuild := pkg.GoBuildNew(goc, cfg)
 
 	// Set env variables encoded as arguments.
@@ -75,11 +84,21 @@ func runBuild(dry bool, configFile, evalEnvs string) error {
 	return nil
 }
 
-func runProvenanceGeneration(subject, digest, commands, envs, workingDir, rekor string) error {
+func runProvenanceGeneration(subject, digest, commands, envs, workingDir, rekor string, reproducible bool, purl string,
+	sortSubjects bool, cc, ccVersionDigest string,
+) error {
+	var toolchain *pkg.CToolchain
+	if cc != "" || ccVersionDigest != "" {
+		toolchain = &pkg.CToolchain{
+			Compiler:      cc,
+			VersionDigest: map[string]string{"sha256": ccVersionDigest},
+		}
+	}
+
 	r := sigstore.NewRekor(rekor)
 	s := sigstore.NewDefaultFulcio()
 	attBytes, err := pkg.GenerateProvenance(subject, digest,
-		commands, envs, workingDir, s, r, nil)
+		commands, envs, workingDir, reproducible, purl, sortSubjects, toolchain, s, r, nil)
 	if err != nil {
 		return err
 	}
@@ -114,6 +133,7 @@ func main() {
 	// Build command.
 	buildCmd := flag.NewFlagSet("build", flag.ExitOnError)
 	buildDry := buildCmd.Bool("dry", false, "dry run of the build without invoking compiler")
+	buildRequireReproducible := buildCmd.Bool("require-reproducible", false, "fail if the build configuration is not reproducible")
 
 	// Provenance command.
 	provenanceCmd := flag.NewFlagSet("provenance", flag.ExitOnError)
@@ -123,6 +143,11 @@ func main() {
 	provenanceEnv := provenanceCmd.String("env", "", "env variables used to compile the binary")
 	provenanceWorkingDir := provenanceCmd.String("workingDir", "", "working directory used to issue compilation commands")
 	provenanceRekor := provenanceCmd.String("rekor", sigstore.DefaultRekorAddr, "rekor server to use for provenance")
+	provenanceReproducible := provenanceCmd.Bool("reproducible", false, "whether the build configuration is reproducible")
+	provenancePURL := provenanceCmd.String("purl", "", "untrusted package URL of the module, recorded as a second subject")
+	provenanceSortSubjects := provenanceCmd.Bool("sort-subjects", true, "sort subjects by name and digest so the provenance is deterministic")
+	provenanceCC := provenanceCmd.String("cc", "", "C compiler used by a cgo build")
+	provenanceCCVersion := provenanceCmd.String("cc-version-sha256", "", "sha256 digest of the C compiler's --version output")
 
 	// Expect a sub-command.
 	if len(os.Args) < 2 {
@@ -138,7 +163,7 @@ func main() {
 		configFile := buildCmd.Args()[0]
 		evaluatedEnvs := buildCmd.Args()[1]
 
-		check(runBuild(*buildDry, configFile, evaluatedEnvs))
+		check(runBuild(*buildDry, configFile, evaluatedEnvs, *buildRequireReproducible))
 
 	case provenanceCmd.Name():
 		check(provenanceCmd.Parse(os.Args[2:]))
@@ -149,7 +174,8 @@ func main() {
 		}
 
 		err := runProvenanceGeneration(*provenanceName, *provenanceDigest,
-			*provenanceCommand, *provenanceEnv, *provenanceWorkingDir, *provenanceRekor)
+			*provenanceCommand, *provenanceEnv, *provenanceWorkingDir, *provenanceRekor, *provenanceReproducible, *provenancePURL,
+			*provenanceSortSubjects, *provenanceCC, *provenanceCCVersion)
 		check(err)
 
 	default:
@@ -300,7 +300,7 @@ func Test_runBuild(t *testing.T) {
 
 			err = runBuild(true,
 				tt.config,
-				tt.evalEnvs)
+				tt.evalEnvs, false)
 
 			if tt.err != nil {
 				tt.err(t, err)
@@ -20,6 +20,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/slsa-framework/slsa-github-generator/github"
@@ -44,28 +46,24 @@ var allowedBuildArgs = map[string]bool{
 	"-tags": true, "-trimpath": true,
 }
 
-var allowedEnvVariablePrefix = map[string]bool{
-	"GO": true, "CGO_": true,
-}
-
 type errEnvVariableNameEmpty struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 type errUnsupportedArguments struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 type errInvalidEnvArgument struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 type errEnvVariableNameNotAllowed struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 type errInvalidFilename struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 // GoBuild implements building a Go application.
@@ -179,6 +177,12 @@ func (b *GoBuild) Run(dry bool) error {
 			return err
 		}
 
+		// Share whether the build is reproducible.
+		reproducible := len(b.cfg.ReproducibilityWarnings()) == 0
+		if err := github.SetOutput("go-reproducible", strconv.FormatBool(reproducible)); err != nil {
+			return err
+		}
+
 		// Share working directory necessary for issuing the vendoring command.
 		return github.SetOutput("go-working-dir", dir)
 	}
@@ -207,8 +211,34 @@ func (b *GoBuild) Run(dry bool) error {
 	}
 
 	// TODO: Add a timeout?
-	_, err = r.Run(context.Background())
-	return err
+	if _, err := r.Run(context.Background()); err != nil {
+		return err
+	}
+
+	// Share the C toolchain of a CGO build so that it is recorded in the
+	// provenance. The toolchain is detected in the job that compiles the
+	// binary.
+	if b.cfg.Cgo {
+		tc, err := DetectCToolchain(b.goc, envs)
+		if err != nil {
+			return err
+		}
+		if err := github.SetOutput("go-cc", tc.Compiler); err != nil {
+			return err
+		}
+		if err := github.SetOutput("go-cc-version-sha256", tc.VersionDigest["sha256"]); err != nil {
+			return err
+		}
+	}
+
+	// Share the package URL of the main module. It is optional so a
+	// failure only prints a warning.
+	purl, err := ModulePURL(binary, os.Getenv("GITHUB_REF"))
+	if err != nil {
+		github.Warning(os.Stderr, nil, fmt.Sprintf("cannot determine the package URL: %v", err))
+		return nil
+	}
+	return github.SetOutput("go-purl", purl)
 }
 
 func getOutputBinaryPath(binary string) (string, error) {
@@ -270,13 +300,19 @@ func (b *GoBuild) generateCommandEnvVariables() ([]string, error) {
 	}
 	env = append(env, fmt.Sprintf("GOARCH=%s", b.cfg.Goarch))
 
-	// Set env variables from config file.
-	for k, v := range b.cfg.Env {
-		if !isAllowedEnvVariable(k) {
-			return env, fmt.Errorf("%w: %s", &errEnvVariableNameNotAllowed{}, v)
+	// Set env variables from config file, in a stable order so that
+	// they are recorded the same way in the provenance.
+	names := make([]string, 0, len(b.cfg.Env))
+	for k := range b.cfg.Env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if reason, denied := deniedEnvVariable(k); denied {
+			return env, fmt.Errorf("%w: %s: %s", &errEnvVariableNameNotAllowed{}, k, reason)
 		}
 
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+		env = append(env, fmt.Sprintf("%s=%s", k, b.cfg.Env[k]))
 	}
 
 	return env, nil
@@ -370,18 +406,6 @@ func isAllowedArg(arg string) bool {
 	return false
 }
 
-// Check if the env variable is allowed. We want to avoid
-// variable injection, e.g. LD_PRELOAD, etc.
-// See an overview in https://www.hale-legacy.com/class/security/s20/handout/slides-env-vars.pdf.
-func isAllowedEnvVariable(name string) bool {
-	for k := range allowedEnvVariablePrefix {
-		if strings.HasPrefix(name, k) {
-			return true
-		}
-	}
-	return false
-}
-
 // TODO: maybe not needed if handled directly by go compiler.
 func (b *GoBuild) generateLdflags() (string, error) {
 	var a []string
@@ -60,7 +60,7 @@ func errInvalidFilenameFunc(t *testing.T, got error) {
 	}
 }
 
-func Test_isAllowedEnvVariable(t *testing.T) {
+func Test_deniedEnvVariable(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
@@ -71,21 +71,56 @@ func Test_isAllowedEnvVariable(t *testing.T) {
 		{
 			name:     "BLA variable",
 			variable: "BLA",
+			expected: true,
+		},
+		{
+			name:     "APP_ prefix",
+			variable: "APP_CHANNEL",
 			expected: false,
 		},
+		{
+			name:     "CC variable",
+			variable: "CC",
+			expected: true,
+		},
+		{
+			name:     "GIT_SSH_COMMAND variable",
+			variable: "GIT_SSH_COMMAND",
+			expected: true,
+		},
 		{
 			name:     "random variable",
 			variable: "random",
-			expected: false,
+			expected: true,
 		},
 		{
 			name:     "GOSOMETHING variable",
 			variable: "GOSOMETHING",
-			expected: true,
+			expected: false,
 		},
 		{
 			name:     "CGO_SOMETHING variable",
 			variable: "CGO_SOMETHING",
+			expected: false,
+		},
+		{
+			name:     "GOFLAGS variable",
+			variable: "GOFLAGS",
+			expected: true,
+		},
+		{
+			name:     "LD_PRELOAD variable",
+			variable: "LD_PRELOAD",
+			expected: true,
+		},
+		{
+			name:     "PATH variable",
+			variable: "PATH",
+			expected: true,
+		},
+		{
+			name:     "GITHUB_ prefix",
+			variable: "GITHUB_TOKEN",
 			expected: true,
 		},
 	}
@@ -94,10 +129,13 @@ func Test_isAllowedEnvVariable(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			r := isAllowedEnvVariable(tt.variable)
+			reason, r := deniedEnvVariable(tt.variable)
 			if !cmp.Equal(r, tt.expected) {
 				t.Errorf(cmp.Diff(r, tt.expected))
 			}
+			if r && reason == "" {
+				t.Errorf("expected a reason for %q", tt.variable)
+			}
 		})
 	}
 }
@@ -673,19 +711,20 @@ func Test_generateEnvVariables(t *testing.T) {
 			},
 		},
 		{
-			name:   "invalid flags",
+			name:   "APP_ flags",
 			goos:   "windows",
 			goarch: "amd64",
-			env:    []string{"VAR1=value1", "VAR2=value2"},
+			env:    []string{"APP_VAR2=value2", "APP_VAR1=value1"},
 			expected: struct {
 				err   func(*testing.T, error)
 				flags []string
 			}{
-				err: errEnvVariableNameNotAllowedFunc,
+				flags: []string{"GOOS=windows", "GOARCH=amd64", "APP_VAR1=value1", "APP_VAR2=value2"},
+				err:   nil,
 			},
 		},
 		{
-			name:   "invalid flags",
+			name:   "valid flags",
 			goos:   "windows",
 			goarch: "amd64",
 			env:    []string{"GOVAR1=value1", "GOVAR2=value2", "CGO_VAR1=val1", "CGO_VAR2=val2"},
@@ -737,6 +776,20 @@ func Test_generateEnvVariables(t *testing.T) {
 	}
 }
 
+// Test_generateEnvVariables_denied tests that denied variables are rejected
+// even if they were not validated by the config.
+func Test_generateEnvVariables_denied(t *testing.T) {
+	t.Parallel()
+
+	b := GoBuildNew("go compiler", &GoReleaserConfig{
+		Goos:   "linux",
+		Goarch: "amd64",
+		Env:    map[string]string{"LD_PRELOAD": "/tmp/evil.so"},
+	})
+	_, err := b.generateCommandEnvVariables()
+	errEnvVariableNameNotAllowedFunc(t, err)
+}
+
 func Test_generateLdflags(t *testing.T) {
 	// Disable to avoid env clobbering between tests.
 	// t.Parallel()
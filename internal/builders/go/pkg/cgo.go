@@ -0,0 +1,66 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// CToolchain describes the C toolchain used by a CGO build.
+type CToolchain struct {
+	// Compiler is the C compiler used by the go command, e.g. gcc.
+	Compiler string `json:"compiler"`
+
+	// VersionDigest is the digest of the output of `<compiler> --version`.
+	VersionDigest slsacommon.DigestSet `json:"versionDigest"`
+}
+
+// DetectCToolchain returns the C compiler that goc uses with the given
+// environment variables, and the SHA-256 digest of its version output.
+func DetectCToolchain(goc string, env []string) (*CToolchain, error) {
+	cmd := exec.Command(goc, "env", "CC")
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s env CC: %w", goc, err)
+	}
+	// NOTE: CC may contain arguments, e.g. "gcc -m64".
+	cc := strings.TrimSpace(string(out))
+	if cc == "" {
+		return nil, fmt.Errorf("%s env CC: no C compiler", goc)
+	}
+
+	args := append(strings.Fields(cc), "--version")
+	//#nosec G204 -- The compiler is resolved by the go command.
+	version, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s --version: %w", cc, err)
+	}
+	sum := sha256.Sum256(version)
+
+	return &CToolchain{
+		Compiler: cc,
+		VersionDigest: slsacommon.DigestSet{
+			"sha256": hex.EncodeToString(sum[:]),
+		},
+	}, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectCToolchain(t *testing.T) {
+	goc, err := exec.LookPath("go")
+	if err != nil {
+		t.Skipf("go not found: %v", err)
+	}
+
+	// A fake C compiler that prints its version.
+	cc := filepath.Join(t.TempDir(), "fake-cc")
+	script := "#!/bin/sh\necho \"fake-cc (Ubuntu 11.3.0) 11.3.0\"\n"
+	if err := os.WriteFile(cc, []byte(script), 0o700); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Setenv("CC", cc)
+
+	got, err := DetectCToolchain(goc, []string{"CGO_ENABLED=1"})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("fake-cc (Ubuntu 11.3.0) 11.3.0\n"))
+	want := &CToolchain{
+		Compiler:      cc,
+		VersionDigest: map[string]string{"sha256": hex.EncodeToString(sum[:])},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected C toolchain (-want +got):\n%s", diff)
+	}
+
+	// A compiler that cannot be run is an error.
+	t.Setenv("CC", filepath.Join(t.TempDir(), "missing-cc"))
+	if _, err := DetectCToolchain(goc, []string{"CGO_ENABLED=1"}); err == nil {
+		t.Errorf("expected an error to occur.")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -36,12 +37,85 @@ type goReleaserConfigFile struct {
 	Goos    string   `yaml:"goos"`
 	Goarch  string   `yaml:"goarch"`
 	Binary  string   `yaml:"binary"`
-	Env     []string `yaml:"env"`
+	Env     envList  `yaml:"env"`
 	Flags   []string `yaml:"flags"`
 	Ldflags []string `yaml:"ldflags"`
+	Cgo     bool     `yaml:"cgo"`
 	Version int      `yaml:"version"`
 }
 
+// envList is the env field of the config file, as a list of "NAME=VALUE"
+// entries. It may be written either as a list or as a mapping of names to
+// values.
+type envList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *envList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		var s []string
+		if err := value.Decode(&s); err != nil {
+			return err
+		}
+		*l = s
+		return nil
+	}
+
+	// Keep the order of the mapping.
+	var s []string
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		k, v := value.Content[i], value.Content[i+1]
+		if k.Kind != yaml.ScalarNode || v.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: env values must be scalars", k.Line)
+		}
+		s = append(s, fmt.Sprintf("%s=%s", k.Value, v.Value))
+	}
+	*l = s
+	return nil
+}
+
+// envVariableNameRe matches the names of environment variables that may be
+// set in the config file.
+var envVariableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// allowedEnvVariablePrefixes are the prefixes of the environment variables
+// that may be set in the config file: the Go settings, and the APP_ namespace
+// for non-secret settings of the project, e.g. APP_CHANNEL. We want to avoid
+// variable injection, e.g. LD_PRELOAD, etc.
+// See an overview in https://www.hale-legacy.com/class/security/s20/handout/slides-env-vars.pdf.
+var allowedEnvVariablePrefixes = []string{"GO", "CGO_", "APP_"}
+
+// deniedEnvVariables are the Go settings that may not be set in the config
+// file, with the reason. They change how dependencies are fetched and verified
+// or which toolchain runs the build, which is not captured by the provenance.
+var deniedEnvVariables = map[string]string{
+	"GOFLAGS":     "it passes build flags that bypass the 'flags' allowlist",
+	"GOPROXY":     "it changes where modules are downloaded from",
+	"GONOPROXY":   "it changes where modules are downloaded from",
+	"GOPRIVATE":   "it disables checksum verification of modules",
+	"GONOSUMDB":   "it disables checksum verification of modules",
+	"GOSUMDB":     "it changes or disables checksum verification of modules",
+	"GOINSECURE":  "it allows modules to be downloaded insecurely",
+	"GOVCS":       "it changes the version control tools used to download modules",
+	"GOWORK":      "it changes the modules used by the build",
+	"GOENV":       "it loads Go settings from a file",
+	"GOROOT":      "it changes the Go toolchain",
+	"GOTOOLCHAIN": "it changes the Go toolchain",
+}
+
+// deniedEnvVariable returns the reason why the environment variable name may
+// not be set in the config file, or false if it may.
+func deniedEnvVariable(name string) (string, bool) {
+	if reason, ok := deniedEnvVariables[name]; ok {
+		return reason, true
+	}
+	for _, prefix := range allowedEnvVariablePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return "", false
+		}
+	}
+	return "only variables starting with " + strings.Join(allowedEnvVariablePrefixes, ", ") + " may be set", true
+}
+
 // GoReleaserConfig tracks configuration for goreleaser.
 type GoReleaserConfig struct {
 	Env     map[string]string
@@ -52,21 +126,30 @@ type GoReleaserConfig struct {
 	Binary  string
 	Flags   []string
 	Ldflags []string
+	// Cgo enables CGO for the build. The C toolchain of the runner is
+	// recorded in the provenance.
+	Cgo bool
 }
 
 // ErrUnsupportedVersion indicates an unsupported Go builder version.
 type ErrUnsupportedVersion struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 // ErrInvalidDirectory indicates an invalid directory.
 type ErrInvalidDirectory struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 // ErrInvalidEnvironmentVariable indicates  an invalid environment variable.
 type ErrInvalidEnvironmentVariable struct {
-	errors.WrappableError
+	errors.ErrInput
+}
+
+// ErrCgoNotDeclared indicates a build that enables CGO through CGO_ENABLED
+// without setting 'cgo: true', or that disables it while setting it.
+type ErrCgoNotDeclared struct {
+	errors.ErrInput
 }
 
 func configFromString(b []byte) (*GoReleaserConfig, error) {
@@ -118,12 +201,17 @@ func fromConfig(cf *goReleaserConfigFile) (*GoReleaserConfig, error) {
 		Binary:  cf.Binary,
 		Main:    cf.Main,
 		Dir:     cf.Dir,
+		Cgo:     cf.Cgo,
 	}
 
 	if err := cfg.setEnvs(cf); err != nil {
 		return nil, err
 	}
 
+	if err := cfg.setCgo(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
@@ -183,6 +271,9 @@ func (r *GoReleaserConfig) setEnvs(cf *goReleaserConfigFile) error {
 		if !present {
 			return errors.Errorf(&ErrInvalidEnvironmentVariable{}, "'%s' contains no '='", e)
 		}
+		if err := validateEnvVariable(name, value); err != nil {
+			return err
+		}
 		m[name] = value
 	}
 
@@ -192,3 +283,42 @@ func (r *GoReleaserConfig) setEnvs(cf *goReleaserConfigFile) error {
 
 	return nil
 }
+
+// validateEnvVariable validates an environment variable of the config file.
+// Values are passed to the build as literals, so templates are rejected rather
+// than left unexpanded.
+func validateEnvVariable(name, value string) error {
+	if !envVariableNameRe.MatchString(name) {
+		return errors.Errorf(&ErrInvalidEnvironmentVariable{}, "'%s' is not a valid variable name", name)
+	}
+	if reason, denied := deniedEnvVariable(name); denied {
+		return errors.Errorf(&ErrInvalidEnvironmentVariable{}, "'%s' may not be set: %s", name, reason)
+	}
+	if strings.Contains(value, "{{") {
+		return errors.Errorf(&ErrInvalidEnvironmentVariable{},
+			"'%s': templates are not supported in env values, use a literal value", name)
+	}
+	return nil
+}
+
+// setCgo sets CGO_ENABLED according to the cgo field. CGO_ENABLED may only be
+// set in env if it agrees with the cgo field, so that a CGO build is always
+// declared as such.
+func (r *GoReleaserConfig) setCgo() error {
+	v, present := r.Env["CGO_ENABLED"]
+	if !r.Cgo {
+		if present && v != "0" {
+			return errors.Errorf(&ErrCgoNotDeclared{}, "'CGO_ENABLED=%s' requires 'cgo: true'", v)
+		}
+		return nil
+	}
+
+	if present && v != "1" {
+		return errors.Errorf(&ErrCgoNotDeclared{}, "'CGO_ENABLED=%s' conflicts with 'cgo: true'", v)
+	}
+	if r.Env == nil {
+		r.Env = make(map[string]string)
+	}
+	r.Env["CGO_ENABLED"] = "1"
+	return nil
+}
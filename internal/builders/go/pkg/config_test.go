@@ -43,6 +43,13 @@ func errInvalidEnvironmentVariableFunc(t *testing.T, got error) {
 	}
 }
 
+func errCgoNotDeclaredFunc(t *testing.T, got error) {
+	want := &ErrCgoNotDeclared{}
+	if !errors.As(got, &want) {
+		t.Fatalf("unexpected error: %v", cmp.Diff(got, want, cmpopts.EquateErrors()))
+	}
+}
+
 func Test_ConfigFromFile(t *testing.T) {
 	t.Parallel()
 
@@ -133,6 +140,34 @@ func Test_ConfigFromFile(t *testing.T) {
 			path: "./testdata/releaser-invalid-envs.yml",
 			err:  errInvalidEnvironmentVariableFunc,
 		},
+		{
+			name: "valid env mapping",
+			path: "./testdata/releaser-valid-envs-map.yml",
+			config: GoReleaserConfig{
+				Goos: "linux", Goarch: "amd64",
+				Flags:   []string{"-trimpath", "-tags=netgo"},
+				Ldflags: []string{"{{ .Env.VERSION_LDFLAGS }}"},
+				Binary:  "binary-{{ .OS }}-{{ .Arch }}",
+				Env: map[string]string{
+					"GO111MODULE": "on", "CGO_ENABLED": "0", "APP_CHANNEL": "stable",
+				},
+			},
+		},
+		{
+			name: "denied env var",
+			path: "./testdata/releaser-invalid-envs-denied.yml",
+			err:  errInvalidEnvironmentVariableFunc,
+		},
+		{
+			name: "template in env value",
+			path: "./testdata/releaser-invalid-envs-template.yml",
+			err:  errInvalidEnvironmentVariableFunc,
+		},
+		{
+			name: "invalid env var name",
+			path: "./testdata/releaser-invalid-envs-name.yml",
+			err:  errInvalidEnvironmentVariableFunc,
+		},
 		{
 			name: "invalid main",
 			path: "./testdata/releaser-invalid-main.yml",
@@ -177,6 +212,30 @@ func Test_ConfigFromFile(t *testing.T) {
 				Dir: asPointer("./path/to/dir"),
 			},
 		},
+		{
+			name: "valid cgo",
+			path: "./testdata/releaser-valid-cgo.yml",
+			config: GoReleaserConfig{
+				Goos: "linux", Goarch: "amd64",
+				Flags:   []string{"-trimpath", "-tags=sqlite"},
+				Ldflags: []string{"{{ .Env.VERSION_LDFLAGS }}"},
+				Binary:  "binary-{{ .OS }}-{{ .Arch }}",
+				Env: map[string]string{
+					"GO111MODULE": "on", "CGO_ENABLED": "1",
+				},
+				Cgo: true,
+			},
+		},
+		{
+			name: "cgo enabled without cgo",
+			path: "./testdata/releaser-invalid-cgo-undeclared.yml",
+			err:  errCgoNotDeclaredFunc,
+		},
+		{
+			name: "cgo disabled with cgo",
+			path: "./testdata/releaser-invalid-cgo-conflict.yml",
+			err:  errCgoNotDeclaredFunc,
+		},
 		{
 			name: "invalid config path with dots",
 			// Resolves to "../releaser-valid-dir.yml".
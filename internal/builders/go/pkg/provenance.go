@@ -19,6 +19,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/slsa-framework/slsa-github-generator/signing"
 
@@ -26,6 +27,7 @@ import (
 	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
 	"github.com/slsa-framework/slsa-github-generator/github"
 	"github.com/slsa-framework/slsa-github-generator/internal/utils"
+	"github.com/slsa-framework/slsa-github-generator/provenance"
 	"github.com/slsa-framework/slsa-github-generator/slsa"
 )
 
@@ -41,41 +43,60 @@ type (
 		Env        []string `json:"env"`
 	}
 	buildConfig struct {
-		Steps   []step `json:"steps"`
-		Version int    `json:"version"`
+		Steps      []step      `json:"steps"`
+		CToolchain *CToolchain `json:"cToolchain,omitempty"`
+		Version    int         `json:"version"`
 	}
 )
 
-type goProvenanceBuild struct {
-	*slsa.GithubActionsBuild
-	buildConfig buildConfig
-}
-
-// URI implements BuildType.URI.
-func (b *goProvenanceBuild) URI() string {
-	return buildType
-}
-
-// BuildConfig implements BuildType.BuildConfig.
-func (b *goProvenanceBuild) BuildConfig(context.Context) (interface{}, error) {
-	return b.buildConfig, nil
-}
-
 // GenerateProvenance translates github context into a SLSA provenance
-// attestation.
+// attestation. toolchain is the C toolchain of a CGO build, or nil. If
+// sortSubjects is set, the subjects are sorted by name and digest.
 // Spec: https://slsa.dev/provenance/v0.2
-func GenerateProvenance(name, digest, command, envs, workingDir string,
-	s signing.Signer, r signing.TransparencyLog, provider slsa.ClientProvider,
+func GenerateProvenance(name, digest, command, envs, workingDir string, reproducible bool, purl string,
+	sortSubjects bool, toolchain *CToolchain, s signing.Signer, r signing.TransparencyLog, provider slsa.ClientProvider,
 ) ([]byte, error) {
 	gh, err := github.GetWorkflowContext()
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := hex.DecodeString(digest); err != nil || len(digest) != 64 {
+	if !isSHA256(digest) {
 		return nil, fmt.Errorf("sha256 digest is not valid: %s", digest)
 	}
 
+	subjects := []intoto.Subject{
+		{
+			Name: name,
+			Digest: slsacommon.DigestSet{
+				"sha256": digest,
+			},
+		},
+	}
+	// The package URL is recorded as a second subject with the digest of the
+	// binary so that the provenance can be looked up by module version.
+	if purl != "" {
+		if err := validatePURL(purl); err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, intoto.Subject{
+			Name: purl,
+			Digest: slsacommon.DigestSet{
+				"sha256": digest,
+			},
+		})
+	}
+
+	if sortSubjects {
+		slsa.SortSubjects(subjects)
+	}
+
+	if toolchain != nil {
+		if d := toolchain.VersionDigest["sha256"]; toolchain.Compiler == "" || !isSHA256(d) {
+			return nil, fmt.Errorf("C toolchain is not valid: %q, %s", toolchain.Compiler, d)
+		}
+	}
+
 	com, err := utils.UnmarshalList(command)
 	if err != nil {
 		return nil, err
@@ -85,23 +106,20 @@ func GenerateProvenance(name, digest, command, envs, workingDir string,
 	if err != nil {
 		return nil, err
 	}
+	env = recordedEnv(env)
 
 	var cmd []string
 	if len(com) > 0 {
 		cmd = []string{com[0], "mod", "vendor"}
 	}
 
-	b := goProvenanceBuild{
-		GithubActionsBuild: slsa.NewGithubActionsBuild([]intoto.Subject{
-			{
-				Name: name,
-				Digest: slsacommon.DigestSet{
-					"sha256": digest,
-				},
-			},
-		}, &gh),
-		buildConfig: buildConfig{
-			Version: buildConfigVersion,
+	b := provenance.Builder{
+		WorkflowContext: &gh,
+		Subjects:        subjects,
+		BuildType:       buildType,
+		BuildConfig: buildConfig{
+			Version:    buildConfigVersion,
+			CToolchain: toolchain,
 			Steps: []step{
 				// Vendoring step.
 				{
@@ -123,23 +141,14 @@ func GenerateProvenance(name, digest, command, envs, workingDir string,
 	}
 
 	// Pre-submit tests don't have access to write OIDC token.
-	if provider != nil {
-		b.WithClients(provider)
-	} else if utils.IsPresubmitTests() {
+	b.Clients = provider
+	if provider == nil && utils.IsPresubmitTests() {
 		// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
-		b.GithubActionsBuild.WithClients(&slsa.NilClientProvider{})
+		b.Clients = &slsa.NilClientProvider{}
 	}
 
 	ctx := context.Background()
-	g := slsa.NewHostedActionsGenerator(&b)
-	// Pre-submit tests don't have access to write OIDC token.
-	if provider != nil {
-		g.WithClients(provider)
-	} else if utils.IsPresubmitTests() {
-		// TODO(github.com/slsa-framework/slsa-github-generator/issues/124): Remove
-		g.WithClients(&slsa.NilClientProvider{})
-	}
-	p, err := g.Generate(ctx)
+	p, err := b.StatementV02(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +166,11 @@ func GenerateProvenance(name, digest, command, envs, workingDir string,
 	invEnv["arch"] = os.Getenv("RUNNER_ARCH")
 	invEnv["os"] = os.Getenv("ImageOS")
 
+	// Record whether the build configuration passed the reproducibility
+	// checks of the build step.
+	// The C toolchain and libc of the runner enter the binary of a CGO build.
+	p.Predicate.Metadata.Reproducible = reproducible && toolchain == nil
+
 	// Add details about the runner's OS to the materials
 	runnerMaterials := slsacommon.ProvenanceMaterial{
 		// TODO: capture the digest here too
@@ -191,3 +205,29 @@ func GenerateProvenance(name, digest, command, envs, workingDir string,
 
 	return att.Bytes(), nil
 }
+
+// recordedEnv returns the environment variables of the build that are
+// recorded in the provenance. All the variables set for the build are
+// recorded, except those that the builder denies, and values that look like
+// secrets are redacted.
+func recordedEnv(env []string) []string {
+	if env == nil {
+		return nil
+	}
+
+	r := make([]string, 0, len(env))
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		if _, denied := deniedEnvVariable(k); denied {
+			continue
+		}
+		r = append(r, fmt.Sprintf("%s=%s", k, slsa.RedactEnvironmentValue(k, v)))
+	}
+	return r
+}
+
+// isSHA256 returns whether digest is a hex encoded SHA-256 digest.
+func isSHA256(digest string) bool {
+	_, err := hex.DecodeString(digest)
+	return err == nil && len(digest) == 64
+}
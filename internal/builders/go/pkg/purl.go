@@ -0,0 +1,139 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"debug/buildinfo"
+	"net/url"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// ErrModuleVersion indicates that the module path or version of a binary
+// could not be determined.
+type ErrModuleVersion struct {
+	errors.WrappableError
+}
+
+// ErrInvalidPURL indicates an invalid Go package URL.
+type ErrInvalidPURL struct {
+	errors.ErrInput
+}
+
+// modulePURLRegex matches the package URLs returned by ModulePURL.
+var modulePURLRegex = regexp.MustCompile(`^pkg:golang/[^@\s]+@[^@\s/]+$`)
+
+// ModulePURL returns the package URL of the main module of the binary, i.e.
+// "pkg:golang/<module>@<version>". See moduleVersion for how the version is
+// determined. ref is the git reference that was built, e.g. $GITHUB_REF.
+func ModulePURL(binary, ref string) (string, error) {
+	info, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		return "", errors.Errorf(&ErrModuleVersion{}, "reading build info: %w", err)
+	}
+	return modulePURL(info, ref)
+}
+
+// modulePURL returns the package URL of the main module in info.
+func modulePURL(info *debug.BuildInfo, ref string) (string, error) {
+	if info.Main.Path == "" {
+		return "", errors.Errorf(&ErrModuleVersion{}, "binary has no main module")
+	}
+
+	version, err := moduleVersion(info, ref)
+	if err != nil {
+		return "", err
+	}
+
+	// NOTE: '+' is not allowed unescaped in package URL versions.
+	return "pkg:golang/" + info.Main.Path + "@" +
+		strings.ReplaceAll(url.PathEscape(version), "+", "%2B"), nil
+}
+
+// moduleVersion returns the version of the main module of the binary. It is,
+// in order of preference:
+//
+//  1. The tag in ref, if it is a canonical semantic version compatible with
+//     the major version suffix of the module path, e.g. v2.1.0 for
+//     example.com/m/v2. Tags of modules in subdirectories, e.g. sub/v1.0.0,
+//     are not used since the module directory is not known.
+//  2. The version recorded by the go command, if any.
+//  3. A pseudo-version built from the revision and commit time recorded by
+//     the go command, with a "+dirty" suffix if the tree was modified.
+//
+// replace directives only apply to dependencies so they don't change the
+// path or version of the main module.
+func moduleVersion(info *debug.BuildInfo, ref string) (string, error) {
+	_, pathMajor, ok := module.SplitPathVersion(info.Main.Path)
+	if !ok {
+		return "", errors.Errorf(&ErrModuleVersion{}, "invalid module path %q", info.Main.Path)
+	}
+
+	if strings.HasPrefix(ref, "refs/tags/") {
+		tag := strings.TrimPrefix(ref, "refs/tags/")
+		if semver.IsValid(tag) && semver.Canonical(tag) == tag && module.CheckPathMajor(tag, pathMajor) == nil {
+			return tag, nil
+		}
+	}
+
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v, nil
+	}
+
+	var rev string
+	var commitTime time.Time
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.time":
+			t, err := time.Parse(time.RFC3339Nano, s.Value)
+			if err != nil {
+				return "", errors.Errorf(&ErrModuleVersion{}, "parsing vcs.time: %w", err)
+			}
+			commitTime = t
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if rev == "" || commitTime.IsZero() {
+		return "", errors.Errorf(&ErrModuleVersion{}, "binary has no version control information")
+	}
+
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	v := module.PseudoVersion(module.PathMajorPrefix(pathMajor), "", commitTime, rev)
+	if modified {
+		v += "+dirty"
+	}
+	return v, nil
+}
+
+// validatePURL checks that purl is a Go package URL with a version.
+func validatePURL(purl string) error {
+	if !modulePURLRegex.MatchString(purl) {
+		return errors.Errorf(&ErrInvalidPURL{}, "invalid Go package URL %q", purl)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+func Test_modulePURL(t *testing.T) {
+	vcs := []debug.BuildSetting{
+		{Key: "vcs", Value: "git"},
+		{Key: "vcs.revision", Value: "8f4a8a8b3d1f7c5e0c0e9d6b5a4f3e2d1c0b9a87"},
+		{Key: "vcs.time", Value: "2023-03-01T12:34:56Z"},
+		{Key: "vcs.modified", Value: "false"},
+	}
+
+	testCases := []struct {
+		name     string
+		path     string
+		version  string
+		settings []debug.BuildSetting
+		ref      string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "release tag",
+			path:     "github.com/foo/bar",
+			version:  "(devel)",
+			settings: vcs,
+			ref:      "refs/tags/v1.2.3",
+			expected: "pkg:golang/github.com/foo/bar@v1.2.3",
+		},
+		{
+			name:     "major version suffix",
+			path:     "github.com/foo/bar/v2",
+			version:  "(devel)",
+			settings: vcs,
+			ref:      "refs/tags/v2.0.1",
+			expected: "pkg:golang/github.com/foo/bar/v2@v2.0.1",
+		},
+		{
+			name:     "tag mismatching major version suffix",
+			path:     "github.com/foo/bar",
+			version:  "(devel)",
+			settings: vcs,
+			ref:      "refs/tags/v2.0.1",
+			expected: "pkg:golang/github.com/foo/bar@v0.0.0-20230301123456-8f4a8a8b3d1f",
+		},
+		{
+			name:     "non-semver tag",
+			path:     "github.com/foo/bar",
+			version:  "(devel)",
+			settings: vcs,
+			ref:      "refs/tags/release-1",
+			expected: "pkg:golang/github.com/foo/bar@v0.0.0-20230301123456-8f4a8a8b3d1f",
+		},
+		{
+			name:     "branch pseudo-version",
+			path:     "github.com/foo/bar/v3",
+			version:  "(devel)",
+			settings: vcs,
+			ref:      "refs/heads/main",
+			expected: "pkg:golang/github.com/foo/bar/v3@v3.0.0-20230301123456-8f4a8a8b3d1f",
+		},
+		{
+			name:    "modified tree",
+			path:    "github.com/foo/bar",
+			version: "(devel)",
+			settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "8f4a8a8b3d1f7c5e0c0e9d6b5a4f3e2d1c0b9a87"},
+				{Key: "vcs.time", Value: "2023-03-01T12:34:56Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+			ref:      "refs/heads/main",
+			expected: "pkg:golang/github.com/foo/bar@v0.0.0-20230301123456-8f4a8a8b3d1f%2Bdirty",
+		},
+		{
+			name:     "stamped version",
+			path:     "github.com/foo/bar",
+			version:  "v1.4.0",
+			ref:      "refs/heads/main",
+			expected: "pkg:golang/github.com/foo/bar@v1.4.0",
+		},
+		{
+			name:    "no vcs information",
+			path:    "github.com/foo/bar",
+			version: "(devel)",
+			ref:     "refs/heads/main",
+			err:     true,
+		},
+		{
+			name: "no main module",
+			ref:  "refs/tags/v1.2.3",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			info := &debug.BuildInfo{
+				Main: debug.Module{
+					Path:    tc.path,
+					Version: tc.version,
+				},
+				Settings: tc.settings,
+			}
+
+			purl, err := modulePURL(info, tc.ref)
+			errVersion := &ErrModuleVersion{}
+			if got := errors.As(err, &errVersion); got != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if want, got := tc.expected, purl; want != got {
+				t.Errorf("unexpected purl, want: %q, got: %q", want, got)
+			}
+			if !tc.err {
+				if err := validatePURL(purl); err != nil {
+					t.Errorf("unexpected failure: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_validatePURL(t *testing.T) {
+	testCases := []struct {
+		name string
+		purl string
+		err  bool
+	}{
+		{
+			name: "valid",
+			purl: "pkg:golang/github.com/foo/bar@v1.2.3",
+		},
+		{
+			name: "wrong type",
+			purl: "pkg:npm/foo@1.2.3",
+			err:  true,
+		},
+		{
+			name: "no version",
+			purl: "pkg:golang/github.com/foo/bar",
+			err:  true,
+		},
+		{
+			name: "whitespace",
+			purl: "pkg:golang/github.com/foo/bar@v1.2.3\nfoo",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePURL(tc.purl)
+			errPURL := &ErrInvalidPURL{}
+			if got := errors.As(err, &errPURL); got != tc.err {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// ErrNotReproducible indicates that the build configuration is not
+// reproducible.
+type ErrNotReproducible struct {
+	errors.ErrInput
+}
+
+// nonReproducibleLdflag matches ldflags values that obviously change between
+// builds, such as the output of `date` or variables holding the build time.
+// Variables holding the commit date, e.g. COMMIT_DATE, are reproducible.
+var nonReproducibleLdflag = regexp.MustCompile(
+	"(?i)(\\$\\(\\s*date\\b|`\\s*date\\b|{{\\s*\\.(Date|Timestamp|Now)\\b|{{\\s*\\.Env\\.\\w*(BUILD_?(DATE|TIME)|TIMESTAMP)\\w*\\s*}})")
+
+// ReproducibilityWarnings returns a warning for each setting of the
+// configuration that prevents the build from being reproducible.
+func (r *GoReleaserConfig) ReproducibilityWarnings() []string {
+	var warnings []string
+
+	trimpath := false
+	for _, f := range r.Flags {
+		if f == "-trimpath" {
+			trimpath = true
+			break
+		}
+	}
+	if !trimpath {
+		warnings = append(warnings, "-trimpath is not set: the binary records paths of the build machine")
+	}
+
+	if r.Cgo {
+		warnings = append(warnings, "cgo is enabled: the C toolchain and libc of the runner enter the binary")
+	}
+
+	for _, v := range r.Ldflags {
+		if nonReproducibleLdflag.MatchString(v) {
+			warnings = append(warnings, fmt.Sprintf("ldflags %q embeds a value that changes between builds", v))
+		}
+	}
+
+	return warnings
+}
+
+// CheckReproducible returns an ErrNotReproducible listing the warnings
+// returned by ReproducibilityWarnings, if any.
+func (r *GoReleaserConfig) CheckReproducible() error {
+	warnings := r.ReproducibilityWarnings()
+	if len(warnings) == 0 {
+		return nil
+	}
+	return errors.Errorf(&ErrNotReproducible{}, "build is not reproducible: %s", strings.Join(warnings, "; "))
+}
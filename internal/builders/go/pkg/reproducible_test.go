@@ -0,0 +1,92 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_ReproducibilityWarnings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		flags    []string
+		ldflags  []string
+		cgo      bool
+		warnings int
+	}{
+		{
+			name:    "reproducible",
+			flags:   []string{"-trimpath", "-tags=netgo"},
+			ldflags: []string{"-X main.Version={{ .Env.VERSION }}", "-X main.CommitDate={{ .Env.COMMIT_DATE }}"},
+		},
+		{
+			name:     "no trimpath",
+			flags:    []string{"-tags=netgo"},
+			warnings: 1,
+		},
+		{
+			name:     "date command",
+			flags:    []string{"-trimpath"},
+			ldflags:  []string{"-X main.BuildTime=$(date -u +%Y-%m-%d)"},
+			warnings: 1,
+		},
+		{
+			name:     "date backticks",
+			flags:    []string{"-trimpath"},
+			ldflags:  []string{"-X main.BuildTime=`date`"},
+			warnings: 1,
+		},
+		{
+			name:     "build time variable",
+			flags:    []string{"-trimpath"},
+			ldflags:  []string{"-X main.BuildTime={{ .Env.BUILD_DATE }}", "-X main.Time={{ .Env.TIMESTAMP }}"},
+			warnings: 2,
+		},
+		{
+			name:     "cgo",
+			flags:    []string{"-trimpath"},
+			cgo:      true,
+			warnings: 1,
+		},
+		{
+			name:     "no trimpath and date",
+			ldflags:  []string{"-X main.BuildTime=$(date)"},
+			warnings: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &GoReleaserConfig{
+				Flags:   tc.flags,
+				Ldflags: tc.ldflags,
+				Cgo:     tc.cgo,
+			}
+
+			warnings := cfg.ReproducibilityWarnings()
+			if want, got := tc.warnings, len(warnings); want != got {
+				t.Errorf("unexpected number of warnings, want: %d, got: %d (%q)", want, got, warnings)
+			}
+
+			err := cfg.CheckReproducible()
+			var errNotReproducible *ErrNotReproducible
+			if want, got := tc.warnings > 0, errors.As(err, &errNotReproducible); want != got {
+				t.Errorf("unexpected error, want error: %v, got: %v", want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Exit codes returned by the builders. They allow workflows to distinguish
+// errors caused by user input from infrastructure errors that may be retried.
+const (
+	// ExitCodeFailure is the exit code for unclassified errors.
+	ExitCodeFailure = 1
+
+	// ExitCodeInput is the exit code for invalid user input.
+	ExitCodeInput = 2
+
+	// ExitCodeSigning is the exit code for signing errors.
+	ExitCodeSigning = 3
+
+	// ExitCodeTransparencyLog is the exit code for transparency log errors.
+	ExitCodeTransparencyLog = 4
+
+	// ExitCodeFilesystem is the exit code for filesystem errors.
+	ExitCodeFilesystem = 5
+)
+
+// ExitCoder is implemented by errors that map to a specific exit code.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// ErrInput indicates invalid user input. It can be embedded in error types
+// instead of WrappableError to classify them.
+type ErrInput struct {
+	WrappableError
+}
+
+// ExitCode implements ExitCoder.
+func (*ErrInput) ExitCode() int {
+	return ExitCodeInput
+}
+
+// ErrSigning indicates an error signing an attestation. It can be embedded in
+// error types instead of WrappableError to classify them.
+type ErrSigning struct {
+	WrappableError
+}
+
+// ExitCode implements ExitCoder.
+func (*ErrSigning) ExitCode() int {
+	return ExitCodeSigning
+}
+
+// ErrTransparencyLog indicates an error uploading to a transparency log. It
+// can be embedded in error types instead of WrappableError to classify them.
+type ErrTransparencyLog struct {
+	WrappableError
+}
+
+// ExitCode implements ExitCoder.
+func (*ErrTransparencyLog) ExitCode() int {
+	return ExitCodeTransparencyLog
+}
+
+// ErrFilesystem indicates a filesystem error. It can be embedded in error
+// types instead of WrappableError to classify them.
+type ErrFilesystem struct {
+	WrappableError
+}
+
+// ExitCode implements ExitCoder.
+func (*ErrFilesystem) ExitCode() int {
+	return ExitCodeFilesystem
+}
+
+// ExitCode returns the exit code for err. The outermost ExitCoder in the
+// chain of wrapped errors takes precedence. Unclassified errors from the fs
+// package are treated as filesystem errors.
+func ExitCode(err error) int {
+	var coder ExitCoder
+	if As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	var pathErr *fs.PathError
+	if As(err, &pathErr) {
+		return ExitCodeFilesystem
+	}
+
+	return ExitCodeFailure
+}
+
+// exitError is the JSON representation of an error written by WriteExitError.
+type exitError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// WriteExitError writes the error message followed by a JSON object with the
+// exit code and message to w, and returns the exit code.
+func WriteExitError(w io.Writer, err error) int {
+	code := ExitCode(err)
+	fmt.Fprintln(w, err)
+
+	b, jsonErr := json.Marshal(&exitError{
+		Code:    code,
+		Message: err.Error(),
+	})
+	if jsonErr == nil {
+		fmt.Fprintln(w, string(b))
+	}
+
+	return code
+}
@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	type errFoo struct {
+		ErrInput
+	}
+
+	testCases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{
+			name: "unclassified",
+			err:  New("foo"),
+			code: ExitCodeFailure,
+		},
+		{
+			name: "embedded class",
+			err:  Errorf(&errFoo{}, "foo"),
+			code: ExitCodeInput,
+		},
+		{
+			name: "wrapped class",
+			err:  fmt.Errorf("wrapped: %w", Errorf(&ErrSigning{}, "foo")),
+			code: ExitCodeSigning,
+		},
+		{
+			name: "outermost class",
+			err:  Errorf(&ErrTransparencyLog{}, "upload: %w", Errorf(&ErrSigning{}, "foo")),
+			code: ExitCodeTransparencyLog,
+		},
+		{
+			name: "path error",
+			err:  fmt.Errorf("open: %w", &fs.PathError{Op: "open", Path: "foo", Err: fs.ErrNotExist}),
+			code: ExitCodeFilesystem,
+		},
+		{
+			name: "classified path error",
+			err:  Errorf(&ErrInput{}, "open: %w", &fs.PathError{Op: "open", Path: "foo", Err: fs.ErrNotExist}),
+			code: ExitCodeInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if want, got := tc.code, ExitCode(tc.err); want != got {
+				t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+			}
+		})
+	}
+}
+
+func TestWriteExitError(t *testing.T) {
+	var buf bytes.Buffer
+	code := WriteExitError(&buf, Errorf(&ErrFilesystem{}, "foo"))
+	if want, got := ExitCodeFilesystem, code; want != got {
+		t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if want, got := 2, len(lines); want != got {
+		t.Fatalf("unexpected number of lines, want: %d, got: %d", want, got)
+	}
+	if want, got := "foo", lines[0]; want != got {
+		t.Errorf("unexpected message, want: %q, got: %q", want, got)
+	}
+
+	var e exitError
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := (exitError{Code: ExitCodeFilesystem, Message: "foo"}), e; want != got {
+		t.Errorf("unexpected error object, want: %v, got: %v", want, got)
+	}
+}
@@ -11,6 +11,9 @@ var (
 
 	// As is the same as errors.As.
 	As = stderrors.As
+
+	// Is is the same as errors.Is.
+	Is = stderrors.Is
 )
 
 // Wrappable is a wrappable error.
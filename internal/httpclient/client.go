@@ -0,0 +1,135 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpclient creates the HTTP clients used to connect to Fulcio,
+// Rekor and the GitHub API. The clients honor the standard HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables and trust the CA
+// certificates in the bundle given by CABundleEnvKey in addition to the
+// system roots.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// CABundleEnvKey is the environment variable with the path to a PEM file of
+// extra CA certificates to trust, similar to NODE_EXTRA_CA_CERTS.
+const CABundleEnvKey = "SLSA_EXTRA_CA_CERTS"
+
+// baseTransport is the transport the transports returned by NewTransport are
+// based on. It is kept since Install replaces http.DefaultTransport.
+var baseTransport = http.DefaultTransport.(*http.Transport)
+
+// ErrCABundle indicates a CA bundle that can't be loaded.
+type ErrCABundle struct {
+	errors.ErrInput
+}
+
+// ErrConnection indicates a failed connection to an endpoint.
+type ErrConnection struct {
+	errors.WrappableError
+}
+
+// New returns a new HTTP client. See the package documentation.
+func New() (*http.Client, error) {
+	t, err := NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t}, nil
+}
+
+// NewTransport returns a new HTTP transport for clients that are created by
+// other libraries. Connection errors report the endpoint and the proxy used,
+// if any.
+func NewTransport() (http.RoundTripper, error) {
+	t := baseTransport.Clone()
+
+	// NOTE: http.ProxyFromEnvironment reads the environment only once so the
+	// proxy configuration is read here instead.
+	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	if path := os.Getenv(CABundleEnvKey); path != "" {
+		pool, err := certPool(path)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &transport{Transport: t}, nil
+}
+
+// Install replaces http.DefaultTransport with a transport returned by
+// NewTransport. This is needed for libraries that don't accept an HTTP client,
+// such as the Fulcio client.
+func Install() error {
+	t, err := NewTransport()
+	if err != nil {
+		return err
+	}
+	http.DefaultTransport = t
+	return nil
+}
+
+// certPool returns the system cert pool with the certificates in the PEM file
+// at path added.
+func certPool(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&ErrCABundle{}, "reading CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.Errorf(&ErrCABundle{}, "no certificates found in CA bundle %q", path)
+	}
+	return pool, nil
+}
+
+// transport wraps connection errors with the endpoint and proxy.
+type transport struct {
+	*http.Transport
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		endpoint := req.URL.Scheme + "://" + req.URL.Host
+		proxy, proxyErr := t.Proxy(req)
+		if proxyErr == nil && proxy != nil {
+			return nil, errors.Errorf(&ErrConnection{}, "connecting to %s via proxy %s: %w", endpoint, proxy.Redacted(), err)
+		}
+		return nil, errors.Errorf(&ErrConnection{}, "connecting to %s without a proxy: %w", endpoint, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// clearProxyEnv unsets the proxy environment variables for the test.
+func clearProxyEnv(t *testing.T) {
+	for _, k := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestNew_caBundle(t *testing.T) {
+	clearProxyEnv(t)
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	if err := os.WriteFile(bundle, b, 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		bundle string
+		err    bool
+	}{
+		{
+			name: "no bundle",
+			err:  true,
+		},
+		{
+			name:   "bundle",
+			bundle: bundle,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(CABundleEnvKey, tc.bundle)
+
+			c, err := New()
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			resp, err := c.Get(s.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.err, err)
+			}
+			if err != nil {
+				if !errors.As(err, new(*ErrConnection)) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if want := "connecting to " + s.URL + " without a proxy"; !strings.Contains(err.Error(), want) {
+					t.Errorf("unexpected error, want: %q, got: %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_invalidCABundle(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	for _, path := range []string{invalid, filepath.Join(dir, "missing.pem")} {
+		t.Setenv(CABundleEnvKey, path)
+		if _, err := New(); !errors.As(err, new(*ErrCABundle)) {
+			t.Errorf("unexpected error for %q: %v", path, err)
+		}
+	}
+}
+
+func TestNew_proxy(t *testing.T) {
+	clearProxyEnv(t)
+
+	// Get an address that refuses connections.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	proxy := "http://" + l.Addr().String()
+	l.Close()
+	t.Setenv("HTTPS_PROXY", proxy)
+
+	c, err := New()
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	resp, err := c.Get("https://rekor.example.com/api/v1/log")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected an error to occur.")
+	}
+	if !errors.As(err, new(*ErrConnection)) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if want := "connecting to https://rekor.example.com via proxy " + proxy; !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error, want: %q, got: %q", want, err)
+	}
+}
@@ -31,13 +31,52 @@ for script in $run_scripts; do
     echo
 done
 
-echo "** Running 'npm pack' **"
-pack_json=$(npm pack --json | tee pack.json | jq -c)
+if [[ "${UNTRUSTED_WORKSPACES:-}" == "true" ]]; then
+    # NOTE: npm resolves the workspaces globs of the root package.json.
+    echo "** Discovering npm workspaces **"
+    workspaces_json=$(npm query .workspace | jq -c '[.[] | {name, private: (.private // false)}]')
+    echo "$workspaces_json" | jq
+
+    # Private packages are never published, so they are not attested.
+    for name in $(echo "$workspaces_json" | jq -r '.[] | select(.private) | .name'); do
+        echo "Skipping private package '$name'"
+    done
+
+    pack_args=()
+    for name in $(echo "$workspaces_json" | jq -r '.[] | select(.private | not) | .name'); do
+        pack_args+=(--workspace "$name")
+    done
+    if [[ ${#pack_args[@]} -eq 0 ]]; then
+        echo "No public packages found in the npm workspaces"
+        exit 1
+    fi
+
+    echo "** Running 'npm pack ${pack_args[*]}' **"
+    npm pack --json "${pack_args[@]}" >pack.json
+else
+    echo "** Running 'npm pack' **"
+    npm pack --json >pack.json
+fi
 jq <pack.json
 ls -lh .
+
+# NOTE: npm only reports the sha1 and sha512 of the tarballs, so the sha256
+# recorded in the provenance is computed here. The absolute path of each file
+# is recorded since we could be in a subdirectory.
+pack_json="[]"
+while read -r entry; do
+    filename=$(echo "$entry" | jq -r '.filename')
+    resolved_filename=$(realpath -e "$filename")
+    sha256=$(sha256sum "$resolved_filename" | awk '{print $1}')
+    pack_json=$(echo "$pack_json" | jq -c --argjson e "$entry" --arg path "$resolved_filename" --arg sha256 "$sha256" \
+        '. + [$e + {path: $path, sha256: $sha256}]')
+done < <(jq -c '.[] | {id, name, version, filename}' pack.json)
 echo "pack_json=$pack_json" >>"$GITHUB_OUTPUT"
 
-filename=$(echo "$pack_json" | jq -r '.[0].filename')
-# NOTE: Get the absolute path of the file since we could be in a subdirectory.
-resolved_filename=$(realpath -e "$filename")
-echo "filename=$resolved_filename" >>"$GITHUB_OUTPUT"
+# The first file is kept as "filename" for callers of a single package.
+echo "filename=$(echo "$pack_json" | jq -r '.[0].path')" >>"$GITHUB_OUTPUT"
+{
+    echo "filenames<<FILENAMES_EOF"
+    echo "$pack_json" | jq -r '.[].path'
+    echo "FILENAMES_EOF"
+} >>"$GITHUB_OUTPUT"
@@ -2,28 +2,34 @@
 
 set -euo pipefail
 
-package_id=$(echo "${PACK_JSON}" | jq -r '.[0].id')
-digest=$(echo "${PACK_JSON}" | jq -r '.[0].shasum')
+# NOTE: PACK_JSON lists the packed packages with the sha256 of their tarball.
+# Each package is recorded as a subject named by its package URL.
+subject='{name: "pkg:npm/\(.id)", digest: {sha256: .sha256}}'
 
-# NOTE: the name of the attestation should be configurable.
-cat <<EOF | jq | tee "$SLSA_OUTPUTS_ARTIFACTS_FILE"
-{
-  "version": 1,
-  "attestations":
-  [
-    {
-      "name": "attestation.intoto",
-      "subjects":
-      [
-        {
-          "name": "pkg:npm/${package_id}",
-          "digest":
-          {
-            "sha256": "${digest}"
-          }
-        }
-      ]
-    }
-  ]
-}
-EOF
+if [[ "${ATTESTATION_PER_PACKAGE:-}" == "true" ]]; then
+    # One attestation per package, named after the package, e.g.
+    # "scope-name.intoto" for "@scope/name".
+    attestations=$(echo "${PACK_JSON}" | jq -c "[.[] | {
+        name: ((.name | sub(\"^@\"; \"\") | gsub(\"/\"; \"-\")) + \".intoto\"),
+        packages: [.name],
+        subjects: [${subject}]
+    }]")
+    if [[ $(echo "$attestations" | jq '[.[].name] | length == (unique | length)') != "true" ]]; then
+        echo "Packages have conflicting attestation names:"
+        echo "$attestations" | jq -r '.[] | "\(.packages[0]): \(.name)"'
+        exit 1
+    fi
+else
+    # NOTE: the name of the attestation should be configurable.
+    attestations=$(echo "${PACK_JSON}" | jq -c "[{
+        name: \"attestation.intoto\",
+        packages: [.[].name],
+        subjects: [.[] | ${subject}]
+    }]")
+fi
+
+echo "$attestations" | jq '{version: 1, attestations: [.[] | {name, subjects}]}' | tee "$SLSA_OUTPUTS_ARTIFACTS_FILE"
+
+# Map the name of each package to the name of its attestation.
+attestations_map=$(echo "$attestations" | jq -c '[.[] | .name as $att | .packages[] | {key: ., value: $att}] | from_entries')
+echo "attestations=$attestations_map" >>"$GITHUB_OUTPUT"
@@ -0,0 +1,204 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+// errKeyFile indicates that a key file could not be read.
+type errKeyFile struct {
+	errors.ErrInput
+}
+
+// errUnsupportedKeyFormat indicates a key that is not a PEM encoded Ed25519
+// key in PKCS#8 (private) or PKIX (public) format.
+type errUnsupportedKeyFormat struct {
+	errors.ErrInput
+}
+
+// errVerify indicates that an envelope does not have a valid signature.
+type errVerify struct {
+	errors.WrappableError
+}
+
+// attestation is a DSSE envelope signed with an Ed25519 key.
+type attestation struct {
+	cert []byte
+	att  []byte
+}
+
+// Bytes returns the signed attestation as an encoded DSSE JSON envelope.
+func (a *attestation) Bytes() []byte {
+	return a.att
+}
+
+// Cert returns the PEM encoded public key used to sign the attestation. File
+// keys do not have an associated certificate.
+func (a *attestation) Cert() []byte {
+	return a.cert
+}
+
+// FileSigner implements Signer using an Ed25519 private key read from a file.
+type FileSigner struct {
+	key ed25519.PrivateKey
+}
+
+// NewFileSigner returns a new Signer that signs using the PKCS#8 PEM encoded
+// Ed25519 private key in the file at path.
+func NewFileSigner(path string) (*FileSigner, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PRIVATE KEY" {
+		// e.g. "RSA PRIVATE KEY" for PKCS#1 or "ENCRYPTED PRIVATE KEY".
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: unsupported PEM type %q, expected a PKCS#8 \"PRIVATE KEY\"", path, block.Type)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: parsing PKCS#8 private key: %w", path, err)
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: unsupported key type %T, expected an Ed25519 key", path, k)
+	}
+	return &FileSigner{key: key}, nil
+}
+
+// Sign signs the given provenance statement and returns the signed
+// attestation. The DSSE pre-authentication encoding of the statement is
+// signed with the Ed25519 key.
+func (s *FileSigner) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling json: %w", err)
+	}
+	return s.SignPayload(ctx, payload)
+}
+
+// SignPayload implements signing.PayloadSigner.SignPayload.
+func (s *FileSigner) SignPayload(_ context.Context, payload []byte) (signing.Attestation, error) {
+	pub := s.key.Public().(ed25519.PublicKey)
+	pubPEM, keyID, err := marshalPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: Ed25519 hashes the message twice, so it can't be streamed and
+	// the pre-authentication encoding has to be built in memory.
+	sig := ed25519.Sign(s.key, dsse.PAE(intoto.PayloadType, payload))
+	env, err := envelope.Marshal(intoto.PayloadType, payload, keyID, sig, nil)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling envelope: %w", err)
+	}
+
+	return &attestation{
+		cert: pubPEM,
+		att:  env,
+	}, nil
+}
+
+// FileVerifier verifies DSSE envelopes signed by a FileSigner.
+type FileVerifier struct {
+	key ed25519.PublicKey
+}
+
+// NewFileVerifier returns a new verifier for the PKIX PEM encoded Ed25519
+// public key in the file at path.
+func NewFileVerifier(path string) (*FileVerifier, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: unsupported PEM type %q, expected a \"PUBLIC KEY\"", path, block.Type)
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: parsing public key: %w", path, err)
+	}
+	key, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: unsupported key type %T, expected an Ed25519 key", path, k)
+	}
+	return &FileVerifier{key: key}, nil
+}
+
+// Verify verifies that the DSSE envelope has a valid signature by the public
+// key and returns the decoded payload.
+func (v *FileVerifier) Verify(envelope []byte) ([]byte, error) {
+	var env dsse.Envelope
+	if err := json.Unmarshal(envelope, &env); err != nil {
+		return nil, errors.Errorf(&errVerify{}, "parsing envelope: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, errors.Errorf(&errVerify{}, "decoding payload: %w", err)
+	}
+
+	pae := dsse.PAE(env.PayloadType, payload)
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(v.key, pae, sig) {
+			return payload, nil
+		}
+	}
+	return nil, errors.Errorf(&errVerify{}, "no valid signature found")
+}
+
+// readPEM reads the first PEM block in the file at path.
+func readPEM(path string) (*pem.Block, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Errorf(&errKeyFile{}, "reading key file: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.Errorf(&errUnsupportedKeyFormat{}, "%q: no PEM data found", path)
+	}
+	return block, nil
+}
+
+// marshalPublicKey returns the PKIX PEM encoding of the public key and its
+// key ID, the hex encoded SHA-256 digest of the DER encoding.
+func marshalPublicKey(pub ed25519.PublicKey) ([]byte, string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshalling public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,248 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+// writePEM writes a PEM file with the given type and contents.
+func writePEM(t testing.TB, name, typ string, b []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return path
+}
+
+// writeKeyPair writes an ephemeral Ed25519 key pair and returns the paths of
+// the private and public key files.
+func writeKeyPair(t testing.TB) (string, string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return writePEM(t, "key.pem", "PRIVATE KEY", privDER), writePEM(t, "key.pub", "PUBLIC KEY", pubDER)
+}
+
+func TestFileSigner_roundTrip(t *testing.T) {
+	privPath, pubPath := writeKeyPair(t)
+
+	s, err := NewFileSigner(privPath)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	v, err := NewFileVerifier(pubPath)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	statement := &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: "https://example.com/predicate",
+		},
+	}
+	att, err := s.Sign(context.Background(), statement)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	pub, err := os.ReadFile(pubPath)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := pub, att.Cert(); !bytes.Equal(want, got) {
+		t.Errorf("unexpected public key, want: %q, got: %q", want, got)
+	}
+
+	payload, err := v.Verify(att.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var got intoto.Statement
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if diff := cmp.Diff(*statement, got); diff != "" {
+		t.Errorf("unexpected statement (-want +got):\n%s", diff)
+	}
+
+	// A different key does not verify the envelope.
+	_, otherPubPath := writeKeyPair(t)
+	other, err := NewFileVerifier(otherPubPath)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	errV := &errVerify{}
+	if _, err := other.Verify(att.Bytes()); !errors.As(err, &errV) {
+		t.Errorf("unexpected error, want errVerify, got: %v", err)
+	}
+
+	// A modified payload does not verify.
+	var env dsse.Envelope
+	if err := json.Unmarshal(att.Bytes(), &env); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	env.PayloadType = "application/json"
+	tampered, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if _, err := v.Verify(tampered); !errors.As(err, &errV) {
+		t.Errorf("unexpected error, want errVerify, got: %v", err)
+	}
+}
+
+func TestNewFileSigner_unsupported(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		path string
+		err  interface{}
+	}{
+		{
+			name: "pkcs1",
+			path: writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			err:  new(*errUnsupportedKeyFormat),
+		},
+		{
+			name: "pkcs8 ecdsa",
+			path: writePEM(t, "ec.pem", "PRIVATE KEY", ecDER),
+			err:  new(*errUnsupportedKeyFormat),
+		},
+		{
+			name: "not pem",
+			path: notPEM,
+			err:  new(*errUnsupportedKeyFormat),
+		},
+		{
+			name: "missing",
+			path: filepath.Join(t.TempDir(), "missing.pem"),
+			err:  new(*errKeyFile),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewFileSigner(tc.path)
+			if !errors.As(err, tc.err) {
+				t.Errorf("unexpected error, want: %T, got: %v", tc.err, err)
+			}
+			if want, got := errors.ExitCodeInput, errors.ExitCode(err); want != got {
+				t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+			}
+		})
+	}
+}
+
+// BenchmarkHashVerifyingSigner_Sign signs a statement with 50k subjects
+// through a HashVerifyingSigner, once hiding that FileSigner is a
+// PayloadSigner and once using the hashed payload. Run with -benchmem to
+// compare the memory used.
+func BenchmarkHashVerifyingSigner_Sign(b *testing.B) {
+	privPath, _ := writeKeyPair(b)
+	s, err := NewFileSigner(privPath)
+	if err != nil {
+		b.Fatalf("unexpected failure: %v", err)
+	}
+
+	p := &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: "https://slsa.dev/provenance/v0.2",
+		},
+	}
+	for i := 0; i < 50000; i++ {
+		h := sha256.Sum256([]byte(fmt.Sprint(i)))
+		p.Subject = append(p.Subject, intoto.Subject{
+			Name:   fmt.Sprintf("dist/artifact-%d.tar.gz", i),
+			Digest: map[string]string{"sha256": hex.EncodeToString(h[:])},
+		})
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		b.Fatalf("unexpected failure: %v", err)
+	}
+	h := sha256.Sum256(payload)
+	expectedHash := hex.EncodeToString(h[:])
+
+	for _, bc := range []struct {
+		name   string
+		signer signing.Signer
+	}{
+		{name: "Sign", signer: struct{ signing.Signer }{s}},
+		{name: "SignPayload", signer: s},
+	} {
+		bc := bc
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hs := signing.NewHashVerifyingSigner(bc.signer).WithExpectedHash(expectedHash)
+				if _, err := hs.Sign(context.Background(), p); err != nil {
+					b.Fatalf("unexpected failure: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fulcio
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/fulcio/pkg/api"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+// DefaultFulcioURL is the URL of the public Fulcio instance.
+const DefaultFulcioURL = api.SigstorePublicServerURL
+
+// oidcAudience is the audience of the OIDC token exchanged at Fulcio.
+const oidcAudience = "sigstore"
+
+// ErrFulcio indicates an error requesting a signing certificate from Fulcio.
+type ErrFulcio struct {
+	errors.ErrSigning
+}
+
+// ErrOIDC indicates an error requesting an OIDC token.
+type ErrOIDC struct {
+	errors.ErrSigning
+}
+
+// attestation is a DSSE envelope signed with an ephemeral key certified by
+// Fulcio.
+type attestation struct {
+	cert []byte
+	att  []byte
+}
+
+// Bytes returns the signed attestation as an encoded DSSE JSON envelope.
+func (a *attestation) Bytes() []byte {
+	return a.att
+}
+
+// Cert returns the PEM encoded certificate issued by Fulcio for the
+// ephemeral signing key.
+func (a *attestation) Cert() []byte {
+	return a.cert
+}
+
+// FulcioSigner implements Signer using Sigstore's keyless signing flow. For
+// each signature, an ephemeral key is generated and certified by Fulcio in
+// exchange for a GitHub Actions OIDC token.
+type FulcioSigner struct {
+	oidcClient   *github.OIDCClient
+	fulcioClient api.LegacyClient
+}
+
+// NewFulcioSigner returns a new Signer that requests OIDC tokens using the
+// given client, and signing certificates from the Fulcio instance at
+// fulcioURL.
+func NewFulcioSigner(oidcClient *github.OIDCClient, fulcioURL string) (*FulcioSigner, error) {
+	if oidcClient == nil {
+		return nil, errors.New("oidc client is nil")
+	}
+
+	u, err := url.Parse(fulcioURL)
+	if err != nil {
+		return nil, errors.Errorf(&ErrFulcio{}, "invalid fulcio url %q: %w", fulcioURL, err)
+	}
+
+	return &FulcioSigner{
+		oidcClient:   oidcClient,
+		fulcioClient: api.NewClient(u),
+	}, nil
+}
+
+// Sign signs the given provenance statement with an ephemeral key and returns
+// the signed attestation, including the certificate issued by Fulcio.
+func (s *FulcioSigner) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling json: %w", err)
+	}
+	return s.SignPayload(ctx, payload)
+}
+
+// SignPayload implements signing.PayloadSigner.SignPayload.
+func (s *FulcioSigner) SignPayload(ctx context.Context, payload []byte) (signing.Attestation, error) {
+	token, err := s.oidcClient.Token(ctx, []string{oidcAudience})
+	if err != nil {
+		return nil, errors.Errorf(&ErrOIDC{}, "requesting oidc token: %w", err)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating ephemeral key: %w", err)
+	}
+
+	cert, err := s.signingCert(priv, token)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.Sum256(dsse.PAE(intoto.PayloadType, payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, h[:])
+	if err != nil {
+		return nil, fmt.Errorf("signing payload: %w", err)
+	}
+
+	env, err := json.Marshal(&dsse.Envelope{
+		PayloadType: intoto.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []dsse.Signature{
+			{
+				Sig: base64.StdEncoding.EncodeToString(sig),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling envelope: %w", err)
+	}
+
+	// Add certificate to envelope.
+	// TODO: Remove when DSSE spec includes a cert field inside the signatures.
+	envWithCert, err := envelope.AddCertToEnvelope(env, cert)
+	if err != nil {
+		return nil, fmt.Errorf("adding certificate to DSSE: %w", err)
+	}
+
+	return &attestation{
+		cert: cert,
+		att:  envWithCert,
+	}, nil
+}
+
+// signingCert exchanges the OIDC token at Fulcio for a certificate for the
+// public key of priv, and returns the PEM encoded certificate.
+func (s *FulcioSigner) signingCert(priv *ecdsa.PrivateKey, token *github.OIDCToken) ([]byte, error) {
+	pub, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling public key: %w", err)
+	}
+
+	// Prove possession of the private key by signing the token subject.
+	h := sha256.Sum256([]byte(token.Subject))
+	proof, err := ecdsa.SignASN1(rand.Reader, priv, h[:])
+	if err != nil {
+		return nil, fmt.Errorf("signing proof of possession: %w", err)
+	}
+
+	resp, err := s.fulcioClient.SigningCert(api.CertificateRequest{
+		PublicKey: api.Key{
+			Algorithm: "ecdsa",
+			Content:   pub,
+		},
+		SignedEmailAddress: proof,
+	}, token.RawToken)
+	if err != nil {
+		return nil, errors.Errorf(&ErrFulcio{}, "requesting signing certificate: %w", err)
+	}
+
+	return resp.CertPEM, nil
+}
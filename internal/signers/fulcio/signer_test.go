@@ -0,0 +1,222 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fulcio
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/fulcio/pkg/api"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+const testSubject = "repo:owner/repo:ref:refs/heads/main"
+
+// fakeFulcioServer is a fake Fulcio server that issues certificates signed by
+// an in-memory CA.
+type fakeFulcioServer struct {
+	t  *testing.T
+	ca *ecdsa.PrivateKey
+
+	// status is returned for all requests if non-zero.
+	status int
+
+	// issued is the PEM encoded certificate issued by the last request.
+	issued []byte
+}
+
+func (f *fakeFulcioServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.status != 0 {
+		http.Error(w, "error", f.status)
+		return
+	}
+	if r.Method != http.MethodPost || r.URL.Path != "/api/v1/signingCert" {
+		http.NotFound(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		http.Error(w, "missing token", http.StatusUnauthorized)
+		return
+	}
+
+	var req api.CertificateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pub, err := x509.ParsePKIXPublicKey(req.PublicKey.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		http.Error(w, "unexpected key type", http.StatusBadRequest)
+		return
+	}
+
+	// Verify the proof of possession of the private key.
+	h := sha256.Sum256([]byte(testSubject))
+	if !ecdsa.VerifyASN1(ecPub, h[:], req.SignedEmailAddress) {
+		http.Error(w, "invalid proof of possession", http.StatusBadRequest)
+		return
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "sigstore"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(10 * time.Minute),
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fulcio"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caTmpl, ecPub, f.ca)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	f.issued, err = cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("SCT", base64.StdEncoding.EncodeToString([]byte("sct")))
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(f.issued); err != nil {
+		f.t.Errorf("unexpected failure: %v", err)
+	}
+}
+
+// newTestSigner returns a FulcioSigner using a fake OIDC server and the given
+// fake Fulcio server.
+func newTestSigner(t *testing.T, f *fakeFulcioServer) *FulcioSigner {
+	t.Helper()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	oidcServer, oidcClient := github.NewTestOIDCServer(t, now, &github.OIDCToken{
+		Audience:          []string{oidcAudience},
+		Expiry:            now.Add(time.Hour),
+		JobWorkflowRef:    "owner/repo/.github/workflows/release.yml@refs/heads/main",
+		RepositoryID:      "1234",
+		RepositoryOwnerID: "4321",
+		ActorID:           "4567",
+		Subject:           testSubject,
+	})
+	t.Cleanup(oidcServer.Close)
+
+	fulcioServer := httptest.NewServer(f)
+	t.Cleanup(fulcioServer.Close)
+
+	s, err := NewFulcioSigner(oidcClient, fulcioServer.URL)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return s
+}
+
+func TestFulcioSigner_Sign(t *testing.T) {
+	ca, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	f := &fakeFulcioServer{t: t, ca: ca}
+	s := newTestSigner(t, f)
+
+	p := &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: "https://slsa.dev/provenance/v0.2",
+		},
+	}
+	att, err := s.Sign(context.Background(), p)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	if !bytes.Equal(att.Cert(), f.issued) {
+		t.Errorf("unexpected certificate, want: %q, got: %q", f.issued, att.Cert())
+	}
+
+	envCert, err := envelope.GetCertFromEnvelope(att.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if !bytes.Equal(envCert, f.issued) {
+		t.Errorf("unexpected envelope certificate, want: %q, got: %q", f.issued, envCert)
+	}
+
+	// Verify the signature using the key in the issued certificate.
+	var env dsse.Envelope
+	if err := json.Unmarshal(att.Bytes(), &env); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := 1, len(env.Signatures); want != got {
+		t.Fatalf("unexpected number of signatures, want: %d, got: %d", want, got)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(f.issued)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	h := sha256.Sum256(dsse.PAE(intoto.PayloadType, payload))
+	if !ecdsa.VerifyASN1(certs[0].PublicKey.(*ecdsa.PublicKey), h[:], sig) {
+		t.Errorf("signature verification failed")
+	}
+}
+
+func TestFulcioSigner_Sign_fulcio_error(t *testing.T) {
+	s := newTestSigner(t, &fakeFulcioServer{t: t, status: http.StatusUnauthorized})
+
+	_, err := s.Sign(context.Background(), &intoto.Statement{})
+	errFulcio := &ErrFulcio{}
+	if !errors.As(err, &errFulcio) {
+		t.Errorf("expected %v but got %v", &ErrFulcio{}, err)
+	}
+}
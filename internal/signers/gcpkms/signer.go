@@ -0,0 +1,174 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpkms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+// ErrKMS indicates an error returned by the Cloud KMS API.
+type ErrKMS struct {
+	errors.ErrSigning
+}
+
+// ErrIntegrity indicates that the data returned by Cloud KMS was corrupted
+// in transit.
+type ErrIntegrity struct {
+	errors.ErrSigning
+}
+
+// attestation is a DSSE envelope signed with a Cloud KMS key.
+type attestation struct {
+	cert []byte
+	att  []byte
+}
+
+// Bytes returns the signed attestation as an encoded DSSE JSON envelope.
+func (a *attestation) Bytes() []byte {
+	return a.att
+}
+
+// Cert returns the PEM encoded public key of the KMS key used to sign the
+// attestation. KMS keys do not have an associated certificate.
+func (a *attestation) Cert() []byte {
+	return a.cert
+}
+
+// GCPKMSSigner implements Signer using an asymmetric signing key stored in
+// Google Cloud KMS.
+type GCPKMSSigner struct {
+	// keyResourceName is the resource name of the CryptoKeyVersion. e.g.
+	// projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*
+	keyResourceName string
+	client          *kms.KeyManagementClient
+}
+
+// NewGCPKMSSigner returns a new Signer that signs using the given Cloud KMS
+// key version. Client options can be given to override the default
+// connection settings.
+func NewGCPKMSSigner(ctx context.Context, keyResourceName string, opts ...option.ClientOption) (*GCPKMSSigner, error) {
+	if keyResourceName == "" {
+		return nil, errors.New("kms key resource name is empty")
+	}
+
+	c, err := kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		return nil, errors.Errorf(&ErrKMS{}, "creating kms client: %w", err)
+	}
+
+	return &GCPKMSSigner{
+		keyResourceName: keyResourceName,
+		client:          c,
+	}, nil
+}
+
+// Close closes the connection to the Cloud KMS API.
+func (s *GCPKMSSigner) Close() error {
+	return s.client.Close()
+}
+
+// Sign signs the given provenance statement and returns the signed
+// attestation. The DSSE pre-authentication encoding of the statement is
+// hashed with SHA-256 and the digest is signed by Cloud KMS.
+func (s *GCPKMSSigner) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling json: %w", err)
+	}
+	return s.SignPayload(ctx, payload)
+}
+
+// SignPayload implements signing.PayloadSigner.SignPayload.
+func (s *GCPKMSSigner) SignPayload(ctx context.Context, payload []byte) (signing.Attestation, error) {
+	sig, err := s.signDigest(ctx, sha256.Sum256(dsse.PAE(intoto.PayloadType, payload)))
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := s.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{
+		Name: s.keyResourceName,
+	})
+	if err != nil {
+		return nil, errors.Errorf(&ErrKMS{}, "getting public key: %w", err)
+	}
+
+	env, err := json.Marshal(&dsse.Envelope{
+		PayloadType: intoto.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []dsse.Signature{
+			{
+				KeyID: s.keyResourceName,
+				Sig:   base64.StdEncoding.EncodeToString(sig),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling envelope: %w", err)
+	}
+
+	return &attestation{
+		cert: []byte(pub.GetPem()),
+		att:  env,
+	}, nil
+}
+
+// signDigest signs the SHA-256 digest and returns the DER-encoded signature.
+func (s *GCPKMSSigner) signDigest(ctx context.Context, digest [sha256.Size]byte) ([]byte, error) {
+	resp, err := s.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name: s.keyResourceName,
+		Digest: &kmspb.Digest{
+			Digest: &kmspb.Digest_Sha256{
+				Sha256: digest[:],
+			},
+		},
+		DigestCrc32C: wrapperspb.Int64(crc32c(digest[:])),
+	})
+	if err != nil {
+		return nil, errors.Errorf(&ErrKMS{}, "signing digest: %w", err)
+	}
+
+	// Verify the request and response were not corrupted in transit.
+	// See: https://cloud.google.com/kms/docs/data-integrity-guidelines
+	if !resp.GetVerifiedDigestCrc32C() {
+		return nil, errors.Errorf(&ErrIntegrity{}, "request digest was not verified by kms")
+	}
+	if resp.GetName() != s.keyResourceName {
+		return nil, errors.Errorf(&ErrIntegrity{}, "unexpected key name %q", resp.GetName())
+	}
+	if resp.GetSignatureCrc32C().GetValue() != crc32c(resp.GetSignature()) {
+		return nil, errors.Errorf(&ErrIntegrity{}, "signature checksum mismatch")
+	}
+
+	return resp.GetSignature(), nil
+}
+
+func crc32c(b []byte) int64 {
+	return int64(crc32.Checksum(b, crc32.MakeTable(crc32.Castagnoli)))
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpkms
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+const testKeyName = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+// fakeKMSServer is a fake Cloud KMS server that signs digests with an
+// in-memory ECDSA key.
+type fakeKMSServer struct {
+	kmspb.UnimplementedKeyManagementServiceServer
+
+	key *ecdsa.PrivateKey
+
+	// corrupt causes the server to return an invalid signature checksum.
+	corrupt bool
+
+	// requests records the AsymmetricSign requests received.
+	requests []*kmspb.AsymmetricSignRequest
+}
+
+func (f *fakeKMSServer) AsymmetricSign(_ context.Context, req *kmspb.AsymmetricSignRequest) (*kmspb.AsymmetricSignResponse, error) {
+	f.requests = append(f.requests, req)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, f.key, req.GetDigest().GetSha256())
+	if err != nil {
+		return nil, err
+	}
+
+	sigCRC := crc32c(sig)
+	if f.corrupt {
+		sigCRC++
+	}
+
+	return &kmspb.AsymmetricSignResponse{
+		Name:                 req.GetName(),
+		Signature:            sig,
+		SignatureCrc32C:      wrapperspb.Int64(sigCRC),
+		VerifiedDigestCrc32C: req.GetDigestCrc32C().GetValue() == crc32c(req.GetDigest().GetSha256()),
+	}, nil
+}
+
+func (f *fakeKMSServer) GetPublicKey(_ context.Context, req *kmspb.GetPublicKeyRequest) (*kmspb.PublicKey, error) {
+	pem, err := cryptoutils.MarshalPublicKeyToPEM(f.key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &kmspb.PublicKey{
+		Name: req.GetName(),
+		Pem:  string(pem),
+	}, nil
+}
+
+// newTestSigner starts a fake KMS server and returns a GCPKMSSigner
+// connected to it.
+func newTestSigner(t *testing.T, srv *fakeKMSServer) *GCPKMSSigner {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	gsrv := grpc.NewServer()
+	kmspb.RegisterKeyManagementServiceServer(gsrv, srv)
+	go func() {
+		_ = gsrv.Serve(l)
+	}()
+	t.Cleanup(gsrv.Stop)
+
+	s, err := NewGCPKMSSigner(context.Background(), testKeyName,
+		option.WithEndpoint(l.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+func TestGCPKMSSigner_Sign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	srv := &fakeKMSServer{key: key}
+	s := newTestSigner(t, srv)
+
+	stmt := &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: "https://example.com/predicate",
+		},
+	}
+	att, err := s.Sign(context.Background(), stmt)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	payload, err := json.Marshal(stmt)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	digest := sha256.Sum256(dsse.PAE(intoto.PayloadType, payload))
+
+	// Verify that KMS was asked to sign the digest of the DSSE PAE.
+	if want, got := 1, len(srv.requests); want != got {
+		t.Fatalf("unexpected number of sign requests, want: %d, got: %d", want, got)
+	}
+	req := srv.requests[0]
+	if want, got := testKeyName, req.GetName(); want != got {
+		t.Errorf("unexpected key name, want: %q, got: %q", want, got)
+	}
+	if want, got := digest[:], req.GetDigest().GetSha256(); !bytes.Equal(want, got) {
+		t.Errorf("unexpected digest, want: %x, got: %x", want, got)
+	}
+
+	// Verify the envelope signature.
+	var env dsse.Envelope
+	if err := json.Unmarshal(att.Bytes(), &env); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := 1, len(env.Signatures); want != got {
+		t.Fatalf("unexpected number of signatures, want: %d, got: %d", want, got)
+	}
+	sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Errorf("signature verification failed")
+	}
+
+	if _, err := cryptoutils.UnmarshalPEMToPublicKey(att.Cert()); err != nil {
+		t.Errorf("unexpected public key: %v", err)
+	}
+}
+
+func TestGCPKMSSigner_Sign_corrupt(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	s := newTestSigner(t, &fakeKMSServer{key: key, corrupt: true})
+
+	_, err = s.Sign(context.Background(), &intoto.Statement{})
+	errIntegrity := &ErrIntegrity{}
+	if !errors.As(err, &errIntegrity) {
+		t.Fatalf("expected %v but got %v", &ErrIntegrity{}, err)
+	}
+}
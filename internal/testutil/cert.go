@@ -0,0 +1,62 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+)
+
+// NewTestCert returns a PEM encoded self-signed certificate with the given
+// URI subject alternative name and Fulcio extensions, like those issued by
+// Fulcio for GitHub Actions workflows. It can be used as the CertVal of a
+// TestAttestation. Extensions are omitted if ext.Issuer is empty. Like
+// Fulcio certificates, it is valid for 10 minutes, from 5 minutes before the
+// integrated time of the entry returned by NewTestLogEntry.
+func NewTestCert(san string, ext certificate.Extensions) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(san)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		URIs:         []*url.URL{u},
+		NotBefore:    time.Unix(testIntegratedTime, 0).Add(-5 * time.Minute),
+		NotAfter:     time.Unix(testIntegratedTime, 0).Add(5 * time.Minute),
+	}
+	if ext.Issuer != "" {
+		tmpl.ExtraExtensions, err = ext.Render()
+		if err != nil {
+			return nil, err
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
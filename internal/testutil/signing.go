@@ -17,6 +17,7 @@ package testutil
 import (
 	"context"
 	"errors"
+	"time"
 
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/slsa-framework/slsa-github-generator/signing"
@@ -48,11 +49,60 @@ func (s TestSigner) Sign(context.Context, *intoto.Statement) (signing.Attestatio
 	return &s.Att, nil
 }
 
+// SignerWithErr is an implementation of Signer that returns an ErrSigner.
+type SignerWithErr struct{}
+
+// ErrSigner is returned by SignerWithErr.Sign.
+var ErrSigner = errors.New("signer error")
+
+// Sign implements Signer.Sign.
+func (SignerWithErr) Sign(context.Context, *intoto.Statement) (signing.Attestation, error) {
+	return nil, ErrSigner
+}
+
+// testIntegratedTime is the integrated time of the entry returned by
+// NewTestLogEntry.
+const testIntegratedTime = 1672531200
+
 // TestLogEntry is a basic LogEntry implementation.
 type TestLogEntry struct {
-	IDVal       string
-	UUIDVal     string
-	LogIndexVal int64
+	IDVal                   string
+	UUIDVal                 string
+	LogIndexVal             int64
+	IntegratedTimeVal       int64
+	SignedEntryTimestampVal []byte
+	InclusionProofVal       *signing.InclusionProof
+}
+
+// NewTestLogEntry returns a syntactically valid LogEntry with an inclusion
+// proof. The signed entry timestamp and proof are not cryptographically
+// valid.
+func NewTestLogEntry() *TestLogEntry {
+	return &TestLogEntry{
+		IDVal:                   "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+		UUIDVal:                 "24296fb24b8ad77a0bd8f9ab1f0a9c2b0d2e1a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f",
+		LogIndexVal:             42,
+		IntegratedTimeVal:       testIntegratedTime,
+		SignedEntryTimestampVal: []byte("signed entry timestamp"),
+		InclusionProofVal: &signing.InclusionProof{
+			LogIndex: 42,
+			RootHash: "5be1758dd2228acfaf2546b4b6ce8aa40c82a3748f3dcb550e0d67ba34f02a45",
+			TreeSize: 43,
+			Hashes: []string{
+				"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+				"7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730",
+			},
+			Checkpoint: "rekor.sigstore.dev - 2605736670972794746\n43\nW+F1jdIiis+vJUa0ts6KpAyCo3SPPctVDg1nujTwKkU=\n",
+		},
+	}
+}
+
+// NewTestLogEntryAt returns the entry returned by NewTestLogEntry with the
+// given integrated time.
+func NewTestLogEntryAt(t time.Time) *TestLogEntry {
+	e := NewTestLogEntry()
+	e.IntegratedTimeVal = t.Unix()
+	return e
 }
 
 // ID implements LogEntry.ID.
@@ -70,13 +120,32 @@ func (e *TestLogEntry) UUID() string {
 	return e.UUIDVal
 }
 
-// TestTransparencyLog is an implementation of TransparencyLog that returns an ErrTransparencyLog.
+// IntegratedTime implements LogEntry.IntegratedTime.
+func (e *TestLogEntry) IntegratedTime() int64 {
+	return e.IntegratedTimeVal
+}
+
+// SignedEntryTimestamp implements LogEntry.SignedEntryTimestamp.
+func (e *TestLogEntry) SignedEntryTimestamp() []byte {
+	return e.SignedEntryTimestampVal
+}
+
+// InclusionProof implements LogEntry.InclusionProof.
+func (e *TestLogEntry) InclusionProof() *signing.InclusionProof {
+	return e.InclusionProofVal
+}
+
+// TestTransparencyLog is an implementation of TransparencyLog that returns
+// Entry, or the entry returned by NewTestLogEntry if Entry is nil.
 type TestTransparencyLog struct {
 	Entry *TestLogEntry
 }
 
 // Upload implements TransparencyLog.Upload.
 func (l TestTransparencyLog) Upload(context.Context, signing.Attestation) (signing.LogEntry, error) {
+	if l.Entry == nil {
+		return NewTestLogEntry(), nil
+	}
 	return l.Entry, nil
 }
 
@@ -90,3 +159,40 @@ var ErrTransparencyLog = errors.New("transparency log error")
 func (TransparencyLogWithErr) Upload(context.Context, signing.Attestation) (signing.LogEntry, error) {
 	return nil, ErrTransparencyLog
 }
+
+// CountingTransparencyLog is an implementation of TransparencyLog that counts
+// calls to Upload. The first FailCount calls return Err, or
+// ErrTransparencyLog if Err is nil. The other calls return the next entry of
+// Entries, or Entry once Entries is exhausted.
+type CountingTransparencyLog struct {
+	Entry     *TestLogEntry
+	Entries   []*TestLogEntry
+	FailCount int
+	Err       error
+	Calls     int
+}
+
+// Upload implements TransparencyLog.Upload.
+func (l *CountingTransparencyLog) Upload(context.Context, signing.Attestation) (signing.LogEntry, error) {
+	l.Calls++
+	if l.Calls <= l.FailCount {
+		if l.Err != nil {
+			return nil, l.Err
+		}
+		return nil, ErrTransparencyLog
+	}
+	if i := l.Calls - l.FailCount - 1; i < len(l.Entries) {
+		return l.Entries[i], nil
+	}
+	return l.Entry, nil
+}
+
+// BlockingTransparencyLog is an implementation of TransparencyLog whose
+// Upload blocks until the context is done and returns the context error.
+type BlockingTransparencyLog struct{}
+
+// Upload implements TransparencyLog.Upload.
+func (BlockingTransparencyLog) Upload(ctx context.Context, _ signing.Attestation) (signing.LogEntry, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transparencylog implements transparency logs other than Rekor.
+package transparencylog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+// LocalLogID is the log ID of entries in a LocalFileTransparencyLog.
+const LocalLogID = "local"
+
+// LocalFileEntry is a line in the file written by LocalFileTransparencyLog.
+type LocalFileEntry struct {
+	// LogIndex is the zero-based line number of the entry in the file.
+	LogIndex int64 `json:"logIndex"`
+
+	// IntegratedTime is the time the entry was appended, in seconds since the
+	// Unix epoch.
+	IntegratedTime int64 `json:"integratedTime"`
+
+	// LeafHash is the hex encoded RFC 6962 leaf hash of the payload.
+	LeafHash string `json:"leafHash"`
+
+	// Payload is the signed attestation. It is base64 encoded in JSON.
+	Payload []byte `json:"payload"`
+}
+
+// LocalFileTransparencyLog implements signing.TransparencyLog by appending
+// entries as newline delimited JSON to a local file. It is meant for
+// air-gapped environments that can't reach Rekor. The file provides no
+// tamper-evidence by itself and needs to be protected by other means.
+type LocalFileTransparencyLog struct {
+	path string
+
+	// now returns the current time. It can be overridden in tests.
+	now func() time.Time
+}
+
+// NewLocalFileTransparencyLog returns a transparency log that appends to the
+// file at path, creating it if needed.
+func NewLocalFileTransparencyLog(path string) *LocalFileTransparencyLog {
+	return &LocalFileTransparencyLog{
+		path: path,
+		now:  time.Now,
+	}
+}
+
+// localEntry implements signing.LogEntry.
+type localEntry struct {
+	e LocalFileEntry
+}
+
+// ID implements LogEntry.ID.
+func (e *localEntry) ID() string {
+	return LocalLogID
+}
+
+// LogIndex implements LogEntry.LogIndex.
+func (e *localEntry) LogIndex() int64 {
+	return e.e.LogIndex
+}
+
+// UUID implements LogEntry.UUID. It is the leaf hash of the entry.
+func (e *localEntry) UUID() string {
+	return e.e.LeafHash
+}
+
+// IntegratedTime implements LogEntry.IntegratedTime.
+func (e *localEntry) IntegratedTime() int64 {
+	return e.e.IntegratedTime
+}
+
+// SignedEntryTimestamp implements LogEntry.SignedEntryTimestamp. Local
+// entries are not signed.
+func (e *localEntry) SignedEntryTimestamp() []byte {
+	return nil
+}
+
+// InclusionProof implements LogEntry.InclusionProof. Local entries have no
+// inclusion proof.
+func (e *localEntry) InclusionProof() *signing.InclusionProof {
+	return nil
+}
+
+// leafHash returns the RFC 6962 leaf hash of b.
+func leafHash(b []byte) string {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// countLines returns the number of newline characters read from r.
+func countLines(r io.Reader) (int64, error) {
+	var n int64
+	buf := make([]byte, 32*1024)
+	for {
+		c, err := r.Read(buf)
+		n += int64(bytes.Count(buf[:c], []byte{'\n'}))
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Upload implements TransparencyLog.Upload. The file is locked while the
+// entry is appended so that concurrent writers get distinct log indexes.
+func (l *LocalFileTransparencyLog) Upload(_ context.Context, att signing.Attestation) (signing.LogEntry, error) {
+	f, err := os.OpenFile(filepath.Clean(l.path), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "opening log file: %w", err)
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "locking log file: %w", err)
+	}
+	// NOTE: The lock is released when the file is closed.
+
+	// The entry's index is the number of entries already in the file.
+	index, err := countLines(f)
+	if err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "reading log file: %w", err)
+	}
+
+	e := LocalFileEntry{
+		LogIndex:       index,
+		IntegratedTime: l.now().Unix(),
+		LeafHash:       leafHash(att.Bytes()),
+		Payload:        att.Bytes(),
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "writing log file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, errors.Errorf(&errors.ErrFilesystem{}, "writing log file: %w", err)
+	}
+
+	fmt.Printf("Appended signed attestation to %s at index %d.\n", l.path, index)
+	return &localEntry{e: e}, nil
+}
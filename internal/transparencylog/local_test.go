@@ -0,0 +1,97 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transparencylog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+)
+
+func TestLocalFileTransparencyLog_Upload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tlog.jsonl")
+	l := NewLocalFileTransparencyLog(path)
+	l.now = func() time.Time { return time.Unix(1677628800, 0) }
+
+	payloads := [][]byte{[]byte("first"), []byte("second")}
+	for i, p := range payloads {
+		entry, err := l.Upload(context.Background(), &testutil.TestAttestation{BytesVal: p})
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if want, got := int64(i), entry.LogIndex(); want != got {
+			t.Errorf("unexpected log index, want: %d, got: %d", want, got)
+		}
+		if want, got := LocalLogID, entry.ID(); want != got {
+			t.Errorf("unexpected log ID, want: %q, got: %q", want, got)
+		}
+		if want, got := leafHash(p), entry.UUID(); want != got {
+			t.Errorf("unexpected UUID, want: %q, got: %q", want, got)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer f.Close()
+
+	var got []LocalFileEntry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e LocalFileEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		got = append(got, e)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := []LocalFileEntry{
+		{
+			LogIndex:       0,
+			IntegratedTime: 1677628800,
+			LeafHash:       leafHash([]byte("first")),
+			Payload:        []byte("first"),
+		},
+		{
+			LogIndex:       1,
+			IntegratedTime: 1677628800,
+			LeafHash:       leafHash([]byte("second")),
+			Payload:        []byte("second"),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
+
+func Test_leafHash(t *testing.T) {
+	// The RFC 6962 leaf hash of the empty string.
+	want := "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d"
+	if got := leafHash(nil); want != got {
+		t.Errorf("unexpected leaf hash, want: %q, got: %q", want, got)
+	}
+}
@@ -30,7 +30,7 @@ type ErrInternal struct {
 
 // ErrInvalidPath indicates an invalid path.
 type ErrInvalidPath struct {
-	errors.WrappableError
+	errors.ErrInput
 }
 
 // PathIsUnderCurrentDirectory checks whether the `path`
@@ -96,7 +96,7 @@ func CreateNewFileUnderCurrentDirectory(path string, flag int) (io.Writer, error
 	// Ensure we never overwrite an existing file.
 	fp, err := os.OpenFile(filepath.Clean(path), flag|os.O_CREATE|os.O_EXCL, 0o600)
 	if err != nil {
-		return nil, errors.Errorf(&ErrInternal{}, "os.OpenFile(): %v", err)
+		return nil, errors.Errorf(&ErrInternal{}, "os.OpenFile(): %w", err)
 	}
 
 	return fp, nil
@@ -118,13 +118,13 @@ func CreateNewFileUnderDirectory(path, dir string, flag int) (io.Writer, error)
 	fullPath := filepath.Join(dir, path)
 	err := os.MkdirAll(filepath.Dir(fullPath), 0o755)
 	if err != nil {
-		return nil, errors.Errorf(&ErrInternal{}, "os.MkdirAll(): %v", err)
+		return nil, errors.Errorf(&ErrInternal{}, "os.MkdirAll(): %w", err)
 	}
 
 	// Ensure we never overwrite an existing file.
 	fp, err := os.OpenFile(filepath.Clean(fullPath), flag|os.O_CREATE|os.O_EXCL, 0o600)
 	if err != nil {
-		return nil, errors.Errorf(&ErrInternal{}, "os.OpenFile(): %v", err)
+		return nil, errors.Errorf(&ErrInternal{}, "os.OpenFile(): %w", err)
 	}
 
 	return fp, nil
@@ -0,0 +1,91 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance_test
+
+import (
+	"context"
+	"fmt"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/provenance"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+func exampleBuilder() *provenance.Builder {
+	return &provenance.Builder{
+		WorkflowContext: &github.WorkflowContext{
+			Repository: "octo-org/octo-repo",
+			Workflow:   ".github/workflows/release.yml",
+			ServerURL:  "https://github.com",
+			SHA:        "abcdef0123456789abcdef0123456789abcdef01",
+			Ref:        "refs/tags/v1.0.0",
+			RunID:      "12345",
+			RunAttempt: "1",
+		},
+		Subjects: []intoto.Subject{
+			{
+				Name: "artifact",
+				Digest: slsacommon.DigestSet{
+					"sha256": "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2",
+				},
+			},
+		},
+		BuildType: "https://example.com/build-type@v1",
+		// NOTE: Use NilClientProvider outside of GitHub Actions. The OIDC
+		// token and the GitHub API are not used.
+		Clients: &slsa.NilClientProvider{},
+	}
+}
+
+func ExampleBuilder_StatementV02() {
+	p, err := exampleBuilder().StatementV02(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(p.PredicateType)
+	fmt.Println(p.Subject[0].Name)
+	fmt.Println(p.Predicate.BuildType)
+	fmt.Println(p.Predicate.Invocation.ConfigSource.URI)
+	fmt.Println(p.Predicate.Metadata.BuildInvocationID)
+	// Output:
+	// https://slsa.dev/provenance/v0.2
+	// artifact
+	// https://example.com/build-type@v1
+	// git+https://github.com/octo-org/octo-repo@refs/tags/v1.0.0
+	// 12345-1
+}
+
+func ExampleBuilder_StatementV1() {
+	p, err := exampleBuilder().StatementV1(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(p.PredicateType)
+	fmt.Println(p.Subject[0].Name)
+	fmt.Println(p.Predicate.BuildDefinition.BuildType)
+	fmt.Println(p.Predicate.BuildDefinition.ResolvedDependencies[0].URI)
+	fmt.Println(p.Predicate.RunDetails.BuildMetadata.InvocationID)
+	// Output:
+	// https://slsa.dev/provenance/v1?draft
+	// artifact
+	// https://example.com/build-type@v1
+	// git+https://github.com/octo-org/octo-repo@refs/tags/v1.0.0
+	// 12345-1
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package provenance generates the SLSA provenance statements created by the
+// builders and generators in this repository, so that other tools can create
+// the same statements without running the builder binaries.
+package provenance
+
+import (
+	"context"
+	"errors"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1.0"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// StatementInTotoV1 is the type of in-toto v1 statements.
+const StatementInTotoV1 = "https://in-toto.io/Statement/v1"
+
+// StatementV1 is an in-toto statement with a SLSA v1.0 provenance predicate.
+type StatementV1 struct {
+	intoto.StatementHeader
+	Predicate slsa1.ProvenancePredicate `json:"predicate"`
+}
+
+// Builder generates SLSA provenance statements for a GitHub Actions workflow
+// run.
+type Builder struct {
+	// WorkflowContext is the context of the workflow run. It is required.
+	WorkflowContext *github.WorkflowContext
+
+	// Subjects are the artifacts the provenance is about.
+	Subjects []intoto.Subject
+
+	// BuildType is the URI of the build type.
+	BuildType string
+
+	// BuildConfig is the build type specific build configuration. It may be
+	// nil.
+	BuildConfig interface{}
+
+	// Clients provides the OIDC and GitHub API clients. If nil, the default
+	// clients for the GitHub Actions environment are used.
+	Clients slsa.ClientProvider
+}
+
+// buildType implements slsa.BuildType for a Builder.
+type buildType struct {
+	*slsa.GithubActionsBuild
+	uri         string
+	buildConfig interface{}
+}
+
+// URI implements BuildType.URI.
+func (b *buildType) URI() string {
+	return b.uri
+}
+
+// BuildConfig implements BuildType.BuildConfig.
+func (b *buildType) BuildConfig(context.Context) (interface{}, error) {
+	return b.buildConfig, nil
+}
+
+// StatementV02 returns an in-toto statement with a SLSA v0.2 provenance
+// predicate.
+func (b *Builder) StatementV02(ctx context.Context) (*intoto.ProvenanceStatement, error) {
+	if b.WorkflowContext == nil {
+		return nil, errors.New("missing workflow context")
+	}
+
+	bt := &buildType{
+		GithubActionsBuild: slsa.NewGithubActionsBuild(b.Subjects, b.WorkflowContext),
+		uri:                b.BuildType,
+		buildConfig:        b.BuildConfig,
+	}
+	g := slsa.NewHostedActionsGenerator(bt)
+	if b.Clients != nil {
+		bt.WithClients(b.Clients)
+		g.WithClients(b.Clients)
+	}
+	return g.Generate(ctx)
+}
+
+// StatementV1 returns an in-toto statement with a SLSA v1.0 provenance
+// predicate. It records the same information as StatementV02:
+//   - externalParameters has the workflow, the workflow inputs and the build
+//     config.
+//   - systemParameters has the invocation environment.
+//   - resolvedDependencies has the materials.
+func (b *Builder) StatementV1(ctx context.Context) (*StatementV1, error) {
+	p, err := b.StatementV02(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertV1(p), nil
+}
+
+// workflowParameters is the workflow in the v1.0 external parameters.
+type workflowParameters struct {
+	Ref        string `json:"ref"`
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+}
+
+// externalParameters is the externalParameters of a v1.0 predicate.
+type externalParameters struct {
+	Workflow    workflowParameters `json:"workflow"`
+	Inputs      interface{}        `json:"inputs,omitempty"`
+	BuildConfig interface{}        `json:"buildConfig,omitempty"`
+}
+
+// ConvertV1 returns the SLSA v1.0 statement for a v0.2 statement, as
+// described in StatementV1. It is useful to convert a statement that was
+// modified after it was returned by StatementV02.
+func ConvertV1(p *intoto.ProvenanceStatement) *StatementV1 {
+	ext := externalParameters{
+		Workflow: workflowParameters{
+			Repository: p.Predicate.Invocation.ConfigSource.URI,
+			Path:       p.Predicate.Invocation.ConfigSource.EntryPoint,
+		},
+		BuildConfig: p.Predicate.BuildConfig,
+	}
+	if env, ok := p.Predicate.Invocation.Environment.(map[string]interface{}); ok {
+		ext.Workflow.Ref, _ = env["github_ref"].(string)
+	}
+	if params, ok := p.Predicate.Invocation.Parameters.(slsa.WorkflowParameters); ok {
+		ext.Inputs = params.EventInputs
+	}
+
+	var deps []slsa1.ArtifactReference
+	for _, m := range p.Predicate.Materials {
+		deps = append(deps, slsa1.ArtifactReference{
+			URI:    m.URI,
+			Digest: m.Digest,
+		})
+	}
+
+	s := &StatementV1{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: slsa1.PredicateSLSAProvenance,
+			Subject:       p.Subject,
+		},
+		Predicate: slsa1.ProvenancePredicate{
+			BuildDefinition: slsa1.ProvenanceBuildDefinition{
+				BuildType:            p.Predicate.BuildType,
+				ExternalParameters:   ext,
+				SystemParameters:     p.Predicate.Invocation.Environment,
+				ResolvedDependencies: deps,
+			},
+			RunDetails: slsa1.ProvenanaceRunDetails{
+				Builder: slsa1.Builder{
+					ID: p.Predicate.Builder.ID,
+				},
+			},
+		},
+	}
+	if p.Predicate.Metadata != nil {
+		s.Predicate.RunDetails.BuildMetadata = slsa1.BuildMetadata{
+			InvocationID: p.Predicate.Metadata.BuildInvocationID,
+			StartedOn:    p.Predicate.Metadata.BuildStartedOn,
+			FinishedOn:   p.Predicate.Metadata.BuildFinishedOn,
+		}
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1.0"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+func TestBuilder_StatementV02_buildConfig(t *testing.T) {
+	b := Builder{
+		WorkflowContext: &github.WorkflowContext{},
+		BuildType:       "https://example.com/build-type@v1",
+		BuildConfig:     map[string]string{"command": "make"},
+		Clients:         &slsa.NilClientProvider{},
+	}
+
+	p, err := b.StatementV02(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if diff := cmp.Diff(b.BuildConfig, p.Predicate.BuildConfig); diff != "" {
+		t.Errorf("unexpected build config (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuilder_StatementV02_noContext(t *testing.T) {
+	b := Builder{
+		Clients: &slsa.NilClientProvider{},
+	}
+	if _, err := b.StatementV02(context.Background()); err == nil {
+		t.Errorf("expected an error to occur.")
+	}
+}
+
+func TestBuilder_StatementV1(t *testing.T) {
+	b := Builder{
+		WorkflowContext: &github.WorkflowContext{
+			Repository: "octo-org/octo-repo",
+			Workflow:   ".github/workflows/release.yml",
+			ServerURL:  "https://github.com",
+			SHA:        "abcdef0123456789abcdef0123456789abcdef01",
+			Ref:        "refs/heads/main",
+			Event: map[string]interface{}{
+				"inputs": map[string]interface{}{"version": "v1.0.0"},
+			},
+		},
+		BuildType:   "https://example.com/build-type@v1",
+		BuildConfig: map[string]string{"command": "make"},
+		Clients:     &slsa.NilClientProvider{},
+	}
+
+	p, err := b.StatementV1(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := externalParameters{
+		Workflow: workflowParameters{
+			Ref:        "refs/heads/main",
+			Repository: "git+https://github.com/octo-org/octo-repo@refs/heads/main",
+			Path:       ".github/workflows/release.yml",
+		},
+		Inputs:      map[string]interface{}{"version": "v1.0.0"},
+		BuildConfig: map[string]string{"command": "make"},
+	}
+	if diff := cmp.Diff(want, p.Predicate.BuildDefinition.ExternalParameters); diff != "" {
+		t.Errorf("unexpected external parameters (-want +got):\n%s", diff)
+	}
+
+	wantDeps := []slsa1.ArtifactReference{
+		{
+			URI: "git+https://github.com/octo-org/octo-repo@refs/heads/main",
+			Digest: slsacommon.DigestSet{
+				"sha1": "abcdef0123456789abcdef0123456789abcdef01",
+			},
+		},
+	}
+	if diff := cmp.Diff(wantDeps, p.Predicate.BuildDefinition.ResolvedDependencies); diff != "" {
+		t.Errorf("unexpected resolved dependencies (-want +got):\n%s", diff)
+	}
+}
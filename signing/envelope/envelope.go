@@ -1,6 +1,8 @@
 package envelope
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
@@ -68,6 +70,53 @@ func AddCertToEnvelope(signedAtt, cert []byte) ([]byte, error) {
 	return json.Marshal(envWithCert)
 }
 
+// Marshal returns the JSON encoding of an envelope with the payload and a
+// single signature, byte for byte as json.Marshal encodes an Envelope, or a
+// dsse.Envelope if cert is nil. The payload is base64 encoded directly into
+// the output so that large payloads are not held in memory more than needed.
+func Marshal(payloadType string, payload []byte, keyID string, sig, cert []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(base64.StdEncoding.EncodedLen(len(payload)) + 2*len(cert) + 512)
+
+	writeString := func(s string) error {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+
+	buf.WriteString(`{"payloadType":`)
+	if err := writeString(payloadType); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`,"payload":"`)
+	enc := base64.NewEncoder(base64.StdEncoding, &buf)
+	if _, err := enc.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`","signatures":[{"keyid":`)
+	if err := writeString(keyID); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`,"sig":`)
+	if err := writeString(base64.StdEncoding.EncodeToString(sig)); err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		buf.WriteString(`,"cert":`)
+		if err := writeString(string(cert)); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString(`}]}`)
+	return buf.Bytes(), nil
+}
+
 // GetCertFromEnvelope takes a signed Envelope and extracts the PEM-encoded
 // certificate from the signature.
 // This assumes there is only one signature present in the envelope.
@@ -84,3 +133,47 @@ func GetCertFromEnvelope(signedAtt []byte) ([]byte, error) {
 
 	return []byte(env.Signatures[0].Cert), nil
 }
+
+// mergedSignature is a signature of a merged envelope. Unlike Signature, the
+// certificate is omitted for signatures that don't have one, e.g. those of
+// KMS keys.
+type mergedSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+	Cert  string `json:"cert,omitempty"`
+}
+
+// Merge returns an envelope with the signatures of all the given envelopes,
+// in the order of the envelopes, so that the encoding is deterministic. The
+// envelopes must have the same payload type and payload, i.e. be signatures
+// over the identical statement.
+func Merge(envs ...[]byte) ([]byte, error) {
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("no envelopes to merge")
+	}
+
+	var merged struct {
+		PayloadType string            `json:"payloadType"`
+		Payload     string            `json:"payload"`
+		Signatures  []mergedSignature `json:"signatures"`
+	}
+	for i, b := range envs {
+		env := &Envelope{}
+		if err := json.Unmarshal(b, env); err != nil {
+			return nil, fmt.Errorf("parsing envelope %d: %w", i+1, err)
+		}
+		if i == 0 {
+			merged.PayloadType = env.PayloadType
+			merged.Payload = env.Payload
+		} else if env.PayloadType != merged.PayloadType || env.Payload != merged.Payload {
+			return nil, fmt.Errorf("envelope %d has a different payload", i+1)
+		}
+		if len(env.Signatures) == 0 {
+			return nil, fmt.Errorf("envelope %d has no signatures", i+1)
+		}
+		for _, sig := range env.Signatures {
+			merged.Signatures = append(merged.Signatures, mergedSignature(sig))
+		}
+	}
+	return json.Marshal(merged)
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"math/big"
@@ -182,3 +183,120 @@ func testRekorSupport(t *testing.T, certPem, envWithCert []byte) {
 		t.Fatalf("error creating valid intoto entry")
 	}
 }
+
+func TestMarshal(t *testing.T) {
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","subject":[{"name":"<a&b>"}]}`)
+	sig := []byte("signature")
+	cert := []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+
+	want, err := json.Marshal(&Envelope{
+		PayloadType: intoto.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []Signature{
+			{KeyID: "key", Sig: base64.StdEncoding.EncodeToString(sig), Cert: string(cert)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	got, err := Marshal(intoto.PayloadType, payload, "key", sig, cert)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("unexpected envelope, want: %s, got: %s", want, got)
+	}
+
+	// Without a certificate the envelope is a DSSE envelope.
+	want, err = json.Marshal(&dsse.Envelope{
+		PayloadType: intoto.PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []dsse.Signature{
+			{KeyID: "key", Sig: base64.StdEncoding.EncodeToString(sig)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	got, err = Marshal(intoto.PayloadType, payload, "key", sig, nil)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("unexpected envelope, want: %s, got: %s", want, got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`)
+	cert := []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+	keyless, err := Marshal(intoto.PayloadType, payload, "", []byte("keyless"), cert)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	kms, err := Marshal(intoto.PayloadType, payload, "kms-key", []byte("kms"), nil)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	other, err := Marshal(intoto.PayloadType, []byte(`{}`), "kms-key", []byte("kms"), nil)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		envs     [][]byte
+		expected string
+		err      bool
+	}{
+		{
+			name: "keyless and key",
+			envs: [][]byte{keyless, kms},
+			expected: `{"payloadType":"application/vnd.in-toto+json","payload":"` +
+				base64.StdEncoding.EncodeToString(payload) + `","signatures":[` +
+				`{"keyid":"","sig":"a2V5bGVzcw==","cert":"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"},` +
+				`{"keyid":"kms-key","sig":"a21z"}]}`,
+		},
+		{
+			name: "key and keyless",
+			envs: [][]byte{kms, keyless},
+			expected: `{"payloadType":"application/vnd.in-toto+json","payload":"` +
+				base64.StdEncoding.EncodeToString(payload) + `","signatures":[` +
+				`{"keyid":"kms-key","sig":"a21z"},` +
+				`{"keyid":"","sig":"a2V5bGVzcw==","cert":"-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"}]}`,
+		},
+		{
+			name: "different payloads",
+			envs: [][]byte{keyless, other},
+			err:  true,
+		},
+		{
+			name: "invalid envelope",
+			envs: [][]byte{keyless, []byte("not json")},
+			err:  true,
+		},
+		{
+			name: "no envelopes",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Merge(tc.envs...)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error to occur")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("unexpected envelope, want: %s, got: %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// ErrPayloadHashMismatch indicates that the statement to be signed does not
+// match the expected hash.
+type ErrPayloadHashMismatch struct {
+	errors.WrappableError
+}
+
+// HashVerifyingSigner is a Signer that wraps another Signer and verifies the
+// SHA-256 hash of the JSON encoded statement before signing it. This guards
+// against the statement being modified between the time it was generated and
+// the time it is signed.
+type HashVerifyingSigner struct {
+	signer       Signer
+	expectedHash string
+}
+
+// NewHashVerifyingSigner returns a new HashVerifyingSigner that wraps the
+// given Signer. Signing fails unless the expected hash is set using
+// WithExpectedHash.
+func NewHashVerifyingSigner(s Signer) *HashVerifyingSigner {
+	return &HashVerifyingSigner{
+		signer: s,
+	}
+}
+
+// WithExpectedHash sets the hex encoded SHA-256 hash that the JSON encoded
+// statement is expected to have.
+func (s *HashVerifyingSigner) WithExpectedHash(h string) *HashVerifyingSigner {
+	s.expectedHash = strings.ToLower(h)
+	return s
+}
+
+// Sign implements Signer.Sign. The wrapped Signer is not called if the
+// statement does not match the expected hash. If the wrapped Signer is a
+// PayloadSigner, the hashed payload is signed so that the statement is only
+// encoded once.
+func (s *HashVerifyingSigner) Sign(ctx context.Context, p *intoto.Statement) (Attestation, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling json: %w", err)
+	}
+
+	if err := s.checkHash(b); err != nil {
+		return nil, err
+	}
+
+	if ps, ok := s.signer.(PayloadSigner); ok {
+		return ps.SignPayload(ctx, b)
+	}
+	return s.signer.Sign(ctx, p)
+}
+
+// SignPayload implements PayloadSigner.SignPayload. The payload is signed as
+// is if it matches the expected hash. Signing fails if the wrapped Signer is
+// not a PayloadSigner.
+func (s *HashVerifyingSigner) SignPayload(ctx context.Context, payload []byte) (Attestation, error) {
+	ps, ok := s.signer.(PayloadSigner)
+	if !ok {
+		return nil, fmt.Errorf("signer %T cannot sign a payload", s.signer)
+	}
+	if err := s.checkHash(payload); err != nil {
+		return nil, err
+	}
+	return ps.SignPayload(ctx, payload)
+}
+
+// checkHash returns an ErrPayloadHashMismatch unless the SHA-256 hash of the
+// payload is the expected hash.
+func (s *HashVerifyingSigner) checkHash(payload []byte) error {
+	h := sha256.Sum256(payload)
+	if got := hex.EncodeToString(h[:]); got != s.expectedHash {
+		return errors.Errorf(&ErrPayloadHashMismatch{}, "payload hash mismatch: expected %q, got %q", s.expectedHash, got)
+	}
+	return nil
+}
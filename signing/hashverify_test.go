@@ -0,0 +1,209 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// countingSigner is a Signer that counts the number of times Sign is called.
+type countingSigner struct {
+	calls int
+}
+
+func (s *countingSigner) Sign(context.Context, *intoto.Statement) (Attestation, error) {
+	s.calls++
+	return nil, nil
+}
+
+// payloadSigner is a PayloadSigner that records the payload it signed.
+type payloadSigner struct {
+	countingSigner
+	payload []byte
+}
+
+func (s *payloadSigner) SignPayload(_ context.Context, payload []byte) (Attestation, error) {
+	s.payload = payload
+	return nil, nil
+}
+
+func statementHash(t *testing.T, p *intoto.Statement) string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func TestHashVerifyingSigner(t *testing.T) {
+	newStatement := func() *intoto.Statement {
+		return &intoto.Statement{
+			StatementHeader: intoto.StatementHeader{
+				Type: intoto.StatementInTotoV01,
+				Subject: []intoto.Subject{
+					{
+						Name: "artifact1",
+						Digest: map[string]string{
+							"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name string
+		// mutate modifies the statement after the expected hash is computed.
+		mutate   func(*intoto.Statement)
+		noHash   bool
+		mismatch bool
+		calls    int
+	}{
+		{
+			name:     "matching hash",
+			mismatch: false,
+			calls:    1,
+		},
+		{
+			name: "corrupted payload",
+			mutate: func(p *intoto.Statement) {
+				p.Subject[0].Name = "artifact2"
+			},
+			mismatch: true,
+			calls:    0,
+		},
+		{
+			name:     "no expected hash",
+			noHash:   true,
+			mismatch: true,
+			calls:    0,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			inner := &countingSigner{}
+			s := NewHashVerifyingSigner(inner)
+
+			p := newStatement()
+			if !tc.noHash {
+				s.WithExpectedHash(statementHash(t, p))
+			}
+			if tc.mutate != nil {
+				tc.mutate(p)
+			}
+
+			_, err := s.Sign(context.Background(), p)
+			errMismatch := &ErrPayloadHashMismatch{}
+			if got := errors.As(err, &errMismatch); got != tc.mismatch {
+				t.Errorf("unexpected error, want mismatch: %v, got: %v", tc.mismatch, err)
+			}
+			if want, got := tc.calls, inner.calls; want != got {
+				t.Errorf("unexpected number of Sign calls, want: %d, got: %d", want, got)
+			}
+		})
+	}
+}
+
+// TestHashVerifyingSigner_payloadSigner tests that the hashed payload is
+// signed by a PayloadSigner instead of encoding the statement again.
+func TestHashVerifyingSigner_payloadSigner(t *testing.T) {
+	p := &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type: intoto.StatementInTotoV01,
+		},
+	}
+	inner := &payloadSigner{}
+	s := NewHashVerifyingSigner(inner).WithExpectedHash(statementHash(t, p))
+	if _, err := s.Sign(context.Background(), p); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if !bytes.Equal(want, inner.payload) {
+		t.Errorf("unexpected payload, want: %q, got: %q", want, inner.payload)
+	}
+	if inner.calls != 0 {
+		t.Errorf("unexpected number of Sign calls, want: 0, got: %d", inner.calls)
+	}
+}
+
+// TestHashVerifyingSigner_SignPayload tests that a payload is signed as is
+// only if it matches the expected hash and the wrapped Signer is a
+// PayloadSigner.
+func TestHashVerifyingSigner_SignPayload(t *testing.T) {
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`)
+	h := sha256.Sum256(payload)
+
+	testCases := []struct {
+		name     string
+		signer   Signer
+		payload  []byte
+		mismatch bool
+		wantErr  bool
+	}{
+		{
+			name:    "matching hash",
+			signer:  &payloadSigner{},
+			payload: payload,
+		},
+		{
+			name:     "corrupted payload",
+			signer:   &payloadSigner{},
+			payload:  []byte(`{"_type":"https://in-toto.io/Statement/v1"}`),
+			mismatch: true,
+			wantErr:  true,
+		},
+		{
+			name:    "not a payload signer",
+			signer:  &countingSigner{},
+			payload: payload,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewHashVerifyingSigner(tc.signer).WithExpectedHash(hex.EncodeToString(h[:]))
+			_, err := s.SignPayload(context.Background(), tc.payload)
+			if got := err != nil; got != tc.wantErr {
+				t.Fatalf("unexpected error, want error: %v, got: %v", tc.wantErr, err)
+			}
+			errMismatch := &ErrPayloadHashMismatch{}
+			if got := errors.As(err, &errMismatch); got != tc.mismatch {
+				t.Errorf("unexpected error, want mismatch: %v, got: %v", tc.mismatch, err)
+			}
+			if ps, ok := tc.signer.(*payloadSigner); ok && !tc.wantErr && !bytes.Equal(tc.payload, ps.payload) {
+				t.Errorf("unexpected payload, want: %q, got: %q", tc.payload, ps.payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"encoding/json"
+)
+
+// logEntryJSON is the JSON encoding of a LogEntry. Field names follow the
+// Rekor API so that the entry can be verified offline with the same tools.
+type logEntryJSON struct {
+	UUID                 string          `json:"uuid"`
+	LogID                string          `json:"logID"`
+	LogIndex             int64           `json:"logIndex"`
+	IntegratedTime       int64           `json:"integratedTime"`
+	SignedEntryTimestamp []byte          `json:"signedEntryTimestamp"`
+	InclusionProof       *InclusionProof `json:"inclusionProof,omitempty"`
+}
+
+// MarshalLogEntry returns the JSON encoding of the log entry. The signed entry
+// timestamp is base64 encoded.
+func MarshalLogEntry(e LogEntry) ([]byte, error) {
+	return json.Marshal(logEntryJSON{
+		UUID:                 e.UUID(),
+		LogID:                e.ID(),
+		LogIndex:             e.LogIndex(),
+		IntegratedTime:       e.IntegratedTime(),
+		SignedEntryTimestamp: e.SignedEntryTimestamp(),
+		InclusionProof:       e.InclusionProof(),
+	})
+}
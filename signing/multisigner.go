@@ -0,0 +1,132 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+// multiAttestation is an attestation whose envelope has several signatures.
+type multiAttestation struct {
+	cert []byte
+	att  []byte
+}
+
+// Bytes returns the signed attestation as an encoded DSSE JSON envelope.
+func (a *multiAttestation) Bytes() []byte {
+	return a.att
+}
+
+// Cert returns the certificate or public key of the first signer.
+func (a *multiAttestation) Cert() []byte {
+	return a.cert
+}
+
+// MultiSigner is a Signer that signs the statement with several Signers, e.g.
+// keyless with Fulcio and with an organizational KMS key, and returns a single
+// envelope with the signature of each, in the order of the Signers. The
+// attestation's Cert is that of the first Signer.
+type MultiSigner struct {
+	signers []Signer
+}
+
+// NewMultiSigner returns a new MultiSigner that signs with the given Signers.
+func NewMultiSigner(signers ...Signer) *MultiSigner {
+	return &MultiSigner{
+		signers: signers,
+	}
+}
+
+// Sign implements Signer.Sign. The statement is encoded once and signed as is
+// by the Signers that are PayloadSigners, so that every signature is over the
+// identical payload. Signing fails if another Signer encodes the statement
+// differently.
+func (s *MultiSigner) Sign(ctx context.Context, p *intoto.Statement) (Attestation, error) {
+	if len(s.signers) == 0 {
+		return nil, fmt.Errorf("no signers")
+	}
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling json: %w", err)
+	}
+
+	var cert []byte
+	envs := make([][]byte, 0, len(s.signers))
+	for i, signer := range s.signers {
+		var att Attestation
+		if ps, ok := signer.(PayloadSigner); ok {
+			att, err = ps.SignPayload(ctx, payload)
+		} else {
+			att, err = signer.Sign(ctx, p)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("signer %d: %w", i+1, err)
+		}
+		if i == 0 {
+			cert = att.Cert()
+		}
+		envs = append(envs, att.Bytes())
+	}
+
+	env, err := envelope.Merge(envs...)
+	if err != nil {
+		return nil, fmt.Errorf("merging signatures: %w", err)
+	}
+	return &multiAttestation{
+		cert: cert,
+		att:  env,
+	}, nil
+}
+
+// SignPayload implements PayloadSigner.SignPayload. The payload is signed as
+// is by every Signer, which must all be PayloadSigners.
+func (s *MultiSigner) SignPayload(ctx context.Context, payload []byte) (Attestation, error) {
+	if len(s.signers) == 0 {
+		return nil, fmt.Errorf("no signers")
+	}
+
+	var cert []byte
+	envs := make([][]byte, 0, len(s.signers))
+	for i, signer := range s.signers {
+		ps, ok := signer.(PayloadSigner)
+		if !ok {
+			return nil, fmt.Errorf("signer %d: %T cannot sign a payload", i+1, signer)
+		}
+		att, err := ps.SignPayload(ctx, payload)
+		if err != nil {
+			return nil, fmt.Errorf("signer %d: %w", i+1, err)
+		}
+		if i == 0 {
+			cert = att.Cert()
+		}
+		envs = append(envs, att.Bytes())
+	}
+
+	env, err := envelope.Merge(envs...)
+	if err != nil {
+		return nil, fmt.Errorf("merging signatures: %w", err)
+	}
+	return &multiAttestation{
+		cert: cert,
+		att:  env,
+	}, nil
+}
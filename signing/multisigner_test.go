@@ -0,0 +1,179 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
+)
+
+var errTestSigner = errors.New("signer error")
+
+// envelopeSigner is a Signer that returns an envelope with a single
+// signature, whose value is the key ID.
+type envelopeSigner struct {
+	keyID string
+	cert  []byte
+	// payload overrides the signed payload if not nil.
+	payload []byte
+	err     error
+}
+
+func (s *envelopeSigner) Sign(_ context.Context, p *intoto.Statement) (Attestation, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	payload := s.payload
+	if payload == nil {
+		var err error
+		if payload, err = json.Marshal(p); err != nil {
+			return nil, err
+		}
+	}
+	env, err := envelope.Marshal(intoto.PayloadType, payload, s.keyID, []byte(s.keyID), s.cert)
+	if err != nil {
+		return nil, err
+	}
+	return &multiAttestation{cert: s.cert, att: env}, nil
+}
+
+func TestMultiSigner(t *testing.T) {
+	keyless := &envelopeSigner{keyID: "keyless", cert: []byte("certificate")}
+	kms := &envelopeSigner{keyID: "kms", cert: []byte("public key")}
+
+	testCases := []struct {
+		name     string
+		signers  []Signer
+		expected []string
+		cert     string
+		err      bool
+	}{
+		{
+			name:     "keyless and key",
+			signers:  []Signer{keyless, kms},
+			expected: []string{"keyless", "kms"},
+			cert:     "certificate",
+		},
+		{
+			name:     "key and keyless",
+			signers:  []Signer{kms, keyless},
+			expected: []string{"kms", "keyless"},
+			cert:     "public key",
+		},
+		{
+			name:    "signer fails",
+			signers: []Signer{keyless, &envelopeSigner{err: errTestSigner}},
+			err:     true,
+		},
+		{
+			name:    "different payload",
+			signers: []Signer{keyless, &envelopeSigner{keyID: "other", payload: []byte(`{}`)}},
+			err:     true,
+		},
+		{
+			name: "no signers",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			att, err := NewMultiSigner(tc.signers...).Sign(context.Background(), &intoto.Statement{})
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error to occur")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			var env envelope.Envelope
+			if err := json.Unmarshal(att.Bytes(), &env); err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			var keyIDs []string
+			for _, s := range env.Signatures {
+				keyIDs = append(keyIDs, s.KeyID)
+			}
+			if diff := cmp.Diff(tc.expected, keyIDs); diff != "" {
+				t.Errorf("unexpected signatures (-want +got):\n%s", diff)
+			}
+			if want, got := tc.cert, string(att.Cert()); want != got {
+				t.Errorf("unexpected cert, want: %q, got: %q", want, got)
+			}
+		})
+	}
+}
+
+// envelopePayloadSigner is an envelopeSigner that is a PayloadSigner and
+// records the payload it signed.
+type envelopePayloadSigner struct {
+	envelopeSigner
+	signed []byte
+}
+
+func (s *envelopePayloadSigner) SignPayload(ctx context.Context, payload []byte) (Attestation, error) {
+	s.signed = payload
+	s.payload = payload
+	return s.envelopeSigner.Sign(ctx, nil)
+}
+
+// TestMultiSigner_payload tests that PayloadSigners sign the payload encoded
+// by the MultiSigner.
+func TestMultiSigner_payload(t *testing.T) {
+	p := &intoto.Statement{StatementHeader: intoto.StatementHeader{Type: intoto.StatementInTotoV01}}
+	ps := &envelopePayloadSigner{envelopeSigner: envelopeSigner{keyID: "key"}}
+	if _, err := NewMultiSigner(&envelopeSigner{keyID: "keyless"}, ps).Sign(context.Background(), p); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if string(want) != string(ps.signed) {
+		t.Errorf("unexpected payload, want: %s, got: %s", want, ps.signed)
+	}
+}
+
+// TestMultiSigner_SignPayload tests that the payload is signed as is by every
+// signer, and that signing fails if one of them is not a PayloadSigner.
+func TestMultiSigner_SignPayload(t *testing.T) {
+	payload := []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`)
+	ps1 := &envelopePayloadSigner{envelopeSigner: envelopeSigner{keyID: "keyless"}}
+	ps2 := &envelopePayloadSigner{envelopeSigner: envelopeSigner{keyID: "key"}}
+	if _, err := NewMultiSigner(ps1, ps2).SignPayload(context.Background(), payload); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	for _, ps := range []*envelopePayloadSigner{ps1, ps2} {
+		if string(payload) != string(ps.signed) {
+			t.Errorf("unexpected payload, want: %s, got: %s", payload, ps.signed)
+		}
+	}
+
+	if _, err := NewMultiSigner(ps1, &envelopeSigner{keyID: "key"}).SignPayload(context.Background(), payload); err == nil {
+		t.Fatalf("expected an error to occur.")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nop implements a Signer and TransparencyLog that do nothing. They
+// are meant for testing workflows end-to-end without access to a signing
+// service and must never be used to produce provenance that is published.
+package nop
+
+import (
+	"context"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/slsa-framework/slsa-github-generator/signing"
+	"github.com/slsa-framework/slsa-github-generator/slsa"
+)
+
+// Attestation is an unsigned attestation. Its bytes are the canonical JSON
+// encoding of the statement.
+type Attestation struct {
+	payload []byte
+}
+
+// Cert implements signing.Attestation.Cert. It always returns nil.
+func (a *Attestation) Cert() []byte {
+	return nil
+}
+
+// Bytes implements signing.Attestation.Bytes.
+func (a *Attestation) Bytes() []byte {
+	return a.payload
+}
+
+// Signer is a signing.Signer that does not sign the statement.
+type Signer struct{}
+
+// Sign implements signing.Signer.Sign. It returns the unsigned statement.
+func (Signer) Sign(_ context.Context, s *intoto.Statement) (signing.Attestation, error) {
+	b, err := slsa.CanonicalizeStatement(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{payload: b}, nil
+}
+
+// SignPayload implements signing.PayloadSigner.SignPayload. It returns the
+// unsigned payload.
+func (Signer) SignPayload(_ context.Context, payload []byte) (signing.Attestation, error) {
+	return &Attestation{payload: payload}, nil
+}
+
+// TransparencyLog is a signing.TransparencyLog that does not upload
+// anything.
+type TransparencyLog = slsa.NopTransparencyLog
@@ -0,0 +1,82 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nop
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+var (
+	_ signing.Signer          = Signer{}
+	_ signing.PayloadSigner   = Signer{}
+	_ signing.TransparencyLog = TransparencyLog{}
+)
+
+func TestSigner_Sign(t *testing.T) {
+	s := &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: "https://slsa.dev/provenance/v0.2",
+			Subject: []intoto.Subject{
+				{Name: "artifact1", Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"}},
+			},
+		},
+	}
+
+	att, err := Signer{}.Sign(context.Background(), s)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if att.Cert() != nil {
+		t.Errorf("unexpected cert, want: nil, got: %q", att.Cert())
+	}
+
+	var got intoto.Statement
+	if err := json.Unmarshal(att.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if diff := cmp.Diff(*s, got); diff != "" {
+		t.Errorf("unexpected statement (-want +got):\n%s", diff)
+	}
+}
+
+func TestSigner_SignPayload(t *testing.T) {
+	payload := []byte(`{"predicate":{},"_type":"https://in-toto.io/Statement/v0.1"}`)
+	att, err := Signer{}.SignPayload(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if want, got := string(payload), string(att.Bytes()); want != got {
+		t.Errorf("unexpected payload, want: %s, got: %s", want, got)
+	}
+}
+
+func TestTransparencyLog_Upload(t *testing.T) {
+	entry, err := TransparencyLog{}.Upload(context.Background(), &Attestation{})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if entry != nil {
+		t.Errorf("unexpected entry, want: nil, got: %v", entry)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// PAEReader returns a reader of the DSSE pre-authentication encoding of the
+// payload. Unlike dsse.PAE, it does not copy the payload, so it can be
+// passed to signers that hash their input as it is read.
+// See https://github.com/secure-systems-lab/dsse/blob/master/protocol.md
+func PAEReader(payloadType string, payload []byte) io.Reader {
+	header := fmt.Sprintf("DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	return io.MultiReader(bytes.NewReader([]byte(header)), bytes.NewReader(payload))
+}
@@ -0,0 +1,36 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+func TestPAEReader(t *testing.T) {
+	for _, payload := range [][]byte{nil, []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`)} {
+		got, err := io.ReadAll(PAEReader(intoto.PayloadType, payload))
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if want := dsse.PAE(intoto.PayloadType, payload); !bytes.Equal(want, got) {
+			t.Errorf("unexpected PAE, want: %q, got: %q", want, got)
+		}
+	}
+}
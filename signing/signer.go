@@ -8,7 +8,9 @@ import (
 
 // Attestation is a signed attestation.
 type Attestation interface {
-	// Cert returns the certificate used to sign the attestation.
+	// Cert returns the PEM encoded certificate used to sign the attestation,
+	// leaf first, or nil if the signer does not use certificates. It can be
+	// inspected with slsa.ParseCertIdentity.
 	Cert() []byte
 
 	// Bytes returns the signed attestation as an encoded DSSE JSON envelope.
@@ -22,6 +24,17 @@ type Signer interface {
 	Sign(context.Context, *intoto.Statement) (Attestation, error)
 }
 
+// PayloadSigner is implemented by Signers that can sign a statement that is
+// already JSON encoded. Callers that have the encoded statement, e.g. to hash
+// it, use it to avoid encoding large statements twice.
+type PayloadSigner interface {
+	Signer
+
+	// SignPayload signs the JSON encoded statement and returns the signed
+	// attestation. The payload is used as is and must not be modified.
+	SignPayload(ctx context.Context, payload []byte) (Attestation, error)
+}
+
 // LogEntry represents a transparency log entry.
 type LogEntry interface {
 	// ID returns the ID of the transparency log.
@@ -32,6 +45,37 @@ type LogEntry interface {
 
 	// UUID return the uuid of the transparency log entry.
 	UUID() string
+
+	// IntegratedTime returns the time the entry was added to the log, in
+	// seconds since the Unix epoch.
+	IntegratedTime() int64
+
+	// SignedEntryTimestamp returns the log's signature over the entry, its
+	// index, the log ID and the integrated time.
+	SignedEntryTimestamp() []byte
+
+	// InclusionProof returns the proof that the entry is included in the
+	// log, or nil if the log did not return one.
+	InclusionProof() *InclusionProof
+}
+
+// InclusionProof is a Merkle tree inclusion proof of a transparency log entry.
+type InclusionProof struct {
+	// LogIndex is the index of the entry in the log.
+	LogIndex int64 `json:"logIndex"`
+
+	// RootHash is the hex encoded root hash of the tree the proof is based on.
+	RootHash string `json:"rootHash"`
+
+	// TreeSize is the size of the tree the proof is based on.
+	TreeSize int64 `json:"treeSize"`
+
+	// Hashes are the hex encoded hashes needed to compute the root hash,
+	// ordered from leaf to root.
+	Hashes []string `json:"hashes"`
+
+	// Checkpoint is the signed tree head the proof is based on.
+	Checkpoint string `json:"checkpoint,omitempty"`
 }
 
 // TransparencyLog allows interaction with a transparency log.
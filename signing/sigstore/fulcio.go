@@ -15,15 +15,21 @@
 package sigstore
 
 import (
-	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/pkg/providers"
-	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/slsa-framework/slsa-github-generator/github"
 	"github.com/slsa-framework/slsa-github-generator/signing"
 	"github.com/slsa-framework/slsa-github-generator/signing/envelope"
 
@@ -34,13 +40,32 @@ const (
 	defaultFulcioAddr   = options.DefaultFulcioURL
 	defaultOIDCIssuer   = options.DefaultOIDCIssuerURL
 	defaultOIDCClientID = "sigstore"
+
+	// certExpiryMargin is how long before its expiry a certificate is no
+	// longer reused, so that it is still valid when the entry is added to
+	// the transparency log.
+	certExpiryMargin = time.Minute
 )
 
-// Fulcio is used to sign provenance statements using Fulcio.
+// Fulcio is used to sign provenance statements using Fulcio. The ephemeral key
+// and its certificate are requested once and reused for all statements signed
+// by the same Fulcio instance until the certificate is about to expire.
 type Fulcio struct {
 	fulcioAddr   string
 	oidcIssuer   string
 	oidcClientID string
+
+	// newSigner requests a new key and certificate from Fulcio.
+	newSigner func(ctx context.Context, ko options.KeyOpts) (*fulcio.Signer, error)
+
+	// debugLog is where debug messages about certificate reuse are written.
+	debugLog io.Writer
+
+	mu        sync.Mutex
+	signer    *fulcio.Signer
+	notAfter  time.Time
+	fetchTime time.Duration
+	saved     time.Duration
 }
 
 // attestation is a signed attestation.
@@ -71,23 +96,35 @@ func NewFulcio(fulcioAddr, oidcIssuer, oidcClientID string) *Fulcio {
 		fulcioAddr:   fulcioAddr,
 		oidcIssuer:   oidcIssuer,
 		oidcClientID: oidcClientID,
+		newSigner:    newAmbientSigner,
+		debugLog:     os.Stderr,
 	}
 }
 
-// Sign signs the given provenance statement and returns the signed
-// attestation.
-func (s *Fulcio) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
-	// Get Fulcio signer
+// newAmbientSigner requests a new key and certificate from Fulcio using the
+// ambient OIDC credentials.
+func newAmbientSigner(ctx context.Context, ko options.KeyOpts) (*fulcio.Signer, error) {
 	if !providers.Enabled(ctx) {
 		return nil, fmt.Errorf("no auth provider is enabled. Are you running outside of Github Actions?")
 	}
+	return fulcio.NewSigner(ctx, ko)
+}
 
-	attBytes, err := json.Marshal(p)
-	if err != nil {
-		return nil, fmt.Errorf("marshalling json: %w", err)
+// getSigner returns the cached Fulcio signer, or requests a new one if there
+// is none or its certificate is about to expire.
+func (s *Fulcio) getSigner(ctx context.Context) (*fulcio.Signer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signer != nil && time.Now().Add(certExpiryMargin).Before(s.notAfter) {
+		s.saved += s.fetchTime
+		github.Debug(s.debugLog, fmt.Sprintf("reusing Fulcio certificate valid until %s, saved %s so far",
+			s.notAfter.Format(time.RFC3339), s.saved))
+		return s.signer, nil
 	}
 
-	k, err := fulcio.NewSigner(ctx, options.KeyOpts{
+	start := time.Now()
+	k, err := s.newSigner(ctx, options.KeyOpts{
 		OIDCIssuer:   s.oidcIssuer,
 		OIDCClientID: s.oidcClientID,
 		FulcioURL:    s.fulcioAddr,
@@ -95,18 +132,64 @@ func (s *Fulcio) Sign(ctx context.Context, p *intoto.Statement) (signing.Attesta
 	if err != nil {
 		return nil, fmt.Errorf("creating fulcio signer: %w", err)
 	}
-	wrappedSigner := dsse.WrapSigner(k, intoto.PayloadType)
+	notAfter, err := certNotAfter(k.Cert)
+	if err != nil {
+		return nil, err
+	}
+	s.signer = k
+	s.notAfter = notAfter
+	s.fetchTime = time.Since(start)
+	github.Debug(s.debugLog, fmt.Sprintf("requested Fulcio certificate valid until %s in %s",
+		notAfter.Format(time.RFC3339), s.fetchTime))
+	return k, nil
+}
+
+// certNotAfter returns the expiry time of a PEM encoded certificate.
+func certNotAfter(certPEM []byte) (time.Time, error) {
+	b, _ := pem.Decode(certPEM)
+	if b == nil {
+		return time.Time{}, fmt.Errorf("no certificate found in Fulcio response")
+	}
+	cert, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing Fulcio certificate: %w", err)
+	}
+	return cert.NotAfter, nil
+}
 
-	signedAtt, err := wrappedSigner.SignMessage(bytes.NewReader(attBytes))
+// Sign signs the given provenance statement and returns the signed
+// attestation.
+func (s *Fulcio) Sign(ctx context.Context, p *intoto.Statement) (signing.Attestation, error) {
+	attBytes, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling json: %w", err)
+	}
+	return s.SignPayload(ctx, attBytes)
+}
+
+// SignPayload implements signing.PayloadSigner.SignPayload. The DSSE
+// pre-authentication encoding is hashed as it is read, and the envelope is
+// encoded directly, so the payload is not copied.
+func (s *Fulcio) SignPayload(ctx context.Context, payload []byte) (signing.Attestation, error) {
+	// Get Fulcio signer
+	k, err := s.getSigner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := k.SignMessage(signing.PAEReader(intoto.PayloadType, payload))
 	if err != nil {
 		return nil, fmt.Errorf("signing message: %v", err)
 	}
 
 	// Add certificate to envelope.
 	// TODO: Remove when DSSE spec includes a cert field inside the signatures.
-	signedAttWithCert, err := envelope.AddCertToEnvelope(signedAtt, k.Cert)
+	if certs, err := cryptoutils.UnmarshalCertificatesFromPEM(k.Cert); err != nil || len(certs) != 1 {
+		return nil, fmt.Errorf("adding certificate to DSSE: invalid certificate, expected PEM encoded certificate")
+	}
+	signedAttWithCert, err := envelope.Marshal(intoto.PayloadType, payload, "", sig, k.Cert)
 	if err != nil {
-		return nil, fmt.Errorf("adding certificate to DSSE: %v", err)
+		return nil, fmt.Errorf("marshalling envelope: %w", err)
 	}
 
 	return &attestation{
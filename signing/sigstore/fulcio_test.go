@@ -0,0 +1,152 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// newFakeFulcio returns a Fulcio instance that requests certificates valid
+// for the given duration from a fake Fulcio server, and a counter of the
+// requests made to the server.
+func newFakeFulcio(t *testing.T, validity time.Duration) (*Fulcio, *int32) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/signingCert" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(&requests, 1)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(n)),
+			Subject:      pkix.Name{CommonName: "fulcio"},
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(validity),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		pem, err := cryptoutils.MarshalCertificateToPEM(cert)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(pem)
+	}))
+	t.Cleanup(srv.Close)
+
+	// The ID token is not verified by the client so it only needs to be a
+	// well-formed JWS.
+	enc := base64.RawURLEncoding.EncodeToString
+	idToken := strings.Join([]string{
+		enc([]byte(`{"alg":"ES256"}`)),
+		enc([]byte(`{"sub":"test","email":"test@example.com","email_verified":true}`)),
+		enc([]byte("signature")),
+	}, ".")
+
+	f := NewFulcio(srv.URL, defaultOIDCIssuer, defaultOIDCClientID)
+	f.newSigner = func(ctx context.Context, ko options.KeyOpts) (*fulcio.Signer, error) {
+		ko.IDToken = idToken
+		return fulcio.NewSigner(ctx, ko)
+	}
+	return f, &requests
+}
+
+// testStatement returns a statement for the subject with the given name.
+func testStatement(name string) *intoto.Statement {
+	return &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: "https://example.com/predicate",
+			Subject:       []intoto.Subject{{Name: name, Digest: map[string]string{"sha256": "abcdef"}}},
+		},
+	}
+}
+
+func TestFulcio_Sign_reuses_certificate(t *testing.T) {
+	t.Setenv("RUNNER_DEBUG", "1")
+	f, requests := newFakeFulcio(t, 10*time.Minute)
+	var log bytes.Buffer
+	f.debugLog = &log
+
+	var cert []byte
+	for i := 0; i < 10; i++ {
+		att, err := f.Sign(context.Background(), testStatement(fmt.Sprintf("artifact%d", i)))
+		if err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+		if cert != nil && !bytes.Equal(cert, att.Cert()) {
+			t.Errorf("unexpected certificate for subject %d", i)
+		}
+		cert = att.Cert()
+	}
+
+	if want, got := int32(1), atomic.LoadInt32(requests); want != got {
+		t.Errorf("unexpected number of Fulcio requests, want: %d, got: %d", want, got)
+	}
+	if want, got := 9, strings.Count(log.String(), "::debug::reusing Fulcio certificate"); want != got {
+		t.Errorf("unexpected number of reuse debug messages, want: %d, got: %d", want, got)
+	}
+}
+
+func TestFulcio_Sign_expired_certificate(t *testing.T) {
+	// The certificate expires within certExpiryMargin so it is never reused.
+	f, requests := newFakeFulcio(t, certExpiryMargin/2)
+
+	for i := 0; i < 3; i++ {
+		if _, err := f.Sign(context.Background(), testStatement("foo")); err != nil {
+			t.Fatalf("unexpected failure: %v", err)
+		}
+	}
+
+	if want, got := int32(3), atomic.LoadInt32(requests); want != got {
+		t.Errorf("unexpected number of Fulcio requests, want: %d, got: %d", want, got)
+	}
+}
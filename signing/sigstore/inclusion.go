@@ -0,0 +1,138 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/client/pubkey"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// errRekorInclusionProofFailed indicates that the inclusion proof of a log
+// entry could not be verified.
+type errRekorInclusionProofFailed struct {
+	errors.ErrTransparencyLog
+}
+
+// verifyInclusion fetches the inclusion proof of the log entry at logIndex
+// and verifies it against the signed tree head in the proof's checkpoint.
+// The checkpoint signature is verified with the first certificate in
+// certChain if set, or else the public key returned by Rekor.
+func verifyInclusion(ctx context.Context, rekorClient *client.Rekor, logIndex int64, certChain []*x509.Certificate) error {
+	params := entries.NewGetLogEntryByIndexParamsWithContext(ctx)
+	params.SetLogIndex(logIndex)
+	resp, err := rekorClient.Entries.GetLogEntryByIndex(params)
+	if err != nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "fetching log entry %d: %w", logIndex, err)
+	}
+	if len(resp.Payload) != 1 {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "expected 1 log entry at index %d, got %d", logIndex, len(resp.Payload))
+	}
+	var entry models.LogEntryAnon
+	for _, e := range resp.Payload {
+		entry = e
+	}
+	if entry.Verification == nil || entry.Verification.InclusionProof == nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "log entry %d has no inclusion proof", logIndex)
+	}
+	p := entry.Verification.InclusionProof
+	if p.LogIndex == nil || p.TreeSize == nil || p.RootHash == nil || p.Checkpoint == nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "log entry %d has an incomplete inclusion proof", logIndex)
+	}
+
+	verifier, err := rekorVerifier(ctx, rekorClient, certChain)
+	if err != nil {
+		return err
+	}
+
+	// Verify the signed tree head.
+	var sth util.SignedCheckpoint
+	if err := sth.UnmarshalText([]byte(*p.Checkpoint)); err != nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "parsing signed tree head: %w", err)
+	}
+	if !sth.Verify(verifier) {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "invalid signed tree head signature")
+	}
+
+	rootHash, err := hex.DecodeString(*p.RootHash)
+	if err != nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "decoding root hash: %w", err)
+	}
+	if sth.Size != uint64(*p.TreeSize) || !bytes.Equal(sth.Hash, rootHash) {
+		return errors.Errorf(&errRekorInclusionProofFailed{},
+			"inclusion proof for tree size %d does not match signed tree head for tree size %d", *p.TreeSize, sth.Size)
+	}
+
+	// Verify the Merkle inclusion proof of the entry.
+	body, ok := entry.Body.(string)
+	if !ok {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "unexpected log entry body type %T", entry.Body)
+	}
+	entryBytes, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "decoding log entry body: %w", err)
+	}
+	hashes := make([][]byte, 0, len(p.Hashes))
+	for _, h := range p.Hashes {
+		b, err := hex.DecodeString(h)
+		if err != nil {
+			return errors.Errorf(&errRekorInclusionProofFailed{}, "decoding proof hash: %w", err)
+		}
+		hashes = append(hashes, b)
+	}
+	leafHash := rfc6962.DefaultHasher.HashLeaf(entryBytes)
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(*p.LogIndex), uint64(*p.TreeSize),
+		leafHash, hashes, rootHash); err != nil {
+		return errors.Errorf(&errRekorInclusionProofFailed{}, "verifying inclusion proof of log entry %d: %w", logIndex, err)
+	}
+	return nil
+}
+
+// rekorVerifier returns a verifier for the Rekor signing key.
+func rekorVerifier(ctx context.Context, rekorClient *client.Rekor, certChain []*x509.Certificate) (signature.Verifier, error) {
+	var pub crypto.PublicKey
+	if len(certChain) > 0 {
+		pub = certChain[0].PublicKey
+	} else {
+		resp, err := rekorClient.Pubkey.GetPublicKey(pubkey.NewGetPublicKeyParamsWithContext(ctx))
+		if err != nil {
+			return nil, errors.Errorf(&errRekorInclusionProofFailed{}, "fetching Rekor public key: %w", err)
+		}
+		pub, err = cryptoutils.UnmarshalPEMToPublicKey([]byte(resp.Payload))
+		if err != nil {
+			return nil, errors.Errorf(&errRekorInclusionProofFailed{}, "parsing Rekor public key: %w", err)
+		}
+	}
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return nil, errors.Errorf(&errRekorInclusionProofFailed{}, "loading Rekor public key: %w", err)
+	}
+	return verifier, nil
+}
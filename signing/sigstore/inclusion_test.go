@@ -0,0 +1,246 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/transparency-dev/merkle/rfc6962"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+)
+
+// testInclusionProof is the inclusion proof served by the fake Rekor server.
+type testInclusionProof struct {
+	logIndex   int64
+	treeSize   int64
+	rootHash   []byte
+	hashes     [][]byte
+	checkpoint string
+}
+
+// newTestInclusionProof returns the inclusion proof of the leaf at index 2
+// of a tree of the four given leaves, with a checkpoint signed by key.
+func newTestInclusionProof(t *testing.T, leaves [4][]byte, key *ecdsa.PrivateKey) *testInclusionProof {
+	t.Helper()
+
+	h := rfc6962.DefaultHasher
+	var hashes [4][]byte
+	for i, l := range leaves {
+		hashes[i] = h.HashLeaf(l)
+	}
+	left := h.HashChildren(hashes[0], hashes[1])
+	root := h.HashChildren(left, h.HashChildren(hashes[2], hashes[3]))
+
+	sth, err := util.CreateSignedCheckpoint(util.Checkpoint{
+		Origin: "rekor.example.com - 1",
+		Size:   4,
+		Hash:   root,
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	signer, err := signature.LoadECDSASigner(key, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if _, err := sth.Sign("rekor.example.com", signer, options.WithContext(context.Background())); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	checkpoint, err := sth.SignedNote.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	return &testInclusionProof{
+		logIndex:   2,
+		treeSize:   4,
+		rootHash:   root,
+		hashes:     [][]byte{hashes[3], left},
+		checkpoint: string(checkpoint),
+	}
+}
+
+// newFakeRekorServer returns a fake Rekor server that serves the entry with
+// the given body and inclusion proof at index 42, and the public key of key.
+func newFakeRekorServer(t *testing.T, body []byte, p *testInclusionProof, key *ecdsa.PrivateKey) *httptest.Server {
+	t.Helper()
+
+	pub, err := cryptoutils.MarshalPublicKeyToPEM(key.Public())
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	var hashes []string
+	for _, h := range p.hashes {
+		hashes = append(hashes, hex.EncodeToString(h))
+	}
+	entry := map[string]interface{}{
+		"uuid": map[string]interface{}{
+			"body":           base64.StdEncoding.EncodeToString(body),
+			"integratedTime": 1672531200,
+			"logID":          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+			"logIndex":       42,
+			"verification": map[string]interface{}{
+				"inclusionProof": map[string]interface{}{
+					"logIndex":   p.logIndex,
+					"treeSize":   p.treeSize,
+					"rootHash":   hex.EncodeToString(p.rootHash),
+					"hashes":     hashes,
+					"checkpoint": p.checkpoint,
+				},
+			},
+		},
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/log/entries":
+			if r.URL.Query().Get("logIndex") != "42" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(entry)
+		case "/api/v1/log/publicKey":
+			w.Header().Set("Content-Type", "application/x-pem-file")
+			_, _ = w.Write(pub)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func Test_verifyInclusion(t *testing.T) {
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	leaves := [4][]byte{[]byte("leaf0"), []byte("leaf1"), []byte("entry"), []byte("leaf3")}
+	otherCertPath, _ := newTestCertFile(t, "other")
+	otherCerts, err := LoadCertChain(otherCertPath)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		body      []byte
+		proof     func() *testInclusionProof
+		certChain []*x509.Certificate
+		fail      bool
+	}{
+		{
+			name: "valid proof",
+			body: leaves[2],
+			proof: func() *testInclusionProof {
+				return newTestInclusionProof(t, leaves, rekorKey)
+			},
+		},
+		{
+			name: "tampered proof hash",
+			body: leaves[2],
+			proof: func() *testInclusionProof {
+				p := newTestInclusionProof(t, leaves, rekorKey)
+				p.hashes[0] = rfc6962.DefaultHasher.HashLeaf([]byte("tampered"))
+				return p
+			},
+			fail: true,
+		},
+		{
+			name: "tampered entry",
+			body: []byte("tampered"),
+			proof: func() *testInclusionProof {
+				return newTestInclusionProof(t, leaves, rekorKey)
+			},
+			fail: true,
+		},
+		{
+			name: "root hash not in signed tree head",
+			body: leaves[2],
+			proof: func() *testInclusionProof {
+				p := newTestInclusionProof(t, leaves, rekorKey)
+				p.rootHash = rfc6962.DefaultHasher.HashLeaf([]byte("tampered"))
+				return p
+			},
+			fail: true,
+		},
+		{
+			name: "signed tree head signed by another key",
+			body: leaves[2],
+			proof: func() *testInclusionProof {
+				return newTestInclusionProof(t, leaves, otherKey)
+			},
+			fail: true,
+		},
+		{
+			name: "pinned cert for another key",
+			body: leaves[2],
+			proof: func() *testInclusionProof {
+				return newTestInclusionProof(t, leaves, rekorKey)
+			},
+			certChain: otherCerts,
+			fail:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeRekorServer(t, tc.body, tc.proof(), rekorKey)
+			rekorClient, err := newRekorClient(s.URL)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			err = verifyInclusion(context.Background(), rekorClient, 42, tc.certChain)
+			if !tc.fail {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				return
+			}
+			errProof := &errRekorInclusionProofFailed{}
+			if !errors.As(err, &errProof) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want, got := errors.ExitCodeTransparencyLog, errors.ExitCode(err); want != got {
+				t.Errorf("unexpected exit code, want: %d, got: %d", want, got)
+			}
+		})
+	}
+}
@@ -2,12 +2,28 @@ package sigstore
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
 	"github.com/sigstore/cosign/pkg/cosign"
-	"github.com/sigstore/rekor/pkg/client"
+	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	rekorclient "github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/rekor/pkg/generated/client"
 	"github.com/sigstore/rekor/pkg/generated/client/entries"
 	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/internal/httpclient"
 	"github.com/slsa-framework/slsa-github-generator/signing"
 )
 
@@ -16,9 +32,23 @@ const (
 	DefaultRekorAddr = "https://rekor.sigstore.dev"
 )
 
+// errUnexpectedRekorCert indicates that the log entry was not signed by the
+// pinned Rekor certificate.
+type errUnexpectedRekorCert struct {
+	errors.ErrTransparencyLog
+}
+
 // Rekor implements TransparencyLog.
 type Rekor struct {
 	rekorAddr string
+
+	// certChain is the pinned certificate chain for the Rekor signing key.
+	// The first certificate is the signing certificate.
+	certChain []*x509.Certificate
+
+	// verifyInclusion enables the verification of the inclusion proof of
+	// uploaded entries against the signed tree head.
+	verifyInclusion bool
 }
 
 type rekorEntryAnon struct {
@@ -47,6 +77,46 @@ func (e *rekorEntryAnon) UUID() string {
 	return e.uuid
 }
 
+// IntegratedTime implements LogEntry.IntegratedTime.
+func (e *rekorEntryAnon) IntegratedTime() int64 {
+	if e.entry.IntegratedTime == nil {
+		return 0
+	}
+	return *e.entry.IntegratedTime
+}
+
+// SignedEntryTimestamp implements LogEntry.SignedEntryTimestamp.
+func (e *rekorEntryAnon) SignedEntryTimestamp() []byte {
+	if e.entry.Verification == nil {
+		return nil
+	}
+	return e.entry.Verification.SignedEntryTimestamp
+}
+
+// InclusionProof implements LogEntry.InclusionProof.
+func (e *rekorEntryAnon) InclusionProof() *signing.InclusionProof {
+	if e.entry.Verification == nil || e.entry.Verification.InclusionProof == nil {
+		return nil
+	}
+	p := e.entry.Verification.InclusionProof
+	proof := signing.InclusionProof{
+		Hashes: p.Hashes,
+	}
+	if p.LogIndex != nil {
+		proof.LogIndex = *p.LogIndex
+	}
+	if p.RootHash != nil {
+		proof.RootHash = *p.RootHash
+	}
+	if p.TreeSize != nil {
+		proof.TreeSize = *p.TreeSize
+	}
+	if p.Checkpoint != nil {
+		proof.Checkpoint = *p.Checkpoint
+	}
+	return &proof
+}
+
 // NewDefaultRekor returns a new Rekor instance for the Rekor public instance.
 func NewDefaultRekor() *Rekor {
 	return NewRekor(DefaultRekorAddr)
@@ -59,9 +129,95 @@ func NewRekor(rekorAddr string) *Rekor {
 	}
 }
 
+// WithCertChain pins the expected certificate chain of the Rekor log's
+// signing key. Uploads fail if the returned log entry was not signed by the
+// first certificate in the chain.
+func (r *Rekor) WithCertChain(certs []*x509.Certificate) *Rekor {
+	r.certChain = certs
+	return r
+}
+
+// WithInclusionVerification enables the verification of the Merkle inclusion
+// proof of uploaded entries against the signed tree head. Uploads fail if the
+// proof can't be verified.
+func (r *Rekor) WithInclusionVerification(verify bool) *Rekor {
+	r.verifyInclusion = verify
+	return r
+}
+
+// LoadCertChain reads a PEM encoded certificate chain from a file.
+func LoadCertChain(path string) ([]*x509.Certificate, error) {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading cert chain: %w", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cert chain: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %q", path)
+	}
+	return certs, nil
+}
+
+// verifyPinnedCert verifies that the signed entry timestamp of the log entry
+// was created by the signing certificate in the pinned chain.
+func verifyPinnedCert(e *models.LogEntryAnon, certs []*x509.Certificate) error {
+	if e.Verification == nil || e.IntegratedTime == nil || e.LogIndex == nil || e.LogID == nil {
+		return errors.Errorf(&errUnexpectedRekorCert{}, "log entry is missing verification data")
+	}
+
+	pub, ok := certs[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.Errorf(&errUnexpectedRekorCert{}, "unsupported public key type %T", certs[0].PublicKey)
+	}
+
+	payload := cbundle.RekorPayload{
+		Body:           e.Body,
+		IntegratedTime: *e.IntegratedTime,
+		LogIndex:       *e.LogIndex,
+		LogID:          *e.LogID,
+	}
+	if err := cosign.VerifySET(payload, []byte(e.Verification.SignedEntryTimestamp), pub); err != nil {
+		return errors.Errorf(&errUnexpectedRekorCert{}, "log entry not signed by pinned certificate %q: %w",
+			certs[0].Subject, err)
+	}
+	return nil
+}
+
+// newRekorClient returns a Rekor client for the given address. It is the same
+// as the client returned by rekorclient.GetRekorClient except that requests
+// use the HTTP client from the httpclient package.
+func newRekorClient(rekorAddr string) (*client.Rekor, error) {
+	u, err := url.Parse(rekorAddr)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := httpclient.New()
+	if err != nil {
+		return nil, err
+	}
+
+	retryableClient := retryablehttp.NewClient()
+	retryableClient.HTTPClient = httpClient
+	retryableClient.RetryMax = rekorclient.DefaultRetryCount
+	retryableClient.Logger = nil
+
+	rt := httptransport.NewWithClient(u.Host, client.DefaultBasePath, []string{u.Scheme}, retryableClient.StandardClient())
+	rt.Consumers["application/json"] = runtime.JSONConsumer()
+	rt.Consumers["application/x-pem-file"] = runtime.TextConsumer()
+	rt.Consumers["application/pem-certificate-chain"] = runtime.TextConsumer()
+	rt.Producers["application/json"] = runtime.JSONProducer()
+
+	registry := strfmt.Default
+	registry.Add("signedCheckpoint", &util.SignedNote{}, util.SignedCheckpointValidator)
+	return client.New(rt, registry), nil
+}
+
 // Upload uploads the signed attestation to the rekor transparency log.
 func (r *Rekor) Upload(ctx context.Context, att signing.Attestation) (signing.LogEntry, error) {
-	rekorClient, err := client.GetRekorClient(r.rekorAddr)
+	rekorClient, err := newRekorClient(r.rekorAddr)
 	if err != nil {
 		return nil, fmt.Errorf("creating rekor client: %w", err)
 	}
@@ -83,10 +239,21 @@ func (r *Rekor) Upload(ctx context.Context, att signing.Attestation) (signing.Lo
 		if err := cosign.VerifyTLogEntry(ctx, rekorClient, &entry); err != nil {
 			return nil, fmt.Errorf("validating log entry: %w", err)
 		}
+		if len(r.certChain) > 0 {
+			if err := verifyPinnedCert(&entry, r.certChain); err != nil {
+				return nil, err
+			}
+		}
 		uuid = ix
 		logEntry = &entry
 	}
 
+	if r.verifyInclusion {
+		if err := verifyInclusion(ctx, rekorClient, *logEntry.LogIndex, r.certChain); err != nil {
+			return nil, err
+		}
+	}
+
 	fmt.Printf("Uploaded signed attestation to rekor with UUID %s.\n", uuid)
 	return &rekorEntryAnon{
 		entry: logEntry,
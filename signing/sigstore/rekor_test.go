@@ -0,0 +1,201 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/google/go-cmp/cmp"
+	cbundle "github.com/sigstore/cosign/pkg/cosign/bundle"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+// newTestCertFile generates a self-signed certificate, writes it as a PEM
+// fixture and returns the path and the private key.
+func newTestCertFile(t *testing.T, name string) (string, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	pem, err := cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), name+".pem")
+	if err := os.WriteFile(path, pem, 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	return path, key
+}
+
+// newTestLogEntry returns a log entry with a signed entry timestamp created
+// by the given key.
+func newTestLogEntry(t *testing.T, key *ecdsa.PrivateKey) *models.LogEntryAnon {
+	t.Helper()
+
+	body := "Ym9keQ=="
+	integratedTime := int64(1672531200)
+	logIndex := int64(42)
+	logID := "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d"
+
+	b, err := json.Marshal(cbundle.RekorPayload{
+		Body:           body,
+		IntegratedTime: integratedTime,
+		LogIndex:       logIndex,
+		LogID:          logID,
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	canonicalized, err := jsoncanonicalizer.Transform(b)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	h := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, key, h[:])
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	return &models.LogEntryAnon{
+		Body:           body,
+		IntegratedTime: &integratedTime,
+		LogIndex:       &logIndex,
+		LogID:          &logID,
+		Verification: &models.LogEntryAnonVerification{
+			SignedEntryTimestamp: set,
+		},
+	}
+}
+
+func Test_verifyPinnedCert(t *testing.T) {
+	rekorPath, rekorKey := newTestCertFile(t, "rekor")
+	otherPath, _ := newTestCertFile(t, "other")
+
+	entry := newTestLogEntry(t, rekorKey)
+
+	testCases := []struct {
+		name     string
+		path     string
+		mismatch bool
+	}{
+		{
+			name: "pinned cert",
+			path: rekorPath,
+		},
+		{
+			name:     "different cert",
+			path:     otherPath,
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			certs, err := LoadCertChain(tc.path)
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+
+			err = verifyPinnedCert(entry, certs)
+			errCert := &errUnexpectedRekorCert{}
+			if got := errors.As(err, &errCert); got != tc.mismatch {
+				t.Errorf("unexpected error, want mismatch: %v, got: %v", tc.mismatch, err)
+			}
+		})
+	}
+}
+
+func TestLoadCertChain_invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if _, err := LoadCertChain(path); err == nil {
+		t.Errorf("expected an error to occur")
+	}
+}
+
+func Test_rekorEntryAnon(t *testing.T) {
+	_, rekorKey := newTestCertFile(t, "rekor")
+	entry := newTestLogEntry(t, rekorKey)
+
+	e := &rekorEntryAnon{entry: entry, uuid: "uuid"}
+	if want, got := int64(1672531200), e.IntegratedTime(); want != got {
+		t.Errorf("unexpected integrated time, want: %d, got: %d", want, got)
+	}
+	if want, got := []byte(entry.Verification.SignedEntryTimestamp), e.SignedEntryTimestamp(); string(want) != string(got) {
+		t.Errorf("unexpected signed entry timestamp, want: %q, got: %q", want, got)
+	}
+	if got := e.InclusionProof(); got != nil {
+		t.Errorf("unexpected inclusion proof: %v", got)
+	}
+
+	logIndex := int64(42)
+	rootHash := "5be1758dd2228acfaf2546b4b6ce8aa40c82a3748f3dcb550e0d67ba34f02a45"
+	treeSize := int64(43)
+	checkpoint := "checkpoint"
+	entry.Verification.InclusionProof = &models.InclusionProof{
+		LogIndex:   &logIndex,
+		RootHash:   &rootHash,
+		TreeSize:   &treeSize,
+		Hashes:     []string{"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+		Checkpoint: &checkpoint,
+	}
+	want := &signing.InclusionProof{
+		LogIndex:   logIndex,
+		RootHash:   rootHash,
+		TreeSize:   treeSize,
+		Hashes:     []string{"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+		Checkpoint: checkpoint,
+	}
+	if diff := cmp.Diff(want, e.InclusionProof()); diff != "" {
+		t.Errorf("unexpected inclusion proof (-want +got):\n%s", diff)
+	}
+}
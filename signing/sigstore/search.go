@@ -0,0 +1,87 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/client/index"
+	"github.com/sigstore/rekor/pkg/generated/models"
+)
+
+// maxSearchUUIDs is the maximum number of UUIDs in a single log query
+// accepted by Rekor.
+const maxSearchUUIDs = 10
+
+// SearchEntry is a log entry returned by a search.
+type SearchEntry struct {
+	UUID  string
+	Entry models.LogEntryAnon
+}
+
+// SearchByDigest returns the log entries indexed by the digest, e.g.
+// "sha256:<hex>", ordered by log index. For in-toto entries the digests of
+// the subjects are indexed. The entries are not verified.
+func (r *Rekor) SearchByDigest(ctx context.Context, digest string) ([]SearchEntry, error) {
+	rekorClient, err := newRekorClient(r.rekorAddr)
+	if err != nil {
+		return nil, fmt.Errorf("creating rekor client: %w", err)
+	}
+
+	params := index.NewSearchIndexParamsWithContext(ctx)
+	params.SetQuery(&models.SearchIndex{Hash: digest})
+	resp, err := rekorClient.Index.SearchIndex(params)
+	if err != nil {
+		return nil, fmt.Errorf("searching index: %w", err)
+	}
+	uuids := resp.Payload
+
+	var found []SearchEntry
+	for len(uuids) > 0 {
+		n := len(uuids)
+		if n > maxSearchUUIDs {
+			n = maxSearchUUIDs
+		}
+
+		qparams := entries.NewSearchLogQueryParamsWithContext(ctx)
+		qparams.SetEntry(&models.SearchLogQuery{EntryUUIDs: uuids[:n]})
+		qresp, err := rekorClient.Entries.SearchLogQuery(qparams)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving log entries: %w", err)
+		}
+		for _, e := range qresp.Payload {
+			for uuid, entry := range e {
+				found = append(found, SearchEntry{UUID: uuid, Entry: entry})
+			}
+		}
+		uuids = uuids[n:]
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return logIndex(found[i].Entry) < logIndex(found[j].Entry)
+	})
+	return found, nil
+}
+
+// logIndex returns the log index of the entry, or -1 if it is not set.
+func logIndex(e models.LogEntryAnon) int64 {
+	if e.LogIndex == nil {
+		return -1
+	}
+	return *e.LogIndex
+}
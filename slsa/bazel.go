@@ -0,0 +1,93 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// bazelBuildEvent is the subset of a Bazel build event needed to create
+// subjects.
+// See https://bazel.build/remote/bep
+type bazelBuildEvent struct {
+	NamedSetOfFiles *bazelNamedSetOfFiles `json:"namedSetOfFiles"`
+}
+
+type bazelNamedSetOfFiles struct {
+	Files []bazelFile `json:"files"`
+}
+
+type bazelFile struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+// ParseBazelBuildEventStream parses a JSON Bazel build event stream, as
+// written by --build_event_json_file, and returns a subject for each file in
+// its namedSetOfFiles events. The subject name is the name of the file
+// relative to its output directory, e.g. "pkg/app.tar.gz". The digests must
+// be SHA-256, Bazel's default --digest_function. Files reported more than
+// once are only returned once. The binary event stream is not supported.
+func ParseBazelBuildEventStream(r io.Reader) ([]intoto.Subject, error) {
+	var subjects []intoto.Subject
+	digests := map[string]string{}
+
+	// NOTE: The stream is a sequence of JSON objects, one per line.
+	d := json.NewDecoder(r)
+	for {
+		var e bazelBuildEvent
+		if err := d.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decoding Bazel build event: %w", err)
+		}
+		if e.NamedSetOfFiles == nil {
+			continue
+		}
+
+		for _, f := range e.NamedSetOfFiles.Files {
+			if f.Name == "" {
+				return nil, errors.New("output file with no name in Bazel build event")
+			}
+			digest := strings.ToLower(f.Digest)
+			if b, err := hex.DecodeString(digest); err != nil || len(b) != 32 {
+				return nil, fmt.Errorf("invalid SHA-256 digest for Bazel output file %q: %q", f.Name, f.Digest)
+			}
+			if other, ok := digests[f.Name]; ok {
+				if other != digest {
+					return nil, fmt.Errorf("output file %q has conflicting digests %q and %q", f.Name, other, digest)
+				}
+				continue
+			}
+			digests[f.Name] = digest
+			subjects = append(subjects, intoto.Subject{
+				Name: f.Name,
+				Digest: slsacommon.DigestSet{
+					"sha256": digest,
+				},
+			})
+		}
+	}
+	return subjects, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+func TestParseBazelBuildEventStream(t *testing.T) {
+	f, err := os.Open("testdata/bazel-build-events.json")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer f.Close()
+
+	subjects, err := ParseBazelBuildEventStream(f)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := []intoto.Subject{
+		{
+			Name:   "app/app.tar.gz",
+			Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+		},
+		{
+			Name:   "lib/lib.jar",
+			Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+		},
+	}
+	if diff := cmp.Diff(want, subjects); diff != "" {
+		t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseBazelBuildEventStream_invalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		events string
+	}{
+		{
+			name:   "invalid json",
+			events: `{"id":{"namedSet":{"id":"0"}},`,
+		},
+		{
+			name:   "missing digest",
+			events: `{"namedSetOfFiles":{"files":[{"name":"app/app.tar.gz"}]}}`,
+		},
+		{
+			name:   "not sha256",
+			events: `{"namedSetOfFiles":{"files":[{"name":"app/app.tar.gz","digest":"da39a3ee5e6b4b0d3255bfef95601890afd80709"}]}}`,
+		},
+		{
+			name:   "missing name",
+			events: `{"namedSetOfFiles":{"files":[{"digest":"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"}]}}`,
+		},
+		{
+			name: "conflicting digests",
+			events: `{"namedSetOfFiles":{"files":[{"name":"app","digest":"b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"}]}}
+{"namedSetOfFiles":{"files":[{"name":"app","digest":"7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"}]}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseBazelBuildEventStream(strings.NewReader(tc.events)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -153,6 +153,20 @@ func (b *GithubActionsBuild) Invocation(ctx context.Context) (slsa.ProvenanceInv
 	// workflow run.
 	addEnvKeyString(env, "github_event_name", b.Context.EventName)
 
+	if pr := b.Context.PullRequest(); pr != nil {
+		// github_head_repository is the repository a pull request was
+		// opened from.
+		addEnvKeyString(env, "github_head_repository", pr.HeadRepository)
+
+		// github_head_repository_is_base is false if the pull request was
+		// opened from a fork.
+		env["github_head_repository_is_base"] = !pr.FromFork()
+
+		// github_author_association is the relationship of the pull
+		// request author with the repository.
+		addEnvKeyString(env, "github_author_association", pr.AuthorAssociation)
+	}
+
 	// github_event_payload is the full event payload.
 	if b.Context.Event != nil {
 		env["github_event_payload"] = b.Context.Event
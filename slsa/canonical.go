@@ -0,0 +1,39 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+)
+
+// CanonicalizeStatement returns the canonical JSON encoding of stmt as
+// defined by RFC 8785 (JSON Canonicalization Scheme). Object keys, including
+// struct fields, are sorted and insignificant whitespace is removed so that
+// equal statements always produce byte-identical output.
+func CanonicalizeStatement(stmt intoto.Statement) ([]byte, error) {
+	b, err := json.Marshal(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling statement: %w", err)
+	}
+	c, err := jsoncanonicalizer.Transform(b)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalizing statement: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"testing"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+func TestCanonicalizeStatement(t *testing.T) {
+	const (
+		sha256 = "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2"
+		sha512 = "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"
+	)
+
+	// statement returns a statement whose digest set and predicate are built
+	// by inserting the same keys in the given order.
+	statement := func(algs []string, keys []string) intoto.Statement {
+		digests := map[string]string{"sha256": sha256, "sha512": sha512}
+		d := slsacommon.DigestSet{}
+		for _, alg := range algs {
+			d[alg] = digests[alg]
+		}
+		predicate := map[string]interface{}{}
+		for _, k := range keys {
+			predicate[k] = "<" + k + ">"
+		}
+		return intoto.Statement{
+			StatementHeader: intoto.StatementHeader{
+				Type:          intoto.StatementInTotoV01,
+				PredicateType: "https://example.com/predicate",
+				Subject:       []intoto.Subject{{Name: "foo", Digest: d}},
+			},
+			Predicate: predicate,
+		}
+	}
+
+	want := `{"_type":"https://in-toto.io/Statement/v0.1",` +
+		`"predicate":{"a":"<a>","b":"<b>","c":"<c>"},` +
+		`"predicateType":"https://example.com/predicate",` +
+		`"subject":[{"digest":{"sha256":"` + sha256 + `","sha512":"` + sha512 + `"},"name":"foo"}]}`
+
+	testCases := []struct {
+		name string
+		algs []string
+		keys []string
+	}{
+		{
+			name: "sorted",
+			algs: []string{"sha256", "sha512"},
+			keys: []string{"a", "b", "c"},
+		},
+		{
+			name: "reversed",
+			algs: []string{"sha512", "sha256"},
+			keys: []string{"c", "b", "a"},
+		},
+		{
+			name: "shuffled",
+			algs: []string{"sha512", "sha256"},
+			keys: []string{"b", "c", "a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Canonicalize more than once since map iteration order is random.
+			for i := 0; i < 10; i++ {
+				b, err := CanonicalizeStatement(statement(tc.algs, tc.keys))
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				if got := string(b); want != got {
+					t.Fatalf("unexpected statement, want: %q, got: %q", want, got)
+				}
+			}
+		})
+	}
+}
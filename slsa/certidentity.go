@@ -0,0 +1,97 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// CertIdentity is the identity of a GitHub Actions workflow recorded in a
+// Fulcio signing certificate.
+type CertIdentity struct {
+	// SANs are the subject alternative names of the certificate. For GitHub
+	// Actions this is the URI of the workflow that requested the
+	// certificate, e.g.
+	// https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0
+	SANs []string
+
+	// Issuer is the OIDC issuer of the token exchanged for the certificate.
+	Issuer string
+
+	// Trigger is the name of the event that triggered the workflow.
+	Trigger string
+
+	// SHA is the commit the workflow was run for.
+	SHA string
+
+	// WorkflowName is the name of the workflow that was triggered.
+	WorkflowName string
+
+	// Repository is the repository the workflow was run in, e.g. owner/repo.
+	Repository string
+
+	// Ref is the git ref the workflow was run for, e.g. refs/heads/main.
+	Ref string
+}
+
+// ParseCertIdentity returns the identity in the first, i.e. leaf,
+// certificate of the PEM encoded certificate chain.
+func ParseCertIdentity(cert []byte) (*CertIdentity, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(cert)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate")
+	}
+
+	ext, err := certificate.ParseExtensions(certs[0].Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate extensions: %w", err)
+	}
+	return &CertIdentity{
+		SANs:         cryptoutils.GetSubjectAlternateNames(certs[0]),
+		Issuer:       ext.Issuer,
+		Trigger:      ext.GithubWorkflowTrigger,
+		SHA:          ext.GithubWorkflowSHA,
+		WorkflowName: ext.GithubWorkflowName,
+		Repository:   ext.GithubWorkflowRepository,
+		Ref:          ext.GithubWorkflowRef,
+	}, nil
+}
+
+// Workflow returns the path of the workflow in the URI subject alternative
+// name, without the server and ref, e.g.
+// owner/repo/.github/workflows/release.yml. It returns an empty string if
+// there is no such subject alternative name.
+func (i *CertIdentity) Workflow() string {
+	for _, san := range i.SANs {
+		if !strings.HasPrefix(san, "https://") {
+			continue
+		}
+		_, path, ok := strings.Cut(strings.TrimPrefix(san, "https://"), "/")
+		if !ok {
+			continue
+		}
+		path, _, _ = strings.Cut(path, "@")
+		return path
+	}
+	return ""
+}
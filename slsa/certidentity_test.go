@@ -0,0 +1,95 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/fulcio/pkg/certificate"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+)
+
+func TestParseCertIdentity(t *testing.T) {
+	san := "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.5.0"
+	cert, err := testutil.NewTestCert(san, certificate.Extensions{
+		Issuer:                   "https://token.actions.githubusercontent.com",
+		GithubWorkflowTrigger:    "push",
+		GithubWorkflowSHA:        "8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c",
+		GithubWorkflowName:       "release",
+		GithubWorkflowRepository: "owner/repo",
+		GithubWorkflowRef:        "refs/tags/v1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	id, err := ParseCertIdentity(cert)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	want := &CertIdentity{
+		SANs:         []string{san},
+		Issuer:       "https://token.actions.githubusercontent.com",
+		Trigger:      "push",
+		SHA:          "8f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c",
+		WorkflowName: "release",
+		Repository:   "owner/repo",
+		Ref:          "refs/tags/v1.0.0",
+	}
+	if diff := cmp.Diff(want, id); diff != "" {
+		t.Errorf("unexpected identity (-want +got):\n%s", diff)
+	}
+	if want, got := "slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml", id.Workflow(); want != got {
+		t.Errorf("unexpected workflow, want: %q, got: %q", want, got)
+	}
+
+	if _, err := ParseCertIdentity([]byte("not a certificate")); err == nil {
+		t.Errorf("expected an error to occur.")
+	}
+}
+
+func TestCertIdentity_Workflow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sans     []string
+		expected string
+	}{
+		{
+			name:     "workflow",
+			sans:     []string{"https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main"},
+			expected: "owner/repo/.github/workflows/release.yml",
+		},
+		{
+			name:     "email",
+			sans:     []string{"user@example.com"},
+			expected: "",
+		},
+		{
+			name: "none",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			id := &CertIdentity{SANs: tc.sans}
+			if got := id.Workflow(); tc.expected != got {
+				t.Errorf("unexpected workflow, want: %q, got: %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package slsa
 
 import (
 	"context"
+	"time"
 
 	githubapi "github.com/google/go-github/v50/github"
 
@@ -31,8 +32,39 @@ type ClientProvider interface {
 // DefaultClientProvider provides a default set of clients based on the Github
 // Actions environment.
 type DefaultClientProvider struct {
-	oidcClient *github.OIDCClient
-	ghClient   *githubapi.Client
+	oidcClient   *github.OIDCClient
+	ghClient     *githubapi.Client
+	oidcAudience []string
+	oidcTimeout  time.Duration
+	bearerToken  string
+	githubToken  string
+}
+
+// WithOIDCAudience overrides the audience of OIDC tokens requested by the
+// OIDC client. The default audience is used if no audience is given.
+func (p *DefaultClientProvider) WithOIDCAudience(audience ...string) *DefaultClientProvider {
+	p.oidcAudience = audience
+	return p
+}
+
+// WithOIDCBearerToken overrides the token used by the OIDC client to request
+// OIDC tokens.
+func (p *DefaultClientProvider) WithOIDCBearerToken(token string) *DefaultClientProvider {
+	p.bearerToken = token
+	return p
+}
+
+// WithOIDCTokenTimeout bounds the time taken by the OIDC client to request
+// and verify a token.
+func (p *DefaultClientProvider) WithOIDCTokenTimeout(timeout time.Duration) *DefaultClientProvider {
+	p.oidcTimeout = timeout
+	return p
+}
+
+// WithGithubToken overrides the token used by the GitHub API client.
+func (p *DefaultClientProvider) WithGithubToken(token string) *DefaultClientProvider {
+	p.githubToken = token
+	return p
 }
 
 // OIDCClient returns a default OIDC client.
@@ -42,16 +74,31 @@ func (p *DefaultClientProvider) OIDCClient() (*github.OIDCClient, error) {
 		if err != nil {
 			return nil, err
 		}
+		if len(p.oidcAudience) > 0 {
+			c.WithAudience(p.oidcAudience)
+		}
+		if p.bearerToken != "" {
+			c.WithBearerToken(p.bearerToken)
+		}
+		if p.oidcTimeout > 0 {
+			c.WithTimeout(p.oidcTimeout)
+		}
 		p.oidcClient = c
 	}
 	return p.oidcClient, nil
 }
 
 // GithubClient returns a Github API client authenticated with the token
-// provided in the github context.
+// given to WithGithubToken, or else the token provided in the github context.
 func (p *DefaultClientProvider) GithubClient(ctx context.Context) (*githubapi.Client, error) {
 	if p.ghClient == nil {
-		c, err := github.NewGithubClient(ctx)
+		var c *githubapi.Client
+		var err error
+		if p.githubToken != "" {
+			c, err = github.NewGithubClientWithToken(ctx, p.githubToken)
+		} else {
+			c, err = github.NewGithubClient(ctx)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,93 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// cycloneDXBOM is the subset of a CycloneDX JSON BOM needed to create
+// subjects.
+// See https://cyclonedx.org/docs/1.4/json/
+type cycloneDXBOM struct {
+	BOMFormat  string               `json:"bomFormat"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	PURL   string          `json:"purl"`
+	Hashes []cycloneDXHash `json:"hashes"`
+
+	// Components are the sub-components of this component.
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// ConvertFromCycloneDXBOM parses a CycloneDX JSON BOM and returns a subject
+// for each component, including nested components, that has a SHA-256 hash.
+// The subject name is the package URL of the component. Components without a
+// SHA-256 hash or a package URL are skipped.
+func ConvertFromCycloneDXBOM(r io.Reader) ([]intoto.Subject, error) {
+	var bom cycloneDXBOM
+	if err := json.NewDecoder(r).Decode(&bom); err != nil {
+		return nil, fmt.Errorf("decoding CycloneDX BOM: %w", err)
+	}
+	if bom.BOMFormat != "CycloneDX" {
+		return nil, fmt.Errorf("unexpected BOM format: %q", bom.BOMFormat)
+	}
+
+	var subjects []intoto.Subject
+	if err := appendCycloneDXSubjects(&subjects, bom.Components); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func appendCycloneDXSubjects(subjects *[]intoto.Subject, components []cycloneDXComponent) error {
+	for _, c := range components {
+		for _, h := range c.Hashes {
+			if h.Alg != "SHA-256" || c.PURL == "" {
+				continue
+			}
+			digest := strings.ToLower(h.Content)
+			if b, err := hex.DecodeString(digest); err != nil || len(b) != 32 {
+				return fmt.Errorf("invalid SHA-256 hash for component %q: %q", c.PURL, h.Content)
+			}
+			*subjects = append(*subjects, intoto.Subject{
+				Name: c.PURL,
+				Digest: slsacommon.DigestSet{
+					"sha256": digest,
+				},
+			})
+			break
+		}
+
+		if err := appendCycloneDXSubjects(subjects, c.Components); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+func TestConvertFromCycloneDXBOM(t *testing.T) {
+	f, err := os.Open("testdata/cyclonedx-bom.json")
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	defer f.Close()
+
+	subjects, err := ConvertFromCycloneDXBOM(f)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := []intoto.Subject{
+		{
+			Name:   "pkg:npm/left-pad@1.3.0",
+			Digest: slsacommon.DigestSet{"sha256": "ac5d7bc2c21a1e5d2e1c3a5b3b0a2e5f8e2f4d1e1b2c3d4e5f60718293a4b5c6"},
+		},
+		{
+			Name:   "pkg:generic/app@0.1.0",
+			Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+		},
+		{
+			Name:   "pkg:generic/nested@0.2.0",
+			Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+		},
+	}
+	if diff := cmp.Diff(want, subjects); diff != "" {
+		t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+	}
+}
+
+func TestConvertFromCycloneDXBOM_invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		bom  string
+	}{
+		{
+			name: "invalid json",
+			bom:  `{"bomFormat": "CycloneDX",`,
+		},
+		{
+			name: "not cyclonedx",
+			bom:  `{"spdxVersion": "SPDX-2.3"}`,
+		},
+		{
+			name: "invalid hash",
+			bom: `{"bomFormat": "CycloneDX", "components": [
+				{"purl": "pkg:npm/foo@1.0.0", "hashes": [{"alg": "SHA-256", "content": "abc"}]}
+			]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ConvertFromCycloneDXBOM(strings.NewReader(tc.bom)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
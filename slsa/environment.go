@@ -0,0 +1,173 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RedactedPrefix is the marker that replaces redacted environment variable
+// values. It is followed by the sha256 digest of the value so that
+// reproducibility checks remain possible.
+const RedactedPrefix = "[REDACTED]"
+
+// RedactedValue replaces the values redacted by RedactContext.
+const RedactedValue = "REDACTED"
+
+// DefaultContextRedactPatterns are the patterns of the GitHub context keys
+// whose values are redacted by RedactContext.
+var DefaultContextRedactPatterns = []string{"secret*", "*token*", "*password*"}
+
+// DefaultEnvironmentAllowlist lists the environment variables that are
+// always safe to record in provenance. Entries ending in "*" match any
+// variable with that prefix.
+var DefaultEnvironmentAllowlist = []string{"GITHUB_*", "RUNNER_*"}
+
+var (
+	// secretNamePattern matches the names of environment variables that are
+	// likely to hold secrets.
+	secretNamePattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIAL|PRIVATE|KEY)`)
+
+	// secretValuePatterns match values that look like common secrets.
+	secretValuePatterns = []*regexp.Regexp{
+		// GitHub tokens.
+		regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`),
+		regexp.MustCompile(`github_pat_[A-Za-z0-9_]{22,}`),
+		// AWS access key IDs.
+		regexp.MustCompile(`(A3T[A-Z0-9]|AKIA|ASIA)[A-Z0-9]{16}`),
+		// Slack tokens.
+		regexp.MustCompile(`xox[abposr]-[A-Za-z0-9-]+`),
+		// JSON Web Tokens.
+		regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		// PEM encoded private keys.
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	}
+)
+
+// CaptureEnvironment returns the environment variables in env that may be
+// recorded in provenance. Only variables matching DefaultEnvironmentAllowlist
+// or allowlist are kept. Values that look like secrets are redacted using
+// RedactEnvironmentValue.
+func CaptureEnvironment(env map[string]string, allowlist []string) map[string]string {
+	captured := make(map[string]string)
+	for k, v := range env {
+		if !envAllowed(k, DefaultEnvironmentAllowlist) && !envAllowed(k, allowlist) {
+			continue
+		}
+		captured[k] = RedactEnvironmentValue(k, v)
+	}
+	return captured
+}
+
+// RedactEnvironmentValue returns the value to record for the environment
+// variable with the given name. If the name or value looks like a secret, the
+// value is replaced with RedactedPrefix followed by its sha256 digest.
+func RedactEnvironmentValue(name, value string) string {
+	if !isSecret(name, value) {
+		return value
+	}
+	return fmt.Sprintf("%s sha256:%x", RedactedPrefix, sha256.Sum256([]byte(value)))
+}
+
+// ParseEnvironment parses a list of environment variables in the "KEY=VALUE"
+// format, as returned by os.Environ.
+func ParseEnvironment(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, e := range environ {
+		k, v, _ := strings.Cut(e, "=")
+		env[k] = v
+	}
+	return env
+}
+
+func envAllowed(name string, allowlist []string) bool {
+	for _, a := range allowlist {
+		if strings.HasSuffix(a, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(a, "*")) {
+				return true
+			}
+		} else if name == a {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecret(name, value string) bool {
+	if value == "" {
+		return false
+	}
+	if secretNamePattern.MatchString(name) {
+		return true
+	}
+	for _, re := range secretValuePatterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRedactPatterns returns an error if a pattern is not a valid
+// path.Match pattern.
+func ValidateRedactPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// RedactContext returns a copy of v, a value decoded from JSON such as the
+// GitHub context, where the values of all object keys matching one of the
+// patterns are replaced with RedactedValue. Patterns use the path.Match
+// syntax and are matched case-insensitively. Invalid patterns never match.
+func RedactContext(v interface{}, patterns []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if keyMatches(k, patterns) {
+				redacted[k] = RedactedValue
+			} else {
+				redacted[k] = RedactContext(val, patterns)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, val := range v {
+			redacted[i] = RedactContext(val, patterns)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+func keyMatches(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), key); ok {
+			return true
+		}
+	}
+	return false
+}
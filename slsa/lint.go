@@ -0,0 +1,143 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1.0"
+)
+
+// Lint warning codes returned by LintProvenance.
+const (
+	// LintNoSubjects indicates a statement without subjects.
+	LintNoSubjects = "no-subjects"
+
+	// LintEmptySubjectName indicates a subject with an empty name.
+	LintEmptySubjectName = "empty-subject-name"
+
+	// LintMissingSHA256 indicates a subject without a sha256 digest.
+	LintMissingSHA256 = "missing-sha256"
+
+	// LintPredicateTypeMismatch indicates a predicate that does not have the
+	// layout of the statement's predicate type.
+	LintPredicateTypeMismatch = "predicate-type-mismatch"
+
+	// LintInvalidBuilderID indicates a builder ID that is empty or not an
+	// absolute URL.
+	LintInvalidBuilderID = "invalid-builder-id"
+)
+
+// LintWarning is a likely mistake in a provenance statement.
+type LintWarning struct {
+	// Code identifies the lint rule, e.g. LintNoSubjects.
+	Code string
+
+	// Message describes the mistake.
+	Message string
+}
+
+// String implements fmt.Stringer.
+func (w LintWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Code, w.Message)
+}
+
+// lintPredicate holds the fields of SLSA v0.2 and v1.0 predicates checked
+// by LintProvenance.
+type lintPredicate struct {
+	// SLSA v0.2
+	Builder *struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType *string `json:"buildType"`
+
+	// SLSA v1.0
+	BuildDefinition *struct {
+		BuildType string `json:"buildType"`
+	} `json:"buildDefinition"`
+	RunDetails *struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+	} `json:"runDetails"`
+}
+
+// LintProvenance checks a provenance statement for common mistakes before it
+// is signed. It returns a warning for each mistake found, in a stable order.
+func LintProvenance(stmt intoto.Statement) []LintWarning {
+	var warnings []LintWarning
+	warn := func(code, format string, a ...interface{}) {
+		warnings = append(warnings, LintWarning{Code: code, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if len(stmt.Subject) == 0 {
+		warn(LintNoSubjects, "statement has no subjects")
+	}
+	for i, s := range stmt.Subject {
+		if s.Name == "" {
+			warn(LintEmptySubjectName, "subject %d has an empty name", i)
+		}
+		if s.Digest["sha256"] == "" {
+			warn(LintMissingSHA256, "subject %q has no sha256 digest", s.Name)
+		}
+	}
+
+	// NOTE: The predicate is compared as JSON so that typed and decoded
+	// predicates are linted the same.
+	var p lintPredicate
+	b, err := json.Marshal(stmt.Predicate)
+	if err == nil {
+		err = json.Unmarshal(b, &p)
+	}
+	if err != nil {
+		warn(LintPredicateTypeMismatch, "predicate is not a JSON object: %v", err)
+		return warnings
+	}
+
+	var builderID string
+	switch stmt.PredicateType {
+	case slsa02.PredicateSLSAProvenance:
+		if p.Builder == nil || p.BuildType == nil || p.BuildDefinition != nil || p.RunDetails != nil {
+			warn(LintPredicateTypeMismatch, "predicate is not a SLSA v0.2 predicate as required by predicate type %q",
+				stmt.PredicateType)
+		}
+		if p.Builder != nil {
+			builderID = p.Builder.ID
+		}
+	case slsa1.PredicateSLSAProvenance:
+		if p.BuildDefinition == nil || p.RunDetails == nil || p.Builder != nil || p.BuildType != nil {
+			warn(LintPredicateTypeMismatch, "predicate is not a SLSA v1.0 predicate as required by predicate type %q",
+				stmt.PredicateType)
+		}
+		if p.RunDetails != nil {
+			builderID = p.RunDetails.Builder.ID
+		}
+	default:
+		warn(LintPredicateTypeMismatch, "unknown predicate type %q", stmt.PredicateType)
+		return warnings
+	}
+
+	if builderID == "" {
+		warn(LintInvalidBuilderID, "builder ID is empty")
+	} else if u, err := url.Parse(builderID); err != nil || !u.IsAbs() || u.Host == "" {
+		warn(LintInvalidBuilderID, "builder ID %q is not an absolute URL", builderID)
+	}
+
+	return warnings
+}
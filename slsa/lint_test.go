@@ -0,0 +1,179 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	slsa02 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v0.2"
+	slsa1 "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/v1.0"
+)
+
+func TestLintProvenance(t *testing.T) {
+	const (
+		sha256 = "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2"
+		// builderID is the ID of the generic generator.
+		builderID = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.5.0"
+	)
+
+	// statement returns a SLSA v0.2 statement without mistakes.
+	statement := func() intoto.Statement {
+		return intoto.Statement{
+			StatementHeader: intoto.StatementHeader{
+				Type:          intoto.StatementInTotoV01,
+				PredicateType: slsa02.PredicateSLSAProvenance,
+				Subject: []intoto.Subject{
+					{Name: "foo", Digest: slsacommon.DigestSet{"sha256": sha256}},
+				},
+			},
+			Predicate: slsa02.ProvenancePredicate{
+				Builder:   slsacommon.ProvenanceBuilder{ID: builderID},
+				BuildType: "https://github.com/slsa-framework/slsa-github-generator/generic@v1",
+			},
+		}
+	}
+	// statementV1 returns a SLSA v1.0 statement without mistakes.
+	statementV1 := func() intoto.Statement {
+		s := statement()
+		s.PredicateType = slsa1.PredicateSLSAProvenance
+		s.Predicate = slsa1.ProvenancePredicate{
+			BuildDefinition: slsa1.ProvenanceBuildDefinition{
+				BuildType: "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+			},
+			RunDetails: slsa1.ProvenanaceRunDetails{
+				Builder: slsa1.Builder{ID: builderID},
+			},
+		}
+		return s
+	}
+
+	testCases := []struct {
+		name     string
+		stmt     func() intoto.Statement
+		expected []string
+	}{
+		{
+			name: "valid v0.2",
+			stmt: statement,
+		},
+		{
+			name: "valid v1.0",
+			stmt: statementV1,
+		},
+		{
+			name: "valid decoded predicate",
+			stmt: func() intoto.Statement {
+				s := statement()
+				s.Predicate = map[string]interface{}{
+					"builder":   map[string]interface{}{"id": builderID},
+					"buildType": "https://github.com/slsa-framework/slsa-github-generator/generic@v1",
+				}
+				return s
+			},
+		},
+		{
+			name: "no subjects",
+			stmt: func() intoto.Statement {
+				s := statement()
+				s.Subject = nil
+				return s
+			},
+			expected: []string{LintNoSubjects},
+		},
+		{
+			name: "empty subject name",
+			stmt: func() intoto.Statement {
+				s := statement()
+				s.Subject[0].Name = ""
+				return s
+			},
+			expected: []string{LintEmptySubjectName},
+		},
+		{
+			name: "missing sha256",
+			stmt: func() intoto.Statement {
+				s := statement()
+				s.Subject[0].Digest = slsacommon.DigestSet{"sha512": sha256 + sha256}
+				return s
+			},
+			expected: []string{LintMissingSHA256},
+		},
+		{
+			name: "v0.2 predicate type with v1.0 predicate",
+			stmt: func() intoto.Statement {
+				s := statementV1()
+				s.PredicateType = slsa02.PredicateSLSAProvenance
+				return s
+			},
+			expected: []string{LintPredicateTypeMismatch, LintInvalidBuilderID},
+		},
+		{
+			name: "v1.0 predicate type with v0.2 predicate",
+			stmt: func() intoto.Statement {
+				s := statement()
+				s.PredicateType = slsa1.PredicateSLSAProvenance
+				return s
+			},
+			expected: []string{LintPredicateTypeMismatch, LintInvalidBuilderID},
+		},
+		{
+			name: "unknown predicate type",
+			stmt: func() intoto.Statement {
+				s := statement()
+				s.PredicateType = "https://example.com/provenance/v1"
+				return s
+			},
+			expected: []string{LintPredicateTypeMismatch},
+		},
+		{
+			name: "empty builder ID",
+			stmt: func() intoto.Statement {
+				s := statementV1()
+				p := s.Predicate.(slsa1.ProvenancePredicate)
+				p.RunDetails.Builder.ID = ""
+				s.Predicate = p
+				return s
+			},
+			expected: []string{LintInvalidBuilderID},
+		},
+		{
+			name: "relative builder ID",
+			stmt: func() intoto.Statement {
+				s := statement()
+				p := s.Predicate.(slsa02.ProvenancePredicate)
+				p.Builder.ID = ".github/workflows/release.yml"
+				s.Predicate = p
+				return s
+			},
+			expected: []string{LintInvalidBuilderID},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var codes []string
+			for _, w := range LintProvenance(tc.stmt()) {
+				codes = append(codes, w.Code)
+			}
+			if diff := cmp.Diff(tc.expected, codes); diff != "" {
+				t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
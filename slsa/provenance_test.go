@@ -150,3 +150,41 @@ func TestHostedActionsProvenance(t *testing.T) {
 		})
 	}
 }
+
+func TestGithubActionsBuild_Invocation_pullRequest(t *testing.T) {
+	b := NewGithubActionsBuild(nil, &github.WorkflowContext{
+		Repository: "org/repo",
+		EventName:  "pull_request",
+		Event: map[string]interface{}{
+			"pull_request": map[string]interface{}{
+				"author_association": "FIRST_TIME_CONTRIBUTOR",
+				"head": map[string]interface{}{
+					"repo": map[string]interface{}{"full_name": "user/repo"},
+				},
+				"base": map[string]interface{}{
+					"repo": map[string]interface{}{"full_name": "org/repo"},
+				},
+			},
+		},
+	}).WithClients(&NilClientProvider{})
+
+	i, err := b.Invocation(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env, ok := i.Environment.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected environment type: %T", i.Environment)
+	}
+	want := map[string]interface{}{
+		"github_event_name":              "pull_request",
+		"github_head_repository":         "user/repo",
+		"github_head_repository_is_base": false,
+		"github_author_association":      "FIRST_TIME_CONTRIBUTOR",
+	}
+	for k, v := range want {
+		if got := env[k]; got != v {
+			t.Errorf("unexpected %s, want: %v, got: %v", k, v, got)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// SubjectChange is a subject whose digest changed between two subject lists.
+type SubjectChange struct {
+	// Name is the name of the subject.
+	Name string
+
+	// OldDigest is the digest of the subject in the old list.
+	OldDigest slsacommon.DigestSet
+
+	// NewDigest is the digest of the subject in the new list.
+	NewDigest slsacommon.DigestSet
+}
+
+// SubjectDiffReport is the difference between two subject lists.
+type SubjectDiffReport struct {
+	// Added are the subjects whose name is only in the new list.
+	Added []intoto.Subject
+
+	// Removed are the subjects whose name is only in the old list.
+	Removed []intoto.Subject
+
+	// Changed are the subjects that are in both lists with different digests.
+	Changed []SubjectChange
+}
+
+// Empty returns true if the subject lists are identical.
+func (r SubjectDiffReport) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// SubjectDiff returns the difference between the old and new subject lists.
+// Subjects are matched by name. Added and Changed are in the order of new and
+// Removed is in the order of old. If a name appears more than once in a list
+// only the first subject with that name is used.
+func SubjectDiff(old, new []intoto.Subject) SubjectDiffReport {
+	oldSubjects := uniqueSubjects(old)
+	newSubjects := uniqueSubjects(new)
+
+	oldByName := make(map[string]slsacommon.DigestSet, len(oldSubjects))
+	for _, s := range oldSubjects {
+		oldByName[s.Name] = s.Digest
+	}
+	newByName := make(map[string]slsacommon.DigestSet, len(newSubjects))
+	for _, s := range newSubjects {
+		newByName[s.Name] = s.Digest
+	}
+
+	var r SubjectDiffReport
+	for _, s := range newSubjects {
+		d, ok := oldByName[s.Name]
+		switch {
+		case !ok:
+			r.Added = append(r.Added, s)
+		case !equalDigests(d, s.Digest):
+			r.Changed = append(r.Changed, SubjectChange{
+				Name:      s.Name,
+				OldDigest: d,
+				NewDigest: s.Digest,
+			})
+		}
+	}
+	for _, s := range oldSubjects {
+		if _, ok := newByName[s.Name]; !ok {
+			r.Removed = append(r.Removed, s)
+		}
+	}
+	return r
+}
+
+// uniqueSubjects returns the first subject with each name in subjects.
+func uniqueSubjects(subjects []intoto.Subject) []intoto.Subject {
+	seen := make(map[string]bool, len(subjects))
+	var unique []intoto.Subject
+	for _, s := range subjects {
+		if !seen[s.Name] {
+			seen[s.Name] = true
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+// equalDigests returns true if a and b have the same algorithms and values.
+func equalDigests(a, b slsacommon.DigestSet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for alg, v := range a {
+		if w, ok := b[alg]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+func TestSubjectDiff(t *testing.T) {
+	const (
+		sha1 = "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2"
+		sha2 = "e712aff3705ac314b9a890e0ec208faa20054eee514d86ab913d768f94e01279"
+	)
+	subject := func(name, sha string) intoto.Subject {
+		return intoto.Subject{Name: name, Digest: slsacommon.DigestSet{"sha256": sha}}
+	}
+
+	testCases := []struct {
+		name     string
+		old      []intoto.Subject
+		new      []intoto.Subject
+		expected SubjectDiffReport
+	}{
+		{
+			name: "identical",
+			old:  []intoto.Subject{subject("foo", sha1), subject("bar", sha2)},
+			new:  []intoto.Subject{subject("bar", sha2), subject("foo", sha1)},
+		},
+		{
+			name: "added",
+			old:  []intoto.Subject{subject("foo", sha1)},
+			new:  []intoto.Subject{subject("foo", sha1), subject("bar", sha2)},
+			expected: SubjectDiffReport{
+				Added: []intoto.Subject{subject("bar", sha2)},
+			},
+		},
+		{
+			name: "removed",
+			old:  []intoto.Subject{subject("foo", sha1), subject("bar", sha2)},
+			new:  []intoto.Subject{subject("foo", sha1)},
+			expected: SubjectDiffReport{
+				Removed: []intoto.Subject{subject("bar", sha2)},
+			},
+		},
+		{
+			name: "changed",
+			old:  []intoto.Subject{subject("foo", sha1)},
+			new:  []intoto.Subject{subject("foo", sha2)},
+			expected: SubjectDiffReport{
+				Changed: []SubjectChange{
+					{
+						Name:      "foo",
+						OldDigest: slsacommon.DigestSet{"sha256": sha1},
+						NewDigest: slsacommon.DigestSet{"sha256": sha2},
+					},
+				},
+			},
+		},
+		{
+			name: "changed algorithm",
+			old:  []intoto.Subject{subject("foo", sha1)},
+			new: []intoto.Subject{
+				{Name: "foo", Digest: slsacommon.DigestSet{"sha256": sha1, "sha512": sha2}},
+			},
+			expected: SubjectDiffReport{
+				Changed: []SubjectChange{
+					{
+						Name:      "foo",
+						OldDigest: slsacommon.DigestSet{"sha256": sha1},
+						NewDigest: slsacommon.DigestSet{"sha256": sha1, "sha512": sha2},
+					},
+				},
+			},
+		},
+		{
+			name: "all",
+			old:  []intoto.Subject{subject("foo", sha1), subject("bar", sha1), subject("baz", sha1)},
+			new:  []intoto.Subject{subject("qux", sha2), subject("foo", sha1), subject("bar", sha2)},
+			expected: SubjectDiffReport{
+				Added:   []intoto.Subject{subject("qux", sha2)},
+				Removed: []intoto.Subject{subject("baz", sha1)},
+				Changed: []SubjectChange{
+					{
+						Name:      "bar",
+						OldDigest: slsacommon.DigestSet{"sha256": sha1},
+						NewDigest: slsacommon.DigestSet{"sha256": sha2},
+					},
+				},
+			},
+		},
+		{
+			name: "duplicate names",
+			old:  []intoto.Subject{subject("foo", sha1), subject("foo", sha2)},
+			new:  []intoto.Subject{subject("foo", sha1)},
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := SubjectDiff(tc.old, tc.new)
+			if diff := cmp.Diff(tc.expected, r); diff != "" {
+				t.Errorf("unexpected report (-want +got):\n%s", diff)
+			}
+			if want, got := tc.expected.Empty(), r.Empty(); want != got {
+				t.Errorf("unexpected empty, want: %v, got: %v", want, got)
+			}
+		})
+	}
+}
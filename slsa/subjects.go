@@ -0,0 +1,46 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"sort"
+	"strings"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// SortSubjects sorts subjects by name and then by digest set so that the
+// provenance does not depend on the order subjects were provided in. The order
+// of subjects is not semantically meaningful.
+func SortSubjects(subjects []intoto.Subject) {
+	sort.SliceStable(subjects, func(i, j int) bool {
+		if subjects[i].Name != subjects[j].Name {
+			return subjects[i].Name < subjects[j].Name
+		}
+		return digestSetKey(subjects[i].Digest) < digestSetKey(subjects[j].Digest)
+	})
+}
+
+// digestSetKey returns the canonical form of the digest set, i.e. its sorted
+// "alg:hex" entries separated by commas.
+func digestSetKey(d slsacommon.DigestSet) string {
+	entries := make([]string, 0, len(d))
+	for alg, value := range d {
+		entries = append(entries, alg+":"+value)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
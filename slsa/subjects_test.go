@@ -0,0 +1,91 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// TestSortSubjects tests that SortSubjects produces a stable order regardless
+// of the input order, including for subjects that only differ by a digest
+// other than sha256.
+func TestSortSubjects(t *testing.T) {
+	want := []intoto.Subject{
+		{
+			Name: "fuga",
+			Digest: slsacommon.DigestSet{
+				"sha256": "e712aff3705ac314b9a890e0ec208faa20054eee514d86ab913d768f94e01279",
+			},
+		},
+		{
+			Name: "hoge",
+			Digest: slsacommon.DigestSet{
+				"sha256": "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2",
+			},
+		},
+		{
+			Name: "hoge",
+			Digest: slsacommon.DigestSet{
+				"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c",
+			},
+		},
+		{
+			Name: "piyo",
+			Digest: slsacommon.DigestSet{
+				"sha256": "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2",
+			},
+		},
+		{
+			Name: "piyo",
+			Digest: slsacommon.DigestSet{
+				"sha256": "2e0390eb024a52963db7b95e84a9c2b12c004054a7bad9a97ec0c7c89d4681d2",
+				"sha512": "0000000000000000000000000000000000000000000000000000000000000000" +
+					"0000000000000000000000000000000000000000000000000000000000000000",
+			},
+		},
+		{
+			Name: "piyo",
+			Digest: slsacommon.DigestSet{
+				"sha512": "1111111111111111111111111111111111111111111111111111111111111111" +
+					"1111111111111111111111111111111111111111111111111111111111111111",
+			},
+		},
+		{
+			Name: "piyo",
+			Digest: slsacommon.DigestSet{
+				"sha512": "2222222222222222222222222222222222222222222222222222222222222222" +
+					"2222222222222222222222222222222222222222222222222222222222222222",
+			},
+		},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		got := append([]intoto.Subject{}, want...)
+		r.Shuffle(len(got), func(i, j int) {
+			got[i], got[j] = got[j], got[i]
+		})
+
+		SortSubjects(got)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+		}
+	}
+}
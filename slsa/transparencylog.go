@@ -0,0 +1,253 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/slsa-framework/slsa-github-generator/github"
+	"github.com/slsa-framework/slsa-github-generator/internal/errors"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+// NopTransparencyLog is a TransparencyLog that does not upload anything. It
+// can be used to disable transparency logging.
+type NopTransparencyLog struct{}
+
+// Upload implements TransparencyLog.Upload. It always returns a nil LogEntry
+// and no error.
+func (NopTransparencyLog) Upload(context.Context, signing.Attestation) (signing.LogEntry, error) {
+	return nil, nil
+}
+
+// IntegratedTimeCheckingTransparencyLog is a TransparencyLog that wraps
+// another TransparencyLog and fails uploads whose log entry was integrated
+// outside the validity window of the signing certificate. Such an entry, e.g.
+// caused by clock skew or a replayed signature, can not be verified later.
+type IntegratedTimeCheckingTransparencyLog struct {
+	tlog signing.TransparencyLog
+}
+
+// NewIntegratedTimeCheckingTransparencyLog returns a new
+// IntegratedTimeCheckingTransparencyLog that checks the entries returned by
+// tlog.
+func NewIntegratedTimeCheckingTransparencyLog(tlog signing.TransparencyLog) *IntegratedTimeCheckingTransparencyLog {
+	return &IntegratedTimeCheckingTransparencyLog{tlog: tlog}
+}
+
+// Upload implements TransparencyLog.Upload.
+func (l *IntegratedTimeCheckingTransparencyLog) Upload(ctx context.Context, att signing.Attestation) (signing.LogEntry, error) {
+	entry, err := l.tlog.Upload(ctx, att)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+	if err := CheckIntegratedTime(att.Cert(), entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// CheckIntegratedTime returns an error if the integrated time of entry is
+// outside the validity window of the first certificate in the PEM encoded
+// cert. Nothing is checked if cert has no certificate, e.g. if it is the
+// public key of a key-based signer.
+func CheckIntegratedTime(cert []byte, entry signing.LogEntry) error {
+	var c *x509.Certificate
+	for rest := cert; c == nil; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		var err error
+		c, err = x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("parsing certificate: %w", err)
+		}
+	}
+
+	t := time.Unix(entry.IntegratedTime(), 0).UTC()
+	if t.Before(c.NotBefore) || t.After(c.NotAfter) {
+		return fmt.Errorf("log entry integrated at %s, outside of the certificate validity window %s to %s",
+			t.Format(time.RFC3339), c.NotBefore.UTC().Format(time.RFC3339), c.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// RetryingTransparencyLog is a TransparencyLog that wraps another
+// TransparencyLog and retries uploads that failed with a transient error with
+// exponential backoff and jitter. Other errors, e.g. a failed verification of
+// the log entry or a 4xx response, are returned immediately.
+type RetryingTransparencyLog struct {
+	tlog      signing.TransparencyLog
+	retries   int
+	baseDelay time.Duration
+
+	// sleep waits for the given duration or until the context is done. This
+	// is used for tests.
+	sleep func(context.Context, time.Duration) error
+}
+
+// NewRetryingTransparencyLog returns a new RetryingTransparencyLog that
+// retries failed uploads to tlog up to retries times. The delay before the
+// n-th retry is baseDelay * 2^(n-1), with up to half of it replaced by random
+// jitter.
+func NewRetryingTransparencyLog(tlog signing.TransparencyLog, retries int, baseDelay time.Duration) *RetryingTransparencyLog {
+	return &RetryingTransparencyLog{
+		tlog:      tlog,
+		retries:   retries,
+		baseDelay: baseDelay,
+	}
+}
+
+// Upload implements TransparencyLog.Upload. Retries stop early if the context
+// is canceled.
+func (l *RetryingTransparencyLog) Upload(ctx context.Context, att signing.Attestation) (signing.LogEntry, error) {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var entry signing.LogEntry
+		entry, err = l.tlog.Upload(ctx, att)
+		if err == nil {
+			return entry, nil
+		}
+		if !isTransientUploadError(err) {
+			return nil, fmt.Errorf("uploading to transparency log: %w", err)
+		}
+		if attempt >= l.retries {
+			break
+		}
+		if sleepErr := l.wait(ctx, l.delay(attempt)); sleepErr != nil {
+			return nil, fmt.Errorf("uploading to transparency log: %w (last error: %v)", sleepErr, err)
+		}
+	}
+	return nil, fmt.Errorf("uploading to transparency log after %d attempts: %w", l.retries+1, err)
+}
+
+// isTransientUploadError returns whether err is worth retrying: a network
+// error, which includes HTTP requests that failed after their own retries, or
+// a 429 or 5xx response. Context errors are not retried.
+func isTransientUploadError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// NOTE: The responses of the generated Rekor client have a Code method.
+	var resp interface{ Code() int }
+	if errors.As(err, &resp) {
+		code := resp.Code()
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// delay returns the delay before the retry following the given attempt.
+func (l *RetryingTransparencyLog) delay(attempt int) time.Duration {
+	d := l.baseDelay << attempt
+	if half := int64(d / 2); half > 0 {
+		//#nosec G404 -- Jitter does not need a cryptographically secure source.
+		d = time.Duration(half + rand.Int63n(half+1))
+	}
+	return d
+}
+
+func (l *RetryingTransparencyLog) wait(ctx context.Context, d time.Duration) error {
+	if l.sleep != nil {
+		return l.sleep(ctx, d)
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// NamedTransparencyLog is a TransparencyLog with a name used in messages,
+// e.g. its URL.
+type NamedTransparencyLog struct {
+	Name string
+	Log  signing.TransparencyLog
+}
+
+// MultiTransparencyLog is a TransparencyLog that uploads the attestation to a
+// primary TransparencyLog and, in parallel, to extra TransparencyLogs, e.g. a
+// public and a private Rekor instance. The entry of the primary log is
+// returned.
+type MultiTransparencyLog struct {
+	primary    signing.TransparencyLog
+	extra      []NamedTransparencyLog
+	requireAll bool
+	w          io.Writer
+}
+
+// NewMultiTransparencyLog returns a new MultiTransparencyLog. A failed upload
+// to an extra log is written to w as a warning, unless requireAll is true in
+// which case the upload fails.
+func NewMultiTransparencyLog(primary signing.TransparencyLog, extra []NamedTransparencyLog, requireAll bool,
+	w io.Writer,
+) *MultiTransparencyLog {
+	return &MultiTransparencyLog{
+		primary:    primary,
+		extra:      extra,
+		requireAll: requireAll,
+		w:          w,
+	}
+}
+
+// Upload implements TransparencyLog.Upload. It waits for all the uploads to
+// finish.
+func (l *MultiTransparencyLog) Upload(ctx context.Context, att signing.Attestation) (signing.LogEntry, error) {
+	var wg sync.WaitGroup
+	errs := make([]error, len(l.extra))
+	for i, e := range l.extra {
+		i, e := i, e
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = e.Log.Upload(ctx, att)
+		}()
+	}
+	entry, err := l.primary.Upload(ctx, att)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if l.requireAll {
+			return nil, fmt.Errorf("uploading to %s: %w", l.extra[i].Name, err)
+		}
+		github.Warning(l.w, nil, fmt.Sprintf("uploading to %s: %v", l.extra[i].Name, err))
+	}
+	return entry, nil
+}
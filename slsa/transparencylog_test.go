@@ -0,0 +1,418 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/certificate"
+
+	"github.com/slsa-framework/slsa-github-generator/internal/testutil"
+	"github.com/slsa-framework/slsa-github-generator/signing"
+)
+
+var (
+	_ signing.TransparencyLog = NopTransparencyLog{}
+	_ signing.TransparencyLog = &MultiTransparencyLog{}
+)
+
+func TestNopTransparencyLog_Upload(t *testing.T) {
+	entry, err := NopTransparencyLog{}.Upload(context.Background(), &testutil.TestAttestation{})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	if entry != nil {
+		t.Errorf("unexpected entry, want: nil, got: %v", entry)
+	}
+}
+
+// errTransient is a network error, which is retried.
+var errTransient = &url.Error{Op: "Post", URL: "https://rekor.example.com", Err: testutil.ErrTransparencyLog}
+
+// statusError is an error response with an HTTP status code, like those of
+// the generated Rekor client.
+type statusError int
+
+func (e statusError) Code() int {
+	return int(e)
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("status %d", int(e))
+}
+
+func TestRetryingTransparencyLog_Upload(t *testing.T) {
+	testCases := []struct {
+		name      string
+		failCount int
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "no failures",
+			retries:   3,
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds on third call",
+			failCount: 2,
+			retries:   3,
+			wantCalls: 3,
+		},
+		{
+			name:      "retries exhausted",
+			failCount: 5,
+			retries:   3,
+			wantCalls: 4,
+			wantErr:   true,
+		},
+		{
+			name:      "no retries",
+			failCount: 1,
+			retries:   0,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tlog := &testutil.CountingTransparencyLog{
+				Entry:     &testutil.TestLogEntry{UUIDVal: "uuid"},
+				FailCount: tc.failCount,
+				Err:       errTransient,
+			}
+
+			var delays []time.Duration
+			l := NewRetryingTransparencyLog(tlog, tc.retries, time.Second)
+			l.sleep = func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			entry, err := l.Upload(context.Background(), &testutil.TestAttestation{})
+			if tc.wantErr {
+				if !errors.Is(err, testutil.ErrTransparencyLog) {
+					t.Errorf("unexpected error, want: %v, got: %v", testutil.ErrTransparencyLog, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				if want, got := "uuid", entry.UUID(); want != got {
+					t.Errorf("unexpected entry, want: %q, got: %q", want, got)
+				}
+			}
+
+			if want, got := tc.wantCalls, tlog.Calls; want != got {
+				t.Errorf("unexpected number of calls, want: %d, got: %d", want, got)
+			}
+
+			// Delays should grow exponentially within the jitter bounds.
+			for i, d := range delays {
+				max := time.Second << i
+				if d < max/2 || d > max {
+					t.Errorf("unexpected delay %d, want: [%v, %v], got: %v", i, max/2, max, d)
+				}
+			}
+		})
+	}
+}
+
+// TestRetryingTransparencyLog_Upload_errors tests that only transient errors
+// are retried.
+func TestRetryingTransparencyLog_Upload_errors(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{
+			name:      "network error",
+			err:       errTransient,
+			wantCalls: 3,
+		},
+		{
+			name:      "too many requests",
+			err:       statusError(http.StatusTooManyRequests),
+			wantCalls: 3,
+		},
+		{
+			name:      "server error",
+			err:       fmt.Errorf("uploading attestation: %w", statusError(http.StatusServiceUnavailable)),
+			wantCalls: 3,
+		},
+		{
+			name:      "bad request",
+			err:       fmt.Errorf("uploading attestation: %w", statusError(http.StatusBadRequest)),
+			wantCalls: 1,
+		},
+		{
+			name:      "conflict",
+			err:       statusError(http.StatusConflict),
+			wantCalls: 1,
+		},
+		{
+			name:      "verification error",
+			err:       errors.New("log entry not signed by pinned certificate"),
+			wantCalls: 1,
+		},
+		{
+			name:      "deadline exceeded",
+			err:       fmt.Errorf("uploading attestation: %w", context.DeadlineExceeded),
+			wantCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tlog := &testutil.CountingTransparencyLog{FailCount: 5, Err: tc.err}
+			l := NewRetryingTransparencyLog(tlog, 2, time.Second)
+			l.sleep = func(context.Context, time.Duration) error {
+				return nil
+			}
+
+			if _, err := l.Upload(context.Background(), &testutil.TestAttestation{}); !errors.Is(err, tc.err) {
+				t.Errorf("unexpected error, want: %v, got: %v", tc.err, err)
+			}
+			if want, got := tc.wantCalls, tlog.Calls; want != got {
+				t.Errorf("unexpected number of calls, want: %d, got: %d", want, got)
+			}
+		})
+	}
+}
+
+func TestRetryingTransparencyLog_Upload_canceled(t *testing.T) {
+	tlog := &testutil.CountingTransparencyLog{FailCount: 5, Err: errTransient}
+	l := NewRetryingTransparencyLog(tlog, 3, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := l.Upload(ctx, &testutil.TestAttestation{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error, want: %v, got: %v", context.Canceled, err)
+	}
+	if want, got := 1, tlog.Calls; want != got {
+		t.Errorf("unexpected number of calls, want: %d, got: %d", want, got)
+	}
+}
+
+func TestCheckIntegratedTime(t *testing.T) {
+	cert, err := testutil.NewTestCert("https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+		certificate.Extensions{})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	// The certificate is valid for 5 minutes on either side of the
+	// integrated time of testutil.NewTestLogEntry.
+	integrated := time.Unix(testutil.NewTestLogEntry().IntegratedTimeVal, 0)
+	notBefore := integrated.Add(-5 * time.Minute)
+	notAfter := integrated.Add(5 * time.Minute)
+
+	testCases := []struct {
+		name    string
+		cert    []byte
+		time    time.Time
+		wantErr bool
+	}{
+		{
+			name: "within validity",
+			cert: cert,
+			time: integrated,
+		},
+		{
+			name: "at not before",
+			cert: cert,
+			time: notBefore,
+		},
+		{
+			name: "at not after",
+			cert: cert,
+			time: notAfter,
+		},
+		{
+			name:    "before not before",
+			cert:    cert,
+			time:    notBefore.Add(-time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "after not after",
+			cert:    cert,
+			time:    notAfter.Add(time.Second),
+			wantErr: true,
+		},
+		{
+			name: "no certificate",
+			time: notAfter.Add(time.Hour),
+		},
+		{
+			name: "public key",
+			cert: []byte("-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEAGb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=\n-----END PUBLIC KEY-----\n"),
+			time: notAfter.Add(time.Hour),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckIntegratedTime(tc.cert, testutil.NewTestLogEntryAt(tc.time))
+			if tc.wantErr != (err != nil) {
+				t.Errorf("unexpected error, want error: %v, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestIntegratedTimeCheckingTransparencyLog_Upload tests that an entry
+// integrated outside the certificate validity fails.
+func TestIntegratedTimeCheckingTransparencyLog_Upload(t *testing.T) {
+	cert, err := testutil.NewTestCert("https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+		certificate.Extensions{})
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	valid := testutil.NewTestLogEntry()
+	skewed := testutil.NewTestLogEntryAt(time.Unix(valid.IntegratedTimeVal, 0).Add(time.Hour))
+
+	testCases := []struct {
+		name    string
+		entry   *testutil.TestLogEntry
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			entry: valid,
+		},
+		{
+			name:    "skewed",
+			entry:   skewed,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tlog := &testutil.CountingTransparencyLog{Entry: tc.entry}
+			l := NewIntegratedTimeCheckingTransparencyLog(tlog)
+
+			entry, err := l.Upload(context.Background(), &testutil.TestAttestation{CertVal: cert})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error to occur.")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected failure: %v", err)
+			}
+			if want, got := valid.IntegratedTimeVal, entry.IntegratedTime(); want != got {
+				t.Errorf("unexpected integrated time, want: %d, got: %d", want, got)
+			}
+		})
+	}
+}
+
+func TestMultiTransparencyLog_Upload(t *testing.T) {
+	testCases := []struct {
+		name         string
+		primaryFails bool
+		extraFails   bool
+		requireAll   bool
+		wantErr      bool
+		wantWarning  bool
+	}{
+		{
+			name: "all succeed",
+		},
+		{
+			name:        "extra fails",
+			extraFails:  true,
+			wantWarning: true,
+		},
+		{
+			name:       "extra fails with require all",
+			extraFails: true,
+			requireAll: true,
+			wantErr:    true,
+		},
+		{
+			name:         "primary fails",
+			primaryFails: true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ACTIONS", "")
+
+			primary := &testutil.CountingTransparencyLog{Entry: &testutil.TestLogEntry{UUIDVal: "primary"}}
+			if tc.primaryFails {
+				primary.FailCount = 1
+			}
+			ok := &testutil.CountingTransparencyLog{Entry: &testutil.TestLogEntry{UUIDVal: "ok"}}
+			other := &testutil.CountingTransparencyLog{Entry: &testutil.TestLogEntry{UUIDVal: "other"}}
+			if tc.extraFails {
+				other.FailCount = 1
+			}
+
+			var buf bytes.Buffer
+			l := NewMultiTransparencyLog(primary, []NamedTransparencyLog{
+				{Name: "https://ok.example.com", Log: ok},
+				{Name: "https://other.example.com", Log: other},
+			}, tc.requireAll, &buf)
+
+			entry, err := l.Upload(context.Background(), &testutil.TestAttestation{})
+			if tc.wantErr {
+				if !errors.Is(err, testutil.ErrTransparencyLog) {
+					t.Errorf("unexpected error, want: %v, got: %v", testutil.ErrTransparencyLog, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected failure: %v", err)
+				}
+				if want, got := "primary", entry.UUID(); want != got {
+					t.Errorf("unexpected entry, want: %q, got: %q", want, got)
+				}
+			}
+
+			// Every log should be uploaded to, even if another fails.
+			for _, l := range []*testutil.CountingTransparencyLog{primary, ok, other} {
+				if want, got := 1, l.Calls; want != got {
+					t.Errorf("unexpected number of calls, want: %d, got: %d", want, got)
+				}
+			}
+
+			warning := strings.Contains(buf.String(), "WARNING: uploading to https://other.example.com")
+			if warning != tc.wantWarning {
+				t.Errorf("unexpected warning, want: %v, got: %q", tc.wantWarning, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"time"
+
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+const (
+	// VSAPredicateType is the predicate type of a SLSA Verification Summary
+	// Attestation.
+	// See https://slsa.dev/spec/v1.0/verification_summary
+	VSAPredicateType = "https://slsa.dev/verification_summary/v1"
+
+	// VSAResultPassed is the verification result of artifacts that passed
+	// verification.
+	VSAResultPassed = "PASSED"
+
+	// VSAResultFailed is the verification result of artifacts that failed
+	// verification.
+	VSAResultFailed = "FAILED"
+
+	// BuildLevel3 is the SLSA level of artifacts built by a hardened build
+	// platform.
+	BuildLevel3 = "SLSA_BUILD_LEVEL_3"
+)
+
+// VSAPredicate is the predicate of a SLSA Verification Summary Attestation.
+type VSAPredicate struct {
+	// Verifier is the entity that performed the verification.
+	Verifier VSAVerifier `json:"verifier"`
+
+	// TimeVerified is when the verification occurred.
+	TimeVerified time.Time `json:"timeVerified"`
+
+	// ResourceURI is the URI of the resource to which the subjects were
+	// verified against, e.g. the source repository.
+	ResourceURI string `json:"resourceUri"`
+
+	// Policy describes the policy that the subjects were verified against.
+	Policy VSAResourceDescriptor `json:"policy"`
+
+	// InputAttestations are the attestations used to perform the
+	// verification, e.g. the provenance.
+	InputAttestations []VSAResourceDescriptor `json:"inputAttestations,omitempty"`
+
+	// VerificationResult is either VSAResultPassed or VSAResultFailed.
+	VerificationResult string `json:"verificationResult"`
+
+	// VerifiedLevels are the levels of the subjects, e.g. BuildLevel3.
+	VerifiedLevels []string `json:"verifiedLevels"`
+
+	// SLSAVersion is the version of the SLSA specification the levels refer
+	// to.
+	SLSAVersion string `json:"slsaVersion,omitempty"`
+}
+
+// VSAVerifier identifies the entity that performed a verification.
+type VSAVerifier struct {
+	// ID is the URI of the verifier, e.g. the URI of the workflow.
+	ID string `json:"id"`
+
+	// Version maps the components of the verifier to their versions.
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// VSAResourceDescriptor describes a resource by URI and digest.
+type VSAResourceDescriptor struct {
+	URI    string               `json:"uri,omitempty"`
+	Digest slsacommon.DigestSet `json:"digest,omitempty"`
+}
+
+// NewVSAStatement returns an unsigned in-toto statement with a VSA predicate
+// for the given subjects.
+func NewVSAStatement(subjects []intoto.Subject, predicate *VSAPredicate) *intoto.Statement {
+	return &intoto.Statement{
+		StatementHeader: intoto.StatementHeader{
+			Type:          intoto.StatementInTotoV01,
+			PredicateType: VSAPredicateType,
+			Subject:       subjects,
+		},
+		Predicate: predicate,
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 SLSA Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsa
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	slsacommon "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+)
+
+// TestNewVSAStatement tests that the VSA statement has the fields required by
+// https://slsa.dev/spec/v1.0/verification_summary.
+func TestNewVSAStatement(t *testing.T) {
+	subjects := []intoto.Subject{
+		{
+			Name:   "app.tar.gz",
+			Digest: slsacommon.DigestSet{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+		},
+	}
+	s := NewVSAStatement(subjects, &VSAPredicate{
+		Verifier: VSAVerifier{
+			ID: "https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+		},
+		TimeVerified: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		ResourceURI:  "git+https://github.com/owner/repo",
+		Policy: VSAResourceDescriptor{
+			URI: "https://slsa.dev/spec/v1.0/levels",
+		},
+		InputAttestations: []VSAResourceDescriptor{
+			{
+				URI:    "app.tar.gz.intoto.jsonl",
+				Digest: slsacommon.DigestSet{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+			},
+		},
+		VerificationResult: VSAResultPassed,
+		VerifiedLevels:     []string{BuildLevel3},
+		SLSAVersion:        "1.0",
+	})
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unexpected failure: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/verification_summary/v1",
+		"subject": []interface{}{
+			map[string]interface{}{
+				"name":   "app.tar.gz",
+				"digest": map[string]interface{}{"sha256": "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c"},
+			},
+		},
+		"predicate": map[string]interface{}{
+			"verifier": map[string]interface{}{
+				"id": "https://github.com/owner/repo/.github/workflows/release.yml@refs/heads/main",
+			},
+			"timeVerified": "2023-01-01T00:00:00Z",
+			"resourceUri":  "git+https://github.com/owner/repo",
+			"policy": map[string]interface{}{
+				"uri": "https://slsa.dev/spec/v1.0/levels",
+			},
+			"inputAttestations": []interface{}{
+				map[string]interface{}{
+					"uri":    "app.tar.gz.intoto.jsonl",
+					"digest": map[string]interface{}{"sha256": "7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730"},
+				},
+			},
+			"verificationResult": "PASSED",
+			"verifiedLevels":     []interface{}{"SLSA_BUILD_LEVEL_3"},
+			"slsaVersion":        "1.0",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected statement (-want +got):\n%s", diff)
+	}
+}